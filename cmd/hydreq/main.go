@@ -5,51 +5,120 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 	bru "github.com/DrWeltschmerz/HydReq/internal/adapters/bruno"
+	"github.com/DrWeltschmerz/HydReq/internal/adapters/curl"
 	har "github.com/DrWeltschmerz/HydReq/internal/adapters/har"
 	in "github.com/DrWeltschmerz/HydReq/internal/adapters/insomnia"
 	nm "github.com/DrWeltschmerz/HydReq/internal/adapters/newman"
 	oai "github.com/DrWeltschmerz/HydReq/internal/adapters/oapi"
+	"github.com/DrWeltschmerz/HydReq/internal/adapters/pact"
 	pm "github.com/DrWeltschmerz/HydReq/internal/adapters/postman"
 	rc "github.com/DrWeltschmerz/HydReq/internal/adapters/restclient"
+	"github.com/DrWeltschmerz/HydReq/internal/openapidiff"
 	"github.com/DrWeltschmerz/HydReq/internal/report"
 	"github.com/DrWeltschmerz/HydReq/internal/runner"
 	"github.com/DrWeltschmerz/HydReq/internal/ui"
 	valfmt "github.com/DrWeltschmerz/HydReq/internal/validate"
 	gui "github.com/DrWeltschmerz/HydReq/internal/webui"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+	"github.com/getkin/kin-openapi/openapi3"
 	jsonschema "github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 	kyaml "sigs.k8s.io/yaml"
 )
 
-func parsePostmanEnvironment(data []byte) (map[string]string, error) {
-	var env struct {
-		Values []struct {
-			Key     string `json:"key"`
-			Value   string `json:"value"`
-			Enabled *bool  `json:"enabled"`
-		} `json:"values"`
+// reportImport lists what an importer could not carry over as-is. It writes
+// to stderr so the YAML on stdout stays clean, and saves the same report as
+// JSON when path is set.
+func reportImport(format string, tests int, warnings []adapters.Warning, path string) error {
+	if len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "%d import warning(s):\n", len(warnings))
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "  - %s\n", w)
+		}
 	}
-
-	if err := json.Unmarshal(data, &env); err != nil {
-		return nil, err
+	if path == "" {
+		return nil
+	}
+	if warnings == nil {
+		warnings = []adapters.Warning{}
 	}
+	b, err := json.MarshalIndent(adapters.Report{Format: format, Tests: tests, Warnings: warnings}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
 
-	vars := make(map[string]string)
-	for _, v := range env.Values {
-		if enabled := v.Enabled; enabled == nil || *enabled {
-			vars[v.Key] = v.Value
+// exportSuite renders the requests of a suite for `hydreq export <format>`,
+// limited to the tests named in only when set. Tests without an HTTP request
+// and auth the format cannot carry are reported as warnings.
+func exportSuite(path, env string, vars []string, keepVars bool, only []string) (adapters.Export, []adapters.Warning, error) {
+	cliVars, err := runner.ParseVarFlags(vars)
+	if err != nil {
+		return adapters.Export{}, nil, err
+	}
+	s, err := runner.LoadSuite(path)
+	if err != nil {
+		return adapters.Export{}, nil, fmt.Errorf("load suite %s: %w", path, err)
+	}
+	if err := runner.ApplyEnvironment(s, path, env, cliVars); err != nil {
+		return adapters.Export{}, nil, fmt.Errorf("load suite %s: %w", path, err)
+	}
+	ex := adapters.Export{Name: s.Name}
+	if keepVars {
+		ex.Vars = s.Variables
+	}
+	var ws adapters.Warnings
+	if keepVars && s.Auth != nil && s.Auth.BearerEnv == "" && s.Auth.BasicEnv != "" {
+		ws.Add("", "auth:basic", "left out (credentials must be encoded; export without --keep-vars to include it)")
+	}
+	if !keepVars {
+		if err := runner.LoadSecretsFile(s); err != nil {
+			return adapters.Export{}, nil, fmt.Errorf("load suite %s: secrets file: %w", path, err)
+		}
+	}
+	want := map[string]bool{}
+	for _, n := range only {
+		want[n] = false
+	}
+	for _, t := range s.Tests {
+		if len(only) > 0 {
+			if _, ok := want[t.Name]; !ok {
+				continue
+			}
+			want[t.Name] = true
+		}
+		if t.SQL != nil {
+			ws.Add(t.Name, "sql", "skipped (no HTTP request)")
+			continue
+		}
+		for _, tc := range runner.ExpandMatrix([]models.TestCase{t}) {
+			if !keepVars {
+				if names := runner.UnknownSecrets(s, tc); len(names) > 0 {
+					ws.Addf(tc.Name, "secrets", "rendered empty (not in the environment or secretsFile)", "%s", strings.Join(names, ", "))
+				}
+			}
+			ex.Requests = append(ex.Requests, adapters.ExportRequest{Name: tc.Name, Request: runner.RenderRequest(s, tc, keepVars)})
+		}
+	}
+	for _, n := range only {
+		if !want[n] {
+			return adapters.Export{}, nil, fmt.Errorf("test %q not found in %s", n, path)
 		}
 	}
-	return vars, nil
+	return ex, ws, nil
 }
 
 func main() {
@@ -66,6 +135,10 @@ func main() {
 	var reportDir string
 	var htmlReport string
 	var output string
+	var envProfile string
+	var varFlags []string
+	var coverageReport string
+	var coverageMin float64
 
 	var rootCmd = &cobra.Command{Use: "hydreq", Short: "HydReq (Hydra Request) - Lightweight API test runner"}
 	// Avoid printing usage/help on runtime errors; we'll print concise messages ourselves.
@@ -94,37 +167,55 @@ func main() {
 			if tags != "" {
 				tagList = strings.Split(tags, ",")
 			}
+			cliVars, err := runner.ParseVarFlags(varFlags)
+			if err != nil {
+				return err
+			}
 			// Helper to run a single suite path
 			runOne := func(suitePath string, br *report.BatchReport) (sumFailed int, runErr error) {
 				s, err := runner.LoadSuite(suitePath)
 				if err != nil {
 					return 0, fmt.Errorf("%w: %s: %v", errLoadSuite, suitePath, err)
 				}
+				if err := runner.ApplyEnvironment(s, suitePath, envProfile, cliVars); err != nil {
+					return 0, fmt.Errorf("%w: %s: %v", errLoadSuite, suitePath, err)
+				}
 				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 				defer cancel()
 				cases := make([]report.TestCase, 0, 64)
+				var hits []*runner.OpenAPIHit
 				// Print header above tests in human mode
 				if output != "json" {
 					ui.SuiteHeader(s.Name)
 				}
 				sum, err := runner.RunSuite(ctx, s, runner.Options{Verbose: verbose, Tags: tagList, Workers: workers, DefaultTimeoutMs: defaultTimeoutMs, OnResult: func(tr runner.TestResult) {
-					cases = append(cases, report.TestCase{Name: tr.Name, Stage: tr.Stage, Tags: tr.Tags, Status: tr.Status, DurationMs: tr.DurationMs, Messages: tr.Messages})
+					cases = append(cases, report.TestCase{Name: tr.Name, Stage: tr.Stage, Tags: tr.Tags, Source: tr.Source, Status: tr.Status, DurationMs: tr.DurationMs, Messages: tr.Messages, Hooks: runner.HookSteps(tr.Hooks)})
+					if tr.OpenAPI != nil {
+						hits = append(hits, tr.OpenAPI)
+					}
 				}})
 				// If suite is not runnable (e.g., missing baseUrl), don't print or emit artifacts/summary
 				if err != nil && errors.Is(err, runner.ErrSuiteNotRunnable) {
 					return 0, fmt.Errorf("%w: %s: %v", errLoadSuite, suitePath, err)
 				}
 				rs := report.FromRunner(sum.Total, sum.Passed, sum.Failed, sum.Skipped, sum.Duration)
+				dr := report.DetailedReport{Suite: s.Name, Summary: rs, TestCases: cases, Hooks: runner.HookSteps(sum.Hooks)}
 				if output == "json" {
 					enc := json.NewEncoder(os.Stdout)
 					enc.SetIndent("", "  ")
-					_ = enc.Encode(report.DetailedReport{Suite: s.Name, Summary: rs, TestCases: cases})
+					_ = enc.Encode(dr)
 				} else {
 					ui.Summary(sum.Total, sum.Passed, sum.Failed, sum.Skipped, sum.Duration)
 				}
 				// Collect into batch
+				if br != nil && sum.OpenAPI != nil {
+					if br.OpenAPI == nil {
+						br.OpenAPI = &report.OpenAPICoverage{}
+					}
+					recordCoverage(br.OpenAPI, sum.OpenAPI, hits)
+				}
 				if br != nil {
-					br.Suites = append(br.Suites, report.DetailedReport{Suite: s.Name, Summary: rs, TestCases: cases})
+					br.Suites = append(br.Suites, dr)
 					br.Summary.Total += rs.Total
 					br.Summary.Passed += rs.Passed
 					br.Summary.Failed += rs.Failed
@@ -138,17 +229,17 @@ func main() {
 					}
 					base := fmt.Sprintf("%s/%s-%s", reportDir, sanitizeFilename(s.Name), runTS)
 					if len(cases) > 0 {
-						_ = report.WriteJSONDetailed(base+".json", report.DetailedReport{Suite: s.Name, Summary: rs, TestCases: cases})
+						_ = report.WriteJSONDetailed(base+".json", dr)
 					} else {
 						_ = report.WriteJSONSummary(base+".json", rs)
 					}
 					_ = report.WriteJUnitDetailed(base+".xml", s.Name, rs, cases)
-					_ = report.WriteHTMLDetailed(base+".html", report.DetailedReport{Suite: s.Name, Summary: rs, TestCases: cases})
+					_ = report.WriteHTMLDetailed(base+".html", dr)
 				} else {
 					// Respect explicit report paths for single-suite mode only
 					if jsonReport != "" {
 						if len(cases) > 0 {
-							_ = report.WriteJSONDetailed(jsonReport, report.DetailedReport{Suite: s.Name, Summary: rs, TestCases: cases})
+							_ = report.WriteJSONDetailed(jsonReport, dr)
 						} else {
 							_ = report.WriteJSONSummary(jsonReport, rs)
 						}
@@ -157,7 +248,7 @@ func main() {
 						_ = report.WriteJUnitDetailed(junitReport, s.Name, rs, cases)
 					}
 					if htmlReport != "" {
-						_ = report.WriteHTMLDetailed(htmlReport, report.DetailedReport{Suite: s.Name, Summary: rs, TestCases: cases})
+						_ = report.WriteHTMLDetailed(htmlReport, dr)
 					}
 				}
 				if err != nil {
@@ -170,6 +261,33 @@ func main() {
 				return sum.Failed, nil
 			}
 
+			// finishCoverage completes the OpenAPI coverage of a run, prints and
+			// writes it, and reports whether it fell below --openapi-coverage-min.
+			finishCoverage := func(br *report.BatchReport) bool {
+				cov := br.OpenAPI
+				if cov == nil {
+					if coverageMin > 0 {
+						ui.Failf("OpenAPI coverage: no suite loaded an OpenAPI spec (--openapi-coverage-min %.1f)", coverageMin)
+						return true
+					}
+					return false
+				}
+				cov.Finish()
+				if output != "json" {
+					ui.Detail(cov.Line())
+				}
+				if coverageReport != "" {
+					if err := report.WriteJSONOpenAPICoverage(coverageReport, *cov); err != nil {
+						fmt.Fprintf(os.Stderr, "openapi coverage report: %v\n", err)
+					}
+				}
+				if coverageMin > 0 && cov.Percent < coverageMin {
+					ui.Failf("OpenAPI coverage %.1f%% is below the minimum of %.1f%%", cov.Percent, coverageMin)
+					return true
+				}
+				return false
+			}
+
 			// Determine whether to run one or all suites
 			failedLoads := make([]string, 0, 8)
 			if strings.TrimSpace(file) == "" {
@@ -228,6 +346,7 @@ func main() {
 					}
 					totalFailed += failed
 				}
+				belowMin := finishCoverage(&br)
 				// Emit batch/run-level artifacts if requested
 				if reportDir != "" {
 					base := fmt.Sprintf("%s/run-%s", reportDir, runTS)
@@ -245,7 +364,7 @@ func main() {
 						_ = appendSummary(path, failedLoads)
 					}
 				}
-				if totalFailed > 0 || len(failedLoads) > 0 {
+				if totalFailed > 0 || len(failedLoads) > 0 || belowMin {
 					// Exit code 2 when only load failures occurred; otherwise 1
 					if totalFailed == 0 && len(failedLoads) > 0 {
 						os.Exit(2)
@@ -260,6 +379,7 @@ func main() {
 			// Single-suite mode
 			brSingle := report.BatchReport{RunAt: time.Now()}
 			failed, err := runOne(file, &brSingle)
+			belowMin := finishCoverage(&brSingle)
 			if reportDir != "" {
 				// Emit batch artifacts reflecting the single suite results, using the same runTS
 				base := fmt.Sprintf("%s/run-%s", reportDir, runTS)
@@ -291,7 +411,7 @@ func main() {
 				os.Exit(1)
 				return nil
 			}
-			if failed > 0 {
+			if failed > 0 || belowMin {
 				os.Exit(1)
 				return nil
 			}
@@ -307,318 +427,427 @@ func main() {
 	runCmd.Flags().StringVar(&reportDir, "report-dir", "", "If set and no explicit report paths provided, write JSON, JUnit and HTML to this directory using suite name and timestamp")
 	runCmd.Flags().StringVar(&htmlReport, "report-html", "", "Write HTML detailed report to file path")
 	runCmd.Flags().StringVar(&output, "output", "summary", "Console output: summary|json")
+	runCmd.Flags().StringVar(&envProfile, "env", "", "Environment profile: suite environments key, <name>.env.yaml next to the suite, or a path to a .env.yaml file")
+	runCmd.Flags().StringVar(&coverageReport, "report-openapi-coverage", "", "Write the OpenAPI coverage report as JSON to file path")
+	runCmd.Flags().Float64Var(&coverageMin, "openapi-coverage-min", 0, "Fail the run when OpenAPI operation coverage is below this percentage")
+	runCmd.Flags().StringArrayVar(&varFlags, "var", nil, "Override a variable (key=value); repeatable, wins over profile and suite vars")
 	rootCmd.AddCommand(runCmd)
 
-	// import command and subcommands
-	var importCmd = &cobra.Command{Use: "import", Short: "Import external collections to a YAML suite"}
-	var outPath string
-	var envFile string
-	var noScripts bool
-	var flatFolders bool
-	var baseURL string
-	var skipAuth bool
-
-	var importPostman = &cobra.Command{Use: "postman <file>", Short: "Import Postman collection (v2.1 JSON)", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
-		b, err := os.ReadFile(args[0])
-		if err != nil {
-			return err
+	// Env command: inspect layered variable resolution
+	var envCmd = &cobra.Command{Use: "env", Short: "Inspect environment profiles and resolved variables"}
+	var envShowFile string
+	var envShowProfile string
+	var envShowVars []string
+	var envShowReveal bool
+	var envShow = &cobra.Command{Use: "show", Short: "Print resolved variables (CLI --var > profile > suite vars > OS env)", RunE: func(cmd *cobra.Command, args []string) error {
+		if envShowFile == "" {
+			return fmt.Errorf("missing -f <suite.hrq.yaml>")
 		}
-
-		// Load environment variables if specified
-		envVars := make(map[string]string)
-		if envFile != "" {
-			envData, err := os.ReadFile(envFile)
-			if err != nil {
-				return fmt.Errorf("failed to read environment file: %w", err)
-			}
-			envVars, err = parsePostmanEnvironment(envData)
-			if err != nil {
-				return fmt.Errorf("failed to parse environment file: %w", err)
-			}
-		}
-
-		s, err := pm.Convert(strings.NewReader(string(b)), envVars)
+		cliVars, err := runner.ParseVarFlags(envShowVars)
 		if err != nil {
 			return err
 		}
-
-		// Apply CLI-level customizations
-		if baseURL != "" {
-			if verbose {
-				fmt.Fprintf(os.Stderr, "Overriding base URL to: %s\n", baseURL)
-			}
-			s.BaseURL = baseURL
-		}
-
-		if verbose {
-			fmt.Fprintf(os.Stderr, "Imported %d tests from Postman collection\n", len(s.Tests))
-			if len(s.Variables) > 0 {
-				fmt.Fprintf(os.Stderr, "Found %d variables\n", len(s.Variables))
-			}
-		}
-
-		y, err := yaml.Marshal(s)
+		s, err := runner.LoadSuite(envShowFile)
 		if err != nil {
 			return err
 		}
-		if outPath == "" {
-			fmt.Print(string(y))
-			return nil
-		}
-		return ioutil.WriteFile(outPath, y, 0644)
-	}}
-	importPostman.Flags().StringVarP(&outPath, "out", "o", "", "Output file (defaults to stdout)")
-	importPostman.Flags().StringVarP(&envFile, "env", "e", "", "Postman environment file to merge variables from")
-	importPostman.Flags().BoolVar(&noScripts, "no-scripts", false, "Skip conversion of pre/post request scripts")
-	importPostman.Flags().BoolVar(&flatFolders, "flat", false, "Flatten folder structure into simple test names")
-	importPostman.Flags().StringVar(&baseURL, "base-url", "", "Override base URL for all requests")
-	importPostman.Flags().BoolVar(&skipAuth, "skip-auth", false, "Skip conversion of authentication settings")
-
-	var importInsomnia = &cobra.Command{Use: "insomnia <file>", Short: "Import Insomnia export JSON", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
-		b, err := os.ReadFile(args[0])
+		resolved, err := runner.ResolveVariables(s, envShowFile, envShowProfile, cliVars)
 		if err != nil {
 			return err
 		}
-		s, err := in.Convert(strings.NewReader(string(b)))
-		if err != nil {
-			return err
+		declared := map[string]bool{}
+		for _, name := range s.Secrets {
+			declared[name] = true
 		}
-
-		// Apply CLI-level customizations
-		if baseURL != "" {
-			if verbose {
-				fmt.Fprintf(os.Stderr, "Overriding base URL to: %s\n", baseURL)
+		width := 4
+		for _, v := range resolved {
+			if len(v.Name) > width {
+				width = len(v.Name)
 			}
-			s.BaseURL = baseURL
 		}
-
-		if verbose {
-			fmt.Fprintf(os.Stderr, "Imported %d tests from Insomnia export\n", len(s.Tests))
-			if len(s.Variables) > 0 {
-				fmt.Fprintf(os.Stderr, "Found %d variables\n", len(s.Variables))
+		for _, v := range resolved {
+			val := v.Value
+			if !envShowReveal && (declared[v.Name] || looksSecret(v.Name)) {
+				val = maskValue(val)
 			}
+			fmt.Printf("%-*s  %-7s  %s\n", width, v.Name, v.Source, val)
 		}
-
-		y, err := yaml.Marshal(s)
-		if err != nil {
-			return err
-		}
-		if outPath == "" {
-			fmt.Print(string(y))
-			return nil
-		}
-		return ioutil.WriteFile(outPath, y, 0644)
+		return nil
 	}}
-	importInsomnia.Flags().StringVarP(&outPath, "out", "o", "", "Output file (defaults to stdout)")
-	importInsomnia.Flags().BoolVar(&noScripts, "no-scripts", false, "Skip conversion of pre/post request scripts")
-	importInsomnia.Flags().BoolVar(&flatFolders, "flat", false, "Flatten folder structure into simple test names")
-	importInsomnia.Flags().StringVar(&baseURL, "base-url", "", "Override base URL for all requests")
-	importInsomnia.Flags().BoolVar(&skipAuth, "skip-auth", false, "Skip conversion of authentication settings")
-
-	var importHAR = &cobra.Command{Use: "har <file>", Short: "Import HAR (HTTP Archive) JSON", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
-		b, err := os.ReadFile(args[0])
-		if err != nil {
-			return err
-		}
-		s, err := har.Convert(strings.NewReader(string(b)))
+	envShow.Flags().StringVarP(&envShowFile, "file", "f", "", "Path to YAML test suite")
+	envShow.Flags().StringVar(&envShowProfile, "env", "", "Environment profile to resolve")
+	envShow.Flags().StringArrayVar(&envShowVars, "var", nil, "Override a variable (key=value); repeatable")
+	envShow.Flags().BoolVar(&envShowReveal, "reveal", false, "Print secret values (declared in secrets: or secret-looking names) in clear text")
+	envCmd.AddCommand(envShow)
+	rootCmd.AddCommand(envCmd)
+
+	// import command and subcommands
+	var importCmd = &cobra.Command{Use: "import", Short: "Import external collections to a YAML suite"}
+	var outPath string
+	var envFile string
+	var noScripts bool
+	var flatFolders bool
+	var folderMode string
+	var includeHosts, excludeExts []string
+	var onlyXHR bool
+	var baseURL string
+	var skipAuth bool
+	var reportPath string
+	importCmd.PersistentFlags().StringVarP(&outPath, "out", "o", "", "Output file (defaults to stdout), or the directory for --folders files")
+	importCmd.PersistentFlags().StringVar(&baseURL, "base-url", "", "Override base URL for all requests")
+	importCmd.PersistentFlags().BoolVar(&skipAuth, "skip-auth", false, "Skip conversion of authentication settings")
+	importCmd.PersistentFlags().StringVar(&reportPath, "report", "", "Write the import warnings as JSON to this file")
+
+	// importOptions turns the import flags into the options every adapter takes
+	importOptions := func() (adapters.Options, error) {
+		opts := adapters.Options{NoScripts: noScripts, SkipAuth: skipAuth, BaseURL: baseURL, IncludeHosts: includeHosts, ExcludeExts: excludeExts, OnlyXHR: onlyXHR}
+		mode, err := adapters.ParseFolderMode(folderMode)
 		if err != nil {
-			return err
+			return opts, err
 		}
-
-		// Apply CLI-level customizations
-		if baseURL != "" {
-			if verbose {
-				fmt.Fprintf(os.Stderr, "Overriding base URL to: %s\n", baseURL)
+		if flatFolders {
+			if folderMode != "" && mode != adapters.FoldersFlat {
+				return opts, fmt.Errorf("--flat conflicts with --folders %s", folderMode)
 			}
-			s.BaseURL = baseURL
+			mode = adapters.FoldersFlat
 		}
-
-		if verbose {
-			fmt.Fprintf(os.Stderr, "Imported %d tests from HAR file\n", len(s.Tests))
+		opts.Folders = mode
+		if envFile != "" {
+			envData, err := os.ReadFile(envFile)
+			if err != nil {
+				return opts, fmt.Errorf("failed to read environment file: %w", err)
+			}
+			opts.Env, err = pm.ParseEnvironment(envData)
+			if err != nil {
+				return opts, fmt.Errorf("failed to parse environment file: %w", err)
+			}
 		}
+		return opts, nil
+	}
 
-		y, err := yaml.Marshal(s)
-		if err != nil {
-			return err
+	// importFiles writes a folder-per-file import below the --out directory
+	importFiles := func(format, source string, in io.Reader, opts adapters.Options, convert func(io.Reader, adapters.Options) ([]adapters.File, []adapters.Warning, error)) error {
+		if convert == nil {
+			return fmt.Errorf("--folders files is not supported for %s", format)
 		}
 		if outPath == "" {
-			fmt.Print(string(y))
-			return nil
+			return fmt.Errorf("--folders files needs --out <dir>")
 		}
-		return ioutil.WriteFile(outPath, y, 0644)
-	}}
-	importHAR.Flags().StringVarP(&outPath, "out", "o", "", "Output file (defaults to stdout)")
-	importHAR.Flags().StringVar(&baseURL, "base-url", "", "Override base URL for all requests")
-
-	var importOAPI = &cobra.Command{Use: "openapi <file>", Short: "Import OpenAPI (3.x) or Swagger (2.0) spec into a basic suite", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
-		b, err := os.ReadFile(args[0])
+		files, warnings, err := convert(in, opts)
 		if err != nil {
 			return err
 		}
-		s, err := oai.Convert(strings.NewReader(string(b)))
-		if err != nil {
-			return err
-		}
-
-		// Apply CLI-level customizations
-		if baseURL != "" {
+		tests := 0
+		for _, f := range files {
+			tests += len(f.Suite.Tests)
+			y, err := yaml.Marshal(f.Suite)
+			if err != nil {
+				return err
+			}
+			p := filepath.Join(outPath, filepath.FromSlash(f.Path))
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(p, y, 0644); err != nil {
+				return err
+			}
 			if verbose {
-				fmt.Fprintf(os.Stderr, "Overriding base URL to: %s\n", baseURL)
+				fmt.Fprintf(os.Stderr, "Wrote %s (%d tests)\n", p, len(f.Suite.Tests))
 			}
-			s.BaseURL = baseURL
 		}
-
 		if verbose {
-			fmt.Fprintf(os.Stderr, "Imported %d tests from OpenAPI spec\n", len(s.Tests))
+			fmt.Fprintf(os.Stderr, "Imported %d tests into %d suites from %s\n", tests, len(files), source)
 		}
+		return reportImport(format, tests, warnings, reportPath)
+	}
 
-		y, err := yaml.Marshal(s)
-		if err != nil {
-			return err
-		}
-		if outPath == "" {
-			fmt.Print(string(y))
-			return nil
-		}
-		return ioutil.WriteFile(outPath, y, 0644)
-	}}
-	importOAPI.Flags().StringVarP(&outPath, "out", "o", "", "Output file (defaults to stdout)")
-	importOAPI.Flags().StringVar(&baseURL, "base-url", "", "Override base URL for all requests")
-
-	var importBruno = &cobra.Command{Use: "bruno <file>", Short: "Import minimal Bruno export JSON", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
-		b, err := os.ReadFile(args[0])
-		if err != nil {
-			return err
-		}
-		s, err := bru.Convert(strings.NewReader(string(b)))
-		if err != nil {
-			return err
-		}
+	// runImport converts the file named by the only argument and writes the
+	// suite, or one suite per folder with --folders files
+	runImport := func(format, source string, convert func(io.Reader, adapters.Options) (*models.Suite, []adapters.Warning, error), files func(io.Reader, adapters.Options) ([]adapters.File, []adapters.Warning, error)) func(*cobra.Command, []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			opts, err := importOptions()
+			if err != nil {
+				return err
+			}
+			// No file or "-" reads stdin
+			var f io.Reader = os.Stdin
+			if len(args) > 0 && args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				f = file
+			}
+			if opts.Folders == adapters.FoldersFiles {
+				return importFiles(format, source, f, opts, files)
+			}
+			s, warnings, err := convert(f, opts)
+			if err != nil {
+				return err
+			}
+			if err := reportImport(format, len(s.Tests), warnings, reportPath); err != nil {
+				return err
+			}
 
-		// Apply CLI-level customizations
-		if baseURL != "" {
 			if verbose {
-				fmt.Fprintf(os.Stderr, "Overriding base URL to: %s\n", baseURL)
+				if baseURL != "" {
+					fmt.Fprintf(os.Stderr, "Overriding base URL to: %s\n", baseURL)
+				}
+				fmt.Fprintf(os.Stderr, "Imported %d tests from %s\n", len(s.Tests), source)
+				if len(s.Variables) > 0 {
+					fmt.Fprintf(os.Stderr, "Found %d variables\n", len(s.Variables))
+				}
 			}
-			s.BaseURL = baseURL
-		}
 
-		if verbose {
-			fmt.Fprintf(os.Stderr, "Imported %d tests from Bruno collection\n", len(s.Tests))
-			if len(s.Variables) > 0 {
-				fmt.Fprintf(os.Stderr, "Found %d variables\n", len(s.Variables))
+			y, err := yaml.Marshal(s)
+			if err != nil {
+				return err
 			}
-		}
-
-		y, err := yaml.Marshal(s)
-		if err != nil {
-			return err
-		}
-		if outPath == "" {
-			fmt.Print(string(y))
-			return nil
-		}
-		return ioutil.WriteFile(outPath, y, 0644)
-	}}
-	importBruno.Flags().StringVarP(&outPath, "out", "o", "", "Output file (defaults to stdout)")
-	importBruno.Flags().BoolVar(&noScripts, "no-scripts", false, "Skip conversion of pre/post request scripts")
-	importBruno.Flags().BoolVar(&flatFolders, "flat", false, "Flatten folder structure into simple test names")
-	importBruno.Flags().StringVar(&baseURL, "base-url", "", "Override base URL for all requests")
-	importBruno.Flags().BoolVar(&skipAuth, "skip-auth", false, "Skip conversion of authentication settings")
-
-	var importRestClient = &cobra.Command{Use: "restclient <file>", Short: "Import VS Code REST Client .http file", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
-		b, err := os.ReadFile(args[0])
-		if err != nil {
-			return err
-		}
-		s, err := rc.Convert(strings.NewReader(string(b)))
-		if err != nil {
-			return err
-		}
-
-		// Apply CLI-level customizations
-		if baseURL != "" {
-			if verbose {
-				fmt.Fprintf(os.Stderr, "Overriding base URL to: %s\n", baseURL)
+			if outPath == "" {
+				fmt.Print(string(y))
+				return nil
 			}
-			s.BaseURL = baseURL
+			return ioutil.WriteFile(outPath, y, 0644)
 		}
+	}
 
-		if verbose {
-			fmt.Fprintf(os.Stderr, "Imported %d tests from REST Client file\n", len(s.Tests))
-		}
+	var importPostman = &cobra.Command{Use: "postman <file>", Short: "Import Postman collection (v2.1 JSON)", Args: cobra.ExactArgs(1), RunE: runImport("postman", "Postman collection", pm.ConvertWithReport, pm.ConvertFiles)}
+	var importInsomnia = &cobra.Command{Use: "insomnia <file>", Short: "Import Insomnia export JSON", Args: cobra.ExactArgs(1), RunE: runImport("insomnia", "Insomnia export", in.ConvertWithReport, in.ConvertFiles)}
+	var importHAR = &cobra.Command{Use: "har <file>", Short: "Import HAR (HTTP Archive) JSON", Args: cobra.ExactArgs(1), RunE: runImport("har", "HAR file", har.ConvertWithReport, nil)}
+	var importOAPI = &cobra.Command{Use: "openapi <file>", Short: "Import OpenAPI (3.x) or Swagger (2.0) spec into a basic suite", Args: cobra.ExactArgs(1), RunE: runImport("openapi", "OpenAPI spec", oai.ConvertWithReport, nil)}
+	var importBruno = &cobra.Command{Use: "bruno <file>", Short: "Import minimal Bruno export JSON", Args: cobra.ExactArgs(1), RunE: runImport("bruno", "Bruno collection", bru.ConvertWithReport, bru.ConvertFiles)}
+	var importRestClient = &cobra.Command{Use: "restclient <file>", Short: "Import VS Code REST Client .http file", Args: cobra.ExactArgs(1), RunE: runImport("restclient", "REST Client file", rc.ConvertWithReport, nil)}
+	var importNewman = &cobra.Command{Use: "newman <file>", Short: "Import Newman (Postman CLI) collection JSON", Args: cobra.ExactArgs(1), RunE: runImport("newman", "Newman collection", nm.ConvertWithReport, nm.ConvertFiles)}
+	var importCurl = &cobra.Command{Use: "curl [file]", Short: "Import curl commands from a file or stdin", Args: cobra.MaximumNArgs(1), RunE: runImport("curl", "curl commands", curl.ConvertWithReport, nil)}
+	var importPact = &cobra.Command{Use: "pact <file>", Short: "Import Pact contract (v2-v4 JSON) into a provider verification suite", Args: cobra.ExactArgs(1), RunE: runImport("pact", "Pact contract", pact.ConvertWithReport, nil)}
 
-		y, err := yaml.Marshal(s)
-		if err != nil {
-			return err
-		}
-		if outPath == "" {
-			fmt.Print(string(y))
-			return nil
-		}
-		return ioutil.WriteFile(outPath, y, 0644)
-	}}
-	importRestClient.Flags().StringVarP(&outPath, "out", "o", "", "Output file (defaults to stdout)")
-	importRestClient.Flags().StringVar(&baseURL, "base-url", "", "Override base URL for all requests")
+	// Collection formats have scripts and folders
+	for _, c := range []*cobra.Command{importPostman, importInsomnia, importBruno, importNewman} {
+		c.Flags().BoolVar(&noScripts, "no-scripts", false, "Skip conversion of pre/post request scripts")
+		c.Flags().BoolVar(&flatFolders, "flat", false, "Flatten folder structure into simple test names (same as --folders flat)")
+		c.Flags().StringVar(&folderMode, "folders", "", "Folder handling: prefix (default), flat, tags or files (one suite per folder in the --out directory)")
+	}
+	importPostman.Flags().StringVarP(&envFile, "env", "e", "", "Postman environment file to merge variables from")
+	importNewman.Flags().StringVarP(&envFile, "env", "e", "", "Postman environment file to merge variables from")
+	importHAR.Flags().StringSliceVar(&includeHosts, "include-host", nil, "Keep only requests to these hosts (repeatable; *.example.com matches subdomains)")
+	importHAR.Flags().StringSliceVar(&excludeExts, "exclude-ext", adapters.StaticExts, "Drop requests whose path has one of these extensions (\"\" keeps all)")
+	importHAR.Flags().BoolVar(&onlyXHR, "only-xhr", false, "Keep only XHR/fetch requests")
 
-	var importNewman = &cobra.Command{Use: "newman <file>", Short: "Import Newman (Postman CLI) collection JSON", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
-		b, err := os.ReadFile(args[0])
-		if err != nil {
-			return err
-		}
+	importCmd.AddCommand(importPostman, importInsomnia, importHAR, importOAPI, importBruno, importRestClient, importNewman, importCurl, importPact)
+	rootCmd.AddCommand(importCmd)
 
-		// Load environment variables if specified
-		envVars := make(map[string]string)
-		if envFile != "" {
-			envData, err := os.ReadFile(envFile)
+	// Generate commands: suites derived from a spec rather than converted from a collection
+	generateCmd := &cobra.Command{Use: "generate", Short: "Generate test suites from an API spec"}
+	var specPath string
+	generateNegative := &cobra.Command{
+		Use:   "negative",
+		Short: "Generate tests that violate an OpenAPI spec and expect 4xx responses",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(specPath)
 			if err != nil {
-				return fmt.Errorf("failed to read environment file: %w", err)
+				return err
 			}
-			envVars, err = parsePostmanEnvironment(envData)
+			defer f.Close()
+			s, warnings, err := oai.Negative(f, adapters.Options{SkipAuth: skipAuth, BaseURL: baseURL})
 			if err != nil {
-				return fmt.Errorf("failed to parse environment file: %w", err)
+				return err
+			}
+			if err := reportImport("openapi", len(s.Tests), warnings, reportPath); err != nil {
+				return err
 			}
-		}
-
-		s, err := nm.Convert(strings.NewReader(string(b)), envVars)
-		if err != nil {
-			return err
-		}
-
-		// Apply CLI-level customizations
-		if baseURL != "" {
 			if verbose {
-				fmt.Fprintf(os.Stderr, "Overriding base URL to: %s\n", baseURL)
+				fmt.Fprintf(os.Stderr, "Generated %d negative tests from %s\n", len(s.Tests), specPath)
 			}
-			s.BaseURL = baseURL
-		}
+			y, err := yaml.Marshal(s)
+			if err != nil {
+				return err
+			}
+			if outPath == "" {
+				fmt.Print(string(y))
+				return nil
+			}
+			return os.WriteFile(outPath, y, 0644)
+		},
+	}
+	generateNegative.Flags().StringVar(&specPath, "spec", "", "OpenAPI (3.x) or Swagger (2.0) spec file")
+	_ = generateNegative.MarkFlagRequired("spec")
+	generateCmd.PersistentFlags().StringVarP(&outPath, "out", "o", "", "Output file (defaults to stdout)")
+	generateCmd.PersistentFlags().StringVar(&baseURL, "base-url", "", "Override base URL for all requests")
+	generateCmd.PersistentFlags().BoolVar(&skipAuth, "skip-auth", false, "Leave out the suite auth and the missing-auth tests")
+	generateCmd.PersistentFlags().StringVar(&reportPath, "report", "", "Write the generation warnings as JSON to this file")
+	generateCmd.AddCommand(generateNegative)
+	rootCmd.AddCommand(generateCmd)
 
-		if verbose {
-			fmt.Fprintf(os.Stderr, "Imported %d tests from Newman collection\n", len(s.Tests))
-			if len(s.Variables) > 0 {
-				fmt.Fprintf(os.Stderr, "Found %d variables\n", len(s.Variables))
+	// OpenAPI commands: checks on the specs themselves
+	openapiCmd := &cobra.Command{Use: "openapi", Short: "Work with OpenAPI specs"}
+	var diffFormat, diffOut string
+	var diffSuites []string
+	openapiDiff := &cobra.Command{
+		Use:   "diff <old> <new>",
+		Short: "Report breaking changes between two OpenAPI specs and the suite tests they affect",
+		Long:  "Compares two versions of an OpenAPI (3.x) spec and lists breaking changes: removed operations, newly required parameters and request fields, parameter type changes, narrowed enums, and removed or changed responses. Tests in the --suites files are matched against the old spec's operations the same way a run matches them. Exits with code 1 when there are breaking changes.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldDoc, err := openapidiff.Load(args[0])
+			if err != nil {
+				return err
 			}
-		}
+			newDoc, err := openapidiff.Load(args[1])
+			if err != nil {
+				return err
+			}
+			r := openapidiff.Report{Old: args[0], New: args[1], Changes: openapidiff.Diff(oldDoc, newDoc)}
+			touches, err := suiteTouches(oldDoc, diffSuites)
+			if err != nil {
+				return err
+			}
+			r.Link(touches)
+			var out strings.Builder
+			if err := openapidiff.Write(&out, r, diffFormat); err != nil {
+				return err
+			}
+			if diffOut == "" {
+				fmt.Print(out.String())
+			} else if err := os.WriteFile(diffOut, []byte(out.String()), 0644); err != nil {
+				return err
+			}
+			if len(r.Changes) > 0 {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+	openapiDiff.Flags().StringVar(&diffFormat, "format", "text", "Output format: text|json|markdown")
+	openapiDiff.Flags().StringVarP(&diffOut, "out", "o", "", "Write the report to this file (defaults to stdout)")
+	openapiDiff.Flags().StringArrayVar(&diffSuites, "suites", []string{"testdata/*.hrq.yaml"}, "Suite files or globs whose tests are matched against changed operations (repeatable)")
+	openapiCmd.AddCommand(openapiDiff)
+	rootCmd.AddCommand(openapiCmd)
 
-		y, err := yaml.Marshal(s)
-		if err != nil {
-			return err
-		}
-		if outPath == "" {
-			fmt.Print(string(y))
+	// Export commands: suites written out in other tools' formats
+	exportCmd := &cobra.Command{Use: "export", Short: "Export test suites to other formats"}
+	var exportFile, exportOut, pactConsumer, pactProvider string
+	exportCmd.PersistentFlags().StringVarP(&exportFile, "file", "f", "", "Path to YAML test suite")
+	exportCmd.PersistentFlags().StringVarP(&exportOut, "out", "o", "", "Output file (defaults to stdout)")
+	_ = exportCmd.MarkPersistentFlagRequired("file")
+	exportPact := &cobra.Command{
+		Use:   "pact",
+		Short: "Run a suite and record its exchanges as a Pact contract",
+		Long:  "Runs the suite and, when every test passes, writes its HTTP exchanges as a Pact (v3) contract: responses are matched by type, except the values pinned with jsonEquals. Provider state hooks as written by `hydreq import pact` become provider states. Nothing is written when a test fails.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliVars, err := runner.ParseVarFlags(varFlags)
+			if err != nil {
+				return err
+			}
+			s, err := runner.LoadSuite(exportFile)
+			if err != nil {
+				return fmt.Errorf("load suite %s: %w", exportFile, err)
+			}
+			if err := runner.ApplyEnvironment(s, exportFile, envProfile, cliVars); err != nil {
+				return fmt.Errorf("load suite %s: %w", exportFile, err)
+			}
+			if exportOut == "" {
+				// stdout carries the contract
+				ui.Enabled = false
+			}
+			tests := make(map[string]models.TestCase, len(s.Tests))
+			order := make(map[string]int, len(s.Tests))
+			for i, t := range s.Tests {
+				tests[t.Name], order[t.Name] = t, i
+			}
+			var exchanges []pact.Exchange
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			defer cancel()
+			sum, err := runner.RunSuite(ctx, s, runner.Options{Verbose: verbose, Workers: workers, DefaultTimeoutMs: defaultTimeoutMs, OnResult: func(tr runner.TestResult) {
+				if tr.Status != "passed" || tr.Request == nil || tr.Response == nil {
+					return
+				}
+				exchanges = append(exchanges, pact.Exchange{Name: tr.Name, Test: tests[tr.Name], Request: *tr.Request, Status: tr.Response.Status, Headers: tr.Response.Headers, Body: tr.Response.Body})
+			}})
+			if sum.Failed > 0 {
+				return fmt.Errorf("%d test(s) failed; no pact written", sum.Failed)
+			}
+			if err != nil {
+				return err
+			}
+			// Tests of one stage finish in any order; keep the suite's
+			sort.SliceStable(exchanges, func(i, j int) bool { return order[exchanges[i].Name] < order[exchanges[j].Name] })
+			consumer, provider := pactConsumer, pactProvider
+			if provider == "" {
+				provider = s.Name
+			}
+			var out strings.Builder
+			if err := pact.Export(&out, s, consumer, provider, exchanges); err != nil {
+				return err
+			}
+			if exportOut == "" {
+				fmt.Print(out.String())
+				return nil
+			}
+			if err := os.WriteFile(exportOut, []byte(out.String()), 0644); err != nil {
+				return err
+			}
+			ui.Successf("Wrote %d interaction(s) to %s", len(exchanges), exportOut)
 			return nil
+		},
+	}
+	exportPact.Flags().StringVar(&pactConsumer, "consumer", "hydreq", "Consumer name recorded in the pact")
+	exportPact.Flags().StringVar(&pactProvider, "provider", "", "Provider name recorded in the pact (defaults to the suite name)")
+	exportPact.Flags().StringVar(&envProfile, "env", "", "Environment profile: suite environments key, <name>.env.yaml next to the suite, or a path to a .env.yaml file")
+	exportPact.Flags().StringArrayVar(&varFlags, "var", nil, "Override a variable (key=value); repeatable, wins over profile and suite vars")
+	exportPact.Flags().IntVar(&workers, "workers", 4, "Number of concurrent workers per stage")
+	exportPact.Flags().IntVar(&defaultTimeoutMs, "default-timeout-ms", 30000, "Default per-request timeout when test.timeoutMs is not set")
+	exportCmd.AddCommand(exportPact)
+	var exportKeepVars bool
+	var exportTests []string
+	for _, f := range []struct {
+		use, short string
+		write      func(io.Writer, adapters.Export) error
+	}{
+		{"postman", "Write a suite as a Postman v2.1 collection", pm.Export},
+		{"curl", "Write a suite as curl commands", curl.Export},
+		{"http", "Write a suite as a VS Code REST Client .http file", rc.Export},
+		{"har", "Write a suite's requests as a HAR 1.2 log", har.Export},
+	} {
+		c := &cobra.Command{
+			Use:   f.use,
+			Short: f.short,
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				ex, warnings, err := exportSuite(exportFile, envProfile, varFlags, exportKeepVars, exportTests)
+				if err != nil {
+					return err
+				}
+				if len(warnings) > 0 {
+					fmt.Fprintf(os.Stderr, "%d export warning(s):\n", len(warnings))
+					for _, w := range warnings {
+						fmt.Fprintf(os.Stderr, "  - %s\n", w)
+					}
+				}
+				var out strings.Builder
+				if err := f.write(&out, ex); err != nil {
+					return err
+				}
+				if exportOut == "" {
+					fmt.Print(out.String())
+					return nil
+				}
+				if err := os.WriteFile(exportOut, []byte(out.String()), 0644); err != nil {
+					return err
+				}
+				ui.Successf("Wrote %d request(s) to %s", len(ex.Requests), exportOut)
+				return nil
+			},
 		}
-		return ioutil.WriteFile(outPath, y, 0644)
-	}}
-	importNewman.Flags().StringVarP(&outPath, "out", "o", "", "Output file (defaults to stdout)")
-	importNewman.Flags().StringVarP(&envFile, "env", "e", "", "Postman environment file to merge variables from")
-	importNewman.Flags().BoolVar(&noScripts, "no-scripts", false, "Skip conversion of pre/post request scripts")
-	importNewman.Flags().BoolVar(&flatFolders, "flat", false, "Flatten folder structure into simple test names")
-	importNewman.Flags().StringVar(&baseURL, "base-url", "", "Override base URL for all requests")
-	importNewman.Flags().BoolVar(&skipAuth, "skip-auth", false, "Skip conversion of authentication settings")
-
-	importCmd.AddCommand(importPostman, importInsomnia, importHAR, importOAPI, importBruno, importRestClient, importNewman)
-	rootCmd.AddCommand(importCmd)
+		c.Flags().BoolVar(&exportKeepVars, "keep-vars", false, "Keep ${name} references as the target format's variables instead of interpolating them")
+		c.Flags().StringArrayVar(&exportTests, "test", nil, "Export only the test with this name; repeatable")
+		c.Flags().StringVar(&envProfile, "env", "", "Environment profile: suite environments key, <name>.env.yaml next to the suite, or a path to a .env.yaml file")
+		c.Flags().StringArrayVar(&varFlags, "var", nil, "Override a variable (key=value); repeatable, wins over profile and suite vars")
+		exportCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(exportCmd)
 
 	// GUI command
 	var detach bool
@@ -717,6 +946,86 @@ func sanitizeFilename(s string) string {
 	return string(out)
 }
 
+// recordCoverage adds a suite's spec and the operations its tests hit.
+func recordCoverage(cov *report.OpenAPICoverage, spec *runner.OpenAPISpec, hits []*runner.OpenAPIHit) {
+	ops := make([]report.OperationCoverage, 0, len(spec.Operations))
+	for _, op := range spec.Operations {
+		oc := report.OperationCoverage{Method: op.Method, Path: op.Path, Params: coverageParams(op.Params)}
+		for _, code := range op.Statuses {
+			oc.Statuses = append(oc.Statuses, report.StatusCoverage{Code: code, Documented: true})
+		}
+		ops = append(ops, oc)
+	}
+	cov.AddSpec(spec.File, ops)
+	for _, h := range hits {
+		cov.Record(h.Spec, h.Method, h.Path, h.Status, coverageParams(h.Params))
+	}
+}
+
+// suiteTouches lists the operations of doc that the tests in the suites
+// matching globs hit. Suites that fail to load are skipped with a note.
+func suiteTouches(doc *openapi3.T, globs []string) ([]openapidiff.Touch, error) {
+	m, err := runner.NewOpenAPIMatcher(doc)
+	if err != nil {
+		return nil, err
+	}
+	var touches []openapidiff.Touch
+	seen := map[string]bool{}
+	for _, g := range globs {
+		paths, err := filepath.Glob(g)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range paths {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			s, err := runner.LoadSuite(p)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "skipping suite %s: %v\n", p, err)
+				continue
+			}
+			for _, t := range s.Tests {
+				if method, path, ok := m.MatchTest(s, t); ok {
+					touches = append(touches, openapidiff.Touch{TestRef: openapidiff.TestRef{Suite: p, Test: t.Name}, Method: method, Path: path})
+				}
+			}
+		}
+	}
+	return touches, nil
+}
+
+func coverageParams(params []runner.OpenAPIParam) []report.ParamCoverage {
+	if len(params) == 0 {
+		return nil
+	}
+	out := make([]report.ParamCoverage, 0, len(params))
+	for _, p := range params {
+		out = append(out, report.ParamCoverage{Name: p.Name, In: p.In})
+	}
+	return out
+}
+
+// looksSecret reports whether a variable name suggests a credential.
+func looksSecret(name string) bool {
+	n := strings.ToLower(name)
+	for _, hint := range []string{"token", "secret", "password", "passwd", "apikey", "api_key", "auth", "credential", "private"} {
+		if strings.Contains(n, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskValue hides a value while hinting whether it is set.
+func maskValue(v string) string {
+	if v == "" {
+		return ""
+	}
+	return "********"
+}
+
 // appendSummary appends a small markdown section to the GitHub Actions step summary file
 // to surface suites that failed to load.
 func appendSummary(path string, failedLoads []string) error {
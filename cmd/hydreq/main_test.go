@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -259,6 +260,66 @@ func TestCLI_HelpAndVersion(t *testing.T) {
 	}
 }
 
+func TestCLI_EnvShow(t *testing.T) {
+	cmd := exec.Command("../../bin/hydreq", "env", "show", "-f", "../../testdata/environments.hrq.yaml", "--env", "staging", "--var", "stage=override")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("env show failed: %v, stderr: %s", err, stderr.String())
+	}
+	out := stdout.String()
+	if strings.Contains(out, "staging-token") || !strings.Contains(out, "********") {
+		t.Errorf("expected apiToken to be masked, got: %s", out)
+	}
+	if !strings.Contains(out, "stage") || !strings.Contains(out, "override") || !strings.Contains(out, "cli") {
+		t.Errorf("expected --var to win with source cli, got: %s", out)
+	}
+
+	cmd = exec.Command("../../bin/hydreq", "env", "show", "-f", "../../testdata/environments.hrq.yaml", "--env", "missing")
+	if err := cmd.Run(); err == nil {
+		t.Errorf("expected unknown profile to fail")
+	}
+}
+
+func TestExportSuite_MatrixAndSecrets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "export.env"), []byte("EXPORT_TOKEN=s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	suite := `name: export
+baseUrl: https://api.example.com
+secretsFile: export.env
+tests:
+  - name: list
+    request:
+      method: GET
+      url: /items?size=${size}
+      headers:
+        X-Token: ${SECRET:EXPORT_TOKEN}
+        X-Other: ${SECRET:EXPORT_MISSING}
+    matrix:
+      size: ["1", "2"]
+`
+	path := filepath.Join(dir, "export.hrq.yaml")
+	if err := os.WriteFile(path, []byte(suite), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ex, ws, err := exportSuite(path, "", nil, false, []string{"list"})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(ex.Requests) != 2 || ex.Requests[0].Name != "list [size=1]" || ex.Requests[1].Request.URL != "https://api.example.com/items?size=2" {
+		t.Fatalf("requests: %+v", ex.Requests)
+	}
+	if got := ex.Requests[0].Request.Headers["X-Token"]; got != "s3cr3t" {
+		t.Fatalf("X-Token = %q", got)
+	}
+	if len(ws) != 2 || ws[0].Feature != "secrets" || ws[0].Detail != "EXPORT_MISSING" {
+		t.Fatalf("warnings: %+v", ws)
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Build the binary before running tests
 	cmd := exec.Command("go", "build", "-o", "../../bin/hydreq", ".")
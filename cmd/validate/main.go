@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/DrWeltschmerz/HydReq/internal/runner"
 	valfmt "github.com/DrWeltschmerz/HydReq/internal/validate"
 	jsonschema "github.com/santhosh-tekuri/jsonschema/v5"
 	kyaml "sigs.k8s.io/yaml"
@@ -72,13 +73,17 @@ func main() {
 		if err := sch.Validate(v); err != nil {
 			fmt.Fprintf(os.Stderr, "FAIL  %s\n  %v\n", f, err)
 			failed++
+		} else if _, err := runner.LoadSuite(f); err != nil {
+			// schema can't see template references; LoadSuite resolves extends
+			fmt.Fprintf(os.Stderr, "FAIL  %s\n  %v\n", f, err)
+			failed++
 		} else if !quiet {
 			fmt.Printf("PASS  %s\n", f)
 		}
 	}
 
 	if failed > 0 {
-		fmt.Fprintf(os.Stderr, "\n%d file(s) failed schema validation\n", failed)
+		fmt.Fprintf(os.Stderr, "\n%d file(s) failed validation\n", failed)
 		os.Exit(1)
 	}
 }
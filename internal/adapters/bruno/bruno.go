@@ -3,7 +3,9 @@ package bruno
 import (
 	"encoding/json"
 	"io"
+	"strings"
 
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 	"github.com/DrWeltschmerz/HydReq/internal/script"
 	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
@@ -19,10 +21,15 @@ type brunoExport struct {
 type brunoItem struct {
 	Uid     string      `json:"uid"`
 	Name    string      `json:"name"`
-	Type    string      `json:"type"` // http-request, folder
+	Type    string      `json:"type"` // http-request, graphql-request, folder
 	Seq     int         `json:"seq,omitempty"`
 	Request *brunoReq   `json:"request,omitempty"`
 	Items   []brunoItem `json:"items,omitempty"`
+	Root    *brunoRoot  `json:"root,omitempty"` // folder settings
+}
+
+type brunoRoot struct {
+	Request *brunoReq `json:"request,omitempty"`
 }
 
 type brunoReq struct {
@@ -61,12 +68,18 @@ type brunoBearer struct {
 }
 
 type brunoBody struct {
-	Mode           string    `json:"mode"`
-	Json           string    `json:"json,omitempty"`
-	Text           string    `json:"text,omitempty"`
-	Xml            string    `json:"xml,omitempty"`
-	FormUrlEncoded []brunoKV `json:"formUrlEncoded,omitempty"`
-	MultipartForm  []brunoKV `json:"multipartForm,omitempty"`
+	Mode           string        `json:"mode"`
+	Json           string        `json:"json,omitempty"`
+	Text           string        `json:"text,omitempty"`
+	Xml            string        `json:"xml,omitempty"`
+	FormUrlEncoded []brunoKV     `json:"formUrlEncoded,omitempty"`
+	MultipartForm  []brunoKV     `json:"multipartForm,omitempty"`
+	Graphql        *brunoGraphql `json:"graphql,omitempty"`
+}
+
+type brunoGraphql struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables,omitempty"`
 }
 
 type brunoScript struct {
@@ -103,10 +116,46 @@ type brunoEnvVar struct {
 
 // Convert converts Bruno JSON export to HydReq Suite
 func Convert(r io.Reader) (*models.Suite, error) {
+	s, _, err := ConvertWithReport(r, adapters.Options{})
+	return s, err
+}
+
+// ConvertWithReport is Convert that also returns what could not be imported
+// as-is. In files mode the folder settings are left out as in prefix mode;
+// use ConvertFiles to get one suite per folder.
+func ConvertWithReport(r io.Reader, opts adapters.Options) (*models.Suite, []adapters.Warning, error) {
+	if opts.Folders == adapters.FoldersFiles {
+		opts.Folders = adapters.FoldersPrefix
+	}
+	s, _, ws, err := convert(r, opts)
+	return s, ws, err
+}
+
+// ConvertFiles converts a collection into one suite per folder (see
+// adapters.Split). Folder auth, vars and scripts become the suite auth, vars
+// and preSuite/postSuite hooks of the folder's file.
+func ConvertFiles(r io.Reader, opts adapters.Options) ([]adapters.File, []adapters.Warning, error) {
+	opts.Folders = adapters.FoldersFiles
+	s, root, ws, err := convert(r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := adapters.Split(s, root)
+	for _, f := range files {
+		opts.Apply(f.Suite)
+	}
+	return files, ws, nil
+}
+
+// convert does the work for both entry points. In files mode the tests are
+// collected in the returned folder tree instead of the suite.
+func convert(r io.Reader, opts adapters.Options) (*models.Suite, *adapters.Folder, adapters.Warnings, error) {
 	var export brunoExport
 	if err := json.NewDecoder(r).Decode(&export); err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
+	var ws adapters.Warnings
+	root := &adapters.Folder{}
 
 	suite := &models.Suite{
 		Name: export.Name,
@@ -127,38 +176,91 @@ func Convert(r io.Reader) (*models.Suite, error) {
 	// Handle legacy requests array or new items
 	if len(export.Requests) > 0 {
 		for _, req := range export.Requests {
-			tc := convertRequest(req)
-			suite.Tests = append(suite.Tests, tc)
+			tc := convertRequest(req, "", opts, &ws)
+			if opts.Folders == adapters.FoldersFiles {
+				root.Tests = append(root.Tests, tc)
+			} else {
+				suite.Tests = append(suite.Tests, tc)
+			}
 		}
 	} else {
 		// Process items recursively
 		for _, item := range export.Items {
-			processItem(&item, suite, "")
+			processItem(&item, suite, root, nil, opts, &ws)
 		}
 	}
 
-	return suite, nil
+	if opts.Folders != adapters.FoldersFiles {
+		opts.Apply(suite)
+	}
+	return suite, root, ws, nil
 }
 
-func processItem(item *brunoItem, suite *models.Suite, prefix string) {
-	fullName := item.Name
-	if prefix != "" {
-		fullName = prefix + "/" + item.Name
-	}
+func processItem(item *brunoItem, suite *models.Suite, folder *adapters.Folder, folders []string, opts adapters.Options, ws *adapters.Warnings) {
+	fullName := strings.Join(append(folders[:len(folders):len(folders)], item.Name), "/")
 
-	if item.Type == "http-request" && item.Request != nil {
-		tc := convertRequest(*item.Request)
-		tc.Name = fullName
-		suite.Tests = append(suite.Tests, tc)
+	if (item.Type == "http-request" || item.Type == "graphql-request") && item.Request != nil {
+		tc := convertRequest(*item.Request, fullName, opts, ws)
+		tc.Name = opts.TestName(folders, item.Name, "/")
+		tc.Tags = opts.FolderTags(folders)
+		if opts.Folders == adapters.FoldersFiles {
+			folder.Tests = append(folder.Tests, tc)
+		} else {
+			suite.Tests = append(suite.Tests, tc)
+		}
 	} else if item.Type == "folder" {
+		sub := folder.Add(item.Name)
+		if item.Root != nil && item.Root.Request != nil {
+			convertFolder(*item.Root.Request, fullName, sub, opts, ws)
+		}
 		for _, subItem := range item.Items {
-			processItem(&subItem, suite, fullName)
+			processItem(&subItem, suite, sub, append(folders[:len(folders):len(folders)], item.Name), opts, ws)
+		}
+	} else {
+		ws.Add(fullName, "item:"+item.Type, "skipped")
+	}
+}
+
+// convertFolder moves a folder's auth, vars and scripts onto f, the
+// suite-level settings of its file; outside files mode they are reported as
+// skipped. Folder scripts become suite hooks that see no response.
+func convertFolder(req brunoReq, name string, f *adapters.Folder, opts adapters.Options, ws *adapters.Warnings) {
+	files := opts.Folders == adapters.FoldersFiles
+	if a := req.Auth; a != nil && a.Mode != "" && a.Mode != "none" && a.Mode != "inherit" && !opts.SkipAuth {
+		switch {
+		case !files:
+			ws.Add(name, "folder auth", "skipped")
+		case a.Mode == "basic" && a.Basic != nil:
+			f.Auth = &models.Auth{BasicEnv: a.Basic.Username + ":" + a.Basic.Password}
+		case a.Mode == "bearer" && a.Bearer != nil:
+			f.Auth = &models.Auth{BearerEnv: a.Bearer.Token}
+		default:
+			ws.Add(name, "auth:"+a.Mode, "skipped (only basic and bearer are supported)")
+		}
+	}
+	if vars := convertVars(req.Vars); len(vars) > 0 {
+		if files {
+			f.Vars = vars
+		} else {
+			ws.Add(name, "folder variables", "skipped")
 		}
 	}
+	if s := req.Script; s != nil && (s.Req != "" || s.Res != "") && !opts.NoScripts {
+		if !files {
+			ws.Add(name, "folder scripts", "skipped")
+			return
+		}
+		pre := script.TranslateJSToHook(s.Req, "bruno", script.PreRequest)
+		post := script.TranslateJSToHook(s.Res, "bruno", script.PreRequest)
+		f.PreSuite, f.PostSuite = pre.Hooks, post.Hooks
+		ws.Script(pre.IssuesFor(name, "req"))
+		ws.Script(post.IssuesFor(name, "res"))
+	}
 }
 
-func convertRequest(req brunoReq) models.TestCase {
+func convertRequest(req brunoReq, name string, opts adapters.Options, ws *adapters.Warnings) models.TestCase {
 	tc := models.TestCase{
+		Name: name,
 		Request: models.Request{
 			Method: req.Method,
 			URL:    req.Url,
@@ -171,6 +273,8 @@ func convertRequest(req brunoReq) models.TestCase {
 		for _, h := range req.Headers {
 			if h.Enabled {
 				tc.Request.Headers[h.Name] = h.Value
+			} else {
+				ws.Disabled(name, "header", h.Name)
 			}
 		}
 	}
@@ -181,13 +285,18 @@ func convertRequest(req brunoReq) models.TestCase {
 		for _, p := range req.Params {
 			if p.Enabled {
 				tc.Request.Query[p.Name] = p.Value
+			} else {
+				ws.Disabled(name, "query parameter", p.Name)
 			}
 		}
 	}
 
 	// Convert auth
-	if req.Auth != nil {
+	if req.Auth != nil && !opts.SkipAuth {
 		authHeaders := convertBrunoAuthToHeaders(req.Auth)
+		if len(authHeaders) == 0 && req.Auth.Mode != "" && req.Auth.Mode != "none" && req.Auth.Mode != "inherit" {
+			ws.Add(name, "auth:"+req.Auth.Mode, "skipped (only basic and bearer are supported)")
+		}
 		if tc.Request.Headers == nil {
 			tc.Request.Headers = make(map[string]string)
 		}
@@ -198,13 +307,8 @@ func convertRequest(req brunoReq) models.TestCase {
 
 	// Convert body
 	if req.Body != nil {
-		tc.Request.Body = convertBody(req.Body)
-	}
-
-	// Convert scripts to hooks
-	if req.Script != nil {
-		tc.Pre = convertScriptToHooks(req.Script.Req, "pre-request")
-		tc.Post = convertScriptToHooks(req.Script.Res, "post-response")
+		tc.Request.Body = convertBody(req.Body, name, ws)
+		tc.Request.GraphQL = convertGraphQL(req.Body, name, ws)
 	}
 
 	// Convert vars
@@ -215,12 +319,27 @@ func convertRequest(req brunoReq) models.TestCase {
 	// Convert assertions
 	if req.Assertions != nil {
 		tc.Assert = convertAssertions(req.Assertions)
+		for _, a := range req.Assertions {
+			if a.Enabled {
+				ws.Addf(name, "assertion", "skipped", "%s", a.Name)
+			}
+		}
+	}
+
+	// Convert scripts to hooks, assertions and extractions
+	if req.Script != nil && !opts.NoScripts {
+		pre := script.TranslateJSToHook(req.Script.Req, "bruno", script.PreRequest)
+		post := script.TranslateJSToHook(req.Script.Res, "bruno", script.PostResponse)
+		pre.ApplyTo(&tc)
+		post.ApplyTo(&tc)
+		ws.Script(pre.IssuesFor(name, "req"))
+		ws.Script(post.IssuesFor(name, "res"))
 	}
 
 	return tc
 }
 
-func convertBody(body *brunoBody) any {
+func convertBody(body *brunoBody, item string, ws *adapters.Warnings) any {
 	if body == nil {
 		return nil
 	}
@@ -238,21 +357,30 @@ func convertBody(body *brunoBody) any {
 		for _, kv := range body.FormUrlEncoded {
 			if kv.Enabled {
 				result[kv.Name] = kv.Value
+			} else {
+				ws.Disabled(item, "form field", kv.Name)
 			}
 		}
 		return result
 	case "multipartForm":
 		// Placeholder - multipart is complex
+		ws.Add(item, "body:multipartForm", "replaced with a placeholder string")
 		return "multipart form data (not yet supported)"
+	case "graphql", "none", "":
+		// graphql bodies become request.graphql, see convertGraphQL
+	default:
+		ws.Add(item, "body:"+body.Mode, "skipped")
 	}
 	return nil
 }
 
-func convertScriptToHooks(jsScript, name string) []models.Hook {
-	if jsScript == "" {
+// convertGraphQL maps a graphql body onto request.graphql. Bruno keeps the
+// variables as JSON text.
+func convertGraphQL(body *brunoBody, item string, ws *adapters.Warnings) *models.GraphQL {
+	if body.Mode != "graphql" || body.Graphql == nil {
 		return nil
 	}
-	return script.TranslateJSToHook(jsScript, "bruno")
+	return adapters.GraphQL(body.Graphql.Query, body.Graphql.Variables, item, ws)
 }
 
 func convertVars(vars *brunoVars) map[string]string {
@@ -3,6 +3,8 @@ package bruno
 import (
 	"strings"
 	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 )
 
 func TestConvert_Minimal(t *testing.T) {
@@ -274,3 +276,50 @@ func TestConvert_InvalidJSON(t *testing.T) {
 		t.Fatal("expected error for invalid JSON")
 	}
 }
+
+func TestConvertWithReport(t *testing.T) {
+	js := `{"name":"b","items":[
+		{"name":"rpc","type":"grpc-request"},
+		{"name":"get","type":"http-request","request":{"method":"GET","url":"https://example.com",
+			"headers":[{"name":"X-Off","value":"1","enabled":false}],
+			"auth":{"mode":"awsv4"}}}
+	]}`
+	s, warnings, err := ConvertWithReport(strings.NewReader(js), adapters.Options{})
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if len(s.Tests) != 1 {
+		t.Fatalf("expected 1 test, got %d", len(s.Tests))
+	}
+	want := []string{
+		"rpc: item:grpc-request: skipped",
+		"get: disabled header: skipped (X-Off)",
+		"get: auth:awsv4: skipped (only basic and bearer are supported)",
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("expected %d warnings, got %v", len(want), warnings)
+	}
+	for i, w := range want {
+		if warnings[i].String() != w {
+			t.Errorf("warning %d: expected %q, got %q", i, w, warnings[i].String())
+		}
+	}
+}
+
+func TestConvertGraphQL(t *testing.T) {
+	js := `{"name":"b","items":[
+		{"name":"user","type":"graphql-request","request":{"method":"POST","url":"https://api.example.com/graphql",
+			"body":{"mode":"graphql","graphql":{"query":"query($id: ID!) { user(id: $id) { name } }","variables":"{\"id\": \"7\"}"}}}}
+	]}`
+	s, ws, err := ConvertWithReport(strings.NewReader(js), adapters.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tests) != 1 || len(ws) != 0 {
+		t.Fatalf("tests %+v, warnings %v", s.Tests, ws)
+	}
+	r := s.Tests[0].Request
+	if r.Body != nil || r.GraphQL == nil || r.GraphQL.Query != "query($id: ID!) { user(id: $id) { name } }" || r.GraphQL.Variables["id"] != "7" {
+		t.Fatalf("request = %+v", r)
+	}
+}
@@ -0,0 +1,610 @@
+// Package curl converts curl commands into HydReq suites and writes suites
+// back out as curl commands.
+package curl
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// command is one shell command with the comment written just above it.
+type command struct {
+	words   []string
+	comment string
+	line    int
+}
+
+// Convert reads curl commands (one or many, as pasted in a terminal or a
+// script) and converts each to a test.
+func Convert(r io.Reader) (*models.Suite, error) {
+	s, _, err := ConvertWithReport(r, adapters.Options{})
+	return s, err
+}
+
+// ConvertWithReport is Convert that also returns what could not be imported
+// as-is.
+//
+// A comment line right above a command names its test. Shell assignments
+// (NAME=value, as written by Export) become suite vars, and $NAME references
+// ${NAME} placeholders. Credentials from -u, --oauth2-bearer and
+// Authorization headers are moved into suite vars listed in secrets, and an
+// origin shared by every request becomes the suite baseUrl.
+func ConvertWithReport(r io.Reader, opts adapters.Options) (*models.Suite, []adapters.Warning, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, defaults, err := lex(string(src))
+	if err != nil {
+		return nil, nil, err
+	}
+	var ws adapters.Warnings
+	s := &models.Suite{Name: "curl import"}
+	c := &converter{suite: s, ws: &ws, opts: opts, creds: map[string]string{}}
+	names := map[string]int{}
+	for _, cmd := range commands(items) {
+		words := cmd.words
+		if len(words) > 0 && words[0] == "$" {
+			words = words[1:] // pasted prompt
+		}
+		if len(words) > 1 && words[0] == "export" {
+			words = words[1:]
+		}
+		switch {
+		case len(words) == 0:
+		case len(words) == 1 && isAssignment(words[0]):
+			name, value, _ := strings.Cut(words[0], "=")
+			if value == "${"+name+"}" {
+				value = defaults[name]
+			}
+			if s.Variables == nil {
+				s.Variables = map[string]string{}
+			}
+			s.Variables[name] = value
+		case path.Base(strings.TrimSuffix(words[0], ".exe")) == "curl":
+			tc, ok := c.convert(words[1:], cmd.line)
+			if !ok {
+				continue
+			}
+			tc.Name = cmd.comment
+			if tc.Name == "" {
+				tc.Name = tc.Request.Method + " " + tc.Request.URL
+			}
+			if n := names[tc.Name]; n > 0 {
+				names[tc.Name] = n + 1
+				tc.Name = fmt.Sprintf("%s #%d", tc.Name, n+1)
+			} else {
+				names[tc.Name] = 1
+			}
+			s.Tests = append(s.Tests, tc)
+		default:
+			ws.Addf("", "command:"+words[0], "skipped (not curl)", "line %d", cmd.line)
+		}
+	}
+	liftBaseURL(s)
+	opts.Apply(s)
+	return s, ws, nil
+}
+
+// commands groups lexed items into commands. Only a comment on the line
+// right above a command names it.
+func commands(items []item) []command {
+	var out []command
+	var cur command
+	comment, commentLine := "", 0
+	for _, it := range items {
+		switch it.kind {
+		case itemComment:
+			if len(cur.words) == 0 {
+				comment, commentLine = it.text, it.line
+			}
+		case itemWord:
+			if len(cur.words) == 0 {
+				cur.line = it.line
+				if commentLine == it.line-1 {
+					cur.comment = comment
+				}
+			}
+			cur.words = append(cur.words, it.text)
+		case itemEnd:
+			if len(cur.words) > 0 {
+				out = append(out, cur)
+				comment = ""
+			}
+			cur = command{}
+		}
+	}
+	if len(cur.words) > 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+func isAssignment(w string) bool {
+	name, _, ok := strings.Cut(w, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isNameByte(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// shortArgs and longArgs list the options that take a value; shortFlags
+// maps the short options that do not to their long names.
+var shortArgs = map[byte]string{
+	'X': "--request", 'H': "--header", 'd': "--data", 'F': "--form", 'u': "--user",
+	'b': "--cookie", 'A': "--user-agent", 'e': "--referer", 'm': "--max-time",
+	'o': "--output", 'w': "--write-out", 'x': "--proxy", 'c': "--cookie-jar",
+	'T': "--upload-file", 'E': "--cert", 'K': "--config", 'r': "--range",
+	'U': "--proxy-user", 'D': "--dump-header", 'C': "--continue-at", 'Y': "--speed-limit",
+	'y': "--speed-time", 'z': "--time-cond", 't': "--telnet-option", 'P': "--ftp-port", 'Q': "--quote",
+}
+
+var shortFlags = map[byte]string{
+	'G': "--get", 'I': "--head", 's': "--silent", 'S': "--show-error", 'v': "--verbose",
+	'i': "--include", 'L': "--location", 'f': "--fail", 'k': "--insecure", 'N': "--no-buffer",
+	'g': "--globoff", 'O': "--remote-name", 'J': "--remote-header-name", '#': "--progress-bar",
+	'0': "--http1.0", '4': "--ipv4", '6': "--ipv6", 'q': "--disable", 'Z': "--parallel",
+}
+
+var longArgs = map[string]bool{
+	"--request": true, "--header": true, "--data": true, "--data-raw": true, "--data-binary": true,
+	"--data-ascii": true, "--data-urlencode": true, "--json": true, "--form": true, "--form-string": true,
+	"--user": true, "--cookie": true, "--user-agent": true, "--referer": true, "--url": true,
+	"--max-time": true, "--oauth2-bearer": true, "--output": true, "--write-out": true, "--proxy": true,
+	"--cookie-jar": true, "--upload-file": true, "--cert": true, "--key": true, "--cacert": true,
+	"--capath": true, "--connect-timeout": true, "--retry": true, "--retry-delay": true,
+	"--retry-max-time": true, "--resolve": true, "--connect-to": true, "--config": true, "--range": true,
+	"--proxy-user": true, "--dump-header": true, "--limit-rate": true, "--max-redirs": true,
+	"--interface": true, "--unix-socket": true, "--aws-sigv4": true, "--continue-at": true,
+	"--speed-limit": true, "--speed-time": true, "--time-cond": true, "--telnet-option": true,
+	"--ftp-port": true, "--quote": true, "--cert-type": true, "--key-type": true, "--pass": true,
+	"--trace": true, "--trace-ascii": true, "--stderr": true, "--variable": true, "--url-query": true,
+}
+
+// ignored options do not change the request a test sends.
+var ignored = map[string]bool{
+	"--silent": true, "--show-error": true, "--verbose": true, "--include": true, "--location": true,
+	"--fail": true, "--fail-with-body": true, "--no-buffer": true, "--globoff": true, "--remote-name": true,
+	"--remote-header-name": true, "--progress-bar": true, "--compressed": true, "--output": true,
+	"--write-out": true, "--dump-header": true, "--connect-timeout": true, "--max-redirs": true,
+	"--http1.0": true, "--http1.1": true, "--http2": true, "--http2-prior-knowledge": true, "--ipv4": true,
+	"--ipv6": true, "--disable": true, "--trace": true, "--trace-ascii": true, "--stderr": true,
+	"--no-progress-meter": true, "--location-trusted": true, "--path-as-is": true, "--no-keepalive": true,
+	"--tr-encoding": true, "--retry-max-time": true, "--retry-all-errors": true, "--retry-connrefused": true,
+	"--cookie-jar": true,
+}
+
+type converter struct {
+	suite *models.Suite
+	ws    *adapters.Warnings
+	opts  adapters.Options
+	creds map[string]string // credential value -> suite var holding it
+}
+
+// data is one -d style argument, kept apart until the method is known.
+type data struct {
+	flag, value string
+}
+
+// convert turns one curl command's arguments into a test.
+func (c *converter) convert(args []string, line int) (models.TestCase, bool) {
+	item := fmt.Sprintf("line %d", line)
+	var (
+		tc                models.TestCase
+		method, rawURL    string
+		urls              []string
+		headers           = map[string]string{}
+		datas             []data
+		form              map[string]string
+		get, head, isJSON bool
+		user, bearer      string
+		cookies           []string
+	)
+	warnOpt := func(opt, action string) { c.ws.Add(item, "option:"+strings.TrimLeft(opt, "-"), action) }
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		var opt, val string
+		switch {
+		case a == "--":
+			urls = append(urls, args[i+1:]...)
+			i = len(args)
+			continue
+		case strings.HasPrefix(a, "--"):
+			opt = a
+			if longArgs[opt] {
+				if i+1 >= len(args) {
+					warnOpt(opt, "skipped (no value)")
+					continue
+				}
+				i++
+				val = args[i]
+			}
+		case strings.HasPrefix(a, "-") && len(a) > 1:
+			// -sSL, -XPOST, -H'Accept: x': flags first, then at most one option with its value
+			j := 1
+			for ; j < len(a); j++ {
+				if long, ok := shortArgs[a[j]]; ok {
+					opt = long
+					break
+				}
+				if long, ok := shortFlags[a[j]]; ok {
+					c.flag(long, &get, &head, warnOpt)
+					continue
+				}
+				warnOpt("-"+string(a[j]), "skipped (unknown option)")
+			}
+			if opt == "" {
+				continue
+			}
+			if val = a[j+1:]; val == "" {
+				if i+1 >= len(args) {
+					warnOpt(opt, "skipped (no value)")
+					continue
+				}
+				i++
+				val = args[i]
+			}
+		default:
+			urls = append(urls, a)
+			continue
+		}
+		switch opt {
+		case "--request":
+			method = strings.ToUpper(val)
+		case "--url":
+			urls = append(urls, val)
+		case "--header":
+			if strings.HasPrefix(val, "@") {
+				warnOpt(opt, "skipped (headers from a file)")
+				continue
+			}
+			k, v, ok := strings.Cut(val, ":")
+			if !ok {
+				if k, ok = strings.CutSuffix(val, ";"); ok {
+					headers[strings.TrimSpace(k)] = ""
+				}
+				continue
+			}
+			if v = strings.TrimSpace(v); v == "" {
+				delete(headers, strings.TrimSpace(k)) // "Name:" removes a header
+				continue
+			}
+			headers[strings.TrimSpace(k)] = v
+		case "--data", "--data-ascii", "--data-binary", "--data-raw", "--data-urlencode":
+			datas = append(datas, data{opt, val})
+		case "--json":
+			datas = append(datas, data{opt, val})
+			isJSON = true
+		case "--form", "--form-string":
+			if form == nil {
+				form = map[string]string{}
+			}
+			k, v, _ := strings.Cut(val, "=")
+			if opt == "--form" && (strings.HasPrefix(v, "@") || strings.HasPrefix(v, "<")) {
+				file, _, _ := strings.Cut(v[1:], ";")
+				v = "file:" + file
+				c.ws.Addf(item, "body:file-param", "kept as a file: placeholder string", "%s", k)
+			} else if opt == "--form" {
+				v, _, _ = strings.Cut(v, ";type=")
+			}
+			form[k] = v
+		case "--user":
+			user = val
+		case "--oauth2-bearer":
+			bearer = val
+		case "--cookie":
+			if !strings.Contains(val, "=") {
+				warnOpt(opt, "skipped (cookie file)")
+				continue
+			}
+			cookies = append(cookies, val)
+		case "--user-agent":
+			headers["User-Agent"] = val
+		case "--referer":
+			headers["Referer"] = strings.TrimSuffix(val, ";auto")
+		case "--max-time":
+			if secs, err := strconv.ParseFloat(val, 64); err == nil && secs > 0 {
+				tc.TimeoutMs = int(secs * 1000)
+			}
+		case "--retry":
+			if n, err := strconv.Atoi(val); err == nil && n > 0 {
+				if tc.Retry == nil {
+					tc.Retry = &models.Retry{}
+				}
+				tc.Retry.Max = n
+			}
+		case "--retry-delay":
+			if secs, err := strconv.ParseFloat(val, 64); err == nil && secs > 0 {
+				if tc.Retry == nil {
+					tc.Retry = &models.Retry{}
+				}
+				tc.Retry.BackoffMs = int(secs * 1000)
+			}
+		case "--url-query":
+			datas = append(datas, data{opt, val})
+		default:
+			c.flag(opt, &get, &head, warnOpt)
+		}
+	}
+	if len(urls) == 0 {
+		c.ws.Add(item, "command", "skipped (no URL)")
+		return tc, false
+	}
+	rawURL = urls[0]
+	if len(urls) > 1 {
+		c.ws.Addf(item, "url", "only the first URL imported", "%s", strings.Join(urls[1:], " "))
+	}
+	if !strings.Contains(rawURL, "://") && !strings.HasPrefix(rawURL, "${") {
+		rawURL = "http://" + rawURL
+	}
+
+	// Body or, with -G, query
+	var body any
+	var bodyParts, queryParts []string
+	for _, d := range datas {
+		v := d.value
+		switch d.flag {
+		case "--data-urlencode", "--url-query":
+			v = urlencode(v, func(file string) { c.ws.Addf(item, "body:file", "kept as a file: placeholder string", "%s", file) })
+		case "--data", "--data-ascii", "--data-binary", "--json":
+			if strings.HasPrefix(v, "@") {
+				c.ws.Addf(item, "body:file", "kept as a file: placeholder string", "%s", v[1:])
+				v = "file:" + v[1:]
+			}
+		}
+		if get || d.flag == "--url-query" {
+			queryParts = append(queryParts, v)
+		} else {
+			bodyParts = append(bodyParts, v)
+		}
+	}
+	switch {
+	case len(form) > 0:
+		body = form
+		if len(bodyParts) > 0 {
+			c.ws.Add(item, "body:data", "skipped (combined with -F)")
+		}
+		c.ws.Add(item, "body:multipart", "sent as a JSON object (multipart is not supported)")
+	case isJSON:
+		body = strings.Join(bodyParts, "")
+		setDefault(headers, "Content-Type", "application/json")
+		setDefault(headers, "Accept", "application/json")
+	case len(bodyParts) > 0:
+		body = strings.Join(bodyParts, "&")
+		setDefault(headers, "Content-Type", "application/x-www-form-urlencoded")
+	}
+	if str, ok := body.(string); ok && strings.Contains(strings.ToLower(headerValue(headers, "Content-Type")), "json") {
+		var v any
+		if err := jsonUnmarshal(str, &v); err == nil {
+			switch v.(type) {
+			case map[string]any, []any:
+				body = v
+			}
+		}
+	}
+
+	switch {
+	case method != "":
+	case head:
+		method = "HEAD"
+	case get:
+		method = "GET"
+	case body != nil:
+		method = "POST"
+	default:
+		method = "GET"
+	}
+
+	// Credentials
+	if !c.opts.SkipAuth {
+		switch {
+		case headerValue(headers, "Authorization") != "":
+		case bearer != "":
+			headers["Authorization"] = "Bearer " + bearer
+		case user != "":
+			if !strings.Contains(user, ":") {
+				c.ws.Add(item, "auth:basic", "imported with an empty password (curl would prompt)")
+				user += ":"
+			}
+			headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(user))
+		}
+	}
+	for k, v := range headers {
+		if !strings.EqualFold(k, "Authorization") {
+			continue
+		}
+		if c.opts.SkipAuth {
+			delete(headers, k)
+			continue
+		}
+		headers[k] = c.lift(v)
+	}
+	if len(cookies) > 0 {
+		if prev := headerValue(headers, "Cookie"); prev != "" {
+			cookies = append([]string{prev}, cookies...)
+		}
+		headers["Cookie"] = strings.Join(cookies, "; ")
+	}
+
+	tc.Request = models.Request{Method: method, URL: rawURL, Body: body}
+	if len(headers) > 0 {
+		tc.Request.Headers = headers
+	}
+	splitQuery(&tc.Request, queryParts)
+	return tc, true
+}
+
+// flag applies an option that takes no value.
+func (c *converter) flag(opt string, get, head *bool, warn func(opt, action string)) {
+	switch {
+	case opt == "--get":
+		*get = true
+	case opt == "--head":
+		*head = true
+	case opt == "--insecure":
+		warn(opt, "skipped (TLS verification stays on)")
+	case opt == "--digest" || opt == "--ntlm" || opt == "--negotiate" || opt == "--anyauth":
+		warn(opt, "skipped (credentials sent as basic auth)")
+	case ignored[opt]:
+	default:
+		warn(opt, "skipped")
+	}
+}
+
+// lift moves the credentials of an Authorization value into a suite var
+// listed in secrets: token for bearer tokens, basicAuth for encoded basic
+// credentials and authorization for other schemes.
+func (c *converter) lift(v string) string {
+	scheme, cred, _ := strings.Cut(v, " ")
+	prefix, name := "", "authorization"
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		prefix, name = "Bearer ", "token"
+	case "basic":
+		prefix, name = "Basic ", "basicAuth"
+	default:
+		cred = v
+	}
+	cred = strings.TrimSpace(cred)
+	if cred == "" || strings.HasPrefix(cred, "${") && strings.HasSuffix(cred, "}") {
+		return v // already a reference
+	}
+	if ref, ok := c.creds[cred]; ok {
+		return prefix + "${" + ref + "}"
+	}
+	s := c.suite
+	if s.Variables == nil {
+		s.Variables = map[string]string{}
+	}
+	ref := name
+	for n := 2; ; n++ {
+		if _, taken := s.Variables[ref]; !taken {
+			break
+		}
+		ref = fmt.Sprintf("%s%d", name, n)
+	}
+	s.Variables[ref] = cred
+	s.Secrets = append(s.Secrets, ref)
+	c.creds[cred] = ref
+	return prefix + "${" + ref + "}"
+}
+
+// liftBaseURL moves an origin every test shares into the suite baseUrl.
+func liftBaseURL(s *models.Suite) {
+	origin := ""
+	for _, t := range s.Tests {
+		u, err := url.Parse(t.Request.URL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return
+		}
+		o := u.Scheme + "://" + u.Host
+		if origin != "" && o != origin {
+			return
+		}
+		origin = o
+	}
+	if origin == "" {
+		return
+	}
+	s.BaseURL = origin
+	for i := range s.Tests {
+		p := strings.TrimPrefix(s.Tests[i].Request.URL, origin)
+		if p == "" || !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		s.Tests[i].Request.URL = p
+	}
+}
+
+// splitQuery moves the URL query and -G data into Request.Query when every
+// name appears once; otherwise all of it stays in the URL.
+func splitQuery(r *models.Request, extra []string) {
+	base, raw, _ := strings.Cut(r.URL, "?")
+	parts := extra
+	if raw != "" {
+		parts = append(strings.Split(raw, "&"), extra...)
+	}
+	if len(parts) == 0 {
+		return
+	}
+	query := map[string]string{}
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(p, "=")
+		if uk, err := url.QueryUnescape(k); err == nil {
+			k = uk
+		}
+		if uv, err := url.QueryUnescape(v); err == nil {
+			v = uv
+		}
+		if _, dup := query[k]; dup {
+			r.URL = base + "?" + strings.Join(parts, "&")
+			return
+		}
+		query[k] = v
+	}
+	r.URL = base
+	if len(query) > 0 {
+		r.Query = query
+	}
+}
+
+// urlencode applies curl's --data-urlencode rules: content, =content,
+// name=content, @file and name@file.
+func urlencode(v string, file func(string)) string {
+	if i := strings.IndexAny(v, "=@"); i >= 0 {
+		name, rest := v[:i], v[i+1:]
+		if v[i] == '@' {
+			file(rest)
+			return name + "=file:" + rest
+		}
+		if name == "" {
+			return url.QueryEscape(rest)
+		}
+		return name + "=" + url.QueryEscape(rest)
+	}
+	return url.QueryEscape(v)
+}
+
+func setDefault(h map[string]string, k, v string) {
+	if headerValue(h, k) == "" {
+		h[k] = v
+	}
+}
+
+func headerValue(h map[string]string, name string) string {
+	for k, v := range h {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// jsonUnmarshal decodes str when it is a single JSON document.
+func jsonUnmarshal(str string, v any) error {
+	dec := json.NewDecoder(strings.NewReader(str))
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("trailing data")
+	}
+	return nil
+}
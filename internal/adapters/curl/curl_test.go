@@ -0,0 +1,181 @@
+package curl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestLex(t *testing.T) {
+	src := `curl -H "X-A: \"q\" $HOME" 'it'\''s' $'a\'b\x41\n' a\ b \
+  "${x:-'d e'}" ${ENV:TOKEN} # trailing
+next; third | fourth`
+	items, defaults, err := lex(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range items {
+		switch it.kind {
+		case itemWord:
+			got = append(got, it.text)
+		case itemEnd:
+			got = append(got, "<end>")
+		case itemComment:
+			got = append(got, "#"+it.text)
+		}
+	}
+	want := []string{"curl", "-H", `X-A: "q" ${HOME}`, "it's", "a'bA\n", "a b", "${x}", "${ENV:TOKEN}", "#trailing", "<end>", "next", "<end>", "third", "<end>", "fourth"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("words:\n got %q\nwant %q", got, want)
+	}
+	if defaults["x"] != "d e" {
+		t.Fatalf("defaults = %v", defaults)
+	}
+	if _, _, err := lex(`curl 'open`); err == nil {
+		t.Fatal("expected an error for an unterminated quote")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	src := `# Create a user
+curl -sS -X post 'https://api.example.com/v1/users?tenant=acme' \
+  -H 'Content-Type: application/json' \
+  -H "Authorization: Bearer abc.def" \
+  --data-raw '{"name":"ann"}'
+
+curl https://api.example.com/v1/users -G -d page=2 --data-urlencode 'q=a b' -u admin:s3cret -b 'sid=1; theme=dark' -m 2.5 --retry 3
+
+$ curl -XPUT https://api.example.com/v1/users/1 -H 'Authorization: Bearer abc.def' -d 'name=bob' | jq .
+curl --json '[1,2]' --url https://api.example.com/v1/items
+`
+	s, ws, err := ConvertWithReport(strings.NewReader(src), adapters.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.BaseURL != "https://api.example.com" {
+		t.Fatalf("baseUrl = %q", s.BaseURL)
+	}
+	if !reflect.DeepEqual(s.Variables, map[string]string{"token": "abc.def", "basicAuth": "YWRtaW46czNjcmV0"}) || !reflect.DeepEqual(s.Secrets, []string{"token", "basicAuth"}) {
+		t.Fatalf("vars %v, secrets %v", s.Variables, s.Secrets)
+	}
+	want := []models.TestCase{
+		{
+			Name: "Create a user",
+			Request: models.Request{
+				Method:  "POST",
+				URL:     "/v1/users",
+				Headers: map[string]string{"Content-Type": "application/json", "Authorization": "Bearer ${token}"},
+				Query:   map[string]string{"tenant": "acme"},
+				Body:    map[string]any{"name": "ann"},
+			},
+		},
+		{
+			Name: "GET https://api.example.com/v1/users",
+			Request: models.Request{
+				Method:  "GET",
+				URL:     "/v1/users",
+				Headers: map[string]string{"Authorization": "Basic ${basicAuth}", "Cookie": "sid=1; theme=dark"},
+				Query:   map[string]string{"page": "2", "q": "a b"},
+			},
+			TimeoutMs: 2500,
+			Retry:     &models.Retry{Max: 3},
+		},
+		{
+			Name: "PUT https://api.example.com/v1/users/1",
+			Request: models.Request{
+				Method:  "PUT",
+				URL:     "/v1/users/1",
+				Headers: map[string]string{"Authorization": "Bearer ${token}", "Content-Type": "application/x-www-form-urlencoded"},
+				Body:    "name=bob",
+			},
+		},
+		{
+			Name: "POST https://api.example.com/v1/items",
+			Request: models.Request{
+				Method:  "POST",
+				URL:     "/v1/items",
+				Headers: map[string]string{"Content-Type": "application/json", "Accept": "application/json"},
+				Body:    []any{float64(1), float64(2)},
+			},
+		},
+	}
+	if !reflect.DeepEqual(s.Tests, want) {
+		t.Fatalf("tests:\n got %+v\nwant %+v", s.Tests, want)
+	}
+	if len(ws) != 1 || ws[0].Feature != "command:jq" {
+		t.Fatalf("warnings = %v", ws)
+	}
+}
+
+func TestConvertWarnings(t *testing.T) {
+	src := `curl -k -F 'doc=@a.pdf;type=application/pdf' -F n=1 -d @body.json --proxy http://p:8080 https://x.test/up
+curl -s`
+	s, ws, err := ConvertWithReport(strings.NewReader(src), adapters.Options{SkipAuth: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tests) != 1 || !reflect.DeepEqual(s.Tests[0].Request.Body, map[string]string{"doc": "file:a.pdf", "n": "1"}) {
+		t.Fatalf("tests = %+v", s.Tests)
+	}
+	var got []string
+	for _, w := range ws {
+		got = append(got, w.Feature+": "+w.Action)
+	}
+	want := []string{
+		"option:insecure: skipped (TLS verification stays on)",
+		"body:file-param: kept as a file: placeholder string",
+		"option:proxy: skipped",
+		"body:file: kept as a file: placeholder string",
+		"body:data: skipped (combined with -F)",
+		"body:multipart: sent as a JSON object (multipart is not supported)",
+		"command: skipped (no URL)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("warnings:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	ex := adapters.Export{
+		Name: "users",
+		Vars: map[string]string{"base": "https://api.example.com", "q": "it's {x}"},
+		Requests: []adapters.ExportRequest{{
+			Name: "create user",
+			Request: models.Request{
+				Method:  "POST",
+				URL:     "${base}/users",
+				Headers: map[string]string{"Authorization": "Bearer ${ENV:TOKEN}", "Content-Type": "application/json"},
+				Query:   map[string]string{"q": "${q}"},
+				Body:    map[string]any{"name": "ann"},
+			},
+		}},
+	}
+	var b strings.Builder
+	if err := Export(&b, ex); err != nil {
+		t.Fatal(err)
+	}
+	s, ws, err := ConvertWithReport(strings.NewReader(b.String()), adapters.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws) != 0 || !reflect.DeepEqual(s.Variables, ex.Vars) || len(s.Secrets) != 0 {
+		t.Fatalf("warnings %v, vars %v, secrets %v", ws, s.Variables, s.Secrets)
+	}
+	want := models.TestCase{
+		Name: "create user",
+		Request: models.Request{
+			Method:  "POST",
+			URL:     "${base}/users",
+			Headers: map[string]string{"Authorization": "Bearer ${TOKEN}", "Content-Type": "application/json"},
+			Query:   map[string]string{"q": "${q}"},
+			Body:    map[string]any{"name": "ann"},
+		},
+	}
+	if len(s.Tests) != 1 || !reflect.DeepEqual(s.Tests[0], want) {
+		t.Fatalf("tests:\n got %+v\nwant %+v", s.Tests, want)
+	}
+}
@@ -0,0 +1,108 @@
+package curl
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+)
+
+var shellName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Export writes one curl command per request, each under a comment with the
+// test name. Placeholders naming a valid shell variable become "${name}"
+// expansions (${ENV:NAME} as "${NAME}"); ex.Vars are assigned first, unless
+// the environment already sets them.
+func Export(w io.Writer, ex adapters.Export) error {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	if ex.Name != "" {
+		b.WriteString("# " + oneLine(ex.Name) + "\n")
+	}
+	names := make([]string, 0, len(ex.Vars))
+	for k := range ex.Vars {
+		if shellName.MatchString(k) {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		b.WriteString("\n")
+	}
+	for _, k := range names {
+		fmt.Fprintf(&b, "%s=${%s:-%s}\n", k, k, Quote(ex.Vars[k]))
+	}
+	for _, r := range ex.Requests {
+		cmd, err := Command(r)
+		if err != nil {
+			return err
+		}
+		b.WriteString("\n# " + oneLine(r.Name) + "\n" + cmd + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Command renders r as a single curl command.
+func Command(r adapters.ExportRequest) (string, error) {
+	body, err := r.Body()
+	if err != nil {
+		return "", err
+	}
+	cmd := "curl"
+	switch m := r.Request.Method; {
+	case m == "HEAD":
+		cmd += " --head"
+	case m == "GET" && body == "", m == "POST" && body != "":
+	default:
+		cmd += " -X " + m
+	}
+	parts := []string{cmd + " " + Quote(r.URL())}
+	for _, k := range r.HeaderNames() {
+		parts = append(parts, "-H "+Quote(k+": "+r.Request.Headers[k]))
+	}
+	if body != "" {
+		parts = append(parts, "--data-raw "+Quote(body))
+	}
+	return strings.Join(parts, " \\\n  "), nil
+}
+
+var placeholder = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+// Quote single-quotes s for a POSIX shell. Placeholders naming a valid shell
+// variable are spliced in as "${name}" so the shell expands them.
+func Quote(s string) string {
+	var b strings.Builder
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			b.WriteString("'" + strings.ReplaceAll(lit.String(), "'", `'\''`) + "'")
+			lit.Reset()
+		}
+	}
+	last := 0
+	for _, loc := range placeholder.FindAllStringSubmatchIndex(s, -1) {
+		name := strings.TrimPrefix(s[loc[2]:loc[3]], "ENV:")
+		lit.WriteString(s[last:loc[0]])
+		if shellName.MatchString(name) {
+			flush()
+			b.WriteString(`"${` + name + `}"`)
+		} else {
+			lit.WriteString(s[loc[0]:loc[1]])
+		}
+		last = loc[1]
+	}
+	lit.WriteString(s[last:])
+	flush()
+	if b.Len() == 0 {
+		return "''"
+	}
+	return b.String()
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,65 @@
+package curl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestQuote(t *testing.T) {
+	cases := map[string]string{
+		"":                    "''",
+		"plain":               "'plain'",
+		"it's":                `'it'\''s'`,
+		"${base}/users":       `"${base}"'/users'`,
+		"Bearer ${ENV:TOKEN}": `'Bearer '"${TOKEN}"`,
+		"${FAKE:uuid}-${a.b}": "'${FAKE:uuid}-${a.b}'",
+		"${a}${b}":            `"${a}""${b}"`,
+	}
+	for in, want := range cases {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	ex := adapters.Export{
+		Name: "users",
+		Vars: map[string]string{"base": "https://api.example.com", "bad-name": "x"},
+		Requests: []adapters.ExportRequest{
+			{Name: "list", Request: models.Request{Method: "GET", URL: "${base}/users", Query: map[string]string{"q": "a b"}}},
+			{Name: "create", Request: models.Request{Method: "POST", URL: "${base}/users", Headers: map[string]string{"Content-Type": "application/json"}, Body: map[string]any{"name": "ann"}}},
+			{Name: "remove", Request: models.Request{Method: "DELETE", URL: "${base}/users/1"}},
+			{Name: "probe", Request: models.Request{Method: "HEAD", URL: "${base}/health"}},
+		},
+	}
+	var b strings.Builder
+	if err := Export(&b, ex); err != nil {
+		t.Fatal(err)
+	}
+	want := `#!/bin/sh
+# users
+
+base=${base:-'https://api.example.com'}
+
+# list
+curl "${base}"'/users?q=a+b'
+
+# create
+curl "${base}"'/users' \
+  -H 'Content-Type: application/json' \
+  --data-raw '{"name":"ann"}'
+
+# remove
+curl -X DELETE "${base}"'/users/1'
+
+# probe
+curl --head "${base}"'/health'
+`
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
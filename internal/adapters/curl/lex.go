@@ -0,0 +1,316 @@
+package curl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// itemKind is what the lexer found: a word, the end of a command or a
+// comment.
+type itemKind int
+
+const (
+	itemWord itemKind = iota
+	itemEnd           // newline, ;, &, &&, | or ||
+	itemComment
+)
+
+type item struct {
+	kind itemKind
+	text string
+	line int
+}
+
+// lexer splits shell input the way a POSIX shell (bash for $'...') reads it:
+// quotes are removed, backslash-newline continues a line, and parameter
+// expansions become HydReq placeholders: $name and ${name:-default} both
+// read ${name}, with the default recorded in defaults.
+type lexer struct {
+	src      string
+	pos      int
+	line     int
+	defaults map[string]string
+}
+
+func lex(src string) ([]item, map[string]string, error) {
+	l := &lexer{src: strings.ReplaceAll(src, "\r\n", "\n"), line: 1, defaults: map[string]string{}}
+	var items []item
+	for {
+		l.skipBlanks()
+		if l.pos >= len(l.src) {
+			return items, l.defaults, nil
+		}
+		switch c := l.src[l.pos]; {
+		case c == '\n':
+			items = append(items, item{kind: itemEnd, line: l.line})
+			l.pos++
+			l.line++
+		case c == '#':
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			if end < 0 {
+				end = len(l.src) - l.pos
+			}
+			items = append(items, item{kind: itemComment, text: strings.TrimSpace(l.src[l.pos+1 : l.pos+end]), line: l.line})
+			l.pos += end
+		case c == ';' || c == '&' || c == '|':
+			l.pos++
+			if l.pos < len(l.src) && l.src[l.pos] == c && c != ';' {
+				l.pos++
+			}
+			items = append(items, item{kind: itemEnd, line: l.line})
+		default:
+			line := l.line
+			w, err := l.word("")
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item{kind: itemWord, text: w, line: line})
+		}
+	}
+}
+
+// skipBlanks skips spaces, tabs and line continuations.
+func (l *lexer) skipBlanks() {
+	for l.pos < len(l.src) {
+		switch {
+		case l.src[l.pos] == ' ' || l.src[l.pos] == '\t':
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "\\\n"):
+			l.pos += 2
+			l.line++
+		default:
+			return
+		}
+	}
+}
+
+// word reads one word, stopping at an unquoted blank, operator or any byte
+// in stop.
+func (l *lexer) word(stop string) (string, error) {
+	var b strings.Builder
+	start := l.line
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case strings.IndexByte(" \t\n;&|", c) >= 0 || strings.IndexByte(stop, c) >= 0:
+			return b.String(), nil
+		case c == '\'':
+			end := strings.IndexByte(l.src[l.pos+1:], '\'')
+			if end < 0 {
+				return "", fmt.Errorf("line %d: unterminated single quote", start)
+			}
+			s := l.src[l.pos+1 : l.pos+1+end]
+			l.line += strings.Count(s, "\n")
+			b.WriteString(s)
+			l.pos += end + 2
+		case c == '$' && strings.HasPrefix(l.src[l.pos:], "$'"):
+			s, err := l.ansiC()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		case c == '"':
+			s, err := l.double()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		case c == '\\':
+			l.pos++
+			if l.pos < len(l.src) {
+				if l.src[l.pos] == '\n' {
+					l.line++
+				} else {
+					b.WriteByte(l.src[l.pos])
+				}
+				l.pos++
+			}
+		case c == '$':
+			s, err := l.expansion()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return b.String(), nil
+}
+
+// double reads a "double quoted" string; l.pos is at the opening quote.
+func (l *lexer) double() (string, error) {
+	var b strings.Builder
+	start := l.line
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return b.String(), nil
+		case c == '\\' && l.pos+1 < len(l.src):
+			next := l.src[l.pos+1]
+			switch next {
+			case '\n':
+				l.line++
+			case '$', '"', '\\', '`':
+				b.WriteByte(next)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(next)
+			}
+			l.pos += 2
+		case c == '$':
+			s, err := l.expansion()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		default:
+			if c == '\n' {
+				l.line++
+			}
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return "", fmt.Errorf("line %d: unterminated double quote", start)
+}
+
+// ansiC reads a bash $'...' string; l.pos is at the $.
+func (l *lexer) ansiC() (string, error) {
+	var b strings.Builder
+	start := l.line
+	l.pos += 2
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\'':
+			l.pos++
+			return b.String(), nil
+		case c == '\\' && l.pos+1 < len(l.src):
+			next := l.src[l.pos+1]
+			l.pos += 2
+			switch next {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'x':
+				n := 0
+				for n < 2 && l.pos+n < len(l.src) && strings.IndexByte("0123456789abcdefABCDEF", l.src[l.pos+n]) >= 0 {
+					n++
+				}
+				if v, err := strconv.ParseUint(l.src[l.pos:l.pos+n], 16, 8); n > 0 && err == nil {
+					b.WriteByte(byte(v))
+					l.pos += n
+				} else {
+					b.WriteString(`\x`)
+				}
+			case 'u':
+				n := 0
+				for n < 4 && l.pos+n < len(l.src) && strings.IndexByte("0123456789abcdefABCDEF", l.src[l.pos+n]) >= 0 {
+					n++
+				}
+				if v, err := strconv.ParseUint(l.src[l.pos:l.pos+n], 16, 32); n > 0 && err == nil {
+					b.WriteRune(rune(v))
+					l.pos += n
+				} else {
+					b.WriteString(`\u`)
+				}
+			case '\'', '"', '\\', '?':
+				b.WriteByte(next)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(next)
+			}
+		default:
+			if c == '\n' {
+				l.line++
+			}
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return "", fmt.Errorf("line %d: unterminated $' quote", start)
+}
+
+// expansion reads $name or ${...}; l.pos is at the $. Anything else, such
+// as $(...) or $1, is kept as written.
+func (l *lexer) expansion() (string, error) {
+	rest := l.src[l.pos+1:]
+	if strings.HasPrefix(rest, "{") {
+		end := closingBrace(rest)
+		if end < 0 {
+			return "", fmt.Errorf("line %d: unterminated ${", l.line)
+		}
+		body := rest[1:end]
+		n := 0
+		for n < len(body) && isNameByte(body[n], n == 0) {
+			n++
+		}
+		if n == 0 {
+			l.pos++
+			return "$", nil
+		}
+		name := body[:n]
+		op := strings.TrimPrefix(body[n:], ":")
+		switch {
+		case body[n:] == "":
+		case strings.HasPrefix(op, "-") || strings.HasPrefix(op, "="):
+			// The default may itself be quoted; read it as a word.
+			sub := &lexer{src: op[1:], line: l.line, defaults: l.defaults}
+			def, err := sub.word("")
+			if err != nil {
+				return "", err
+			}
+			if _, ok := l.defaults[name]; !ok {
+				l.defaults[name] = def
+			}
+		default:
+			// Not a shell default, e.g. a HydReq ${ENV:NAME}: keep it.
+			name = body
+		}
+		l.pos += end + 2
+		return "${" + name + "}", nil
+	}
+	n := 0
+	for n < len(rest) && isNameByte(rest[n], n == 0) {
+		n++
+	}
+	if n == 0 {
+		l.pos++
+		return "$", nil
+	}
+	l.pos += n + 1
+	return "${" + rest[:n] + "}", nil
+}
+
+// closingBrace finds the } closing the ${ that s starts with, skipping
+// quoted text.
+func closingBrace(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '}':
+			return i
+		case '\\':
+			i++
+		case '\'', '"':
+			end := strings.IndexByte(s[i+1:], s[i])
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		}
+	}
+	return -1
+}
+
+func isNameByte(c byte, first bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || !first && c >= '0' && c <= '9'
+}
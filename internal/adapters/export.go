@@ -0,0 +1,105 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// Export is what the exporters write: a suite's requests, in suite order,
+// rendered the way they are sent.
+type Export struct {
+	Name     string
+	Vars     map[string]string // values for the ${name} placeholders kept in the requests; empty when interpolated
+	Requests []ExportRequest
+}
+
+// ExportRequest is one test's request with an absolute URL and the headers
+// it is sent with, auth included.
+type ExportRequest struct {
+	Name    string
+	Request models.Request
+}
+
+// URL returns the request URL with the query parameters appended, sorted
+// by name. Placeholders in them are left unescaped.
+func (r ExportRequest) URL() string {
+	if len(r.Request.Query) == 0 {
+		return r.Request.URL
+	}
+	var b strings.Builder
+	for i, k := range sortedKeys(r.Request.Query) {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(queryEscape(k) + "=" + queryEscape(r.Request.Query[k]))
+	}
+	sep := "?"
+	if strings.Contains(r.Request.URL, "?") {
+		sep = "&"
+	}
+	return r.Request.URL + sep + b.String()
+}
+
+// HeaderNames returns the request header names sorted.
+func (r ExportRequest) HeaderNames() []string {
+	return sortedKeys(r.Request.Headers)
+}
+
+// Body returns the request body as sent: strings as-is, anything else as
+// JSON.
+func (r ExportRequest) Body() (string, error) {
+	switch b := r.Request.Body.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return b, nil
+	case []byte:
+		return string(b), nil
+	default:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(b); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	}
+}
+
+var placeholder = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+// ReplaceVars rewrites each ${name} placeholder in s, ${ENV:NAME} ones
+// included, with what repl returns for its name.
+func ReplaceVars(s string, repl func(name string) string) string {
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		return repl(m[2 : len(m)-1])
+	})
+}
+
+// queryEscape escapes a query component, keeping placeholders intact.
+func queryEscape(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholder.FindAllStringIndex(s, -1) {
+		b.WriteString(url.QueryEscape(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(url.QueryEscape(s[last:]))
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
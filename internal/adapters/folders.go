@@ -0,0 +1,117 @@
+package adapters
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// Folder is a collection folder as a folder-per-file import sees it: its own
+// settings and the tests directly inside it.
+type Folder struct {
+	Name      string
+	Auth      *models.Auth
+	Vars      map[string]string
+	PreSuite  []models.Hook
+	PostSuite []models.Hook
+	Tests     []models.TestCase
+	Folders   []*Folder
+}
+
+// Add appends a subfolder and returns it.
+func (f *Folder) Add(name string) *Folder {
+	sub := &Folder{Name: name}
+	f.Folders = append(f.Folders, sub)
+	return sub
+}
+
+// File is one suite of a folder-per-file import.
+type File struct {
+	Path  string // slash-separated, relative to the output directory
+	Suite *models.Suite
+}
+
+// Split turns root, a collection's folder tree, into one suite per folder
+// that holds tests. suite carries the collection-level settings, which every
+// file starts from; the root's own tests go into a file named after the
+// collection. Folder settings are layered on in path order: auth replaces,
+// vars are merged, preSuite hooks are appended and postSuite hooks prepended
+// so the innermost folder's hooks run closest to its tests.
+func Split(suite *models.Suite, root *Folder) []File {
+	var files []File
+	used := map[string]int{}
+	name := func(dir, base string) string {
+		p := path.Join(dir, base)
+		used[p]++
+		if n := used[p]; n > 1 {
+			p = fmt.Sprintf("%s-%d", p, n)
+		}
+		return p + ".hrq.yaml"
+	}
+
+	if len(root.Tests) > 0 {
+		s := *suite
+		s.Tests = root.Tests
+		files = append(files, File{Path: name("", slug(suite.Name, "collection")), Suite: &s})
+	}
+
+	var walk func(f *Folder, parent models.Suite, names []string, dir string)
+	walk = func(f *Folder, parent models.Suite, names []string, dir string) {
+		names = append(names[:len(names):len(names)], f.Name)
+		s := parent
+		s.Name = strings.Join(names, " > ")
+		if f.Auth != nil {
+			s.Auth = f.Auth
+		}
+		if len(f.Vars) > 0 {
+			s.Variables = make(map[string]string, len(parent.Variables)+len(f.Vars))
+			for k, v := range parent.Variables {
+				s.Variables[k] = v
+			}
+			for k, v := range f.Vars {
+				s.Variables[k] = v
+			}
+		}
+		s.PreSuite = append(parent.PreSuite[:len(parent.PreSuite):len(parent.PreSuite)], f.PreSuite...)
+		s.PostSuite = append(f.PostSuite[:len(f.PostSuite):len(f.PostSuite)], parent.PostSuite...)
+		s.Tests = f.Tests
+		base := slug(f.Name, "folder")
+		if len(f.Tests) > 0 {
+			out := s
+			files = append(files, File{Path: name(dir, base), Suite: &out})
+		}
+		for _, sub := range f.Folders {
+			walk(sub, s, names, path.Join(dir, base))
+		}
+	}
+	top := []string{}
+	if suite.Name != "" {
+		top = append(top, suite.Name)
+	}
+	for _, f := range root.Folders {
+		walk(f, *suite, top, "")
+	}
+	return files
+}
+
+// slug makes s usable as a file name: lower case letters, digits and dashes.
+func slug(s, fallback string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	out := strings.TrimSuffix(b.String(), "-")
+	if out == "" {
+		return fallback
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestSplit(t *testing.T) {
+	suite := &models.Suite{
+		Name:      "Shop API",
+		Variables: map[string]string{"host": "a", "role": "guest"},
+		Auth:      &models.Auth{BearerEnv: "TOKEN"},
+		PreSuite:  []models.Hook{{Name: "collection"}},
+	}
+	root := &Folder{Tests: []models.TestCase{{Name: "ping"}}}
+	users := root.Add("Users")
+	users.Vars = map[string]string{"role": "admin"}
+	users.PreSuite = []models.Hook{{Name: "users"}}
+	users.PostSuite = []models.Hook{{Name: "users after"}}
+	users.Tests = []models.TestCase{{Name: "list"}}
+	admin := users.Add("Admin / Audit")
+	admin.Auth = &models.Auth{BasicEnv: "u:p"}
+	admin.Tests = []models.TestCase{{Name: "audit"}}
+	root.Add("Empty")
+	root.Add("users").Tests = []models.TestCase{{Name: "dup"}}
+
+	files := Split(suite, root)
+
+	want := []string{"shop-api.hrq.yaml", "users.hrq.yaml", "users/admin-audit.hrq.yaml", "users-2.hrq.yaml"}
+	if len(files) != len(want) {
+		t.Fatalf("expected %d files, got %+v", len(want), files)
+	}
+	for i, p := range want {
+		if files[i].Path != p {
+			t.Errorf("file %d: expected %s, got %s", i, p, files[i].Path)
+		}
+	}
+	a := files[2].Suite
+	if a.Name != "Shop API > Users > Admin / Audit" || a.Auth.BasicEnv != "u:p" || a.Variables["role"] != "admin" || a.Variables["host"] != "a" {
+		t.Errorf("unexpected nested suite %+v", a)
+	}
+	if len(a.PreSuite) != 2 || a.PreSuite[0].Name != "collection" || a.PreSuite[1].Name != "users" {
+		t.Errorf("unexpected preSuite %+v", a.PreSuite)
+	}
+	if len(a.PostSuite) != 1 || a.Tests[0].Name != "audit" {
+		t.Errorf("unexpected postSuite/tests %+v %+v", a.PostSuite, a.Tests)
+	}
+	if suite.Variables["role"] != "guest" || files[1].Suite.Auth.BearerEnv != "TOKEN" {
+		t.Errorf("collection settings changed or not inherited")
+	}
+}
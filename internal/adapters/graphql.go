@@ -0,0 +1,21 @@
+package adapters
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// GraphQL builds request.graphql from a query and its variables as JSON
+// text, the way Postman and Bruno store them. Variables that are not a JSON
+// object are reported on ws and left out.
+func GraphQL(query, variables, item string, ws *Warnings) *models.GraphQL {
+	g := &models.GraphQL{Query: query}
+	if v := strings.TrimSpace(variables); v != "" {
+		if err := json.Unmarshal([]byte(v), &g.Variables); err != nil {
+			ws.Addf(item, "body:graphql-variables", "skipped (not a JSON object)", "%s", v)
+		}
+	}
+	return g
+}
@@ -0,0 +1,15 @@
+package adapters
+
+import "testing"
+
+func TestGraphQL(t *testing.T) {
+	var ws Warnings
+	g := GraphQL("query($id: ID!) { user(id: $id) { name } }", ` {"id": "7"} `, "user", &ws)
+	if g.Query == "" || g.Variables["id"] != "7" || len(ws) != 0 {
+		t.Fatalf("graphql = %+v, warnings = %+v", g, ws)
+	}
+	g = GraphQL("{ me { id } }", "[1]", "me", &ws)
+	if g.Variables != nil || len(ws) != 1 || ws[0].Feature != "body:graphql-variables" {
+		t.Fatalf("graphql = %+v, warnings = %+v", g, ws)
+	}
+}
@@ -0,0 +1,122 @@
+package har
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+)
+
+// HAR 1.2 structures written by Export
+type exportLog struct {
+	Log struct {
+		Version string        `json:"version"`
+		Creator exportCreator `json:"creator"`
+		Entries []exportEntry `json:"entries"`
+	} `json:"log"`
+}
+type exportCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+type exportEntry struct {
+	StartedDateTime string         `json:"startedDateTime"`
+	Time            int            `json:"time"`
+	Request         exportRequest  `json:"request"`
+	Response        exportResponse `json:"response"`
+	Cache           struct{}       `json:"cache"`
+	Timings         map[string]int `json:"timings"`
+	Comment         string         `json:"comment,omitempty"`
+}
+type exportRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []exportPair `json:"cookies"`
+	Headers     []exportPair `json:"headers"`
+	QueryString []exportPair `json:"queryString"`
+	PostData    *exportPost  `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+type exportPair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+type exportPost struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+type exportResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []exportPair   `json:"cookies"`
+	Headers     []exportPair   `json:"headers"`
+	Content     map[string]any `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// Export writes ex as a HAR 1.2 log of requests that have not been sent:
+// each entry carries the test name as its comment and an empty response.
+// HAR has no variables, so placeholders are written as they are.
+func Export(w io.Writer, ex adapters.Export) error {
+	var h exportLog
+	h.Log.Version = "1.2"
+	h.Log.Creator = exportCreator{Name: "hydreq", Version: "1"}
+	h.Log.Entries = []exportEntry{}
+	started := time.Now().UTC().Format(time.RFC3339)
+	for _, r := range ex.Requests {
+		u := r.URL()
+		req := exportRequest{Method: r.Request.Method, URL: u, HTTPVersion: "HTTP/1.1", Cookies: []exportPair{}, Headers: []exportPair{}, QueryString: []exportPair{}, HeadersSize: -1}
+		for _, k := range r.HeaderNames() {
+			req.Headers = append(req.Headers, exportPair{Name: k, Value: r.Request.Headers[k]})
+		}
+		if _, raw, ok := strings.Cut(u, "?"); ok {
+			req.QueryString = append(req.QueryString, splitQuery(raw)...)
+		}
+		body, err := r.Body()
+		if err != nil {
+			return err
+		}
+		if body != "" {
+			req.PostData = &exportPost{MimeType: r.Request.Headers["Content-Type"], Text: body}
+			req.BodySize = len(body)
+		}
+		h.Log.Entries = append(h.Log.Entries, exportEntry{
+			StartedDateTime: started,
+			Request:         req,
+			Response:        exportResponse{Cookies: []exportPair{}, Headers: []exportPair{}, Content: map[string]any{"size": 0, "mimeType": ""}, HeadersSize: -1, BodySize: -1},
+			Timings:         map[string]int{"send": 0, "wait": 0, "receive": 0},
+			Comment:         r.Name,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(h)
+}
+
+// splitQuery lists query parameters in the order they appear.
+func splitQuery(raw string) []exportPair {
+	var out []exportPair
+	for _, part := range strings.Split(raw, "&") {
+		if part == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(part, "=")
+		if uk, err := url.QueryUnescape(k); err == nil {
+			k = uk
+		}
+		if uv, err := url.QueryUnescape(v); err == nil {
+			v = uv
+		}
+		out = append(out, exportPair{Name: k, Value: v})
+	}
+	return out
+}
@@ -3,7 +3,12 @@ package har
 import (
 	"encoding/json"
 	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
 
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
 
@@ -17,40 +22,326 @@ type harLog struct {
 	} `json:"log"`
 }
 type harEntry struct {
-	Request harRequest `json:"request"`
+	Request      harRequest  `json:"request"`
+	Response     harResponse `json:"response"`
+	ResourceType string      `json:"_resourceType"` // Chrome: xhr, fetch, document, script, ...
 }
 type harRequest struct {
 	Method   string       `json:"method"`
 	URL      string       `json:"url"`
 	Headers  []harHeader  `json:"headers"`
+	Cookies  []harHeader  `json:"cookies"`
 	PostData *harPostData `json:"postData"`
 }
 type harHeader struct{ Name, Value string }
 type harPostData struct {
-	MimeType string `json:"mimeType"`
-	Text     string `json:"text"`
+	MimeType string      `json:"mimeType"`
+	Text     string      `json:"text"`
+	Params   []harHeader `json:"params"`
+}
+type harResponse struct {
+	Status  int `json:"status"`
+	Content struct {
+		MimeType string `json:"mimeType"`
+	} `json:"content"`
+	RedirectURL string `json:"redirectURL"`
+}
+
+// dropped are request headers a replay must not copy: the connection's own
+// (hop-by-hop, Host, Content-Length), ones the browser adds for itself, and
+// cache validators that would turn a replay into a 304. HTTP/2 pseudo
+// headers and the sec-* and proxy-* families are dropped as well; cookies
+// are rebuilt from the entry's cookie list.
+var dropped = map[string]bool{
+	"connection": true, "keep-alive": true, "te": true, "trailer": true, "transfer-encoding": true, "upgrade": true,
+	"host": true, "content-length": true, "accept-encoding": true,
+	"user-agent": true, "accept-language": true, "referer": true, "dnt": true, "priority": true,
+	"upgrade-insecure-requests": true, "cache-control": true, "pragma": true,
+	"if-none-match": true, "if-modified-since": true, "cookie": true,
 }
 
 func Convert(r io.Reader) (*models.Suite, error) {
+	s, _, err := ConvertWithReport(r, adapters.Options{})
+	return s, err
+}
+
+// ConvertWithReport is Convert that also returns what could not be imported
+// as-is.
+func ConvertWithReport(r io.Reader, opts adapters.Options) (*models.Suite, []adapters.Warning, error) {
 	var h harLog
 	if err := json.NewDecoder(r).Decode(&h); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	var ws adapters.Warnings
 	s := &models.Suite{Name: h.Log.Creator.Name}
+
+	responses := map[string]harResponse{}
+	type kept struct {
+		e harEntry
+		u *url.URL
+	}
+	var entries []kept
+	origins := map[string]int{}
 	for _, e := range h.Log.Entries {
-		headers := map[string]string{}
-		for _, hh := range e.Request.Headers {
-			headers[hh.Name] = hh.Value
+		if _, seen := responses[e.Request.URL]; !seen {
+			responses[e.Request.URL] = e.Response
+		}
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			ws.Addf("", "request:url", "skipped (invalid URL)", "%s", e.Request.URL)
+			continue
+		}
+		switch u.Scheme {
+		case "http", "https", "ws", "wss":
+		default:
+			continue // data:, blob:, browser extensions
+		}
+		if isPreflight(e.Request) || !keep(e, u, opts) {
+			continue
+		}
+		if u.Scheme == "ws" || u.Scheme == "wss" {
+			ws.Add(e.Request.Method+" "+e.Request.URL, "request:websocket", "skipped (not supported)")
+			continue
+		}
+		entries = append(entries, kept{e, u})
+		origins[u.Scheme+"://"+u.Host]++
+	}
+
+	// The origin most requests go to becomes the baseUrl; others stay absolute.
+	for _, k := range entries {
+		if o := k.u.Scheme + "://" + k.u.Host; origins[o] > origins[s.BaseURL] {
+			s.BaseURL = o
+		}
+	}
+
+	names := map[string]int{}
+	for _, k := range entries {
+		e, u := k.e, k.u
+		target := *u
+		target.RawQuery, target.Fragment, target.RawFragment = "", "", ""
+		p := target.String()
+		if s.BaseURL != "" && strings.HasPrefix(p, s.BaseURL) {
+			if p = strings.TrimPrefix(p, s.BaseURL); p == "" {
+				p = "/"
+			}
+		}
+		method := strings.ToUpper(e.Request.Method)
+		name := method + " " + p
+		if names[name]++; names[name] > 1 {
+			name += " (" + strconv.Itoa(names[name]) + ")"
+		}
+		req := models.Request{Method: method, URL: p, Headers: headers(e.Request, opts)}
+		if q, ok := query(u.RawQuery); ok {
+			req.Query = q
+		} else {
+			req.URL += "?" + u.RawQuery
+		}
+		if pd := e.Request.PostData; pd != nil {
+			req.Body = body(pd, name, &ws)
+			if req.Body != nil && pd.MimeType != "" && headerName(req.Headers, "Content-Type") == "" {
+				req.Headers["Content-Type"] = pd.MimeType
+			}
+		}
+		if len(req.Headers) == 0 {
+			req.Headers = nil
+		}
+		s.Tests = append(s.Tests, models.TestCase{Name: name, Request: req, Assert: assertions(e.Response, responses, name, &ws)})
+	}
+	opts.Apply(s)
+	return s, ws, nil
+}
+
+// keep applies the capture filters in opts.
+func keep(e harEntry, u *url.URL, opts adapters.Options) bool {
+	if len(opts.IncludeHosts) > 0 {
+		host, hostname := strings.ToLower(u.Host), strings.ToLower(u.Hostname())
+		ok := false
+		for _, pat := range opts.IncludeHosts {
+			pat = strings.ToLower(strings.TrimSpace(pat))
+			m1, _ := path.Match(pat, host)
+			m2, _ := path.Match(pat, hostname)
+			if ok = m1 || m2; ok {
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	exts := opts.ExcludeExts
+	if exts == nil {
+		exts = adapters.StaticExts
+	}
+	if ext := strings.ToLower(strings.TrimPrefix(path.Ext(u.Path), ".")); ext != "" {
+		for _, x := range exts {
+			if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(x), "."), ext) {
+				return false
+			}
+		}
+	}
+	if opts.OnlyXHR {
+		if e.ResourceType != "" {
+			return e.ResourceType == "xhr" || e.ResourceType == "fetch"
+		}
+		// Without Chrome's _resourceType, go by what the browser sent.
+		return strings.EqualFold(header(e.Request, "X-Requested-With"), "XMLHttpRequest") || strings.EqualFold(header(e.Request, "Sec-Fetch-Dest"), "empty")
+	}
+	return true
+}
+
+// isPreflight reports a CORS preflight, which the browser sends on its own.
+func isPreflight(r harRequest) bool {
+	return strings.EqualFold(r.Method, "OPTIONS") && header(r, "Access-Control-Request-Method") != ""
+}
+
+// headers copies the request headers a replay needs. Repeated names (HTTP/2
+// captures list each value) are joined, keeping the first spelling.
+func headers(r harRequest, opts adapters.Options) map[string]string {
+	out := map[string]string{}
+	for _, hh := range r.Headers {
+		n := strings.ToLower(hh.Name)
+		if strings.HasPrefix(n, ":") || strings.HasPrefix(n, "sec-") || strings.HasPrefix(n, "proxy-") || dropped[n] {
+			continue
+		}
+		if opts.SkipAuth && n == "authorization" {
+			continue
+		}
+		if k := headerName(out, hh.Name); k != "" {
+			out[k] += ", " + hh.Value
+			continue
 		}
-		var body any
-		if e.Request.PostData != nil && e.Request.PostData.Text != "" {
-			body = e.Request.PostData.Text
+		out[hh.Name] = hh.Value
+	}
+	if opts.SkipAuth {
+		return out
+	}
+	var cookies []string
+	for _, c := range r.Cookies {
+		cookies = append(cookies, c.Name+"="+c.Value)
+	}
+	if len(cookies) == 0 {
+		for _, hh := range r.Headers {
+			if strings.EqualFold(hh.Name, "Cookie") {
+				cookies = append(cookies, hh.Value)
+			}
+		}
+	}
+	if len(cookies) > 0 {
+		out["Cookie"] = strings.Join(cookies, "; ")
+	}
+	return out
+}
+
+// header returns the first value of the named request header.
+func header(r harRequest, name string) string {
+	for _, hh := range r.Headers {
+		if strings.EqualFold(hh.Name, name) {
+			return hh.Value
+		}
+	}
+	return ""
+}
+
+// headerName finds name in m, ignoring case.
+func headerName(m map[string]string, name string) string {
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return ""
+}
+
+// query splits a raw query into Request.Query; ok is false when a name
+// repeats, and the query then stays in the URL.
+func query(raw string) (map[string]string, bool) {
+	if raw == "" {
+		return nil, true
+	}
+	q := map[string]string{}
+	for _, part := range strings.Split(raw, "&") {
+		if part == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(part, "=")
+		if uk, err := url.QueryUnescape(k); err == nil {
+			k = uk
+		}
+		if uv, err := url.QueryUnescape(v); err == nil {
+			v = uv
+		}
+		if _, dup := q[k]; dup {
+			return nil, false
 		}
-		s.Tests = append(s.Tests, models.TestCase{
-			Name:    e.Request.Method + " " + e.Request.URL,
-			Request: models.Request{Method: e.Request.Method, URL: e.Request.URL, Headers: headers, Body: body},
-			Assert:  models.Assertions{Status: 200},
-		})
+		q[k] = v
 	}
-	return s, nil
+	if len(q) == 0 {
+		return nil, true
+	}
+	return q, true
+}
+
+// body reads postData: JSON text becomes a structure, form params without
+// text are encoded again.
+func body(pd *harPostData, item string, ws *adapters.Warnings) any {
+	mt := mediaType(pd.MimeType)
+	if pd.Text != "" {
+		if adapters.IsJSON(mt) {
+			var v any
+			if err := json.Unmarshal([]byte(pd.Text), &v); err == nil {
+				return v
+			}
+		}
+		return pd.Text
+	}
+	if len(pd.Params) == 0 {
+		return nil
+	}
+	if mt != "application/x-www-form-urlencoded" {
+		ws.Addf(item, "body:params", "skipped (no text in postData)", "%s", pd.MimeType)
+		return nil
+	}
+	parts := make([]string, 0, len(pd.Params))
+	for _, p := range pd.Params {
+		parts = append(parts, url.QueryEscape(p.Name)+"="+url.QueryEscape(p.Value))
+	}
+	return strings.Join(parts, "&")
+}
+
+// assertions checks the recorded status and content type. Redirects are
+// followed when a test runs, so they are checked against the response the
+// redirect chain ends at; a 304 becomes 200 because the cache validators
+// are not replayed. Entries without a response keep the status 200 default.
+func assertions(resp harResponse, responses map[string]harResponse, item string, ws *adapters.Warnings) models.Assertions {
+	for hops := 0; isRedirect(resp.Status); hops++ {
+		next, ok := responses[resp.RedirectURL]
+		if !ok || hops == 10 {
+			ws.Addf(item, "response:redirect", "status not checked (redirect target not captured)", "%d to %s", resp.Status, resp.RedirectURL)
+			return models.Assertions{}
+		}
+		resp = next
+	}
+	a := models.Assertions{Status: resp.Status}
+	switch resp.Status {
+	case 0, 304:
+		a.Status = 200
+	case 204:
+		return a
+	}
+	if mt := mediaType(resp.Content.MimeType); resp.Status != 0 && mt != "" && mt != "x-unknown" {
+		a.JS = adapters.ContentTypeCheck(mt)
+	}
+	return a
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case 301, 302, 303, 307, 308:
+		return true
+	}
+	return false
+}
+
+func mediaType(v string) string {
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(v, ";", 2)[0]))
 }
@@ -1,8 +1,12 @@
 package har
 
 import (
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
 
 func TestConvertSimple(t *testing.T) {
@@ -25,7 +29,7 @@ func TestConvertSimple(t *testing.T) {
 		t.Fatalf("tests count: %d", len(s.Tests))
 	}
 	tc := s.Tests[0]
-	if tc.Request.Method != "GET" || tc.Request.URL != "https://example.com/api" {
+	if s.BaseURL != "https://example.com" || tc.Name != "GET /api" || tc.Request.Method != "GET" || tc.Request.URL != "/api" {
 		t.Fatalf("unexpected request: %+v", tc.Request)
 	}
 	if tc.Assert.Status != 200 {
@@ -33,6 +37,96 @@ func TestConvertSimple(t *testing.T) {
 	}
 }
 
+const captureHAR = `{"log": {"creator": {"name": "WebInspector"}, "entries": [
+  {"_resourceType": "document", "request": {"method": "GET", "url": "https://app.example.com/", "headers": [{"name": ":authority", "value": "app.example.com"}, {"name": "user-agent", "value": "Mozilla/5.0"}]},
+   "response": {"status": 200, "content": {"mimeType": "text/html; charset=utf-8"}}},
+  {"_resourceType": "script", "request": {"method": "GET", "url": "https://cdn.example.net/app.js", "headers": []},
+   "response": {"status": 200, "content": {"mimeType": "application/javascript"}}},
+  {"_resourceType": "preflight", "request": {"method": "OPTIONS", "url": "https://app.example.com/api/users", "headers": [{"name": "Access-Control-Request-Method", "value": "POST"}]},
+   "response": {"status": 204, "content": {"mimeType": "x-unknown"}}},
+  {"_resourceType": "fetch", "request": {"method": "post", "url": "https://app.example.com/api/users?tenant=acme&x=%20y", "headers": [
+     {"name": ":method", "value": "POST"}, {"name": "accept", "value": "application/json"}, {"name": "content-type", "value": "application/json"},
+     {"name": "authorization", "value": "Bearer t"}, {"name": "sec-fetch-mode", "value": "cors"}, {"name": "connection", "value": "keep-alive"},
+     {"name": "cookie", "value": "ignored=1"}, {"name": "if-none-match", "value": "\"abc\""}, {"name": "x-trace", "value": "a"}, {"name": "X-Trace", "value": "b"}],
+     "cookies": [{"name": "sid", "value": "1"}, {"name": "theme", "value": "dark"}],
+     "postData": {"mimeType": "application/json", "text": "{\"name\":\"ann\"}"}},
+   "response": {"status": 201, "content": {"mimeType": "application/json; charset=utf-8"}}},
+  {"_resourceType": "xhr", "request": {"method": "GET", "url": "https://app.example.com/api/users?tag=a&tag=b", "headers": []},
+   "response": {"status": 304, "content": {"mimeType": "application/json"}}},
+  {"_resourceType": "xhr", "request": {"method": "GET", "url": "https://app.example.com/old", "headers": []},
+   "response": {"status": 302, "redirectURL": "https://app.example.com/new", "content": {"mimeType": ""}}},
+  {"_resourceType": "xhr", "request": {"method": "GET", "url": "https://app.example.com/new", "headers": []},
+   "response": {"status": 200, "content": {"mimeType": "application/json"}}},
+  {"_resourceType": "xhr", "request": {"method": "POST", "url": "https://auth.example.org/login", "headers": [],
+     "postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "user", "value": "a b"}]}},
+   "response": {"status": 204, "content": {"mimeType": "x-unknown"}}},
+  {"_resourceType": "websocket", "request": {"method": "GET", "url": "wss://app.example.com/live", "headers": []}, "response": {"status": 101}}
+]}}`
+
+func TestConvertCapture(t *testing.T) {
+	s, ws, err := ConvertWithReport(strings.NewReader(captureHAR), adapters.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.BaseURL != "https://app.example.com" {
+		t.Fatalf("baseUrl = %q", s.BaseURL)
+	}
+	jsType := func(mt string) string {
+		return `check("content-type is ` + mt + `", (response.header("Content-Type") || "").indexOf("` + mt + `") >= 0);`
+	}
+	want := []models.TestCase{
+		{Name: "GET /", Request: models.Request{Method: "GET", URL: "/"}, Assert: models.Assertions{Status: 200, JS: jsType("text/html")}},
+		{
+			Name: "POST /api/users",
+			Request: models.Request{
+				Method:  "POST",
+				URL:     "/api/users",
+				Headers: map[string]string{"accept": "application/json", "content-type": "application/json", "authorization": "Bearer t", "x-trace": "a, b", "Cookie": "sid=1; theme=dark"},
+				Query:   map[string]string{"tenant": "acme", "x": " y"},
+				Body:    map[string]any{"name": "ann"},
+			},
+			Assert: models.Assertions{Status: 201, JS: jsType("application/json")},
+		},
+		{Name: "GET /api/users", Request: models.Request{Method: "GET", URL: "/api/users?tag=a&tag=b"}, Assert: models.Assertions{Status: 200, JS: jsType("application/json")}},
+		{Name: "GET /old", Request: models.Request{Method: "GET", URL: "/old"}, Assert: models.Assertions{Status: 200, JS: jsType("application/json")}},
+		{Name: "GET /new", Request: models.Request{Method: "GET", URL: "/new"}, Assert: models.Assertions{Status: 200, JS: jsType("application/json")}},
+		{
+			Name:    "POST https://auth.example.org/login",
+			Request: models.Request{Method: "POST", URL: "https://auth.example.org/login", Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, Body: "user=a+b"},
+			Assert:  models.Assertions{Status: 204},
+		},
+	}
+	if !reflect.DeepEqual(s.Tests, want) {
+		t.Fatalf("tests:\n got %+v\nwant %+v", s.Tests, want)
+	}
+	if len(ws) != 1 || ws[0].Feature != "request:websocket" {
+		t.Fatalf("warnings = %v", ws)
+	}
+}
+
+func TestConvertFilters(t *testing.T) {
+	names := func(opts adapters.Options) []string {
+		s, _, err := ConvertWithReport(strings.NewReader(captureHAR), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, tc := range s.Tests {
+			out = append(out, tc.Name)
+		}
+		return out
+	}
+	if got := names(adapters.Options{OnlyXHR: true, IncludeHosts: []string{"*.example.com"}}); !reflect.DeepEqual(got, []string{"POST /api/users", "GET /api/users", "GET /old", "GET /new"}) {
+		t.Fatalf("only xhr, app host: %q", got)
+	}
+	if got := names(adapters.Options{IncludeHosts: []string{"cdn.example.net"}, ExcludeExts: []string{}}); !reflect.DeepEqual(got, []string{"GET /app.js"}) {
+		t.Fatalf("cdn host, no extension filter: %q", got)
+	}
+	if got := names(adapters.Options{IncludeHosts: []string{"auth.example.org"}, SkipAuth: true}); !reflect.DeepEqual(got, []string{"POST /login"}) {
+		t.Fatalf("auth host: %q", got)
+	}
+}
+
 func TestConvert_EmptyHAR(t *testing.T) {
 	harJSON := `{"log": {"creator": {"name": "test"}, "entries": []}}`
 	s, err := Convert(strings.NewReader(harJSON))
@@ -50,3 +144,33 @@ func TestConvert_InvalidHAR(t *testing.T) {
 		t.Fatal("expected error for invalid HAR")
 	}
 }
+
+func TestExport(t *testing.T) {
+	ex := adapters.Export{Requests: []adapters.ExportRequest{{
+		Name: "create",
+		Request: models.Request{
+			Method:  "POST",
+			URL:     "https://example.com/api?v=1",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Query:   map[string]string{"q": "a b"},
+			Body:    `{"a":1}`,
+		},
+	}}}
+	var b strings.Builder
+	if err := Export(&b, ex); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"version": "1.2"`, `"comment": "create"`, `"name": "q",`, `"value": "a b"`, `"mimeType": "application/json",`} {
+		if !strings.Contains(b.String(), want) {
+			t.Fatalf("missing %s in\n%s", want, b.String())
+		}
+	}
+	s, err := Convert(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("re-import: %v", err)
+	}
+	tc := s.Tests[0]
+	if tc.Request.URL != "/api" || !reflect.DeepEqual(tc.Request.Query, map[string]string{"v": "1", "q": "a b"}) || !reflect.DeepEqual(tc.Request.Body, map[string]any{"a": float64(1)}) || tc.Request.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("unexpected re-import %+v", tc.Request)
+	}
+}
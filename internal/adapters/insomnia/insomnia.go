@@ -6,6 +6,7 @@ import (
 	"io"
 	"strings"
 
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 	"github.com/DrWeltschmerz/HydReq/internal/script"
 	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
@@ -116,10 +117,47 @@ type Resource struct {
 }
 
 func Convert(r io.Reader) (*models.Suite, error) {
+	s, _, err := ConvertWithReport(r, adapters.Options{})
+	return s, err
+}
+
+// ConvertWithReport is Convert that also returns what could not be imported
+// as-is. In files mode the folder settings are left out as in prefix mode;
+// use ConvertFiles to get one suite per folder.
+func ConvertWithReport(r io.Reader, opts adapters.Options) (*models.Suite, []adapters.Warning, error) {
+	if opts.Folders == adapters.FoldersFiles {
+		opts.Folders = adapters.FoldersPrefix
+	}
+	s, _, ws, err := convert(r, opts)
+	return s, ws, err
+}
+
+// ConvertFiles converts an export into one suite per folder (see
+// adapters.Split). Folder auth, environment and scripts become the suite
+// auth, vars and preSuite/postSuite hooks of the folder's file.
+func ConvertFiles(r io.Reader, opts adapters.Options) ([]adapters.File, []adapters.Warning, error) {
+	opts.Folders = adapters.FoldersFiles
+	s, root, ws, err := convert(r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := adapters.Split(s, root)
+	for _, f := range files {
+		opts.Apply(f.Suite)
+	}
+	return files, ws, nil
+}
+
+// convert does the work for both entry points. In files mode the tests are
+// collected in the returned folder tree instead of the suite.
+func convert(r io.Reader, opts adapters.Options) (*models.Suite, *adapters.Folder, adapters.Warnings, error) {
 	var exp Export
 	if err := json.NewDecoder(r).Decode(&exp); err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
+	var ws adapters.Warnings
+	files := opts.Folders == adapters.FoldersFiles
+	root := &adapters.Folder{}
 
 	var suiteName string
 	if exp.Name != "" {
@@ -143,15 +181,31 @@ func Convert(r io.Reader) (*models.Suite, error) {
 
 	// Handle collection items
 	if exp.Collection != nil {
-		var walk func(items []CollectionItem, path []string)
-		walk = func(items []CollectionItem, path []string) {
+		var walk func(items []CollectionItem, path []string, folder *adapters.Folder)
+		walk = func(items []CollectionItem, path []string, folder *adapters.Folder) {
 			for _, item := range items {
 				currentPath := append(path, item.Name)
-				if item.Type == "folder" && len(item.Items) > 0 {
-					walk(item.Items, currentPath)
+				name := strings.Join(currentPath, " > ")
+				if item.Type == "folder" {
+					sub := folder.Add(item.Name)
+					if files {
+						convertFolder(item, name, sub, opts, &ws)
+					} else {
+						if itemAuth(item) != nil && !opts.SkipAuth {
+							ws.Add(name, "folder auth", "skipped")
+						}
+						if (item.PreRequestScript != "" || item.AfterResponseScript != "") && !opts.NoScripts {
+							ws.Add(name, "folder scripts", "skipped")
+						}
+						if len(item.Environment) > 0 {
+							ws.Add(name, "folder environment", "skipped")
+						}
+					}
+					walk(item.Items, currentPath, sub)
 					continue
 				}
 				if item.Type != "http-request" || item.Request == nil {
+					ws.Add(name, "item:"+item.Type, "skipped")
 					continue
 				}
 				req := item.Request
@@ -159,6 +213,8 @@ func Convert(r io.Reader) (*models.Suite, error) {
 				for _, h := range req.Headers {
 					if !h.Disabled {
 						headers[h.Name] = h.Value
+					} else {
+						ws.Disabled(name, "header", h.Name)
 					}
 				}
 
@@ -169,6 +225,8 @@ func Convert(r io.Reader) (*models.Suite, error) {
 					for _, p := range req.Parameters {
 						if !p.Disabled {
 							queryParts = append(queryParts, fmt.Sprintf("%s=%s", p.Name, p.Value))
+						} else {
+							ws.Disabled(name, "query parameter", p.Name)
 						}
 					}
 					if len(queryParts) > 0 {
@@ -177,7 +235,10 @@ func Convert(r io.Reader) (*models.Suite, error) {
 				}
 
 				var body any
-				if req.Body != nil {
+				var gql *models.GraphQL
+				if req.Body != nil && req.Body.MimeType == "application/graphql" {
+					gql = convertGraphQL(req.Body.Text, name, &ws)
+				} else if req.Body != nil {
 					if req.Body.Text != "" {
 						body = req.Body.Text
 					} else if len(req.Body.Params) > 0 {
@@ -186,6 +247,8 @@ func Convert(r io.Reader) (*models.Suite, error) {
 						for _, p := range req.Body.Params {
 							if !p.Disabled {
 								params[p.Name] = p.Value
+							} else {
+								ws.Disabled(name, "form field", p.Name)
 							}
 						}
 						body = params
@@ -196,35 +259,39 @@ func Convert(r io.Reader) (*models.Suite, error) {
 				}
 
 				// Handle auth (check all possible locations)
-				var auth *Authentication
-				if item.Authentication != nil && !item.Authentication.Disabled {
-					auth = item.Authentication
-				} else if item.Auth != nil && !item.Auth.Disabled {
-					auth = item.Auth
-				} else if req.Auth != nil && !req.Auth.Disabled {
+				auth := itemAuth(item)
+				if auth == nil && req.Auth != nil && !req.Auth.Disabled {
 					auth = req.Auth
-				} else if req.Authentication != nil && !req.Authentication.Disabled {
+				} else if auth == nil && req.Authentication != nil && !req.Authentication.Disabled {
 					auth = req.Authentication
 				}
-				if auth != nil {
+				if auth != nil && !opts.SkipAuth {
 					authHeaders := convertAuthToHeaders(auth)
+					if len(authHeaders) == 0 && auth.Type != "" && auth.Type != "none" {
+						ws.Add(name, "auth:"+auth.Type, "skipped (only basic and bearer are supported)")
+					}
 					for k, v := range authHeaders {
 						headers[k] = v
 					}
 				}
 
 				tc := models.TestCase{
-					Name:    strings.Join(currentPath, " > "),
-					Request: models.Request{Method: req.Method, URL: url, Headers: headers, Body: body},
+					Name:    opts.TestName(path, item.Name, " > "),
+					Request: models.Request{Method: req.Method, URL: url, Headers: headers, Body: body, GraphQL: gql},
 					Assert:  models.Assertions{Status: 200},
+					Tags:    opts.FolderTags(path),
 				}
 
 				// Handle scripts
-				if item.PreRequestScript != "" {
-					tc.Pre = script.TranslateJSToHook(item.PreRequestScript, "insomnia")
+				if item.PreRequestScript != "" && !opts.NoScripts {
+					tr := script.TranslateJSToHook(item.PreRequestScript, "insomnia", script.PreRequest)
+					tr.ApplyTo(&tc)
+					ws.Script(tr.IssuesFor(name, "preRequestScript"))
 				}
-				if item.AfterResponseScript != "" {
-					tc.Post = script.TranslateJSToHook(item.AfterResponseScript, "insomnia")
+				if item.AfterResponseScript != "" && !opts.NoScripts {
+					tr := script.TranslateJSToHook(item.AfterResponseScript, "insomnia", script.PostResponse)
+					tr.ApplyTo(&tc)
+					ws.Script(tr.IssuesFor(name, "afterResponseScript"))
 				}
 
 				// Handle item environment
@@ -237,14 +304,21 @@ func Convert(r io.Reader) (*models.Suite, error) {
 					}
 				}
 
-				suite.Tests = append(suite.Tests, tc)
+				if files {
+					folder.Tests = append(folder.Tests, tc)
+				} else {
+					suite.Tests = append(suite.Tests, tc)
+				}
 			}
 		}
-		walk(exp.Collection.Items, []string{})
+		walk(exp.Collection.Items, []string{}, root)
 	} else {
 		// Fallback to legacy resource format
 		for _, res := range exp.Resources {
 			if res.Type != "request" {
+				if res.Type != "request_group" && res.Type != "workspace" && res.Type != "environment" {
+					ws.Add(res.Name, "resource:"+res.Type, "skipped")
+				}
 				continue
 			}
 			headers := map[string]string{}
@@ -252,19 +326,72 @@ func Convert(r io.Reader) (*models.Suite, error) {
 				headers[h.Name] = h.Value
 			}
 			var body any
-			if res.Body != nil && res.Body.Text != "" {
+			var gql *models.GraphQL
+			if res.Body != nil && res.Body.MimeType == "application/graphql" {
+				gql = convertGraphQL(res.Body.Text, res.Name, &ws)
+			} else if res.Body != nil && res.Body.Text != "" {
 				body = res.Body.Text
 			}
 			tc := models.TestCase{
 				Name:    res.Name,
-				Request: models.Request{Method: res.Method, URL: res.URL, Headers: headers, Body: body},
+				Request: models.Request{Method: res.Method, URL: res.URL, Headers: headers, Body: body, GraphQL: gql},
 				Assert:  models.Assertions{Status: 200},
 			}
 			suite.Tests = append(suite.Tests, tc)
 		}
 	}
 
-	return suite, nil
+	if !files {
+		opts.Apply(suite)
+	}
+	return suite, root, ws, nil
+}
+
+// itemAuth returns the enabled auth set on an item under either field name.
+func itemAuth(item CollectionItem) *Authentication {
+	if item.Authentication != nil && !item.Authentication.Disabled {
+		return item.Authentication
+	}
+	if item.Auth != nil && !item.Auth.Disabled {
+		return item.Auth
+	}
+	return nil
+}
+
+// convertFolder moves a folder's auth, environment and scripts onto f, the
+// suite-level settings of its file. Folder scripts become suite hooks that
+// see no response.
+func convertFolder(item CollectionItem, name string, f *adapters.Folder, opts adapters.Options, ws *adapters.Warnings) {
+	if auth := itemAuth(item); auth != nil && !opts.SkipAuth {
+		switch {
+		case auth.Type == "basic" && (auth.Username != "" || auth.Password != ""):
+			f.Auth = &models.Auth{BasicEnv: auth.Username + ":" + auth.Password}
+		case auth.Type == "bearer" && auth.Token != "":
+			f.Auth = &models.Auth{BearerEnv: auth.Token}
+		case auth.Type != "" && auth.Type != "none":
+			ws.Add(name, "auth:"+auth.Type, "skipped (only basic and bearer are supported)")
+		}
+	}
+	for k, v := range item.Environment {
+		if str, ok := v.(string); ok {
+			if f.Vars == nil {
+				f.Vars = map[string]string{}
+			}
+			f.Vars[k] = str
+		}
+	}
+	if !opts.NoScripts {
+		if item.PreRequestScript != "" {
+			tr := script.TranslateJSToHook(item.PreRequestScript, "insomnia", script.PreRequest)
+			f.PreSuite = tr.Hooks
+			ws.Script(tr.IssuesFor(name, "preRequestScript"))
+		}
+		if item.AfterResponseScript != "" {
+			tr := script.TranslateJSToHook(item.AfterResponseScript, "insomnia", script.PreRequest)
+			f.PostSuite = tr.Hooks
+			ws.Script(tr.IssuesFor(name, "afterResponseScript"))
+		}
+	}
 }
 
 func convertAuthToHeaders(auth *Authentication) map[string]string {
@@ -286,3 +413,29 @@ func convertAuthToHeaders(auth *Authentication) map[string]string {
 	}
 	return headers
 }
+
+// convertGraphQL maps a GraphQL body, which Insomnia stores as JSON text with
+// query, variables and operationName, onto request.graphql.
+func convertGraphQL(text, item string, ws *adapters.Warnings) *models.GraphQL {
+	var in struct {
+		Query         string          `json:"query"`
+		Variables     json.RawMessage `json:"variables"`
+		OperationName string          `json:"operationName"`
+	}
+	if err := json.Unmarshal([]byte(text), &in); err != nil {
+		ws.Add(item, "body:graphql", "skipped (not valid JSON)")
+		return nil
+	}
+	v := in.Variables
+	if string(v) == "null" {
+		v = nil
+	}
+	// Older exports keep the variables as a JSON string.
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		v = json.RawMessage(s)
+	}
+	g := adapters.GraphQL(in.Query, string(v), item, ws)
+	g.OperationName = in.OperationName
+	return g
+}
@@ -1,8 +1,12 @@
 package insomnia
 
 import (
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
 
 func TestConvertMinimal(t *testing.T) {
@@ -104,3 +108,28 @@ func TestConvertEmptyInsomniaCollection(t *testing.T) {
 		t.Fatalf("expected 0 tests for empty collection")
 	}
 }
+
+func TestConvertGraphQL(t *testing.T) {
+	js := `{"resources":[
+		{"_type":"request","name":"user","method":"POST","url":"https://api.example.com/graphql",
+			"body":{"mimeType":"application/graphql","text":"{\"query\":\"query U($id: ID!) { user(id: $id) { name } }\",\"variables\":{\"id\":\"7\"},\"operationName\":\"U\"}"}},
+		{"_type":"request","name":"old","method":"POST","url":"https://api.example.com/graphql",
+			"body":{"mimeType":"application/graphql","text":"{\"query\":\"{ me { id } }\",\"variables\":\"{\\\"a\\\": 1}\"}"}}
+	]}`
+	s, ws, err := ConvertWithReport(strings.NewReader(js), adapters.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*models.GraphQL{
+		{Query: "query U($id: ID!) { user(id: $id) { name } }", Variables: map[string]any{"id": "7"}, OperationName: "U"},
+		{Query: "{ me { id } }", Variables: map[string]any{"a": float64(1)}},
+	}
+	if len(s.Tests) != 2 || len(ws) != 0 {
+		t.Fatalf("tests %+v, warnings %v", s.Tests, ws)
+	}
+	for i, w := range want {
+		if r := s.Tests[i].Request; r.Body != nil || !reflect.DeepEqual(r.GraphQL, w) {
+			t.Fatalf("test %d: %+v", i, r)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSString quotes s as a JavaScript string literal.
+func JSString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// ContentTypeCheck returns an assert.js check that the response Content-Type
+// contains the media type mt.
+func ContentTypeCheck(mt string) string {
+	return fmt.Sprintf("check(%s, (response.header(\"Content-Type\") || \"\").indexOf(%s) >= 0);", JSString("content-type is "+mt), JSString(mt))
+}
+
+// IsJSON reports whether a media type carries JSON.
+func IsJSON(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	return ct == "application/json" || strings.HasSuffix(ct, "+json")
+}
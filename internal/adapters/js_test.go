@@ -0,0 +1,27 @@
+package adapters
+
+import "testing"
+
+func TestContentTypeCheck(t *testing.T) {
+	want := `check("content-type is application/json", (response.header("Content-Type") || "").indexOf("application/json") >= 0);`
+	if got := ContentTypeCheck("application/json"); got != want {
+		t.Fatalf("got %s", got)
+	}
+	if got := JSString(`say "hi"`); got != `"say \"hi\""` {
+		t.Fatalf("JSString = %s", got)
+	}
+}
+
+func TestIsJSON(t *testing.T) {
+	for ct, want := range map[string]bool{
+		"application/json":                true,
+		"Application/JSON; charset=utf-8": true,
+		"application/problem+json":        true,
+		"text/plain":                      false,
+		"":                                false,
+	} {
+		if got := IsJSON(ct); got != want {
+			t.Errorf("IsJSON(%q) = %v", ct, got)
+		}
+	}
+}
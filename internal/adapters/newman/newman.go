@@ -3,6 +3,7 @@ package newman
 import (
 	"io"
 
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 	"github.com/DrWeltschmerz/HydReq/internal/adapters/postman"
 	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
@@ -15,3 +16,14 @@ func Convert(r io.Reader, envVars map[string]string) (*models.Suite, error) {
 	// TODO: Add any Newman-specific features like run configurations, data files, etc.
 	return postman.Convert(r, envVars)
 }
+
+// ConvertWithReport is Convert that also returns what could not be imported
+// as-is.
+func ConvertWithReport(r io.Reader, opts adapters.Options) (*models.Suite, []adapters.Warning, error) {
+	return postman.ConvertWithReport(r, opts)
+}
+
+// ConvertFiles converts a collection into one suite per folder.
+func ConvertFiles(r io.Reader, opts adapters.Options) ([]adapters.File, []adapters.Warning, error) {
+	return postman.ConvertFiles(r, opts)
+}
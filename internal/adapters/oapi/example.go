@@ -0,0 +1,206 @@
+package oapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxDepth bounds example generation for deeply nested or recursive schemas.
+const maxDepth = 8
+
+// formatExamples are values for the common string formats.
+var formatExamples = map[string]string{
+	"date-time": "2024-01-01T00:00:00Z",
+	"date":      "2024-01-01",
+	"time":      "12:00:00",
+	"email":     "user@example.com",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"byte":      "ZXhhbXBsZQ==",
+	"password":  "password",
+}
+
+// paramValue picks a value for a parameter: its example, the first of its
+// examples, then one generated from its schema.
+func paramValue(p *openapi3.Parameter) string {
+	if p.Example != nil {
+		return scalar(p.Example)
+	}
+	if v, ok := firstExample(p.Examples); ok {
+		return scalar(v)
+	}
+	if p.Schema != nil {
+		return scalar(example(p.Schema, false, 0, nil))
+	}
+	if mt := p.Content.Get("application/json"); mt != nil {
+		if v, ok := mediaExample(mt, false); ok {
+			return scalar(v)
+		}
+	}
+	return "example"
+}
+
+// mediaExample picks a body for a media type: its example, the first of its
+// examples, then one generated from its schema. forResponse keeps readOnly
+// properties and drops writeOnly ones; requests do the opposite.
+func mediaExample(mt *openapi3.MediaType, forResponse bool) (any, bool) {
+	if mt.Example != nil {
+		return mt.Example, true
+	}
+	if v, ok := firstExample(mt.Examples); ok {
+		return v, true
+	}
+	if mt.Schema != nil {
+		return example(mt.Schema, forResponse, 0, nil), true
+	}
+	return nil, false
+}
+
+// firstExample returns the value of the alphabetically first named example.
+func firstExample(examples openapi3.Examples) (any, bool) {
+	names := make([]string, 0, len(examples))
+	for n := range examples {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if ref := examples[n]; ref != nil && ref.Value != nil && ref.Value.Value != nil {
+			return ref.Value.Value, true
+		}
+	}
+	return nil, false
+}
+
+// example generates a value for a schema from its example, default or enum,
+// falling back to its type and format. seen guards against recursive refs.
+func example(ref *openapi3.SchemaRef, forResponse bool, depth int, seen map[*openapi3.Schema]bool) any {
+	if ref == nil || ref.Value == nil || depth > maxDepth || seen[ref.Value] {
+		return nil
+	}
+	s := ref.Value
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	}
+	if seen == nil {
+		seen = map[*openapi3.Schema]bool{}
+	}
+	seen[s] = true
+	defer delete(seen, s)
+
+	if len(s.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, sub := range s.AllOf {
+			if m, ok := example(sub, forResponse, depth+1, seen).(map[string]any); ok {
+				for k, v := range m {
+					merged[k] = v
+				}
+			}
+		}
+		for k, v := range objectExample(s, forResponse, depth, seen) {
+			merged[k] = v
+		}
+		return merged
+	}
+	if len(s.OneOf) > 0 {
+		return example(s.OneOf[0], forResponse, depth+1, seen)
+	}
+	if len(s.AnyOf) > 0 {
+		return example(s.AnyOf[0], forResponse, depth+1, seen)
+	}
+
+	switch {
+	case s.Type.Is("object") || s.Type == nil && len(s.Properties) > 0:
+		return objectExample(s, forResponse, depth, seen)
+	case s.Type.Is("array"):
+		item := example(s.Items, forResponse, depth+1, seen)
+		if m, ok := item.(map[string]any); item != nil && (!ok || len(m) > 0) {
+			return []any{item}
+		}
+		return []any{}
+	case s.Type.Is("integer"):
+		if s.Min != nil {
+			return int64(*s.Min)
+		}
+		return 1
+	case s.Type.Is("number"):
+		if s.Min != nil {
+			return *s.Min
+		}
+		return 1.5
+	case s.Type.Is("boolean"):
+		return true
+	}
+	if v, ok := formatExamples[s.Format]; ok {
+		return v
+	}
+	v := "example"
+	if n := int(s.MinLength); n > len(v) {
+		v += strings.Repeat("x", n-len(v))
+	}
+	if s.MaxLength != nil && int(*s.MaxLength) < len(v) {
+		v = v[:*s.MaxLength]
+	}
+	return v
+}
+
+func objectExample(s *openapi3.Schema, forResponse bool, depth int, seen map[*openapi3.Schema]bool) map[string]any {
+	out := map[string]any{}
+	for name, prop := range s.Properties {
+		if prop == nil || prop.Value == nil {
+			continue
+		}
+		if forResponse && prop.Value.WriteOnly || !forResponse && prop.Value.ReadOnly {
+			continue
+		}
+		if v := example(prop, forResponse, depth+1, seen); v != nil {
+			out[name] = v
+		}
+	}
+	return out
+}
+
+// scalar renders an example as a parameter string; objects and arrays
+// become JSON.
+func scalar(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		if x == float64(int64(x)) {
+			return fmt.Sprint(int64(x))
+		}
+	case []any, map[string]any:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// formBody encodes an object example as application/x-www-form-urlencoded.
+func formBody(v any) string {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return scalar(v)
+	}
+	form := url.Values{}
+	for k, val := range m {
+		form.Set(k, scalar(val))
+	}
+	return form.Encode()
+}
@@ -0,0 +1,363 @@
+package oapi
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// any4xx is the assertion used when an operation documents no matching 4xx
+// code. Requests that break the spec also reject 401 and 403, so a request
+// turned away for its credentials does not pass as rejected for its content.
+const (
+	any4xx        = `check("status is 4xx", response.status >= 400 && response.status < 500);`
+	anyInvalid4xx = `check("status is 4xx other than 401/403", response.status >= 400 && response.status < 500 && response.status !== 401 && response.status !== 403);`
+)
+
+// violation is a value that breaks one rule of a schema.
+type violation struct {
+	what  string // e.g. "wrong type", "above maximum"
+	value any
+}
+
+// Negative reads an OpenAPI (3.x) or Swagger (2.0) spec and produces a suite
+// of requests that break it: missing required parameters and body fields,
+// wrong types, numbers out of range, overlong strings, values outside an
+// enum and, for secured operations, no credentials. Each test starts from the
+// request Convert would send and expects a 4xx response: the operation's
+// documented 400 or 422 (401 or 403 for missing auth) when there is one.
+func Negative(r io.Reader, opts adapters.Options) (*models.Suite, []adapters.Warning, error) {
+	doc, baseURL, err := read(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ws adapters.Warnings
+	s := newSuite(doc, baseURL, opts, &ws)
+	if s.Name == "" {
+		s.Name = "negative"
+	} else {
+		s.Name += " (negative)"
+	}
+	for _, o := range operations(doc) {
+		base := o.request(&ws)
+		if !opts.SkipAuth {
+			o.sendKeys(doc, s, &base)
+		}
+		tags := append([]string{"negative"}, o.op.Tags...)
+		invalid := expect4xx(o.op, anyInvalid4xx, 400, 422)
+		add := func(what string, req models.Request, assert models.Assertions) {
+			s.Tests = append(s.Tests, models.TestCase{Name: o.name() + ": " + what, Request: req, Assert: assert, Tags: tags})
+		}
+
+		for _, p := range o.params {
+			if p.In != openapi3.ParameterInQuery && p.In != openapi3.ParameterInHeader && p.In != openapi3.ParameterInPath {
+				continue
+			}
+			if p.In == openapi3.ParameterInHeader && (base.Headers[p.Name] == "" || isKeyHeader(doc, o.op, p.Name)) {
+				continue // Content-Type, Accept and Authorization are not sent as parameters; API keys get the no auth test
+			}
+			label := p.In + "." + p.Name
+			if p.Required && p.In != openapi3.ParameterInPath {
+				add(label+" missing", o.withParam(base, p, nil), invalid)
+			}
+			if p.Schema == nil || p.Schema.Value == nil {
+				continue
+			}
+			for _, v := range violations(p.Schema.Value, false) {
+				val := scalar(v.value)
+				add(label+" "+v.what, o.withParam(base, p, &val), invalid)
+			}
+		}
+
+		if ct, mt := o.body(); mt != nil && adapters.IsJSON(ct) && mt.Schema != nil && mt.Schema.Value != nil {
+			if obj, ok := base.Body.(map[string]any); ok {
+				props, required := properties(mt.Schema.Value)
+				for _, name := range required {
+					if _, ok := obj[name]; ok {
+						add("body."+name+" missing", withBody(base, obj, name, nil), invalid)
+					}
+				}
+				names := make([]string, 0, len(props))
+				for name := range props {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					prop := props[name]
+					if prop == nil || prop.Value == nil || prop.Value.ReadOnly {
+						continue
+					}
+					for _, v := range violations(prop.Value, true) {
+						val := v.value
+						add("body."+name+" "+v.what, withBody(base, obj, name, &val), invalid)
+					}
+				}
+			}
+		}
+
+		if !opts.SkipAuth && requiresAuth(doc, o.op) {
+			if header, ok := authHeader(doc, o.op, s.Auth, base); ok {
+				req := cloneRequest(base)
+				if req.Headers == nil {
+					req.Headers = map[string]string{}
+				}
+				// An explicit empty header keeps the suite auth (or the key) off this request
+				req.Headers[header] = ""
+				add("no auth", req, expect4xx(o.op, any4xx, 401, 403))
+			} else {
+				ws.Addf(o.name()+": no auth", "security", "skipped (the suite does not send these credentials)", "%s", strings.Join(schemeNames(effectiveSecurity(doc, o.op)[0]), ", "))
+			}
+		}
+	}
+	opts.Apply(s)
+	return s, ws, nil
+}
+
+// violations lists values breaking sch. Parameters travel as strings, so
+// only numbers and booleans can have the wrong type there.
+func violations(sch *openapi3.Schema, inBody bool) []violation {
+	var out []violation
+	switch {
+	case sch.Type.Is("integer"), sch.Type.Is("number"):
+		out = append(out, violation{"wrong type", "not-a-number"})
+	case sch.Type.Is("boolean"):
+		out = append(out, violation{"wrong type", "not-a-boolean"})
+	case !inBody:
+	case sch.Type.Is("string"):
+		out = append(out, violation{"wrong type", 12345})
+	case sch.Type.Is("array"):
+		out = append(out, violation{"wrong type", "not-an-array"})
+	case sch.Type.Is("object"):
+		out = append(out, violation{"wrong type", "not-an-object"})
+	}
+	if len(sch.Enum) > 0 {
+		out = append(out, violation{"not in enum", outsideEnum(sch.Enum)})
+	}
+	number := sch.Type.Is("integer") || sch.Type.Is("number")
+	if number && sch.Min != nil {
+		v := *sch.Min
+		if !sch.ExclusiveMin {
+			v--
+		}
+		out = append(out, violation{"below minimum", numberValue(sch, v)})
+	}
+	if number && sch.Max != nil {
+		v := *sch.Max
+		if !sch.ExclusiveMax {
+			v++
+		}
+		out = append(out, violation{"above maximum", numberValue(sch, v)})
+	}
+	if sch.MaxLength != nil && (sch.Type == nil || sch.Type.Is("string")) {
+		out = append(out, violation{"too long", strings.Repeat("x", int(*sch.MaxLength)+1)})
+	}
+	return out
+}
+
+func numberValue(sch *openapi3.Schema, v float64) any {
+	if sch.Type.Is("integer") {
+		return int64(v)
+	}
+	return v
+}
+
+// outsideEnum returns a value of the enum's kind that is not one of its values.
+func outsideEnum(enum []any) any {
+	if n, ok := enum[0].(float64); ok {
+		for _, e := range enum {
+			if f, ok := e.(float64); ok && f > n {
+				n = f
+			}
+		}
+		return n + 1
+	}
+	v := "not-a-valid-value"
+	for in := true; in; {
+		in = false
+		for _, e := range enum {
+			if fmt.Sprint(e) == v {
+				v += "-x"
+				in = true
+			}
+		}
+	}
+	return v
+}
+
+// properties returns the top-level properties and required fields of an
+// object schema, including those it pulls in with allOf.
+func properties(sch *openapi3.Schema) (openapi3.Schemas, []string) {
+	props := openapi3.Schemas{}
+	var required []string
+	seen := map[string]bool{}
+	var walk func(s *openapi3.Schema)
+	walk = func(s *openapi3.Schema) {
+		for _, sub := range s.AllOf {
+			if sub != nil && sub.Value != nil {
+				walk(sub.Value)
+			}
+		}
+		for name, p := range s.Properties {
+			props[name] = p
+		}
+		for _, r := range s.Required {
+			if !seen[r] {
+				seen[r] = true
+				required = append(required, r)
+			}
+		}
+	}
+	walk(sch)
+	return props, required
+}
+
+// effectiveSecurity returns op's security requirements, or the spec's.
+func effectiveSecurity(doc *openapi3.T, op *openapi3.Operation) openapi3.SecurityRequirements {
+	if op.Security != nil {
+		return *op.Security
+	}
+	return doc.Security
+}
+
+// requiresAuth reports whether op needs credentials: it or the spec lists
+// security requirements and none of them is empty (anonymous access).
+func requiresAuth(doc *openapi3.T, op *openapi3.Operation) bool {
+	sec := effectiveSecurity(doc, op)
+	if len(sec) == 0 {
+		return false
+	}
+	for _, req := range sec {
+		if len(req) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isKeyHeader reports whether header carries an apiKey of op's security.
+func isKeyHeader(doc *openapi3.T, op *openapi3.Operation, header string) bool {
+	sec := effectiveSecurity(doc, op)
+	if len(sec) == 0 {
+		return false
+	}
+	for h := range apiKeyHeaders(sec[0], securitySchemes(doc)) {
+		if strings.EqualFold(h, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// authHeader returns the header carrying the credentials of op's first
+// security requirement, if the suite sends them: Authorization for http
+// basic/bearer schemes under suite auth, or the apiKey header sendKeys set.
+func authHeader(doc *openapi3.T, op *openapi3.Operation, auth *models.Auth, req models.Request) (string, bool) {
+	schemes := securitySchemes(doc)
+	for _, name := range schemeNames(effectiveSecurity(doc, op)[0]) {
+		ref := schemes[name]
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		switch sch := ref.Value; sch.Type {
+		case "http":
+			if auth != nil && (strings.EqualFold(sch.Scheme, "basic") || strings.EqualFold(sch.Scheme, "bearer")) {
+				return "Authorization", true
+			}
+		case "apiKey":
+			if sch.In != "header" {
+				continue
+			}
+			for h := range req.Headers {
+				if strings.EqualFold(h, sch.Name) {
+					return h, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// expect4xx asserts the first of codes op documents, or runs the fallback check.
+func expect4xx(op *openapi3.Operation, fallback string, codes ...int) models.Assertions {
+	if op.Responses != nil {
+		for _, c := range codes {
+			if op.Responses.Value(strconv.Itoa(c)) != nil {
+				return models.Assertions{Status: c}
+			}
+		}
+	}
+	return models.Assertions{JS: fallback}
+}
+
+// withParam copies req with parameter p set to v, or left out when v is nil.
+func (o operation) withParam(req models.Request, p *openapi3.Parameter, v *string) models.Request {
+	out := cloneRequest(req)
+	switch p.In {
+	case openapi3.ParameterInQuery:
+		if out.Query == nil {
+			out.Query = map[string]string{}
+		}
+		if v == nil {
+			delete(out.Query, p.Name)
+		} else {
+			out.Query[p.Name] = *v
+		}
+	case openapi3.ParameterInHeader:
+		if v == nil {
+			delete(out.Headers, p.Name)
+		} else {
+			out.Headers[p.Name] = *v
+		}
+	case openapi3.ParameterInPath:
+		values := map[string]string{}
+		for _, pp := range o.params {
+			if pp.In == openapi3.ParameterInPath {
+				values[pp.Name] = paramValue(pp)
+			}
+		}
+		if v != nil {
+			values[p.Name] = *v
+		}
+		out.URL = o.url(values)
+	}
+	return out
+}
+
+// withBody copies req with the body field name set to v, or left out when v is nil.
+func withBody(req models.Request, body map[string]any, name string, v *any) models.Request {
+	out := cloneRequest(req)
+	b := make(map[string]any, len(body))
+	for k, val := range body {
+		b[k] = val
+	}
+	if v == nil {
+		delete(b, name)
+	} else {
+		b[name] = *v
+	}
+	out.Body = b
+	return out
+}
+
+func cloneRequest(req models.Request) models.Request {
+	out := req
+	if req.Headers != nil {
+		out.Headers = make(map[string]string, len(req.Headers))
+		for k, v := range req.Headers {
+			out.Headers[k] = v
+		}
+	}
+	if req.Query != nil {
+		out.Query = make(map[string]string, len(req.Query))
+		for k, v := range req.Query {
+			out.Query[k] = v
+		}
+	}
+	return out
+}
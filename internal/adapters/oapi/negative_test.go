@@ -0,0 +1,193 @@
+package oapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+const negativeSpec = `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+security: [{bearer: []}]
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+paths:
+  /pets:
+    get:
+      security: []
+      parameters:
+        - {name: limit, in: query, required: true, schema: {type: integer, minimum: 1, maximum: 100}}
+      responses: {"200": {description: ok}, "400": {description: bad}}
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                id: {type: integer, readOnly: true}
+                name: {type: string, maxLength: 5}
+                kind: {type: string, enum: [cat, dog]}
+                age: {type: number, minimum: 0, exclusiveMinimum: true}
+      responses: {"201": {description: created}, "422": {description: invalid}, "401": {description: unauth}}
+  /pets/{id}:
+    get:
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses: {"200": {description: ok}}
+`
+
+func TestNegative(t *testing.T) {
+	s, _, err := Negative(strings.NewReader(negativeSpec), adapters.Options{})
+	if err != nil {
+		t.Fatalf("negative: %v", err)
+	}
+	if s.Name != "Pets (negative)" || s.Auth == nil || s.Auth.BearerEnv != "token" {
+		t.Fatalf("suite: %q %+v", s.Name, s.Auth)
+	}
+	tests := map[string]models.TestCase{}
+	for _, tc := range s.Tests {
+		tests[tc.Name] = tc
+		if len(tc.Tags) == 0 || tc.Tags[0] != "negative" {
+			t.Fatalf("%s: tags %v", tc.Name, tc.Tags)
+		}
+	}
+	want := []string{
+		"GET /pets: query.limit missing",
+		"GET /pets: query.limit wrong type",
+		"GET /pets: query.limit below minimum",
+		"GET /pets: query.limit above maximum",
+		"POST /pets: body.name missing",
+		"POST /pets: body.name wrong type",
+		"POST /pets: body.name too long",
+		"POST /pets: body.kind wrong type",
+		"POST /pets: body.kind not in enum",
+		"POST /pets: body.age wrong type",
+		"POST /pets: body.age below minimum",
+		"POST /pets: no auth",
+		"GET /pets/{id}: path.id wrong type",
+		"GET /pets/{id}: no auth",
+	}
+	for _, name := range want {
+		if _, ok := tests[name]; !ok {
+			t.Errorf("missing test %q", name)
+		}
+	}
+	if len(s.Tests) != len(want) {
+		names := make([]string, 0, len(s.Tests))
+		for _, tc := range s.Tests {
+			names = append(names, tc.Name)
+		}
+		t.Fatalf("got %d tests, want %d: %v", len(s.Tests), len(want), names)
+	}
+
+	if tc := tests["GET /pets: query.limit missing"]; tc.Request.Query["limit"] != "" || tc.Assert.Status != 400 {
+		t.Fatalf("missing limit: %+v", tc)
+	}
+	if tc := tests["GET /pets: query.limit above maximum"]; tc.Request.Query["limit"] != "101" {
+		t.Fatalf("limit above maximum: %v", tc.Request.Query)
+	}
+	if tc := tests["POST /pets: body.age below minimum"]; tc.Request.Body.(map[string]any)["age"] != 0.0 || tc.Assert.Status != 422 {
+		t.Fatalf("exclusive minimum: %+v", tc)
+	}
+	if tc := tests["POST /pets: body.name missing"]; tc.Request.Body.(map[string]any)["name"] != nil {
+		t.Fatalf("name still sent: %v", tc.Request.Body)
+	}
+	if tc := tests["POST /pets: body.name too long"]; tc.Request.Body.(map[string]any)["name"] != "xxxxxx" {
+		t.Fatalf("too long: %v", tc.Request.Body)
+	}
+	if tc := tests["POST /pets: no auth"]; tc.Request.Headers["Authorization"] != "" || tc.Assert.Status != 401 {
+		t.Fatalf("no auth: %+v", tc)
+	} else if _, ok := tc.Request.Headers["Authorization"]; !ok {
+		t.Fatal("no auth: Authorization header not blanked")
+	}
+	if tc := tests["GET /pets/{id}: path.id wrong type"]; tc.Request.URL != "/pets/not-a-number" || tc.Assert.JS != anyInvalid4xx {
+		t.Fatalf("path id: %+v", tc)
+	}
+	if tc := tests["GET /pets/{id}: no auth"]; tc.Assert.JS != any4xx {
+		t.Fatalf("no auth: %+v", tc)
+	}
+}
+
+func TestNegative_SkipAuth(t *testing.T) {
+	s, _, err := Negative(strings.NewReader(negativeSpec), adapters.Options{SkipAuth: true})
+	if err != nil {
+		t.Fatalf("negative: %v", err)
+	}
+	if s.Auth != nil {
+		t.Fatalf("auth: %+v", s.Auth)
+	}
+	for _, tc := range s.Tests {
+		if strings.HasSuffix(tc.Name, "no auth") {
+			t.Fatalf("unexpected %q", tc.Name)
+		}
+	}
+}
+
+func TestNegative_APIKey(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: Keys, version: "1"}
+security: [{key: []}]
+components:
+  securitySchemes:
+    key: {type: apiKey, in: header, name: X-API-Key}
+    query-key: {type: apiKey, in: query, name: api_key}
+paths:
+  /items:
+    get:
+      parameters:
+        - {name: X-API-Key, in: header, required: true, schema: {type: string}}
+        - {name: limit, in: query, schema: {type: integer}}
+      responses: {"200": {description: ok}, "403": {description: forbidden}, "422": {description: invalid}}
+  /orders:
+    get:
+      responses: {"200": {description: ok}}
+  /legacy:
+    get:
+      security: [{query-key: []}]
+      responses: {"200": {description: ok}}
+`
+	s, ws, err := Negative(strings.NewReader(spec), adapters.Options{})
+	if err != nil {
+		t.Fatalf("negative: %v", err)
+	}
+	if s.Variables["key"] != "" || len(s.Secrets) != 1 || s.Secrets[0] != "key" {
+		t.Fatalf("vars %v, secrets %v", s.Variables, s.Secrets)
+	}
+	tests := map[string]models.TestCase{}
+	for _, tc := range s.Tests {
+		tests[tc.Name] = tc
+		if strings.HasPrefix(tc.Name, "GET /items: header.X-API-Key") {
+			t.Fatalf("unexpected %q: the key is covered by no auth", tc.Name)
+		}
+	}
+	for name, status := range map[string]int{"GET /items: no auth": 403, "GET /orders: no auth": 0} {
+		tc, ok := tests[name]
+		if !ok || tc.Assert.Status != status {
+			t.Fatalf("%s: %+v", name, tc)
+		}
+		if v, ok := tc.Request.Headers["X-API-Key"]; !ok || v != "" {
+			t.Fatalf("%s: X-API-Key not blanked: %v", name, tc.Request.Headers)
+		}
+		if _, ok := tc.Request.Headers["Authorization"]; ok {
+			t.Fatalf("%s: unexpected Authorization header: %v", name, tc.Request.Headers)
+		}
+	}
+	if tc := tests["GET /items: query.limit wrong type"]; tc.Request.Headers["X-API-Key"] != "${key}" || tc.Assert.Status != 422 {
+		t.Fatalf("wrong type: %+v", tc)
+	}
+	if _, ok := tests["GET /legacy: no auth"]; ok {
+		t.Fatal("unexpected no auth test for a query key")
+	}
+	found := false
+	for _, w := range ws {
+		found = found || w.Item == "GET /legacy: no auth" && w.Feature == "security" && w.Detail == "query-key"
+	}
+	if !found {
+		t.Fatalf("missing skipped warning: %+v", ws)
+	}
+}
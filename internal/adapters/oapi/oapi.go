@@ -1,230 +1,448 @@
 package oapi
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
+	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 	"github.com/DrWeltschmerz/HydReq/pkg/models"
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
 	"gopkg.in/yaml.v3"
+	kyaml "sigs.k8s.io/yaml"
 )
 
-type spec struct {
-	// OpenAPI 3.0+ fields
-	OpenAPI string `yaml:"openapi,omitempty"`
-	Info    struct {
-		Title string `yaml:"title"`
-	} `yaml:"info"`
-	Servers []struct {
-		URL string `yaml:"url"`
-	} `yaml:"servers,omitempty"`
-
-	// Swagger 2.0 fields
-	Swagger  string   `yaml:"swagger,omitempty"`
-	Host     string   `yaml:"host,omitempty"`
-	BasePath string   `yaml:"basePath,omitempty"`
-	Schemes  []string `yaml:"schemes,omitempty"`
+// header is the part of a spec read before loading it, to tell Swagger 2.0
+// from OpenAPI 3.x.
+type header struct {
+	Swagger string `yaml:"swagger,omitempty"`
+}
 
-	Paths      map[string]pathItem   `yaml:"paths"`
-	Components *components           `yaml:"components,omitempty"`
-	Security   []map[string][]string `yaml:"security,omitempty"`
+// methods lists the operations of a path item in import order.
+var methods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
 
-	// Swagger 2.0 security definitions
-	SecurityDefinitions map[string]securityScheme `yaml:"securityDefinitions,omitempty"`
+// Convert reads an OpenAPI (3.x) or Swagger (2.0) YAML/JSON spec and produces a Suite with one test per operation.
+func Convert(r io.Reader) (*models.Suite, error) {
+	s, _, err := ConvertWithReport(r, adapters.Options{})
+	return s, err
 }
 
-type components struct {
-	SecuritySchemes map[string]securityScheme `yaml:"securitySchemes,omitempty"`
+// ConvertWithReport is Convert that also returns what could not be imported
+// as-is.
+func ConvertWithReport(r io.Reader, opts adapters.Options) (*models.Suite, []adapters.Warning, error) {
+	doc, baseURL, err := read(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ws adapters.Warnings
+	s := newSuite(doc, baseURL, opts, &ws)
+	for _, o := range operations(doc) {
+		name := o.name()
+		status, resp := pickResponse(o.op)
+		req := o.request(&ws)
+		if !opts.SkipAuth {
+			o.sendKeys(doc, s, &req)
+		}
+		tc := models.TestCase{
+			Name:    name,
+			Request: req,
+			Assert:  models.Assertions{Status: status, JS: responseChecks(resp)},
+			Tags:    o.op.Tags,
+		}
+
+		// Handle operation security; apiKey headers are sent per test above
+		if o.op.Security != nil && len(*o.op.Security) > 0 && !opts.SkipAuth {
+			if other := otherSchemes((*o.op.Security)[0], securitySchemes(doc)); len(other) > 0 {
+				// TODO: per-test auth
+				ws.Addf(name, "operation security", "skipped (suite auth applies)", "%s", strings.Join(other, ", "))
+			}
+		}
+
+		s.Tests = append(s.Tests, tc)
+	}
+	opts.Apply(s)
+	return s, ws, nil
 }
 
-type securityScheme struct {
-	Type   string `yaml:"type"`
-	Scheme string `yaml:"scheme,omitempty"`
-	In     string `yaml:"in,omitempty"`
-	Name   string `yaml:"name,omitempty"`
+// newSuite starts a suite for doc with its title, base URL and global
+// security.
+func newSuite(doc *openapi3.T, baseURL string, opts adapters.Options, ws *adapters.Warnings) *models.Suite {
+	s := &models.Suite{BaseURL: baseURL}
+	if doc.Info != nil {
+		s.Name = doc.Info.Title
+	}
+	if !opts.SkipAuth && len(doc.Security) > 0 {
+		s.Auth = convertSecurity(doc.Security[0], securitySchemes(doc))
+		if s.Auth == nil && len(apiKeyHeaders(doc.Security[0], securitySchemes(doc))) == 0 {
+			ws.Addf("", "security", "skipped (only http basic, bearer and apiKey headers are supported)", "%s", strings.Join(schemeNames(doc.Security[0]), ", "))
+		}
+	}
+	return s
 }
 
-type pathItem struct {
-	Parameters []parameter `yaml:"parameters,omitempty"`
-	Get        *operation  `yaml:"get,omitempty"`
-	Put        *operation  `yaml:"put,omitempty"`
-	Post       *operation  `yaml:"post,omitempty"`
-	Delete     *operation  `yaml:"delete,omitempty"`
-	Options    *operation  `yaml:"options,omitempty"`
-	Head       *operation  `yaml:"head,omitempty"`
-	Patch      *operation  `yaml:"patch,omitempty"`
-	Trace      *operation  `yaml:"trace,omitempty"`
+func securitySchemes(doc *openapi3.T) openapi3.SecuritySchemes {
+	if doc.Components == nil {
+		return nil
+	}
+	return doc.Components.SecuritySchemes
 }
 
+// operation is one method of a path together with its parameters.
 type operation struct {
-	Tags        []string               `yaml:"tags,omitempty"`
-	Parameters  []parameter            `yaml:"parameters,omitempty"`
-	RequestBody *requestBody           `yaml:"requestBody,omitempty"`
-	Responses   map[string]interface{} `yaml:"responses,omitempty"`
-	Security    []map[string][]string  `yaml:"security,omitempty"`
+	method string
+	path   string
+	op     *openapi3.Operation
+	params []*openapi3.Parameter // path item and operation parameters; the operation's win
 }
 
-type parameter struct {
-	Name     string  `yaml:"name"`
-	In       string  `yaml:"in"`
-	Required bool    `yaml:"required,omitempty"`
-	Schema   *schema `yaml:"schema,omitempty"`
+func (o operation) name() string { return o.method + " " + o.path }
+
+// operations lists the operations of doc sorted by path, in method order.
+func operations(doc *openapi3.T) []operation {
+	if doc.Paths == nil {
+		return nil
+	}
+	paths := doc.Paths.Map()
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+	var out []operation
+	for _, p := range keys {
+		item := paths[p]
+		for _, m := range methods {
+			if op := item.GetOperation(m); op != nil {
+				out = append(out, operation{method: m, path: p, op: op, params: mergeParams(item.Parameters, op.Parameters)})
+			}
+		}
+	}
+	return out
 }
 
-type requestBody struct {
-	Required bool                 `yaml:"required,omitempty"`
-	Content  map[string]mediaType `yaml:"content,omitempty"`
+// mergeParams lets operation parameters override path item ones with the
+// same name and location.
+func mergeParams(lists ...openapi3.Parameters) []*openapi3.Parameter {
+	var out []*openapi3.Parameter
+	index := map[string]int{}
+	for _, list := range lists {
+		for _, ref := range list {
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			key := ref.Value.In + ":" + ref.Value.Name
+			if i, ok := index[key]; ok {
+				out[i] = ref.Value
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, ref.Value)
+		}
+	}
+	return out
 }
 
-type mediaType struct {
-	Schema *schema `yaml:"schema,omitempty"`
+// request builds a request for o from its parameters' and body's examples.
+func (o operation) request(ws *adapters.Warnings) models.Request {
+	name := o.name()
+	req := models.Request{Method: o.method}
+	headers := make(map[string]string)
+	query := make(map[string]string)
+	pathValues := make(map[string]string)
+	for _, p := range o.params {
+		switch p.In {
+		case openapi3.ParameterInHeader:
+			// Content-Type, Accept and Authorization are described elsewhere in a spec
+			switch strings.ToLower(p.Name) {
+			case "content-type", "accept", "authorization":
+				continue
+			}
+			headers[p.Name] = paramValue(p)
+		case openapi3.ParameterInQuery:
+			query[p.Name] = paramValue(p)
+		case openapi3.ParameterInPath:
+			pathValues[p.Name] = paramValue(p)
+		default:
+			ws.Addf(name, "parameter:"+p.In, "skipped", "%s", p.Name)
+		}
+	}
+	req.URL = o.url(pathValues)
+
+	// Handle request body, preferring JSON
+	if ct, mt := o.body(); mt != nil {
+		v, ok := mediaExample(mt, false)
+		switch {
+		case !ok:
+			ws.Add(name, "body:"+ct, "skipped (no schema or example)")
+		case adapters.IsJSON(ct):
+			headers["Content-Type"] = ct
+			req.Body = v
+		case ct == "application/x-www-form-urlencoded":
+			headers["Content-Type"] = ct
+			req.Body = formBody(v)
+		case strings.HasPrefix(ct, "text/"):
+			headers["Content-Type"] = ct
+			req.Body = scalar(v)
+		default:
+			ws.Add(name, "body:"+ct, "skipped (only JSON, form and text bodies are generated)")
+		}
+	}
+	if len(headers) > 0 {
+		req.Headers = headers
+	}
+	if len(query) > 0 {
+		req.Query = query
+	}
+	return req
 }
 
-type schema struct {
-	Type string `yaml:"type,omitempty"`
+// url fills the path template with values.
+func (o operation) url(values map[string]string) string {
+	u := o.path
+	for name, v := range values {
+		u = strings.ReplaceAll(u, "{"+name+"}", url.PathEscape(v))
+	}
+	return u
 }
 
-// Convert reads an OpenAPI (3.x) or Swagger (2.0) YAML/JSON spec and produces a basic Suite with one test per operation.
-func Convert(r io.Reader) (*models.Suite, error) {
+// body returns the request body media type to generate, if any.
+func (o operation) body() (string, *openapi3.MediaType) {
+	if o.op.RequestBody == nil || o.op.RequestBody.Value == nil {
+		return "", nil
+	}
+	return pickContent(o.op.RequestBody.Value.Content)
+}
+
+// read loads a spec from r.
+func read(r io.Reader) (*openapi3.T, string, error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	return load(data)
+}
+
+// load parses an OpenAPI 3.x or Swagger 2.0 spec, resolving $refs, and
+// returns it as OpenAPI 3 together with its base URL.
+func load(data []byte) (*openapi3.T, string, error) {
+	var h header
+	if err := yaml.Unmarshal(data, &h); err != nil {
+		return nil, "", err
+	}
+	if h.Swagger == "" {
+		doc, err := openapi3.NewLoader().LoadFromData(data)
+		if err != nil {
+			return nil, "", err
+		}
+		base := ""
+		if len(doc.Servers) > 0 {
+			base = doc.Servers[0].URL
+		}
+		return doc, base, nil
 	}
-	var sp spec
-	if err := yaml.Unmarshal(data, &sp); err != nil {
-		return nil, err
+	js, err := kyaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, "", err
 	}
+	var doc2 openapi2.T
+	if err := json.Unmarshal(js, &doc2); err != nil {
+		return nil, "", err
+	}
+	doc, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, "", err
+	}
+	base := ""
+	if doc2.Host != "" {
+		scheme := "https" // default
+		if len(doc2.Schemes) > 0 {
+			scheme = doc2.Schemes[0] // prefer first scheme
+		}
+		base = scheme + "://" + doc2.Host + strings.TrimSuffix(doc2.BasePath, "/")
+	}
+	return doc, base, nil
+}
 
-	s := &models.Suite{Name: sp.Info.Title}
+// pickContent chooses the media type to generate: JSON first, then the
+// alphabetically first one.
+func pickContent(content openapi3.Content) (string, *openapi3.MediaType) {
+	types := make([]string, 0, len(content))
+	for ct := range content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+	for _, ct := range types {
+		if adapters.IsJSON(ct) {
+			return ct, content[ct]
+		}
+	}
+	if len(types) == 0 {
+		return "", nil
+	}
+	return types[0], content[types[0]]
+}
 
-	// Handle base URL for both OpenAPI 3.0+ and Swagger 2.0
-	if sp.OpenAPI != "" {
-		// OpenAPI 3.0+ format
-		if len(sp.Servers) > 0 {
-			s.BaseURL = sp.Servers[0].URL
+func schemeNames(sec openapi3.SecurityRequirement) []string {
+	names := make([]string, 0, len(sec))
+	for n := range sec {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func convertSecurity(sec openapi3.SecurityRequirement, schemes openapi3.SecuritySchemes) *models.Auth {
+	for _, schemeName := range schemeNames(sec) {
+		ref, ok := schemes[schemeName]
+		if !ok || ref == nil || ref.Value == nil {
+			continue
 		}
-	} else if sp.Swagger == "2.0" {
-		// Swagger 2.0 format
-		if sp.Host != "" {
-			scheme := "https" // default
-			if len(sp.Schemes) > 0 {
-				scheme = sp.Schemes[0] // prefer first scheme
-			}
-			s.BaseURL = scheme + "://" + sp.Host
-			if sp.BasePath != "" {
-				s.BaseURL += sp.BasePath
+		scheme := ref.Value
+		switch scheme.Type {
+		case "http":
+			if strings.EqualFold(scheme.Scheme, "basic") {
+				return &models.Auth{BasicEnv: "username:password"}
+			} else if strings.EqualFold(scheme.Scheme, "bearer") {
+				return &models.Auth{BearerEnv: "token"}
 			}
 		}
 	}
+	return nil
+}
 
-	// Handle global security
-	if sp.OpenAPI != "" && len(sp.Security) > 0 && sp.Components != nil {
-		// OpenAPI 3.0+ security
-		s.Auth = convertSecurity(sp.Security[0], sp.Components.SecuritySchemes)
-	} else if sp.Swagger == "2.0" && len(sp.Security) > 0 && sp.SecurityDefinitions != nil {
-		// Swagger 2.0 security
-		s.Auth = convertSecurity(sp.Security[0], sp.SecurityDefinitions)
+// apiKeyHeaders maps the apiKey header schemes of sec to the suite var
+// holding each key, named after the scheme.
+func apiKeyHeaders(sec openapi3.SecurityRequirement, schemes openapi3.SecuritySchemes) map[string]string {
+	out := map[string]string{}
+	for _, name := range schemeNames(sec) {
+		if ref := schemes[name]; ref != nil && ref.Value != nil && ref.Value.Type == "apiKey" && ref.Value.In == "header" {
+			out[ref.Value.Name] = keyVar.ReplaceAllString(name, "_")
+		}
 	}
+	return out
+}
 
-	add := func(method, path string, op *operation, pathParams []parameter) {
-		if op == nil {
-			return
-		}
-		status := pickStatus(op)
-		req := models.Request{Method: method, URL: path}
+// keyVar matches what may not appear in a var name.
+var keyVar = regexp.MustCompile(`[^A-Za-z0-9_]`)
 
-		// Combine path and operation parameters
-		allParams := append(pathParams, op.Parameters...)
+// otherSchemes lists the schemes of sec that are not apiKey headers.
+func otherSchemes(sec openapi3.SecurityRequirement, schemes openapi3.SecuritySchemes) []string {
+	var out []string
+	for _, name := range schemeNames(sec) {
+		if ref := schemes[name]; ref != nil && ref.Value != nil && ref.Value.Type == "apiKey" && ref.Value.In == "header" {
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
 
-		// Handle parameters
-		headers := make(map[string]string)
-		query := make(map[string]string)
-		for _, p := range allParams {
-			if p.In == "header" {
-				headers[p.Name] = "example" // TODO: generate from schema
-			} else if p.In == "query" {
-				query[p.Name] = "example"
-			} else if p.In == "path" {
-				// Replace in URL
-				req.URL = strings.Replace(req.URL, "{"+p.Name+"}", "example", -1)
+// sendKeys sets the apiKey headers o's security requires on req, as
+// references to suite vars listed in secrets; the key values are left empty
+// for the user to fill in.
+func (o operation) sendKeys(doc *openapi3.T, s *models.Suite, req *models.Request) {
+	sec := effectiveSecurity(doc, o.op)
+	if len(sec) == 0 {
+		return
+	}
+	for header, v := range apiKeyHeaders(sec[0], securitySchemes(doc)) {
+		if req.Headers == nil {
+			req.Headers = map[string]string{}
+		}
+		for h := range req.Headers {
+			if strings.EqualFold(h, header) {
+				delete(req.Headers, h)
 			}
 		}
-		req.Headers = headers
-		req.Query = query
-
-		// Handle request body
-		if op.RequestBody != nil && len(op.RequestBody.Content) > 0 {
-			for contentType, mt := range op.RequestBody.Content {
-				headers["Content-Type"] = contentType
-				if mt.Schema != nil && mt.Schema.Type == "object" {
-					req.Body = map[string]interface{}{"example": "data"} // TODO: generate from schema
-				}
-				break // Use first content type
+		req.Headers[header] = "${" + v + "}"
+		if _, ok := s.Variables[v]; !ok {
+			if s.Variables == nil {
+				s.Variables = map[string]string{}
 			}
+			s.Variables[v] = ""
+			s.Secrets = append(s.Secrets, v)
 		}
+	}
+}
 
-		tc := models.TestCase{
-			Name:    method + " " + path,
-			Request: req,
-			Assert:  models.Assertions{Status: status},
-			Tags:    op.Tags,
+// pickResponse chooses the response a test expects: 200, else the lowest
+// 2xx, else the lowest documented code; default alone means 200.
+func pickResponse(op *openapi3.Operation) (int, *openapi3.Response) {
+	if op.Responses == nil {
+		return 200, nil
+	}
+	var codes []int
+	for code := range op.Responses.Map() {
+		if n, err := strconv.Atoi(code); err == nil {
+			codes = append(codes, n)
 		}
-
-		// Handle operation security
-		if len(op.Security) > 0 && sp.Components != nil {
-			// TODO: per-test auth
+	}
+	if len(codes) == 0 {
+		if ref := op.Responses.Default(); ref != nil {
+			return 200, ref.Value
 		}
-
-		s.Tests = append(s.Tests, tc)
+		return 200, nil
 	}
-
-	for p, item := range sp.Paths {
-		add("GET", p, item.Get, item.Parameters)
-		add("PUT", p, item.Put, item.Parameters)
-		add("POST", p, item.Post, item.Parameters)
-		add("DELETE", p, item.Delete, item.Parameters)
-		add("OPTIONS", p, item.Options, item.Parameters)
-		add("HEAD", p, item.Head, item.Parameters)
-		add("PATCH", p, item.Patch, item.Parameters)
-		add("TRACE", p, item.Trace, item.Parameters)
-	}
-	return s, nil
-}
-
-func convertSecurity(sec map[string][]string, schemes map[string]securityScheme) *models.Auth {
-	for schemeName := range sec {
-		if scheme, ok := schemes[schemeName]; ok {
-			switch scheme.Type {
-			case "http":
-				if scheme.Scheme == "basic" {
-					return &models.Auth{BasicEnv: "username:password"}
-				} else if scheme.Scheme == "bearer" {
-					return &models.Auth{BearerEnv: "token"}
-				}
-			case "apiKey":
-				if scheme.In == "header" {
-					// TODO: handle api key
-				}
-			}
+	sort.Ints(codes)
+	pick := codes[0]
+	for _, n := range codes {
+		if n == 200 {
+			pick = 200
+			break
+		}
+		if n >= 200 && n < 300 && (pick < 200 || pick >= 300) {
+			pick = n
 		}
 	}
-	return nil
+	if ref := op.Responses.Value(strconv.Itoa(pick)); ref != nil {
+		return pick, ref.Value
+	}
+	return pick, nil
 }
 
-func pickStatus(op *operation) int {
-	if op == nil || len(op.Responses) == 0 {
-		return 200
+// responseChecks builds an assert.js script checking the response's content
+// type and, for JSON, its shape: an array, or an object with the required
+// fields present.
+func responseChecks(resp *openapi3.Response) string {
+	if resp == nil {
+		return ""
 	}
-	if _, ok := op.Responses["200"]; ok {
-		return 200
+	ct, mt := pickContent(resp.Content)
+	if mt == nil || strings.Contains(ct, "*") {
+		return ""
 	}
-	for code := range op.Responses {
-		if code == "default" {
-			continue
+	var lines []string
+	lines = append(lines, adapters.ContentTypeCheck(ct))
+	if !adapters.IsJSON(ct) || mt.Schema == nil || mt.Schema.Value == nil {
+		return strings.Join(lines, "\n")
+	}
+	sch := mt.Schema.Value
+	required := append([]string(nil), sch.Required...)
+	for _, sub := range sch.AllOf {
+		if sub != nil && sub.Value != nil {
+			required = append(required, sub.Value.Required...)
 		}
-		if n, err := strconv.Atoi(code); err == nil {
-			return n
+	}
+	switch {
+	case sch.Type.Is("array"):
+		lines = append(lines, `check("body is an array", Array.isArray(response.json()));`)
+	case len(required) > 0:
+		lines = append(lines, "const body = response.json();")
+		seen := map[string]bool{}
+		for _, f := range required {
+			if seen[f] {
+				continue
+			}
+			seen[f] = true
+			lines = append(lines, fmt.Sprintf("check(%s, body != null && body[%s] !== undefined);", adapters.JSString("body has "+f), adapters.JSString(f)))
 		}
 	}
-	return 200
+	return strings.Join(lines, "\n")
 }
@@ -3,6 +3,9 @@ package oapi
 import (
 	"strings"
 	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
 
 func TestConvert_Basic(t *testing.T) {
@@ -71,6 +74,40 @@ paths:
 	}
 }
 
+func TestConvert_APIKeyHeader(t *testing.T) {
+	y := `openapi: 3.0.3
+info: {title: Keys, version: "1"}
+security: [{api-key: []}]
+components:
+  securitySchemes:
+    api-key: {type: apiKey, in: header, name: X-API-Key}
+paths:
+  /items:
+    get:
+      responses: {"200": {description: ok}}
+  /health:
+    get:
+      security: []
+      responses: {"200": {description: ok}}
+`
+	s, ws, err := ConvertWithReport(strings.NewReader(y), adapters.Options{})
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if s.Auth != nil || len(ws) != 0 {
+		t.Fatalf("auth %+v, warnings %+v", s.Auth, ws)
+	}
+	if s.Variables["api_key"] != "" || len(s.Secrets) != 1 || s.Secrets[0] != "api_key" {
+		t.Fatalf("vars %v, secrets %v", s.Variables, s.Secrets)
+	}
+	for _, tc := range s.Tests {
+		want := map[string]string{"GET /items": "${api_key}", "GET /health": ""}[tc.Name]
+		if got := tc.Request.Headers["X-API-Key"]; got != want {
+			t.Fatalf("%s: X-API-Key = %q, want %q", tc.Name, got, want)
+		}
+	}
+}
+
 func TestConvert_OpenAPIInvalidYAML(t *testing.T) {
 	_, err := Convert(strings.NewReader(`invalid: yaml: content: [`))
 	if err == nil {
@@ -92,3 +129,165 @@ paths: {}
 		t.Fatalf("expected 0 tests for empty API")
 	}
 }
+
+func TestConvert_SchemaExamples(t *testing.T) {
+	y := `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths:
+  /pets/{petId}:
+    parameters:
+      - {name: petId, in: path, required: true, schema: {type: integer}, example: 42}
+    put:
+      parameters:
+        - {name: status, in: query, schema: {type: string, enum: [available, sold]}}
+        - {name: X-Trace, in: header, schema: {type: string, format: uuid}}
+        - name: since
+          in: query
+          examples:
+            b: {value: "2024-02-02"}
+            a: {value: "2024-01-01"}
+      requestBody:
+        content:
+          text/plain: {schema: {type: string}}
+          application/json:
+            schema: {$ref: '#/components/schemas/Pet'}
+      responses:
+        "400": {description: bad}
+        "201": {description: created}
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Pet'}
+  /login:
+    post:
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                user: {type: string, default: ada}
+      responses:
+        "204": {description: no content}
+  /upload:
+    post:
+      requestBody:
+        content:
+          multipart/form-data:
+            schema: {type: object}
+      responses:
+        "201": {description: created}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name]
+      properties:
+        id: {type: integer, readOnly: true}
+        name: {type: string, example: Rex}
+        born: {type: string, format: date}
+        weight: {type: number, minimum: 0.5}
+        tags:
+          type: array
+          items: {$ref: '#/components/schemas/Pet'}
+`
+	s, ws, err := ConvertWithReport(strings.NewReader(y), adapters.Options{})
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	tests := map[string]models.TestCase{}
+	for _, tc := range s.Tests {
+		tests[tc.Name] = tc
+	}
+
+	put := tests["PUT /pets/{petId}"]
+	if put.Request.URL != "/pets/42" {
+		t.Fatalf("url: %s", put.Request.URL)
+	}
+	if put.Request.Query["status"] != "available" || put.Request.Query["since"] != "2024-01-01" {
+		t.Fatalf("query: %v", put.Request.Query)
+	}
+	if put.Request.Headers["X-Trace"] != "3fa85f64-5717-4562-b3fc-2c963f66afa6" || put.Request.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("headers: %v", put.Request.Headers)
+	}
+	body, ok := put.Request.Body.(map[string]any)
+	if !ok {
+		t.Fatalf("body: %#v", put.Request.Body)
+	}
+	if body["name"] != "Rex" || body["born"] != "2024-01-01" || body["weight"] != 0.5 {
+		t.Fatalf("body: %v", body)
+	}
+	if _, ok := body["id"]; ok {
+		t.Fatalf("readOnly id in request body: %v", body)
+	}
+	if tags, ok := body["tags"].([]any); !ok || len(tags) != 0 {
+		t.Fatalf("recursive tags: %#v", body["tags"])
+	}
+	if put.Assert.Status != 200 {
+		t.Fatalf("status: %d", put.Assert.Status)
+	}
+	for _, want := range []string{`indexOf("application/json")`, `body["id"] !== undefined`, `body["name"] !== undefined`} {
+		if !strings.Contains(put.Assert.JS, want) {
+			t.Fatalf("assert.js missing %q:\n%s", want, put.Assert.JS)
+		}
+	}
+
+	login := tests["POST /login"]
+	if login.Request.Body != "user=ada" || login.Request.Headers["Content-Type"] != "application/x-www-form-urlencoded" {
+		t.Fatalf("form body: %#v %v", login.Request.Body, login.Request.Headers)
+	}
+	if login.Assert.Status != 204 || login.Assert.JS != "" {
+		t.Fatalf("login assert: %+v", login.Assert)
+	}
+
+	if up := tests["POST /upload"]; up.Request.Body != nil {
+		t.Fatalf("multipart body: %#v", up.Request.Body)
+	}
+	if len(ws) != 1 || ws[0].Item != "POST /upload" || ws[0].Feature != "body:multipart/form-data" {
+		t.Fatalf("warnings: %+v", ws)
+	}
+}
+
+func TestConvert_Swagger2(t *testing.T) {
+	y := `swagger: "2.0"
+info: {title: Legacy, version: "1"}
+host: legacy.example.com
+basePath: /api/
+schemes: [http]
+paths:
+  /items:
+    post:
+      consumes: [application/json]
+      produces: [application/json]
+      parameters:
+        - {name: body, in: body, schema: {$ref: '#/definitions/Item'}}
+      responses:
+        "201":
+          description: created
+          schema: {$ref: '#/definitions/Item'}
+definitions:
+  Item:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string, default: widget}
+`
+	s, err := Convert(strings.NewReader(y))
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if s.BaseURL != "http://legacy.example.com/api" {
+		t.Fatalf("baseUrl: %s", s.BaseURL)
+	}
+	if len(s.Tests) != 1 {
+		t.Fatalf("tests: %d", len(s.Tests))
+	}
+	tc := s.Tests[0]
+	if b, ok := tc.Request.Body.(map[string]any); !ok || b["name"] != "widget" {
+		t.Fatalf("body: %#v", tc.Request.Body)
+	}
+	if tc.Assert.Status != 201 || !strings.Contains(tc.Assert.JS, `body["name"]`) {
+		t.Fatalf("assert: %+v", tc.Assert)
+	}
+}
@@ -0,0 +1,78 @@
+package adapters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// FolderMode selects how collection folders show up in an imported suite.
+type FolderMode string
+
+const (
+	FoldersPrefix FolderMode = "prefix" // test names carry the folder path (default)
+	FoldersFlat   FolderMode = "flat"   // test names are the request names only
+	FoldersTags   FolderMode = "tags"   // request names, with the folder names as tags
+	FoldersFiles  FolderMode = "files"  // one suite file per folder, see Split
+)
+
+// ParseFolderMode checks a --folders value; empty means FoldersPrefix.
+func ParseFolderMode(s string) (FolderMode, error) {
+	switch m := FolderMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return FoldersPrefix, nil
+	case FoldersPrefix, FoldersFlat, FoldersTags, FoldersFiles:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown folder mode %q (want prefix, flat, tags or files)", s)
+}
+
+// Options controls an import. The zero value converts scripts and auth,
+// prefixes test names with their folder path, keeps the source's base URL
+// and drops static assets from captures.
+type Options struct {
+	NoScripts bool              // leave pre/post request scripts out
+	Folders   FolderMode        // how folders map onto tests
+	SkipAuth  bool              // leave collection, folder and request auth out
+	BaseURL   string            // overrides the suite baseUrl when set
+	Env       map[string]string // merged into the suite variables, taking precedence
+
+	// Capture filters (HAR)
+	IncludeHosts []string // keep only requests to these hosts; *.example.com matches subdomains
+	ExcludeExts  []string // drop requests whose path has one of these extensions; nil means StaticExts
+	OnlyXHR      bool     // keep only XHR/fetch requests
+}
+
+// StaticExts are the file extensions a capture import drops by default.
+var StaticExts = []string{"js", "mjs", "css", "map", "html", "htm", "png", "jpg", "jpeg", "gif", "svg", "ico", "webp", "avif", "woff", "woff2", "ttf", "otf", "eot", "mp4", "webm", "mp3"}
+
+// TestName names a request found under folders. In prefix mode the path is
+// joined with sep, which keeps each tool's own separator.
+func (o Options) TestName(folders []string, name, sep string) string {
+	if o.Folders != "" && o.Folders != FoldersPrefix || len(folders) == 0 {
+		return name
+	}
+	return strings.Join(folders, sep) + sep + name
+}
+
+// FolderTags returns the folder names to tag a request with in tags mode.
+func (o Options) FolderTags(folders []string) []string {
+	if o.Folders != FoldersTags || len(folders) == 0 {
+		return nil
+	}
+	return append([]string(nil), folders...)
+}
+
+// Apply sets the base URL and environment overrides on s.
+func (o Options) Apply(s *models.Suite) {
+	if o.BaseURL != "" {
+		s.BaseURL = o.BaseURL
+	}
+	if len(o.Env) > 0 && s.Variables == nil {
+		s.Variables = make(map[string]string, len(o.Env))
+	}
+	for k, v := range o.Env {
+		s.Variables[k] = v
+	}
+}
@@ -0,0 +1,185 @@
+package pact
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// Exchange is a passed test and the HTTP exchange it verified.
+type Exchange struct {
+	Name    string          // test name as reported by the run
+	Test    models.TestCase // the test as written: assertions and provider state hooks
+	Request models.Request  // request as sent, with an absolute URL
+	Status  int
+	Headers http.Header
+	Body    []byte
+}
+
+// Pact v3 structures written by Export
+type contract struct {
+	Consumer     pacticipant     `json:"consumer"`
+	Provider     pacticipant     `json:"provider"`
+	Interactions []v3Interaction `json:"interactions"`
+	Metadata     map[string]any  `json:"metadata"`
+}
+type v3Interaction struct {
+	Description    string          `json:"description"`
+	ProviderStates []providerState `json:"providerStates,omitempty"`
+	Request        v3Request       `json:"request"`
+	Response       v3Response      `json:"response"`
+}
+type v3Request struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Query   map[string][]string `json:"query,omitempty"`
+	Headers map[string]string   `json:"headers,omitempty"`
+	Body    any                 `json:"body,omitempty"`
+}
+type v3Response struct {
+	Status        int                        `json:"status"`
+	Headers       map[string]string          `json:"headers,omitempty"`
+	Body          any                        `json:"body,omitempty"`
+	MatchingRules map[string]map[string]rule `json:"matchingRules,omitempty"`
+}
+
+// Export writes a Pact (v3) contract between consumer and provider from the
+// exchanges of a passing run of s. Responses are recorded as examples
+// matched by type, except the values the test pinned with jsonEquals, which
+// must stay equal. Authorization headers are left out; provider states come
+// from hooks shaped like the ones ConvertWithReport generates.
+func Export(w io.Writer, s *models.Suite, consumer, provider string, exchanges []Exchange) error {
+	c := contract{
+		Consumer:     pacticipant{Name: consumer},
+		Provider:     pacticipant{Name: provider},
+		Interactions: []v3Interaction{},
+		Metadata:     map[string]any{"pactSpecification": map[string]string{"version": "3.0.0"}},
+	}
+	suiteStates := hookStates(s.PreSuite)
+	for _, ex := range exchanges {
+		in := v3Interaction{
+			Description:    ex.Name,
+			ProviderStates: append(append([]providerState(nil), suiteStates...), hookStates(ex.Test.Pre)...),
+			Request:        exportRequest(ex.Request),
+			Response:       v3Response{Status: ex.Status},
+		}
+		keep := map[string]bool{"content-type": true}
+		for k := range ex.Test.Assert.HeaderEquals {
+			keep[strings.ToLower(k)] = true
+		}
+		for k := range ex.Headers {
+			if keep[strings.ToLower(k)] {
+				if in.Response.Headers == nil {
+					in.Response.Headers = map[string]string{}
+				}
+				in.Response.Headers[k] = strings.Join(ex.Headers.Values(k), ", ")
+			}
+		}
+		if len(ex.Body) > 0 {
+			var v any
+			if adapters.IsJSON(ex.Headers.Get("Content-Type")) && json.Unmarshal(ex.Body, &v) == nil {
+				in.Response.Body = v
+				rules := map[string]rule{"$": {Matchers: []map[string]any{{"match": "type"}}}}
+				for p := range ex.Test.Assert.JSONEquals {
+					if jp, ok := jsonPath(p); ok {
+						rules[jp] = rule{Matchers: []map[string]any{{"match": "equality"}}}
+					}
+				}
+				in.Response.MatchingRules = map[string]map[string]rule{"body": rules}
+			} else {
+				in.Response.Body = string(ex.Body)
+			}
+		}
+		c.Interactions = append(c.Interactions, in)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+func exportRequest(sent models.Request) v3Request {
+	req := v3Request{Method: sent.Method, Path: "/"}
+	query := url.Values{}
+	if u, err := url.Parse(sent.URL); err == nil {
+		if u.Path != "" {
+			req.Path = u.Path
+		}
+		query = u.Query()
+	}
+	for k, v := range sent.Query {
+		query.Add(k, v)
+	}
+	if len(query) > 0 {
+		req.Query = query
+	}
+	for k, v := range sent.Headers {
+		if strings.EqualFold(k, "Authorization") {
+			continue
+		}
+		if req.Headers == nil {
+			req.Headers = map[string]string{}
+		}
+		req.Headers[k] = v
+	}
+	req.Body = sent.Body
+	if str, ok := sent.Body.(string); ok && adapters.IsJSON(headerValue(sent.Headers, "Content-Type")) {
+		var v any
+		if json.Unmarshal([]byte(str), &v) == nil {
+			req.Body = v
+		}
+	}
+	return req
+}
+
+// hookStates reads provider states back from state change hooks.
+func hookStates(hooks []models.Hook) []providerState {
+	var out []providerState
+	for _, h := range hooks {
+		if h.Request == nil {
+			continue
+		}
+		body, ok := h.Request.Body.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := body["state"].(string)
+		if action, _ := body["action"].(string); name == "" || action != "" && action != "setup" {
+			continue
+		}
+		st := providerState{Name: name}
+		st.Params, _ = body["params"].(map[string]any)
+		out = append(out, st)
+	}
+	return out
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// jsonPath converts a plain gjson path (a.0.b) to a JSONPath ($.a[0].b).
+// Paths using gjson queries, wildcards or modifiers are not converted.
+func jsonPath(p string) (string, bool) {
+	if p == "" || strings.ContainsAny(p, `#*?|@!{}[]'\`) {
+		return "", false
+	}
+	var b strings.Builder
+	b.WriteString("$")
+	for _, seg := range strings.Split(p, ".") {
+		switch {
+		case seg == "":
+			return "", false
+		case strings.Trim(seg, "0123456789") == "":
+			b.WriteString("[" + seg + "]")
+		case plainKey.MatchString(seg):
+			b.WriteString("." + seg)
+		default:
+			b.WriteString("['" + seg + "']")
+		}
+	}
+	return b.String(), true
+}
@@ -0,0 +1,470 @@
+// Package pact converts Pact contracts into suites that verify the provider,
+// and records passing suites as Pact contracts.
+package pact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// StatesURL is the variable holding the provider state change endpoint that
+// the generated hooks call before an interaction.
+const StatesURL = "providerStatesUrl"
+
+// defaultBaseURL lets CI point the suite at the provider under test.
+const defaultBaseURL = "${ENV:PROVIDER_BASE_URL}"
+
+// checked are the matchers pact.match evaluates; the others are reported.
+var checked = map[string]bool{
+	"type": true, "regex": true, "integer": true, "decimal": true, "number": true, "boolean": true,
+	"null": true, "equality": true, "include": true, "notEmpty": true,
+	"date": true, "time": true, "datetime": true, "timestamp": true,
+}
+
+// Pact file structures (specification v2, v3 and v4, HTTP interactions only)
+type pactFile struct {
+	Consumer     pacticipant       `json:"consumer"`
+	Provider     pacticipant       `json:"provider"`
+	Interactions []interaction     `json:"interactions"`
+	Messages     []json.RawMessage `json:"messages"`
+}
+type pacticipant struct {
+	Name string `json:"name"`
+}
+type interaction struct {
+	Type           string          `json:"type"` // v4 only, e.g. Synchronous/HTTP
+	Description    string          `json:"description"`
+	ProviderState  string          `json:"providerState"`  // v2
+	ProviderStates []providerState `json:"providerStates"` // v3+
+	Request        pactRequest     `json:"request"`
+	Response       pactResponse    `json:"response"`
+}
+type providerState struct {
+	Name   string         `json:"name"`
+	Params map[string]any `json:"params,omitempty"`
+}
+type pactRequest struct {
+	Method  string                     `json:"method"`
+	Path    string                     `json:"path"`
+	Query   json.RawMessage            `json:"query"` // v2 string, v3+ name -> values
+	Headers map[string]json.RawMessage `json:"headers"`
+	Body    json.RawMessage            `json:"body"`
+}
+type pactResponse struct {
+	Status        int                        `json:"status"`
+	Headers       map[string]json.RawMessage `json:"headers"`
+	Body          json.RawMessage            `json:"body"`
+	MatchingRules map[string]json.RawMessage `json:"matchingRules"`
+}
+
+// v4Body is how v4 wraps request and response bodies.
+type v4Body struct {
+	Content     json.RawMessage `json:"content"`
+	ContentType string          `json:"contentType"`
+	Encoded     any             `json:"encoded"` // false, "base64" or "json"
+}
+
+// rule is a matching rule in the v3 shape; v2 rules are converted to it.
+type rule struct {
+	Matchers []map[string]any `json:"matchers"`
+	Combine  string           `json:"combine,omitempty"`
+}
+
+// Convert reads a Pact file and produces a suite verifying the provider.
+func Convert(r io.Reader) (*models.Suite, error) {
+	s, _, err := ConvertWithReport(r, adapters.Options{})
+	return s, err
+}
+
+// ConvertWithReport is Convert that also returns what could not be imported
+// as-is. Each HTTP interaction becomes a test; its provider states become
+// hooks posting {state, params, action: "setup"} to ${providerStatesUrl},
+// in preSuite for states every interaction shares and in the test's pre
+// hooks otherwise. Response matching rules (type, regex, eachLike via
+// type+min, integer, ...) are checked by pact.match in assert.js.
+func ConvertWithReport(r io.Reader, opts adapters.Options) (*models.Suite, []adapters.Warning, error) {
+	var p pactFile
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, nil, err
+	}
+	if p.Interactions == nil && p.Messages == nil {
+		return nil, nil, fmt.Errorf("not a pact file: no interactions")
+	}
+	var ws adapters.Warnings
+	if len(p.Messages) > 0 {
+		ws.Addf("", "messages", "skipped", "%d message interaction(s)", len(p.Messages))
+	}
+	s := &models.Suite{
+		Name:      p.Consumer.Name + " -> " + p.Provider.Name,
+		BaseURL:   defaultBaseURL,
+		Variables: map[string]string{StatesURL: "/_pact/provider-states"},
+	}
+
+	var inters []interaction
+	for _, in := range p.Interactions {
+		if in.Type != "" && in.Type != "Synchronous/HTTP" {
+			ws.Addf(in.Description, "interaction:"+in.Type, "skipped", "only HTTP interactions are verified")
+			continue
+		}
+		inters = append(inters, in)
+	}
+	shared := sharedStates(inters)
+	for _, st := range shared {
+		s.PreSuite = append(s.PreSuite, stateHook(p.Consumer.Name, st))
+	}
+
+	names := map[string]int{}
+	for _, in := range inters {
+		name := in.Description
+		states := in.states()
+		if names[name] > 0 && len(states) > 0 {
+			name += " (given " + stateNames(states) + ")"
+		}
+		if n := names[name]; n > 0 {
+			names[name]++
+			name = fmt.Sprintf("%s #%d", name, n+1)
+		} else {
+			names[name] = 1
+		}
+		tc := models.TestCase{Name: name, Request: request(in, opts, name, &ws), Assert: assertions(in, name, &ws)}
+		for _, st := range states {
+			if !containsState(shared, st) {
+				tc.Pre = append(tc.Pre, stateHook(p.Consumer.Name, st))
+			}
+		}
+		s.Tests = append(s.Tests, tc)
+	}
+	opts.Apply(s)
+	return s, ws, nil
+}
+
+// states returns the provider states of an interaction in either spec version.
+func (in interaction) states() []providerState {
+	if len(in.ProviderStates) > 0 {
+		return in.ProviderStates
+	}
+	if in.ProviderState != "" {
+		return []providerState{{Name: in.ProviderState}}
+	}
+	return nil
+}
+
+// sharedStates returns the states every interaction is given, in order.
+func sharedStates(inters []interaction) []providerState {
+	if len(inters) == 0 {
+		return nil
+	}
+	var out []providerState
+	for _, st := range inters[0].states() {
+		all := true
+		for _, in := range inters[1:] {
+			if !containsState(in.states(), st) {
+				all = false
+				break
+			}
+		}
+		if all {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+func containsState(list []providerState, st providerState) bool {
+	key := stateKey(st)
+	for _, s := range list {
+		if stateKey(s) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func stateKey(st providerState) string {
+	b, _ := json.Marshal(st)
+	return string(b)
+}
+
+func stateNames(states []providerState) string {
+	names := make([]string, 0, len(states))
+	for _, st := range states {
+		names = append(names, st.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// stateHook sets up a provider state the way Pact verifiers do.
+func stateHook(consumer string, st providerState) models.Hook {
+	body := map[string]any{"consumer": consumer, "state": st.Name, "action": "setup"}
+	if len(st.Params) > 0 {
+		body["params"] = st.Params
+	}
+	return models.Hook{
+		Name: "given " + st.Name,
+		Request: &models.Request{
+			Method:  "POST",
+			URL:     "${" + StatesURL + "}",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    body,
+		},
+		Assert: models.Assertions{JS: `check("provider state set up", response.status < 300, "status " + response.status);`},
+	}
+}
+
+func request(in interaction, opts adapters.Options, item string, ws *adapters.Warnings) models.Request {
+	req := models.Request{Method: strings.ToUpper(in.Request.Method), URL: in.Request.Path}
+	if req.Method == "" {
+		req.Method = "GET"
+	}
+	if req.URL == "" {
+		req.URL = "/"
+	}
+	query := queryValues(in.Request.Query)
+	if len(query) > 0 {
+		multi := false
+		for _, vs := range query {
+			multi = multi || len(vs) > 1
+		}
+		if multi {
+			// Repeated parameters only survive in the URL
+			req.URL += "?" + query.Encode()
+		} else {
+			req.Query = make(map[string]string, len(query))
+			for k, vs := range query {
+				req.Query[k] = vs[0]
+			}
+		}
+	}
+	headers := headerValues(in.Request.Headers)
+	for k, v := range headers {
+		if opts.SkipAuth && strings.EqualFold(k, "Authorization") {
+			continue
+		}
+		if req.Headers == nil {
+			req.Headers = map[string]string{}
+		}
+		req.Headers[k] = v
+	}
+	body, ct, ok := decodeBody(in.Request.Body, in.Type != "", headerValue(headers, "Content-Type"))
+	if !ok {
+		ws.Add(item, "body:encoded", "skipped")
+	}
+	if ct != "" && headerValue(req.Headers, "Content-Type") == "" {
+		if req.Headers == nil {
+			req.Headers = map[string]string{}
+		}
+		req.Headers["Content-Type"] = ct
+	}
+	req.Body = body
+	return req
+}
+
+func assertions(in interaction, item string, ws *adapters.Warnings) models.Assertions {
+	a := models.Assertions{Status: in.Response.Status}
+	bodyRules, headerRules := matchingRules(in.Response.MatchingRules, item, ws)
+	headers := headerValues(in.Response.Headers)
+	var lines []string
+	for _, name := range sortedKeys(headers) {
+		v := headers[name]
+		switch r, ok := headerRules[strings.ToLower(name)]; {
+		case ok:
+			lines = append(lines,
+				fmt.Sprintf("var m = pact.match(response.header(%s), %s, %s);", adapters.JSString(name), adapters.JSString(v), jsJSON(map[string]rule{"$": r})),
+				fmt.Sprintf("check(%s, m.length === 0, m.join(\"; \"));", adapters.JSString("header "+name+" matches the pact")))
+		case strings.EqualFold(name, "Content-Type"):
+			mt := strings.TrimSpace(strings.SplitN(v, ";", 2)[0])
+			lines = append(lines, adapters.ContentTypeCheck(mt))
+		default:
+			if a.HeaderEquals == nil {
+				a.HeaderEquals = map[string]string{}
+			}
+			a.HeaderEquals[name] = v
+		}
+	}
+
+	body, ct, ok := decodeBody(in.Response.Body, in.Type != "", headerValue(headers, "Content-Type"))
+	if !ok {
+		ws.Add(item, "body:encoded", "not checked")
+	}
+	if ct == "" {
+		ct = headerValue(headers, "Content-Type")
+	}
+	switch {
+	case body == nil:
+	case adapters.IsJSON(ct) || ct == "" && isStructured(body):
+		lines = append(lines,
+			fmt.Sprintf("var m = pact.match(response.json(), %s, %s);", jsJSON(body), jsJSON(bodyRules)),
+			`check("body matches the pact", m.length === 0, m.join("; "));`)
+	case len(bodyRules) > 0:
+		lines = append(lines,
+			fmt.Sprintf("var m = pact.match(response.text(), %s, %s);", jsJSON(body), jsJSON(bodyRules)),
+			`check("body matches the pact", m.length === 0, m.join("; "));`)
+	default:
+		lines = append(lines, fmt.Sprintf("check(\"body matches the pact\", response.text() === %s);", adapters.JSString(fmt.Sprint(body))))
+	}
+	a.JS = strings.Join(lines, "\n")
+	return a
+}
+
+// matchingRules splits response rules into body rules (paths rooted at $)
+// and header rules (by lower-case name), accepting v2 and v3+ layouts.
+func matchingRules(raw map[string]json.RawMessage, item string, ws *adapters.Warnings) (map[string]rule, map[string]rule) {
+	body, headers := map[string]rule{}, map[string]rule{}
+	add := func(into map[string]rule, key string, r rule) {
+		for _, m := range r.Matchers {
+			name, _ := m["match"].(string)
+			if name == "" {
+				continue // v2 min/max without match means type
+			}
+			if !checked[name] {
+				ws.Addf(item, "matcher:"+name, "not checked", "%s", key)
+			}
+		}
+		into[key] = r
+	}
+	for _, key := range sortedKeys(raw) {
+		switch {
+		case strings.HasPrefix(key, "$."): // v2: one flat map of JSONPaths
+			var m map[string]any
+			if err := json.Unmarshal(raw[key], &m); err != nil {
+				continue
+			}
+			r := rule{Matchers: []map[string]any{m}}
+			switch {
+			case key == "$.body":
+				add(body, "$", r)
+			case strings.HasPrefix(key, "$.body"):
+				add(body, "$"+strings.TrimPrefix(key, "$.body"), r)
+			case strings.HasPrefix(key, "$.headers."):
+				add(headers, strings.ToLower(strings.TrimPrefix(key, "$.headers.")), r)
+			default:
+				ws.Addf(item, "matchingRules", "skipped", "%s", key)
+			}
+		case key == "body" || key == "header":
+			var rules map[string]rule
+			if err := json.Unmarshal(raw[key], &rules); err != nil {
+				ws.Addf(item, "matchingRules", "skipped", "%s: %v", key, err)
+				continue
+			}
+			for _, p := range sortedKeys(rules) {
+				if key == "body" {
+					add(body, p, rules[p])
+				} else {
+					add(headers, strings.ToLower(p), rules[p])
+				}
+			}
+		default:
+			ws.Addf(item, "matchingRules", "skipped", "%s", key)
+		}
+	}
+	return body, headers
+}
+
+// decodeBody returns a body and, for v4, its declared content type. ok is
+// false for base64-encoded v4 bodies, which are dropped.
+func decodeBody(raw json.RawMessage, v4 bool, contentType string) (body any, ct string, ok bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, "", true
+	}
+	if v4 {
+		var b v4Body
+		if err := json.Unmarshal(raw, &b); err == nil && b.Content != nil {
+			if enc := strings.ToLower(fmt.Sprint(b.Encoded)); enc == "base64" || enc == "true" {
+				return nil, b.ContentType, false
+			}
+			raw, ct, contentType = b.Content, b.ContentType, b.ContentType
+		}
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, ct, true
+	}
+	// A JSON body given as a string (as v4 does) is parsed.
+	if str, isStr := v.(string); isStr && adapters.IsJSON(contentType) {
+		var parsed any
+		if json.Unmarshal([]byte(str), &parsed) == nil {
+			v = parsed
+		}
+	}
+	return v, ct, true
+}
+
+func queryValues(raw json.RawMessage) url.Values {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		q, _ := url.ParseQuery(s)
+		return q
+	}
+	var m map[string]json.RawMessage
+	if json.Unmarshal(raw, &m) != nil {
+		return nil
+	}
+	q := url.Values{}
+	for k, v := range m {
+		var list []string
+		if json.Unmarshal(v, &list) == nil {
+			q[k] = list
+		} else if json.Unmarshal(v, &s) == nil {
+			q[k] = []string{s}
+		}
+	}
+	return q
+}
+
+// headerValues flattens headers given as strings or, in v4, lists.
+func headerValues(raw map[string]json.RawMessage) map[string]string {
+	out := make(map[string]string, len(raw))
+	for k, v := range raw {
+		var s string
+		var list []string
+		switch {
+		case json.Unmarshal(v, &s) == nil:
+			out[k] = s
+		case json.Unmarshal(v, &list) == nil:
+			out[k] = strings.Join(list, ", ")
+		}
+	}
+	return out
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+func isStructured(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any:
+		return true
+	}
+	return false
+}
+
+// jsJSON renders v as a JavaScript literal.
+func jsJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,199 @@
+package pact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+const v3Pact = `{
+  "consumer": {"name": "web"},
+  "provider": {"name": "users-api"},
+  "interactions": [
+    {
+      "description": "get user",
+      "providerStates": [{"name": "users exist"}, {"name": "user 1 exists", "params": {"id": 1}}],
+      "request": {"method": "GET", "path": "/users/1", "query": {"fields": ["name"], "tag": ["a", "b"]}, "headers": {"Accept": "application/json", "Authorization": "Bearer x"}},
+      "response": {
+        "status": 200,
+        "headers": {"Content-Type": "application/json; charset=utf-8", "X-Request-Id": "abc-1"},
+        "body": {"id": 1, "name": "Ann", "roles": [{"name": "admin"}]},
+        "matchingRules": {
+          "body": {
+            "$.id": {"matchers": [{"match": "integer"}]},
+            "$.roles": {"matchers": [{"match": "type", "min": 1}]},
+            "$.roles[*].name": {"matchers": [{"match": "regex", "regex": "admin|user"}]},
+            "$.name": {"matchers": [{"match": "semver"}]}
+          },
+          "header": {"X-Request-Id": {"matchers": [{"match": "regex", "regex": "[a-z]+-\\d+"}]}}
+        }
+      }
+    },
+    {
+      "description": "list users",
+      "providerStates": [{"name": "users exist"}],
+      "request": {"method": "GET", "path": "/users", "query": {"page": ["2"]}},
+      "response": {"status": 200, "body": [{"id": 1}]}
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "3.0.0"}}
+}`
+
+func TestConvert_V3(t *testing.T) {
+	s, ws, err := ConvertWithReport(strings.NewReader(v3Pact), adapters.Options{SkipAuth: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "web -> users-api" || s.BaseURL != defaultBaseURL || s.Variables[StatesURL] == "" {
+		t.Fatalf("suite = %+v", s)
+	}
+	if len(s.PreSuite) != 1 || s.PreSuite[0].Name != "given users exist" {
+		t.Fatalf("preSuite = %+v", s.PreSuite)
+	}
+	if len(s.Tests) != 2 {
+		t.Fatalf("tests = %d", len(s.Tests))
+	}
+
+	get := s.Tests[0]
+	if len(get.Pre) != 1 || get.Pre[0].Request.URL != "${providerStatesUrl}" {
+		t.Fatalf("pre = %+v", get.Pre)
+	}
+	body := get.Pre[0].Request.Body.(map[string]any)
+	if body["state"] != "user 1 exists" || body["action"] != "setup" || body["params"].(map[string]any)["id"] != float64(1) {
+		t.Fatalf("state body = %+v", body)
+	}
+	if get.Request.URL != "/users/1?fields=name&tag=a&tag=b" || get.Request.Headers["Authorization"] != "" {
+		t.Fatalf("request = %+v", get.Request)
+	}
+	if get.Assert.Status != 200 || len(get.Assert.HeaderEquals) != 0 {
+		t.Fatalf("assert = %+v", get.Assert)
+	}
+	for _, want := range []string{
+		`pact.match(response.header("X-Request-Id"), "abc-1", {"$":{"matchers":[{"match":"regex","regex":"[a-z]+-\\d+"}]}})`,
+		`check("content-type is application/json", (response.header("Content-Type") || "").indexOf("application/json") >= 0);`,
+		`"$.roles":{"matchers":[{"match":"type","min":1}]}`,
+		`check("body matches the pact", m.length === 0, m.join("; "));`,
+	} {
+		if !strings.Contains(get.Assert.JS, want) {
+			t.Errorf("assert.js lacks %s:\n%s", want, get.Assert.JS)
+		}
+	}
+	if len(ws) != 1 || ws[0].Feature != "matcher:semver" {
+		t.Fatalf("warnings = %+v", ws)
+	}
+
+	list := s.Tests[1]
+	if len(list.Pre) != 0 || list.Request.Query["page"] != "2" || !strings.Contains(list.Assert.JS, `pact.match(response.json(), [{"id":1}], {})`) {
+		t.Fatalf("list users = %+v", list)
+	}
+}
+
+func TestConvert_V2(t *testing.T) {
+	pact := `{
+      "consumer": {"name": "web"}, "provider": {"name": "api"},
+      "interactions": [{
+        "description": "search", "providerState": "items exist",
+        "request": {"method": "get", "path": "/items", "query": "q=box&limit=5"},
+        "response": {"status": 200, "headers": {"Content-Type": "application/json"}, "body": {"items": [{"id": 1}]},
+          "matchingRules": {"$.body.items": {"min": 1, "match": "type"}, "$.headers.Content-Type": {"match": "regex", "regex": "application/json.*"}}}
+      }]
+    }`
+	s, ws, err := ConvertWithReport(strings.NewReader(pact), adapters.Options{})
+	if err != nil || len(ws) != 0 {
+		t.Fatalf("err = %v, warnings = %+v", err, ws)
+	}
+	tc := s.Tests[0]
+	if tc.Request.Method != "GET" || tc.Request.Query["q"] != "box" || tc.Request.Query["limit"] != "5" {
+		t.Fatalf("request = %+v", tc.Request)
+	}
+	if len(s.PreSuite) != 1 || s.PreSuite[0].Name != "given items exist" {
+		t.Fatalf("preSuite = %+v", s.PreSuite)
+	}
+	if !strings.Contains(tc.Assert.JS, `{"$.items":{"matchers":[{"match":"type","min":1}]}}`) || !strings.Contains(tc.Assert.JS, `pact.match(response.header("Content-Type")`) {
+		t.Fatalf("assert.js:\n%s", tc.Assert.JS)
+	}
+}
+
+func TestConvert_V4(t *testing.T) {
+	pact := `{
+      "consumer": {"name": "web"}, "provider": {"name": "api"},
+      "interactions": [
+        {"type": "Synchronous/HTTP", "description": "create",
+         "request": {"method": "POST", "path": "/items", "headers": {"Content-Type": ["application/json"]}, "body": {"content": {"name": "box"}, "contentType": "application/json", "encoded": false}},
+         "response": {"status": 201, "body": {"content": "created", "contentType": "text/plain", "encoded": false}}},
+        {"type": "Asynchronous/Messages", "description": "item created event"}
+      ],
+      "metadata": {"pactSpecification": {"version": "4.0"}}
+    }`
+	s, ws, err := ConvertWithReport(strings.NewReader(pact), adapters.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tests) != 1 || len(ws) != 1 || ws[0].Feature != "interaction:Asynchronous/Messages" {
+		t.Fatalf("tests = %+v, warnings = %+v", s.Tests, ws)
+	}
+	tc := s.Tests[0]
+	if b, ok := tc.Request.Body.(map[string]any); !ok || b["name"] != "box" || tc.Request.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("request = %+v", tc.Request)
+	}
+	if tc.Assert.JS != `check("body matches the pact", response.text() === "created");` {
+		t.Fatalf("assert.js = %s", tc.Assert.JS)
+	}
+}
+
+func TestConvert_NotPact(t *testing.T) {
+	if _, err := Convert(strings.NewReader(`{"info": {}}`)); err == nil {
+		t.Fatal("expected an error for a file without interactions")
+	}
+}
+
+func TestExport(t *testing.T) {
+	s := &models.Suite{PreSuite: []models.Hook{{Name: "given users exist", Request: &models.Request{Method: "POST", URL: "/_state", Body: map[string]any{"state": "users exist", "action": "setup"}}}}}
+	ex := Exchange{
+		Name:    "get user",
+		Test:    models.TestCase{Name: "get user", Assert: models.Assertions{JSONEquals: map[string]any{"name": "Ann", "roles.0.name": "admin", "items.#": 2}}},
+		Request: models.Request{Method: "GET", URL: "http://localhost:8080/api/users/1?fields=name", Headers: map[string]string{"Authorization": "Bearer x", "Accept": "application/json"}, Query: map[string]string{"v": "2"}},
+		Status:  200,
+		Headers: http.Header{"Content-Type": {"application/json"}, "Date": {"today"}},
+		Body:    []byte(`{"id":1,"name":"Ann","roles":[{"name":"admin"}]}`),
+	}
+	var buf bytes.Buffer
+	if err := Export(&buf, s, "web", "users-api", []Exchange{ex}); err != nil {
+		t.Fatal(err)
+	}
+	var c contract
+	if err := json.Unmarshal(buf.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Consumer.Name != "web" || c.Provider.Name != "users-api" || len(c.Interactions) != 1 {
+		t.Fatalf("contract = %+v", c)
+	}
+	in := c.Interactions[0]
+	if len(in.ProviderStates) != 1 || in.ProviderStates[0].Name != "users exist" {
+		t.Fatalf("states = %+v", in.ProviderStates)
+	}
+	if in.Request.Path != "/api/users/1" || in.Request.Query["fields"][0] != "name" || in.Request.Query["v"][0] != "2" || in.Request.Headers["Authorization"] != "" {
+		t.Fatalf("request = %+v", in.Request)
+	}
+	if len(in.Response.Headers) != 1 || in.Response.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("response headers = %+v", in.Response.Headers)
+	}
+	rules := in.Response.MatchingRules["body"]
+	if len(rules) != 3 || rules["$"].Matchers[0]["match"] != "type" || rules["$.name"].Matchers[0]["match"] != "equality" || rules["$.roles[0].name"].Matchers[0]["match"] != "equality" {
+		t.Fatalf("rules = %+v", rules)
+	}
+
+	// The exported contract imports back into an equivalent verification suite.
+	back, _, err := ConvertWithReport(&buf, adapters.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.PreSuite) != 1 || len(back.Tests) != 1 || back.Tests[0].Request.URL != "/api/users/1" {
+		t.Fatalf("round trip = %+v", back)
+	}
+}
@@ -0,0 +1,58 @@
+package postman
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+)
+
+const schemaV21 = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// Export writes ex as a Postman v2.1 collection, one request per test.
+// Placeholders become {{name}} references (${ENV:NAME} as {{NAME}}, for a
+// Postman environment to fill) and ex.Vars the collection variables.
+func Export(w io.Writer, ex adapters.Export) error {
+	c := Collection{Item: []Item{}}
+	c.Info.Name = ex.Name
+	c.Info.Schema = schemaV21
+	names := make([]string, 0, len(ex.Vars))
+	for k := range ex.Vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		c.Variable = append(c.Variable, Variable{Key: k, Value: vars(ex.Vars[k])})
+	}
+	for _, r := range ex.Requests {
+		req := &Request{Method: r.Request.Method, URL: vars(r.URL())}
+		for _, k := range r.HeaderNames() {
+			req.Header = append(req.Header, Header{Key: k, Value: vars(r.Request.Headers[k])})
+		}
+		body, err := r.Body()
+		if err != nil {
+			return err
+		}
+		if body != "" {
+			req.Body = &Body{Mode: "raw", Raw: vars(body)}
+			if json.Valid([]byte(body)) {
+				req.Body.Options = &BodyOpts{}
+				req.Body.Options.Raw.Language = "json"
+			}
+		}
+		c.Item = append(c.Item, Item{Name: r.Name, Request: req})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(c)
+}
+
+// vars turns HydReq placeholders into Postman ones.
+func vars(s string) string {
+	return adapters.ReplaceVars(s, func(name string) string {
+		return "{{" + strings.TrimPrefix(name, "ENV:") + "}}"
+	})
+}
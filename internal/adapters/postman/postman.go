@@ -6,6 +6,7 @@ import (
 	"io"
 	"strings"
 
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 	"github.com/DrWeltschmerz/HydReq/internal/script"
 	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
@@ -13,7 +14,8 @@ import (
 // Postman v2.1 structures
 type Collection struct {
 	Info struct {
-		Name string `json:"name"`
+		Name   string `json:"name"`
+		Schema string `json:"schema,omitempty"`
 	} `json:"info"`
 	Item     []Item     `json:"item"`
 	Variable []Variable `json:"variable,omitempty"`
@@ -40,9 +42,10 @@ type Request struct {
 }
 
 type Header struct {
-	Key     string `json:"key"`
-	Value   string `json:"value"`
-	Enabled *bool  `json:"enabled,omitempty"` // defaults to true
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Enabled  *bool  `json:"enabled,omitempty"` // defaults to true
+	Disabled bool   `json:"disabled,omitempty"`
 }
 
 type Auth struct {
@@ -75,14 +78,22 @@ type Body struct {
 	Urlencoded []FormParam `json:"urlencoded,omitempty"`
 	File       *FileSrc    `json:"file,omitempty"`
 	Graphql    *Graphql    `json:"graphql,omitempty"`
+	Options    *BodyOpts   `json:"options,omitempty"`
+}
+
+type BodyOpts struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
 }
 
 type FormParam struct {
-	Key     string   `json:"key"`
-	Value   string   `json:"value"`
-	Type    string   `json:"type,omitempty"` // text or file
-	Src     []string `json:"src,omitempty"`  // for file type
-	Enabled *bool    `json:"enabled,omitempty"`
+	Key      string   `json:"key"`
+	Value    string   `json:"value"`
+	Type     string   `json:"type,omitempty"` // text or file
+	Src      []string `json:"src,omitempty"`  // for file type
+	Enabled  *bool    `json:"enabled,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
 }
 
 type FileSrc struct {
@@ -95,10 +106,11 @@ type Graphql struct {
 }
 
 type Variable struct {
-	Key     string `json:"key"`
-	Value   string `json:"value"`
-	Type    string `json:"type,omitempty"`
-	Enabled *bool  `json:"enabled,omitempty"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Type     string `json:"type,omitempty"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
 }
 
 type UrlObject struct {
@@ -111,23 +123,64 @@ type UrlObject struct {
 }
 
 type QueryParam struct {
-	Key     string `json:"key"`
-	Value   string `json:"value"`
-	Enabled *bool  `json:"enabled,omitempty"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
 }
 
 // Convert reads a Postman v2.1 collection and produces a basic Suite with one test per request.
 // envVars are merged with collection variables, with environment variables taking precedence.
 func Convert(r io.Reader, envVars map[string]string) (*models.Suite, error) {
+	s, _, err := ConvertWithReport(r, adapters.Options{Env: envVars})
+	return s, err
+}
+
+// ConvertWithReport is Convert that also returns warnings for everything it
+// dropped or changed: unsupported auth and bodies, disabled entries, folder
+// settings and untranslatable script statements. opts.Env takes the place of
+// Convert's envVars.
+// In files mode the folder settings are left out as in prefix mode; use
+// ConvertFiles to get one suite per folder.
+func ConvertWithReport(r io.Reader, opts adapters.Options) (*models.Suite, []adapters.Warning, error) {
+	if opts.Folders == adapters.FoldersFiles {
+		opts.Folders = adapters.FoldersPrefix
+	}
+	s, _, ws, err := convert(r, opts)
+	return s, ws, err
+}
+
+// ConvertFiles converts a collection into one suite per folder (see
+// adapters.Split). Folder auth, variables and scripts become the suite auth,
+// vars and preSuite/postSuite hooks of the folder's file.
+func ConvertFiles(r io.Reader, opts adapters.Options) ([]adapters.File, []adapters.Warning, error) {
+	opts.Folders = adapters.FoldersFiles
+	s, root, ws, err := convert(r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := adapters.Split(s, root)
+	for _, f := range files {
+		opts.Apply(f.Suite)
+	}
+	return files, ws, nil
+}
+
+// convert does the work for both entry points. In files mode the tests are
+// collected in the returned folder tree instead of the suite.
+func convert(r io.Reader, opts adapters.Options) (*models.Suite, *adapters.Folder, adapters.Warnings, error) {
 	var c Collection
 	if err := json.NewDecoder(r).Decode(&c); err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
+	var ws adapters.Warnings
 	suite := &models.Suite{Name: c.Info.Name}
+	files := opts.Folders == adapters.FoldersFiles
+	root := &adapters.Folder{}
 
 	// Handle collection-level auth
-	if c.Auth != nil {
-		suite.Auth = convertAuth(c.Auth)
+	if c.Auth != nil && !opts.SkipAuth {
+		suite.Auth = convertAuth(c.Auth, "collection", &ws)
 	}
 
 	// Handle collection variables (merge with environment variables)
@@ -136,31 +189,47 @@ func Convert(r io.Reader, envVars map[string]string) (*models.Suite, error) {
 	// First add collection variables
 	if len(c.Variable) > 0 {
 		for _, v := range c.Variable {
-			if enabled := v.Enabled; enabled == nil || *enabled {
+			if enabled(v.Enabled, v.Disabled) {
 				suite.Variables[v.Key] = v.Value
+			} else {
+				ws.Disabled("collection", "variable", v.Key)
 			}
 		}
 	}
 
-	// Then add/override with environment variables
-	for k, v := range envVars {
-		suite.Variables[k] = v
-	}
-
-	// Handle collection scripts (pre-suite)
-	if len(c.Event) > 0 {
-		suite.PreSuite = convertEventsToHooks(c.Event, "prerequest")
-		suite.PostSuite = convertEventsToHooks(c.Event, "test")
+	// Handle collection scripts (pre-suite). Suite hooks see no response, so
+	// both are translated as plain hooks.
+	if len(c.Event) > 0 && !opts.NoScripts {
+		pre := convertEvents(c.Event, "prerequest", script.PreRequest)
+		post := convertEvents(c.Event, "test", script.PreRequest)
+		suite.PreSuite, suite.PostSuite = pre.Hooks, post.Hooks
+		ws.Script(pre.IssuesFor("collection", "prerequest"))
+		ws.Script(post.IssuesFor("collection", "test"))
 	}
 
 	// Process items recursively
-	var walk func(items []Item, path []string)
-	walk = func(items []Item, path []string) {
+	var walk func(items []Item, path []string, folder *adapters.Folder)
+	walk = func(items []Item, path []string, folder *adapters.Folder) {
 		for _, it := range items {
 			currentPath := append(path, it.Name)
+			name := strings.Join(currentPath, " > ")
 			if it.Request == nil && len(it.Item) > 0 {
 				// Folder
-				walk(it.Item, currentPath)
+				sub := folder.Add(it.Name)
+				if files {
+					convertFolder(it, name, sub, opts, &ws)
+				} else {
+					if it.Auth != nil && !opts.SkipAuth {
+						ws.Add(name, "folder auth", "skipped")
+					}
+					if len(it.Event) > 0 && !opts.NoScripts {
+						ws.Add(name, "folder scripts", "skipped")
+					}
+					if len(it.Variable) > 0 {
+						ws.Add(name, "folder variables", "skipped")
+					}
+				}
+				walk(it.Item, currentPath, sub)
 				continue
 			}
 			if it.Request == nil {
@@ -170,11 +239,13 @@ func Convert(r io.Reader, envVars map[string]string) (*models.Suite, error) {
 			url := resolveURLFromPostman(req.URL)
 			headers := map[string]string{}
 			for _, h := range req.Header {
-				if enabled := h.Enabled; enabled == nil || *enabled {
+				if enabled(h.Enabled, h.Disabled) {
 					headers[h.Key] = h.Value
+				} else {
+					ws.Disabled(name, "header", h.Key)
 				}
 			}
-			body := convertBody(req.Body)
+			body := convertBody(req.Body, name, &ws)
 			if body != nil && req.Body.Mode == "raw" {
 				if _, ok := headers["Content-Type"]; !ok {
 					headers["Content-Type"] = "application/json"
@@ -182,38 +253,108 @@ func Convert(r io.Reader, envVars map[string]string) (*models.Suite, error) {
 			}
 
 			tc := models.TestCase{
-				Name:    strings.Join(currentPath, " > "),
-				Request: models.Request{Method: req.Method, URL: url, Headers: headers, Body: body},
+				Name:    opts.TestName(path, it.Name, " > "),
+				Request: models.Request{Method: req.Method, URL: url, Headers: headers, Body: body, GraphQL: convertGraphQL(req.Body, name, &ws)},
 				Assert:  models.Assertions{Status: 200},
+				Tags:    opts.FolderTags(path),
 			}
 
 			// Handle request-level auth
-			if req.Auth != nil {
-				// For now, add as test variables or ignore
+			if req.Auth != nil && req.Auth.Type != "noauth" && !opts.SkipAuth {
 				// TODO: better auth handling
+				ws.Add(name, "auth:"+req.Auth.Type, "skipped (request-level auth is not imported)")
 			}
 
 			// Handle request scripts
-			if len(it.Event) > 0 {
-				tc.Pre = convertEventsToHooks(it.Event, "prerequest")
-				tc.Post = convertEventsToHooks(it.Event, "test")
+			if len(it.Event) > 0 && !opts.NoScripts {
+				pre := convertEvents(it.Event, "prerequest", script.PreRequest)
+				post := convertEvents(it.Event, "test", script.PostResponse)
+				pre.ApplyTo(&tc)
+				post.ApplyTo(&tc)
+				ws.Script(pre.IssuesFor(name, "prerequest"))
+				ws.Script(post.IssuesFor(name, "test"))
 			}
 
 			// Handle item variables
 			if len(it.Variable) > 0 {
 				tc.Vars = make(map[string]string)
 				for _, v := range it.Variable {
-					if enabled := v.Enabled; enabled == nil || *enabled {
+					if enabled(v.Enabled, v.Disabled) {
 						tc.Vars[v.Key] = v.Value
+					} else {
+						ws.Disabled(name, "variable", v.Key)
 					}
 				}
 			}
 
-			suite.Tests = append(suite.Tests, tc)
+			if files {
+				folder.Tests = append(folder.Tests, tc)
+			} else {
+				suite.Tests = append(suite.Tests, tc)
+			}
 		}
 	}
-	walk(c.Item, []string{})
-	return suite, nil
+	walk(c.Item, []string{}, root)
+	if !files {
+		opts.Apply(suite)
+	}
+	return suite, root, ws, nil
+}
+
+// convertFolder moves a folder's auth, variables and scripts onto f, the
+// suite-level settings of its file. Like the collection's, folder scripts
+// become suite hooks that see no response.
+func convertFolder(it Item, name string, f *adapters.Folder, opts adapters.Options, ws *adapters.Warnings) {
+	if it.Auth != nil && !opts.SkipAuth {
+		f.Auth = convertAuth(it.Auth, name, ws)
+	}
+	for _, v := range it.Variable {
+		if !enabled(v.Enabled, v.Disabled) {
+			ws.Disabled(name, "variable", v.Key)
+			continue
+		}
+		if f.Vars == nil {
+			f.Vars = map[string]string{}
+		}
+		f.Vars[v.Key] = v.Value
+	}
+	if len(it.Event) > 0 && !opts.NoScripts {
+		pre := convertEvents(it.Event, "prerequest", script.PreRequest)
+		post := convertEvents(it.Event, "test", script.PreRequest)
+		f.PreSuite, f.PostSuite = pre.Hooks, post.Hooks
+		ws.Script(pre.IssuesFor(name, "prerequest"))
+		ws.Script(post.IssuesFor(name, "test"))
+	}
+}
+
+// ParseEnvironment reads a Postman environment export and returns its
+// enabled values.
+func ParseEnvironment(data []byte) (map[string]string, error) {
+	var env struct {
+		Values []struct {
+			Key     string `json:"key"`
+			Value   string `json:"value"`
+			Enabled *bool  `json:"enabled"`
+		} `json:"values"`
+	}
+
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string)
+	for _, v := range env.Values {
+		if enabled := v.Enabled; enabled == nil || *enabled {
+			vars[v.Key] = v.Value
+		}
+	}
+	return vars, nil
+}
+
+// enabled reports whether an entry is on; Postman marks entries with either
+// "disabled": true or "enabled": false.
+func enabled(on *bool, disabled bool) bool {
+	return !disabled && (on == nil || *on)
 }
 
 func resolveURLFromPostman(u interface{}) string {
@@ -270,7 +411,8 @@ func resolveURLFromPostman(u interface{}) string {
 	}
 }
 
-func convertAuth(auth *Auth) *models.Auth {
+// convertAuth maps basic and bearer auth; other types are reported and skipped.
+func convertAuth(auth *Auth, item string, ws *adapters.Warnings) *models.Auth {
 	if auth == nil {
 		return nil
 	}
@@ -303,23 +445,37 @@ func convertAuth(auth *Auth) *models.Auth {
 				}
 			}
 		}
+	case "noauth", "":
+		return nil
+	default:
+		ws.Add(item, "auth:"+auth.Type, "skipped (only basic and bearer are supported)")
+		return nil
 	}
+	ws.Add(item, "auth:"+auth.Type, "skipped (no credentials)")
 	return nil
 }
 
-func convertEventsToHooks(events []Event, listenType string) []models.Hook {
-	var hooks []models.Hook
+// convertEvents translates the scripts listening on listenType ("prerequest" or "test") as one script.
+func convertEvents(events []Event, listenType string, phase script.Phase) script.Translation {
+	var lines []string
 	for _, event := range events {
 		if event.Listen == listenType && event.Script != nil {
-			jsScript := strings.Join(event.Script.Exec, "\n")
-			translatedHooks := script.TranslateJSToHook(jsScript, "postman")
-			hooks = append(hooks, translatedHooks...)
+			lines = append(lines, event.Script.Exec...)
 		}
 	}
-	return hooks
+	return script.TranslateJSToHook(strings.Join(lines, "\n"), "postman", phase)
 }
 
-func convertBody(body *Body) any {
+// convertGraphQL maps a graphql body onto request.graphql. Postman keeps the
+// variables as JSON text.
+func convertGraphQL(body *Body, item string, ws *adapters.Warnings) *models.GraphQL {
+	if body == nil || body.Mode != "graphql" || body.Graphql == nil {
+		return nil
+	}
+	return adapters.GraphQL(body.Graphql.Query, body.Graphql.Variables, item, ws)
+}
+
+func convertBody(body *Body, item string, ws *adapters.Warnings) any {
 	if body == nil {
 		return nil
 	}
@@ -329,35 +485,39 @@ func convertBody(body *Body) any {
 	case "formdata":
 		data := make(map[string]string)
 		for _, param := range body.Formdata {
-			if enabled := param.Enabled; enabled == nil || *enabled {
-				if param.Type == "text" {
-					data[param.Key] = param.Value
-				} else if param.Type == "file" {
-					// TODO: handle file uploads
-					data[param.Key] = fmt.Sprintf("file:%s", strings.Join(param.Src, ","))
-				}
+			if !enabled(param.Enabled, param.Disabled) {
+				ws.Disabled(item, "form field", param.Key)
+				continue
+			}
+			if param.Type == "file" {
+				// TODO: handle file uploads
+				data[param.Key] = fmt.Sprintf("file:%s", strings.Join(param.Src, ","))
+				ws.Addf(item, "body:file-param", "kept as a file: placeholder string", "%s", param.Key)
+			} else {
+				data[param.Key] = param.Value
 			}
 		}
 		return data
 	case "urlencoded":
 		data := make(map[string]string)
 		for _, param := range body.Urlencoded {
-			if enabled := param.Enabled; enabled == nil || *enabled {
+			if enabled(param.Enabled, param.Disabled) {
 				data[param.Key] = param.Value
+			} else {
+				ws.Disabled(item, "form field", param.Key)
 			}
 		}
 		return data
 	case "file":
 		if body.File != nil && len(body.File.Src) > 0 {
+			ws.Addf(item, "body:file", "kept as a file: placeholder string", "%s", body.File.Src[0])
 			return fmt.Sprintf("file:%s", body.File.Src[0])
 		}
-	case "graphql":
-		if body.Graphql != nil {
-			return map[string]any{
-				"query":     body.Graphql.Query,
-				"variables": body.Graphql.Variables,
-			}
-		}
+	case "graphql", "none", "":
+		// graphql bodies become request.graphql, see convertGraphQL
+		return nil
+	default:
+		ws.Add(item, "body:"+body.Mode, "skipped")
 	}
 	return nil
 }
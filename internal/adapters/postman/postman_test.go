@@ -3,6 +3,9 @@ package postman
 import (
 	"strings"
 	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
 
 func TestConvertMinimal(t *testing.T) {
@@ -160,7 +163,14 @@ func TestConvertWithScripts(t *testing.T) {
 			"name": "test with scripts",
 			"event": [
 				{"listen": "prerequest", "script": {"exec": ["console.log('pre-request');", "pm.environment.set('timestamp', new Date().toISOString());"]}},
-				{"listen": "test", "script": {"exec": ["pm.test('Status is 200', function() { pm.response.to.have.status(200); });"]}}
+				{"listen": "test", "script": {"exec": [
+					"var jsonData = pm.response.json();",
+					"pm.test('Status is 201', function() {",
+					"    pm.response.to.have.status(201);",
+					"});",
+					"pm.environment.set('userId', jsonData.id);",
+					"console.log(jsonData.name);"
+				]}}
 			],
 			"request": {"method": "GET", "url": "https://httpbin.org/get"}
 		}]
@@ -176,15 +186,65 @@ func TestConvertWithScripts(t *testing.T) {
 	if len(test.Pre) == 0 {
 		t.Fatalf("expected pre-request hooks, got none")
 	}
-	if len(test.Post) == 0 {
-		t.Fatalf("expected post-request hooks, got none")
+	if len(test.Post) != 1 {
+		t.Fatalf("expected 1 post-request hook, got %d", len(test.Post))
 	}
 	// Check that scripts were translated (basic check for non-empty hooks)
 	if test.Pre[0].JS.Code == "" {
 		t.Fatalf("pre-request script not converted")
 	}
-	if test.Post[0].JS.Code == "" {
-		t.Fatalf("post-request script not converted")
+	if test.Post[0].JS.Code != "var jsonData = pm.response.json();\nconsole.log(jsonData.name);" {
+		t.Fatalf("post-request script not converted: %q", test.Post[0].JS.Code)
+	}
+	// pm.test and the response-derived variable become native
+	if test.Assert.Status != 201 {
+		t.Fatalf("expected status 201 from pm.test, got %d", test.Assert.Status)
+	}
+	if test.Extract["userId"].JSONPath != "id" {
+		t.Fatalf("expected userId extract, got %+v", test.Extract)
+	}
+}
+
+func TestConvertWithReport(t *testing.T) {
+	js := `{
+		"info": {"name": "report test"},
+		"auth": {"type": "oauth2", "oauth2": []},
+		"item": [{"name": "folder", "item": [{
+			"name": "next",
+			"event": [{"listen": "test", "script": {"exec": ["postman.setNextRequest('other');"]}}],
+			"request": {
+				"method": "GET",
+				"url": "https://httpbin.org/get",
+				"header": [{"key": "X-Debug", "value": "1", "disabled": true}]
+			}
+		}]}]
+	}`
+	s, warnings, err := ConvertWithReport(strings.NewReader(js), adapters.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tests[0].Post) != 0 {
+		t.Fatalf("unsupported statement should be dropped, got %+v", s.Tests[0].Post)
+	}
+	if _, ok := s.Tests[0].Request.Headers["X-Debug"]; ok {
+		t.Fatalf("disabled header should not be imported")
+	}
+	want := []adapters.Warning{
+		{Item: "collection", Feature: "auth:oauth2", Action: "skipped (only basic and bearer are supported)"},
+		{Item: "folder > next", Feature: "disabled header", Action: "skipped", Detail: "X-Debug"},
+		{Item: "folder > next", Feature: "script:test", Action: "statement skipped"},
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("expected %d warnings, got %+v", len(want), warnings)
+	}
+	for i, w := range want {
+		got := warnings[i]
+		if got.Item != w.Item || got.Feature != w.Feature || got.Action != w.Action || (w.Detail != "" && got.Detail != w.Detail) {
+			t.Errorf("warning %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+	if !strings.HasPrefix(warnings[2].Detail, "line 1: ") {
+		t.Errorf("expected script line in detail, got %q", warnings[2].Detail)
 	}
 }
 
@@ -223,3 +283,138 @@ func TestConvertMissingRequiredFields(t *testing.T) {
 		// This is actually OK - the converter should handle missing fields gracefully
 	}
 }
+
+func TestConvertWithOptions(t *testing.T) {
+	js := `{
+		"info": {"name": "opts"},
+		"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "t"}]},
+		"variable": [{"key": "host", "value": "a"}],
+		"item": [{"name": "users", "item": [{
+			"name": "list",
+			"event": [{"listen": "test", "script": {"exec": ["pm.response.to.have.status(201);"]}}],
+			"request": {"method": "GET", "url": "https://example.com/users"}
+		}]}]
+	}`
+	opts := adapters.Options{
+		NoScripts: true,
+		SkipAuth:  true,
+		Folders:   adapters.FoldersTags,
+		BaseURL:   "https://staging.example.com",
+		Env:       map[string]string{"host": "b"},
+	}
+	s, _, err := ConvertWithReport(strings.NewReader(js), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Auth != nil || s.BaseURL != "https://staging.example.com" || s.Variables["host"] != "b" {
+		t.Fatalf("unexpected suite settings auth=%+v baseUrl=%q vars=%v", s.Auth, s.BaseURL, s.Variables)
+	}
+	tc := s.Tests[0]
+	if tc.Name != "list" || len(tc.Tags) != 1 || tc.Tags[0] != "users" {
+		t.Fatalf("expected tagged test, got name %q tags %v", tc.Name, tc.Tags)
+	}
+	if tc.Assert.Status != 200 || len(tc.Post) != 0 {
+		t.Fatalf("scripts should be skipped, got %+v post %+v", tc.Assert, tc.Post)
+	}
+
+	s, _, err = ConvertWithReport(strings.NewReader(js), adapters.Options{Folders: adapters.FoldersFlat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Tests[0].Name != "list" || s.Tests[0].Tags != nil {
+		t.Fatalf("expected flat name without tags, got %q %v", s.Tests[0].Name, s.Tests[0].Tags)
+	}
+}
+
+func TestConvertFiles(t *testing.T) {
+	js := `{
+		"info": {"name": "Shop"},
+		"item": [{
+			"name": "Users",
+			"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "t"}]},
+			"variable": [{"key": "role", "value": "admin"}],
+			"event": [{"listen": "prerequest", "script": {"exec": ["pm.environment.set('x', '1');"]}}],
+			"item": [{"name": "list", "request": {"method": "GET", "url": "https://example.com/users"}}]
+		}]
+	}`
+	files, warnings, err := ConvertFiles(strings.NewReader(js), adapters.Options{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 || len(files) != 1 || files[0].Path != "users.hrq.yaml" {
+		t.Fatalf("unexpected files %+v warnings %v", files, warnings)
+	}
+	s := files[0].Suite
+	if s.Name != "Shop > Users" || s.BaseURL != "https://example.com" || s.Auth == nil || s.Auth.BearerEnv != "t" || s.Variables["role"] != "admin" {
+		t.Fatalf("unexpected suite %+v", s)
+	}
+	if len(s.PreSuite) != 1 || s.PreSuite[0].Vars["x"] != "1" || s.Tests[0].Name != "list" {
+		t.Fatalf("unexpected preSuite %+v tests %+v", s.PreSuite, s.Tests)
+	}
+}
+
+func TestExport(t *testing.T) {
+	ex := adapters.Export{
+		Name: "users",
+		Vars: map[string]string{"base": "https://api.example.com"},
+		Requests: []adapters.ExportRequest{{
+			Name: "create",
+			Request: models.Request{
+				Method:  "POST",
+				URL:     "${base}/users",
+				Headers: map[string]string{"Authorization": "Bearer ${ENV:TOKEN}", "Content-Type": "application/json"},
+				Query:   map[string]string{"dry": "${dry}"},
+				Body:    map[string]any{"name": "ann"},
+			},
+		}},
+	}
+	var b strings.Builder
+	if err := Export(&b, ex); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"`,
+		`"url": "{{base}}/users?dry={{dry}}"`,
+		`"value": "Bearer {{TOKEN}}"`,
+		`"raw": "{\"name\":\"ann\"}"`,
+		`"language": "json"`,
+		`"key": "base"`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Fatalf("missing %s in\n%s", want, b.String())
+		}
+	}
+	s, err := Convert(strings.NewReader(b.String()), nil)
+	if err != nil {
+		t.Fatalf("re-import: %v", err)
+	}
+	if len(s.Tests) != 1 || s.Tests[0].Name != "create" || s.Tests[0].Request.Method != "POST" {
+		t.Fatalf("unexpected re-import %+v", s.Tests)
+	}
+}
+
+func TestConvertGraphQL(t *testing.T) {
+	js := `{"info":{"name":"g"},"item":[
+		{"name":"user","request":{"method":"POST","url":"https://api.example.com/graphql",
+			"body":{"mode":"graphql","graphql":{"query":"query($id: ID!) { user(id: $id) { name } }","variables":"{\n  \"id\": \"7\"\n}"}}}},
+		{"name":"broken","request":{"method":"POST","url":"https://api.example.com/graphql",
+			"body":{"mode":"graphql","graphql":{"query":"{ me { id } }","variables":"{\"id\": {{id}}}"}}}}
+	]}`
+	s, ws, err := ConvertWithReport(strings.NewReader(js), adapters.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tests) != 2 {
+		t.Fatalf("tests = %+v", s.Tests)
+	}
+	g := s.Tests[0].Request.GraphQL
+	if s.Tests[0].Request.Body != nil || g == nil || g.Query != "query($id: ID!) { user(id: $id) { name } }" || g.Variables["id"] != "7" {
+		t.Fatalf("user request = %+v", s.Tests[0].Request)
+	}
+	if g := s.Tests[1].Request.GraphQL; g == nil || g.Query != "{ me { id } }" || g.Variables != nil {
+		t.Fatalf("broken request = %+v", s.Tests[1].Request)
+	}
+	if len(ws) != 1 || ws[0].String() != `broken: body:graphql-variables: skipped (not a JSON object) ({"id": {{id}}})` {
+		t.Fatalf("warnings = %v", ws)
+	}
+}
@@ -0,0 +1,71 @@
+// Package adapters holds what the importers and exporters in its subpackages
+// share: the report of everything an import could not carry over as-is and
+// the rendered requests an export writes.
+package adapters
+
+import (
+	"fmt"
+
+	"github.com/DrWeltschmerz/HydReq/internal/script"
+)
+
+// Warning records one thing an importer dropped or changed.
+type Warning struct {
+	Item    string `json:"item,omitempty"`   // request or folder path; empty for the whole file
+	Feature string `json:"feature"`          // e.g. "auth:oauth2", "body:graphql", "script:test"
+	Action  string `json:"action"`           // what the importer did instead, e.g. "skipped"
+	Detail  string `json:"detail,omitempty"` // source specifics such as a script line
+}
+
+func (w Warning) String() string {
+	s := w.Feature + ": " + w.Action
+	if w.Item != "" {
+		s = w.Item + ": " + s
+	}
+	if w.Detail != "" {
+		s += " (" + w.Detail + ")"
+	}
+	return s
+}
+
+// Report is the outcome of one import, as printed by `hydreq import --report`
+// and returned by the Web UI import endpoint.
+type Report struct {
+	Format   string    `json:"format"`
+	Tests    int       `json:"tests"`
+	Warnings []Warning `json:"warnings"`
+}
+
+// Warnings collects warnings while an adapter converts a file.
+type Warnings []Warning
+
+// Add records a warning for item.
+func (ws *Warnings) Add(item, feature, action string) {
+	*ws = append(*ws, Warning{Item: item, Feature: feature, Action: action})
+}
+
+// Addf records a warning with a formatted detail.
+func (ws *Warnings) Addf(item, feature, action, format string, a ...any) {
+	*ws = append(*ws, Warning{Item: item, Feature: feature, Action: action, Detail: fmt.Sprintf(format, a...)})
+}
+
+// Script records the statements script translation left out.
+func (ws *Warnings) Script(issues []script.Issue) {
+	for _, is := range issues {
+		detail := is.Reason
+		if is.Line > 0 {
+			detail = fmt.Sprintf("line %d: %s", is.Line, detail)
+		}
+		if is.Code != "" {
+			detail += ": " + is.Code
+		}
+		*ws = append(*ws, Warning{Item: is.Item, Feature: "script:" + is.Script, Action: "statement skipped", Detail: detail})
+	}
+}
+
+// Disabled records entries switched off in the source, which are not imported.
+func (ws *Warnings) Disabled(item, kind string, names ...string) {
+	for _, n := range names {
+		*ws = append(*ws, Warning{Item: item, Feature: "disabled " + kind, Action: "skipped", Detail: n})
+	}
+}
@@ -0,0 +1,67 @@
+package restclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+)
+
+// Export writes ex as a VS Code REST Client .http file: ex.Vars as file
+// variables, then one request per test after a ### separator and a comment
+// with its name. Placeholders become {{name}} references, ${ENV:NAME} as
+// {{$processEnv NAME}}.
+func Export(w io.Writer, ex adapters.Export) error {
+	var b strings.Builder
+	if ex.Name != "" {
+		b.WriteString("# " + oneLine(ex.Name) + "\n")
+	}
+	names := make([]string, 0, len(ex.Vars))
+	for k := range ex.Vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		b.WriteString("@" + k + " = " + vars(ex.Vars[k]) + "\n")
+	}
+	for _, r := range ex.Requests {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("###\n# " + oneLine(r.Name) + "\n")
+		b.WriteString(r.Request.Method + " " + vars(r.URL()) + "\n")
+		for _, k := range r.HeaderNames() {
+			b.WriteString(k + ": " + vars(r.Request.Headers[k]) + "\n")
+		}
+		body, err := r.Body()
+		if err != nil {
+			return err
+		}
+		if body != "" {
+			var pretty bytes.Buffer
+			if json.Indent(&pretty, []byte(body), "", "  ") == nil {
+				body = pretty.String()
+			}
+			b.WriteString("\n" + vars(body) + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// vars turns HydReq placeholders into REST Client ones.
+func vars(s string) string {
+	return adapters.ReplaceVars(s, func(name string) string {
+		if env, ok := strings.CutPrefix(name, "ENV:"); ok {
+			return "{{$processEnv " + env + "}}"
+		}
+		return "{{" + name + "}}"
+	})
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
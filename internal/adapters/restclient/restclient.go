@@ -7,12 +7,22 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
 
 // Convert parses a VS Code REST Client .http file and converts it to a HydReq suite
 func Convert(r io.Reader) (*models.Suite, error) {
+	s, _, err := ConvertWithReport(r, adapters.Options{})
+	return s, err
+}
+
+// ConvertWithReport is Convert that also returns what could not be imported
+// as-is.
+func ConvertWithReport(r io.Reader, opts adapters.Options) (*models.Suite, []adapters.Warning, error) {
 	scanner := bufio.NewScanner(r)
+	var ws adapters.Warnings
+	lineNo := 0
 	suite := &models.Suite{
 		Name: "REST Client Import",
 	}
@@ -23,6 +33,7 @@ func Convert(r io.Reader) (*models.Suite, error) {
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
+		lineNo++
 
 		// Skip empty lines and comments (but not request separators)
 		if line == "" || (strings.HasPrefix(line, "#") && line != "###") || strings.HasPrefix(line, "//") {
@@ -86,6 +97,12 @@ func Convert(r io.Reader) (*models.Suite, error) {
 			}
 		}
 
+		// File variables are not resolved
+		if strings.HasPrefix(line, "@") {
+			ws.Addf("", "file variable", "skipped", "line %d: %s", lineNo, line)
+			continue
+		}
+
 		// Skip if we still don't have a current request
 		if currentRequest == nil {
 			continue
@@ -103,6 +120,9 @@ func Convert(r io.Reader) (*models.Suite, error) {
 				case "authorization":
 					// For now, just add as a regular header
 					// TODO: Could extract bearer tokens to suite-level auth
+					if opts.SkipAuth {
+						continue
+					}
 					if currentRequest.Request.Headers == nil {
 						currentRequest.Request.Headers = make(map[string]string)
 					}
@@ -135,6 +155,8 @@ func Convert(r io.Reader) (*models.Suite, error) {
 		if inBody {
 			currentBody.WriteString(line)
 			currentBody.WriteString("\n")
+		} else {
+			ws.Addf(currentRequest.Request.Method+" "+currentRequest.Request.URL, "body", "line skipped (only JSON bodies are supported)", "line %d", lineNo)
 		}
 	}
 
@@ -147,7 +169,7 @@ func Convert(r io.Reader) (*models.Suite, error) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Generate names for requests that don't have them
@@ -157,7 +179,8 @@ func Convert(r io.Reader) (*models.Suite, error) {
 		}
 	}
 
-	return suite, nil
+	opts.Apply(suite)
+	return suite, ws, nil
 }
 
 // isHTTPMethod checks if a string is a valid HTTP method
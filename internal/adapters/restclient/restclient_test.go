@@ -3,6 +3,9 @@ package restclient
 import (
 	"strings"
 	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
 
 func TestConvert(t *testing.T) {
@@ -175,3 +178,70 @@ X-Custom-Header: value2
 		t.Fatalf("Expected X-Custom-Header to be 'value2' (last value), got %v", test.Request.Headers)
 	}
 }
+
+func TestConvertWithReport_RESTClient(t *testing.T) {
+	httpContent := `@host = https://api.example.com
+
+POST https://api.example.com/form
+Content-Type: application/x-www-form-urlencoded
+
+name=ada
+`
+
+	suite, warnings, err := ConvertWithReport(strings.NewReader(httpContent), adapters.Options{})
+	if err != nil {
+		t.Fatalf("ConvertWithReport failed: %v", err)
+	}
+	if len(suite.Tests) != 1 {
+		t.Fatalf("Expected 1 test, got %d", len(suite.Tests))
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %v", warnings)
+	}
+	if warnings[0].Feature != "file variable" || warnings[0].Detail != "line 1: @host = https://api.example.com" {
+		t.Errorf("unexpected first warning %+v", warnings[0])
+	}
+	if warnings[1].Item != "POST https://api.example.com/form" || warnings[1].Detail != "line 6" {
+		t.Errorf("unexpected second warning %+v", warnings[1])
+	}
+}
+
+func TestExport(t *testing.T) {
+	ex := adapters.Export{
+		Name: "users",
+		Vars: map[string]string{"base": "https://api.example.com"},
+		Requests: []adapters.ExportRequest{
+			{Name: "list", Request: models.Request{Method: "GET", URL: "${base}/users", Query: map[string]string{"q": "a b"}}},
+			{Name: "create", Request: models.Request{
+				Method:  "POST",
+				URL:     "${base}/users",
+				Headers: map[string]string{"Authorization": "Bearer ${ENV:TOKEN}", "Content-Type": "application/json"},
+				Body:    map[string]any{"name": "${name}"},
+			}},
+		},
+	}
+	var b strings.Builder
+	if err := Export(&b, ex); err != nil {
+		t.Fatal(err)
+	}
+	want := `# users
+@base = https://api.example.com
+
+###
+# list
+GET {{base}}/users?q=a+b
+
+###
+# create
+POST {{base}}/users
+Authorization: Bearer {{$processEnv TOKEN}}
+Content-Type: application/json
+
+{
+  "name": "{{name}}"
+}
+`
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -26,6 +26,29 @@ func New(timeout time.Duration) *Client {
 }
 
 func (c *Client) Do(ctx context.Context, method, urlStr string, headers map[string]string, query map[string]string, body any) (*Response, error) {
+	req, err := NewRequest(ctx, method, urlStr, headers, query, body)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := c.base.Do(req)
+	dur := time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Status: resp.StatusCode, Headers: resp.Header, Body: b, DurationMs: dur.Milliseconds()}, nil
+}
+
+// NewRequest builds the request Do sends: query merged into the URL, strings
+// and bytes sent as-is and anything else as JSON (setting Content-Type in
+// headers when missing).
+func NewRequest(ctx context.Context, method, urlStr string, headers map[string]string, query map[string]string, body any) (*http.Request, error) {
 	u, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, err
@@ -65,17 +88,5 @@ func (c *Client) Do(ctx context.Context, method, urlStr string, headers map[stri
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
-
-	start := time.Now()
-	resp, err := c.base.Do(req)
-	dur := time.Since(start)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	return &Response{Status: resp.StatusCode, Headers: resp.Header, Body: b, DurationMs: dur.Milliseconds()}, nil
+	return req, nil
 }
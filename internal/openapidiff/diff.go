@@ -0,0 +1,411 @@
+// Package openapidiff finds breaking changes between two versions of an
+// OpenAPI spec and the suite tests they affect.
+package openapidiff
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Kinds of breaking change.
+const (
+	OperationRemoved     = "operation-removed"
+	ParameterRequired    = "parameter-required"
+	ParameterTypeChanged = "parameter-type-changed"
+	EnumNarrowed         = "enum-narrowed"
+	RequestBodyRequired  = "request-body-required"
+	RequestFieldRequired = "request-field-required"
+	RequestChanged       = "request-changed"
+	ResponseRemoved      = "response-removed"
+	ResponseChanged      = "response-changed"
+)
+
+// maxDepth bounds schema comparison for deeply nested or recursive schemas.
+const maxDepth = 8
+
+// Change is one breaking difference on an operation of the old spec.
+type Change struct {
+	Kind   string    `json:"kind"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"` // path template in the old spec
+	Detail string    `json:"detail"`
+	Tests  []TestRef `json:"tests,omitempty"`
+}
+
+// TestRef names a suite test that hits a changed operation.
+type TestRef struct {
+	Suite string `json:"suite"`
+	Test  string `json:"test"`
+}
+
+// Touch records the operation of the old spec a test hits.
+type Touch struct {
+	TestRef
+	Method string
+	Path   string
+}
+
+// Report is the result of comparing two specs.
+type Report struct {
+	Old     string   `json:"old"`
+	New     string   `json:"new"`
+	Changes []Change `json:"changes"`
+}
+
+// Link attaches the tests that hit each changed operation.
+func (r *Report) Link(touches []Touch) {
+	for i := range r.Changes {
+		c := &r.Changes[i]
+		for _, t := range touches {
+			if t.Method == c.Method && t.Path == c.Path {
+				c.Tests = append(c.Tests, t.TestRef)
+			}
+		}
+	}
+}
+
+// Load reads and validates a spec file the way a suite run does.
+func Load(path string) (*openapi3.T, error) {
+	loader := &openapi3.Loader{IsExternalRefsAllowed: true}
+	doc, err := loader.LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", path, err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("%s is not a valid spec: %w", path, err)
+	}
+	return doc, nil
+}
+
+// Diff lists the breaking changes from old to new: removed operations,
+// newly required parameters and request fields, parameter type changes,
+// narrowed enums, and removed or changed responses.
+func Diff(old, new *openapi3.T) []Change {
+	d := &differ{}
+	newOps := map[string]*openapi3.Operation{}
+	newParams := map[string]openapi3.Parameters{}
+	for _, o := range operations(new) {
+		k := o.method + " " + normalize(o.path)
+		newOps[k] = o.op
+		newParams[k] = o.params
+	}
+	for _, o := range operations(old) {
+		d.method, d.path = o.method, o.path
+		k := o.method + " " + normalize(o.path)
+		nop, ok := newOps[k]
+		if !ok {
+			d.add(OperationRemoved, "operation removed")
+			continue
+		}
+		d.params(o.params, newParams[k])
+		d.requestBody(o.op.RequestBody, nop.RequestBody)
+		d.responses(o.op.Responses, nop.Responses)
+	}
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		a, b := d.changes[i], d.changes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return d.changes
+}
+
+type differ struct {
+	method, path string
+	changes      []Change
+}
+
+func (d *differ) add(kind, format string, args ...any) {
+	d.changes = append(d.changes, Change{Kind: kind, Method: d.method, Path: d.path, Detail: fmt.Sprintf(format, args...)})
+}
+
+func (d *differ) params(old, new openapi3.Parameters) {
+	oldByKey := map[string]*openapi3.Parameter{}
+	for _, ref := range old {
+		if ref != nil && ref.Value != nil {
+			oldByKey[ref.Value.In+":"+ref.Value.Name] = ref.Value
+		}
+	}
+	for _, ref := range new {
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		np := ref.Value
+		label := np.In + " parameter " + np.Name
+		op, existed := oldByKey[np.In+":"+np.Name]
+		if np.Required && (!existed || !op.Required) && np.In != openapi3.ParameterInPath {
+			if existed {
+				d.add(ParameterRequired, "%s is now required", label)
+			} else {
+				d.add(ParameterRequired, "new required %s", label)
+			}
+		}
+		if !existed || op.Schema == nil || op.Schema.Value == nil || np.Schema == nil || np.Schema.Value == nil {
+			continue
+		}
+		os, ns := op.Schema.Value, np.Schema.Value
+		if typeChanged(os, ns) {
+			d.add(ParameterTypeChanged, "%s changed type from %s to %s", label, typeName(os), typeName(ns))
+		}
+		if msg := narrowed(os.Enum, ns.Enum); msg != "" {
+			d.add(EnumNarrowed, "%s: %s", label, msg)
+		}
+	}
+}
+
+func (d *differ) requestBody(old, new *openapi3.RequestBodyRef) {
+	if new == nil || new.Value == nil {
+		return
+	}
+	oldRequired := old != nil && old.Value != nil && old.Value.Required
+	if new.Value.Required && !oldRequired {
+		d.add(RequestBodyRequired, "request body is now required")
+	}
+	if old == nil || old.Value == nil {
+		return
+	}
+	for _, ct := range sortedKeys(old.Value.Content) {
+		nmt := new.Value.Content.Get(ct)
+		if nmt == nil {
+			d.add(RequestChanged, "request content type %s removed", ct)
+			continue
+		}
+		if omt := old.Value.Content[ct]; omt.Schema != nil && nmt.Schema != nil {
+			d.request("body", omt.Schema.Value, nmt.Schema.Value, 0, map[[2]*openapi3.Schema]bool{})
+		}
+	}
+}
+
+// request compares a request schema: what clients send must stay acceptable.
+func (d *differ) request(at string, old, new *openapi3.Schema, depth int, seen map[[2]*openapi3.Schema]bool) {
+	if old == nil || new == nil || depth > maxDepth || seen[[2]*openapi3.Schema{old, new}] {
+		return
+	}
+	seen[[2]*openapi3.Schema{old, new}] = true
+	if typeChanged(old, new) {
+		d.add(RequestChanged, "%s changed type from %s to %s", at, typeName(old), typeName(new))
+		return
+	}
+	if msg := narrowed(old.Enum, new.Enum); msg != "" {
+		d.add(EnumNarrowed, "%s: %s", at, msg)
+	}
+	oldProps, oldReq := properties(old)
+	newProps, newReq := properties(new)
+	for _, name := range newReq {
+		if !contains(oldReq, name) {
+			d.add(RequestFieldRequired, "%s.%s is now required", at, name)
+		}
+	}
+	for _, name := range sortedKeys(oldProps) {
+		if np, ok := newProps[name]; ok && np != nil && oldProps[name] != nil {
+			d.request(at+"."+name, oldProps[name].Value, np.Value, depth+1, seen)
+		}
+	}
+	if old.Items != nil && new.Items != nil {
+		d.request(at+"[]", old.Items.Value, new.Items.Value, depth+1, seen)
+	}
+}
+
+func (d *differ) responses(old, new *openapi3.Responses) {
+	if old == nil {
+		return
+	}
+	for _, code := range sortedKeys(old.Map()) {
+		oref := old.Value(code)
+		var nref *openapi3.ResponseRef
+		if new != nil {
+			nref = new.Value(code)
+		}
+		if nref == nil || nref.Value == nil {
+			d.add(ResponseRemoved, "response %s removed", code)
+			continue
+		}
+		if oref == nil || oref.Value == nil {
+			continue
+		}
+		for _, ct := range sortedKeys(oref.Value.Content) {
+			nmt := nref.Value.Content.Get(ct)
+			if nmt == nil {
+				d.add(ResponseChanged, "response %s: content type %s removed", code, ct)
+				continue
+			}
+			if omt := oref.Value.Content[ct]; omt.Schema != nil && nmt.Schema != nil {
+				d.response(code, "body", omt.Schema.Value, nmt.Schema.Value, 0, map[[2]*openapi3.Schema]bool{})
+			}
+		}
+	}
+}
+
+// response compares a response schema: what clients read must stay there.
+func (d *differ) response(code, at string, old, new *openapi3.Schema, depth int, seen map[[2]*openapi3.Schema]bool) {
+	if old == nil || new == nil || depth > maxDepth || seen[[2]*openapi3.Schema{old, new}] {
+		return
+	}
+	seen[[2]*openapi3.Schema{old, new}] = true
+	if typeChanged(old, new) {
+		d.add(ResponseChanged, "response %s: %s changed type from %s to %s", code, at, typeName(old), typeName(new))
+		return
+	}
+	if len(old.Enum) > 0 {
+		if added := missing(new.Enum, old.Enum); len(added) > 0 {
+			d.add(ResponseChanged, "response %s: %s may now be %s", code, at, strings.Join(added, ", "))
+		}
+	}
+	oldProps, oldReq := properties(old)
+	newProps, newReq := properties(new)
+	for _, name := range sortedKeys(oldProps) {
+		np, ok := newProps[name]
+		if !ok {
+			d.add(ResponseChanged, "response %s: %s.%s removed", code, at, name)
+			continue
+		}
+		if contains(oldReq, name) && !contains(newReq, name) {
+			d.add(ResponseChanged, "response %s: %s.%s is no longer required", code, at, name)
+		}
+		if np != nil && oldProps[name] != nil {
+			d.response(code, at+"."+name, oldProps[name].Value, np.Value, depth+1, seen)
+		}
+	}
+	if old.Items != nil && new.Items != nil {
+		d.response(code, at+"[]", old.Items.Value, new.Items.Value, depth+1, seen)
+	}
+}
+
+// narrowed describes enum values new no longer accepts, or a new enum where
+// any value was accepted.
+func narrowed(old, new []any) string {
+	if len(new) == 0 {
+		return ""
+	}
+	if len(old) == 0 {
+		return "now restricted to " + strings.Join(values(new), ", ")
+	}
+	if removed := missing(old, new); len(removed) > 0 {
+		return "no longer accepts " + strings.Join(removed, ", ")
+	}
+	return ""
+}
+
+// missing lists the values of from that are not in in.
+func missing(from, in []any) []string {
+	have := map[string]bool{}
+	for _, v := range values(in) {
+		have[v] = true
+	}
+	var out []string
+	for _, v := range values(from) {
+		if !have[v] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func values(enum []any) []string {
+	out := make([]string, 0, len(enum))
+	for _, v := range enum {
+		out = append(out, fmt.Sprint(v))
+	}
+	return out
+}
+
+func typeChanged(old, new *openapi3.Schema) bool {
+	if old.Type == nil || new.Type == nil {
+		return false
+	}
+	return typeName(old) != typeName(new)
+}
+
+func typeName(s *openapi3.Schema) string {
+	if s.Type == nil {
+		return "any"
+	}
+	return strings.Join(s.Type.Slice(), "|")
+}
+
+// properties returns the properties and required fields of an object
+// schema, including those it pulls in with allOf.
+func properties(s *openapi3.Schema) (openapi3.Schemas, []string) {
+	props := openapi3.Schemas{}
+	var required []string
+	var walk func(s *openapi3.Schema, depth int)
+	walk = func(s *openapi3.Schema, depth int) {
+		if depth > maxDepth {
+			return
+		}
+		for _, sub := range s.AllOf {
+			if sub != nil && sub.Value != nil {
+				walk(sub.Value, depth+1)
+			}
+		}
+		for name, p := range s.Properties {
+			props[name] = p
+		}
+		for _, r := range s.Required {
+			if !contains(required, r) {
+				required = append(required, r)
+			}
+		}
+	}
+	walk(s, 0)
+	sort.Strings(required)
+	return props, required
+}
+
+type operation struct {
+	method, path string
+	op           *openapi3.Operation
+	params       openapi3.Parameters // path item and operation parameters
+}
+
+func operations(doc *openapi3.T) []operation {
+	if doc == nil || doc.Paths == nil {
+		return nil
+	}
+	var out []operation
+	for _, p := range doc.Paths.InMatchingOrder() {
+		item := doc.Paths.Value(p)
+		for method, op := range item.Operations() {
+			params := append(append(openapi3.Parameters(nil), item.Parameters...), op.Parameters...)
+			out = append(out, operation{method: method, path: p, op: op, params: params})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].path != out[j].path {
+			return out[i].path < out[j].path
+		}
+		return out[i].method < out[j].method
+	})
+	return out
+}
+
+var templateParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// normalize makes path templates comparable when only parameter names differ.
+func normalize(path string) string {
+	return templateParam.ReplaceAllString(strings.TrimSuffix(path, "/"), "{}")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,189 @@
+package openapidiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const oldSpec = `openapi: 3.0.3
+info: {title: pets, version: "1"}
+paths:
+  /pets:
+    get:
+      parameters:
+        - {name: limit, in: query, schema: {type: integer}}
+        - {name: status, in: query, schema: {type: string, enum: [available, pending, sold]}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: "#/components/schemas/Pet"}
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string}
+                tag: {type: string}
+      responses:
+        "201": {description: created}
+  /pets/{id}:
+    delete:
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses:
+        "204": {description: deleted}
+        "404": {description: missing}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name]
+      properties:
+        id: {type: integer}
+        name: {type: string}
+        owner: {$ref: "#/components/schemas/Pet"}
+`
+
+const newSpec = `openapi: 3.0.3
+info: {title: pets, version: "2"}
+paths:
+  /pets:
+    get:
+      parameters:
+        - {name: limit, in: query, required: true, schema: {type: string}}
+        - {name: status, in: query, schema: {type: string, enum: [available, pending]}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: "#/components/schemas/Pet"}
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name, tag]
+              properties:
+                name: {type: string}
+                tag: {type: string}
+      responses:
+        "201": {description: created}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id]
+      properties:
+        id: {type: string}
+        name: {type: string}
+        owner: {$ref: "#/components/schemas/Pet"}
+`
+
+func load(t *testing.T, spec string) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestDiff(t *testing.T) {
+	changes := Diff(load(t, oldSpec), load(t, newSpec))
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Method+" "+c.Path+" "+c.Kind+": "+c.Detail)
+	}
+	want := []string{
+		"GET /pets parameter-required: query parameter limit is now required",
+		"GET /pets parameter-type-changed: query parameter limit changed type from integer to string",
+		"GET /pets enum-narrowed: query parameter status: no longer accepts sold",
+		"GET /pets response-changed: response 200: body[].id changed type from integer to string",
+		"GET /pets response-changed: response 200: body[].name is no longer required",
+		"POST /pets request-body-required: request body is now required",
+		"POST /pets request-field-required: body.tag is now required",
+		"DELETE /pets/{id} operation-removed: operation removed",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	if changes := Diff(load(t, oldSpec), load(t, oldSpec)); len(changes) != 0 {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+}
+
+func TestDiffRenamedPathParam(t *testing.T) {
+	renamed := strings.Replace(strings.Replace(oldSpec, "/pets/{id}", "/pets/{petId}", 1), "name: id, in: path", "name: petId, in: path", 1)
+	for _, c := range Diff(load(t, oldSpec), load(t, renamed)) {
+		if c.Kind == OperationRemoved {
+			t.Fatalf("renaming a path parameter removed %s %s", c.Method, c.Path)
+		}
+	}
+}
+
+func TestDiffResponseRemoved(t *testing.T) {
+	trimmed := strings.Replace(oldSpec, "        \"404\": {description: missing}\n", "", 1)
+	changes := Diff(load(t, oldSpec), load(t, trimmed))
+	if len(changes) != 1 || changes[0].Kind != ResponseRemoved || changes[0].Detail != "response 404 removed" {
+		t.Fatalf("changes = %+v", changes)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := Report{Old: "old.yaml", New: "new.yaml", Changes: Diff(load(t, oldSpec), load(t, newSpec))}
+	r.Link([]Touch{
+		{TestRef: TestRef{Suite: "pets.hrq.yaml", Test: "delete pet"}, Method: "DELETE", Path: "/pets/{id}"},
+		{TestRef: TestRef{Suite: "pets.hrq.yaml", Test: "health"}, Method: "GET", Path: "/health"},
+	})
+
+	var text bytes.Buffer
+	if err := Write(&text, r, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "8 breaking change(s)") || !strings.Contains(text.String(), "DELETE /pets/{id}\n  - operation-removed: operation removed\n      affects pets.hrq.yaml: delete pet\n") {
+		t.Fatalf("text:\n%s", text.String())
+	}
+
+	var md bytes.Buffer
+	if err := Write(&md, r, "markdown"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "| `DELETE /pets/{id}` | operation-removed | operation removed | `pets.hrq.yaml`: delete pet |") {
+		t.Fatalf("markdown:\n%s", md.String())
+	}
+
+	var js bytes.Buffer
+	if err := Write(&js, r, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var back Report
+	if err := json.Unmarshal(js.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Changes) != 8 || len(back.Changes[7].Tests) != 1 {
+		t.Fatalf("json round trip = %+v", back)
+	}
+
+	if err := Write(&js, r, "xml"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,88 @@
+package openapidiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats accepted by Write.
+var Formats = []string{"text", "json", "markdown"}
+
+// Write renders the report as text, json or markdown.
+func Write(w io.Writer, r Report, format string) error {
+	switch format {
+	case "", "text":
+		return WriteText(w, r)
+	case "json":
+		return WriteJSON(w, r)
+	case "markdown", "md":
+		return WriteMarkdown(w, r)
+	}
+	return fmt.Errorf("unknown format %q (want %s)", format, strings.Join(Formats, ", "))
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r Report) error {
+	if r.Changes == nil {
+		r.Changes = []Change{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes the changes grouped by operation, each followed by the
+// tests that hit it.
+func WriteText(w io.Writer, r Report) error {
+	if len(r.Changes) == 0 {
+		_, err := fmt.Fprintf(w, "No breaking changes between %s and %s\n", r.Old, r.New)
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d breaking change(s) between %s and %s\n", len(r.Changes), r.Old, r.New)
+	for i, c := range r.Changes {
+		if i == 0 || c.Method != r.Changes[i-1].Method || c.Path != r.Changes[i-1].Path {
+			fmt.Fprintf(&b, "\n%s %s\n", c.Method, c.Path)
+		}
+		fmt.Fprintf(&b, "  - %s: %s\n", c.Kind, c.Detail)
+		for _, t := range c.Tests {
+			fmt.Fprintf(&b, "      affects %s: %s\n", t.Suite, t.Test)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes the changes as a table, ready to post as a pull
+// request comment.
+func WriteMarkdown(w io.Writer, r Report) error {
+	var b strings.Builder
+	b.WriteString("### OpenAPI breaking changes\n\n")
+	if len(r.Changes) == 0 {
+		fmt.Fprintf(&b, "No breaking changes between `%s` and `%s`.\n", r.Old, r.New)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "**%d** breaking change(s) between `%s` and `%s`.\n\n", len(r.Changes), r.Old, r.New)
+	b.WriteString("| Operation | Change | Detail | Affected tests |\n|---|---|---|---|\n")
+	for _, c := range r.Changes {
+		tests := make([]string, 0, len(c.Tests))
+		for _, t := range c.Tests {
+			tests = append(tests, fmt.Sprintf("`%s`: %s", t.Suite, cell(t.Test)))
+		}
+		affected := strings.Join(tests, "<br>")
+		if affected == "" {
+			affected = "-"
+		}
+		fmt.Fprintf(&b, "| `%s %s` | %s | %s | %s |\n", c.Method, c.Path, c.Kind, cell(c.Detail), affected)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// cell escapes text for a markdown table cell.
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
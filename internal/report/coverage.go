@@ -0,0 +1,200 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// OpenAPICoverage summarises which spec operations and response codes a run
+// exercised, across every spec the suites referenced.
+type OpenAPICoverage struct {
+	Total     int                   `json:"total"`   // operations across all specs
+	Covered   int                   `json:"covered"` // operations hit at least once
+	Percent   float64               `json:"percent"`
+	Responses ResponseCoverage      `json:"responses"`
+	Specs     []OpenAPISpecCoverage `json:"specs"`
+}
+
+// ResponseCoverage counts documented response codes and how many were seen.
+type ResponseCoverage struct {
+	Total   int `json:"total"`
+	Covered int `json:"covered"`
+}
+
+// OpenAPISpecCoverage is the coverage of one spec file.
+type OpenAPISpecCoverage struct {
+	File       string              `json:"file"`
+	Total      int                 `json:"total"`
+	Covered    int                 `json:"covered"`
+	Percent    float64             `json:"percent"`
+	Responses  ResponseCoverage    `json:"responses"`
+	Operations []OperationCoverage `json:"operations"`
+}
+
+// OperationCoverage records the hits of one method and path template.
+type OperationCoverage struct {
+	Method   string           `json:"method"`
+	Path     string           `json:"path"`
+	Hits     int              `json:"hits"`
+	Statuses []StatusCoverage `json:"statuses,omitempty"`
+	Params   []ParamCoverage  `json:"params,omitempty"`
+}
+
+// StatusCoverage counts responses for a documented code (200, 4XX, default)
+// or for a code the spec does not document.
+type StatusCoverage struct {
+	Code       string `json:"code"`
+	Documented bool   `json:"documented"`
+	Hits       int    `json:"hits"`
+}
+
+// ParamCoverage counts the requests that carried a declared parameter.
+type ParamCoverage struct {
+	Name string `json:"name"`
+	In   string `json:"in"`
+	Hits int    `json:"hits"`
+}
+
+// Line renders the run totals as a single human-readable line.
+func (c *OpenAPICoverage) Line() string {
+	return fmt.Sprintf("OpenAPI coverage: %d/%d operations (%.1f%%), %d/%d documented responses",
+		c.Covered, c.Total, c.Percent, c.Responses.Covered, c.Responses.Total)
+}
+
+// AddSpec registers the operations of a spec. Adding a file again (several
+// suites sharing one spec) keeps the hits recorded so far.
+func (c *OpenAPICoverage) AddSpec(file string, ops []OperationCoverage) {
+	if c.spec(file) != nil {
+		return
+	}
+	sc := OpenAPISpecCoverage{File: filepath.Clean(file)}
+	for _, op := range ops {
+		op.Hits = 0
+		op.Statuses = append([]StatusCoverage(nil), op.Statuses...)
+		for i := range op.Statuses {
+			op.Statuses[i].Documented, op.Statuses[i].Hits = true, 0
+		}
+		op.Params = append([]ParamCoverage(nil), op.Params...)
+		for i := range op.Params {
+			op.Params[i].Hits = 0
+		}
+		sc.Operations = append(sc.Operations, op)
+	}
+	c.Specs = append(c.Specs, sc)
+}
+
+// Record counts a request that matched method and path in file and got
+// status back. params are the declared parameters the request carried.
+// Hits on specs or operations that were never added are ignored.
+func (c *OpenAPICoverage) Record(file, method, path string, status int, params []ParamCoverage) {
+	sc := c.spec(file)
+	if sc == nil {
+		return
+	}
+	for i := range sc.Operations {
+		op := &sc.Operations[i]
+		if op.Method != method || op.Path != path {
+			continue
+		}
+		op.Hits++
+		op.countStatus(status)
+		for _, p := range params {
+			for j := range op.Params {
+				if op.Params[j].Name == p.Name && op.Params[j].In == p.In {
+					op.Params[j].Hits++
+				}
+			}
+		}
+		return
+	}
+}
+
+// countStatus credits the most specific documented code: the exact code,
+// then its range (2XX), then default. Anything else is listed as undocumented.
+func (op *OperationCoverage) countStatus(status int) {
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", "default"} {
+		for i := range op.Statuses {
+			if op.Statuses[i].Documented && op.Statuses[i].Code == key {
+				op.Statuses[i].Hits++
+				return
+			}
+		}
+	}
+	for i := range op.Statuses {
+		if !op.Statuses[i].Documented && op.Statuses[i].Code == code {
+			op.Statuses[i].Hits++
+			return
+		}
+	}
+	op.Statuses = append(op.Statuses, StatusCoverage{Code: code, Hits: 1})
+	sort.SliceStable(op.Statuses, func(i, j int) bool { return op.Statuses[i].Code < op.Statuses[j].Code })
+}
+
+// Finish computes the totals and percentages; call it once all hits are in.
+func (c *OpenAPICoverage) Finish() {
+	c.Total, c.Covered, c.Responses = 0, 0, ResponseCoverage{}
+	for i := range c.Specs {
+		sc := &c.Specs[i]
+		sc.Total, sc.Covered, sc.Responses = len(sc.Operations), 0, ResponseCoverage{}
+		for _, op := range sc.Operations {
+			if op.Hits > 0 {
+				sc.Covered++
+			}
+			for _, st := range op.Statuses {
+				if !st.Documented {
+					continue
+				}
+				sc.Responses.Total++
+				if st.Hits > 0 {
+					sc.Responses.Covered++
+				}
+			}
+		}
+		sc.Percent = percent(sc.Covered, sc.Total)
+		c.Total += sc.Total
+		c.Covered += sc.Covered
+		c.Responses.Total += sc.Responses.Total
+		c.Responses.Covered += sc.Responses.Covered
+	}
+	c.Percent = percent(c.Covered, c.Total)
+}
+
+func (c *OpenAPICoverage) spec(file string) *OpenAPISpecCoverage {
+	file = filepath.Clean(file)
+	for i := range c.Specs {
+		if c.Specs[i].File == file {
+			return &c.Specs[i]
+		}
+	}
+	return nil
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// WriteJSONOpenAPICoverage writes the coverage report as indented JSON.
+func WriteJSONOpenAPICoverage(path string, c OpenAPICoverage) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return WriteJSONOpenAPICoverageTo(f, c)
+}
+
+// WriteJSONOpenAPICoverageTo writes the coverage report as indented JSON into w.
+func WriteJSONOpenAPICoverageTo(w io.Writer, c OpenAPICoverage) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
@@ -0,0 +1,81 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPICoverage(t *testing.T) {
+	ops := []OperationCoverage{
+		{Method: "GET", Path: "/users", Statuses: []StatusCoverage{{Code: "200"}, {Code: "4XX"}}, Params: []ParamCoverage{{Name: "limit", In: "query"}}},
+		{Method: "POST", Path: "/users", Statuses: []StatusCoverage{{Code: "201"}, {Code: "default"}}},
+		{Method: "DELETE", Path: "/users/{id}", Statuses: []StatusCoverage{{Code: "204"}}},
+	}
+	var c OpenAPICoverage
+	c.AddSpec("specs/users.yaml", ops)
+	c.AddSpec("./specs/users.yaml", ops) // second suite on the same spec
+	c.Record("specs/users.yaml", "GET", "/users", 200, []ParamCoverage{{Name: "limit", In: "query"}})
+	c.Record("specs/users.yaml", "GET", "/users", 404, nil)
+	c.Record("specs/users.yaml", "GET", "/users", 500, nil)
+	c.Record("specs/users.yaml", "POST", "/users", 409, nil)
+	c.Record("other.yaml", "GET", "/users", 200, nil)
+	c.Finish()
+
+	if len(c.Specs) != 1 {
+		t.Fatalf("specs = %d, want 1", len(c.Specs))
+	}
+	if c.Total != 3 || c.Covered != 2 {
+		t.Fatalf("covered %d/%d, want 2/3", c.Covered, c.Total)
+	}
+	if got := c.Percent; got < 66.6 || got > 66.7 {
+		t.Fatalf("percent = %v", got)
+	}
+	if c.Responses.Total != 5 || c.Responses.Covered != 3 {
+		t.Fatalf("responses %d/%d, want 3/5", c.Responses.Covered, c.Responses.Total)
+	}
+	get := c.Specs[0].Operations[0]
+	if get.Hits != 3 || get.Params[0].Hits != 1 {
+		t.Fatalf("GET /users = %+v", get)
+	}
+	want := []StatusCoverage{{Code: "200", Documented: true, Hits: 1}, {Code: "4XX", Documented: true, Hits: 1}, {Code: "500", Hits: 1}}
+	if len(get.Statuses) != len(want) {
+		t.Fatalf("statuses = %+v", get.Statuses)
+	}
+	for i := range want {
+		if get.Statuses[i] != want[i] {
+			t.Fatalf("statuses[%d] = %+v, want %+v", i, get.Statuses[i], want[i])
+		}
+	}
+	if post := c.Specs[0].Operations[1]; post.Statuses[1].Code != "default" || post.Statuses[1].Hits != 1 {
+		t.Fatalf("POST /users statuses = %+v", post.Statuses)
+	}
+	if !strings.Contains(c.Line(), "2/3 operations (66.7%)") {
+		t.Fatalf("line = %q", c.Line())
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSONOpenAPICoverageTo(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	var back OpenAPICoverage
+	if err := json.Unmarshal(buf.Bytes(), &back); err != nil || back.Covered != 2 {
+		t.Fatalf("round trip: %v %+v", err, back)
+	}
+}
+
+func TestWriteHTMLBatch_OpenAPICoverage(t *testing.T) {
+	c := &OpenAPICoverage{}
+	c.AddSpec("spec.yaml", []OperationCoverage{{Method: "GET", Path: "/pets", Statuses: []StatusCoverage{{Code: "200"}}}})
+	c.Record("spec.yaml", "GET", "/pets", 200, nil)
+	c.Finish()
+	var buf bytes.Buffer
+	if err := WriteHTMLBatchTo(&buf, BatchReport{OpenAPI: c}); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	if !strings.Contains(html, "OpenAPI coverage") || !strings.Contains(html, "GET /pets") {
+		t.Fatal("coverage section missing from batch HTML")
+	}
+}
@@ -26,6 +26,7 @@ func funcMapCommon() template.FuncMap {
 		"durationSeconds": tmplDurationSeconds,
 		"nowRFC3339":      tmplNowRFC3339,
 		"toJSON":          tmplToJSON,
+		"hookLine":        HookLine,
 		"pct": func(part int, total int) float64 {
 			if total <= 0 {
 				return 0.0
@@ -35,9 +36,20 @@ func funcMapCommon() template.FuncMap {
 	}
 }
 
+// hooksPartial lists hook results with their messages and console output;
+// every HTML report shares it via {{template "hooks" .}}.
+const hooksPartial = `{{define "hooks"}}{{range .Hooks}}• {{hookLine .}}&#10;{{range .Messages}}    {{.}}&#10;{{end}}{{range .Console}}    console: {{.}}&#10;{{end}}{{end}}{{end}}`
+
+// newHTMLTemplate parses tpl together with the shared partials.
+func newHTMLTemplate(name, tpl string) *template.Template {
+	t := template.Must(template.New(name).Funcs(funcMapCommon()).Parse(hooksPartial))
+	return template.Must(t.Parse(tpl))
+}
+
 // WriteHTMLDetailed renders a standalone HTML report with inline CSS
 // showing the suite summary and per-test results.
 func WriteHTMLDetailed(path string, rep DetailedReport) error {
+	rep = redactDetailed(rep)
 	const tpl = `<!DOCTYPE html>
 <html lang="en" data-theme="dark">
 <head>
@@ -237,6 +249,14 @@ func WriteHTMLDetailed(path string, rep DetailedReport) error {
       </div>
     </div>
 
+    {{if .Hooks}}
+    <div class="mt-4">
+      <details>
+        <summary class="text-sm">Suite hooks ({{len .Hooks}})</summary>
+        <pre class="mono" style="white-space:pre-wrap">{{template "hooks" .}}</pre>
+      </details>
+    </div>
+    {{end}}
     <div class="mt-4 overflow-x-auto">
       <table class="table table-zebra">
         <thead class="sticky">
@@ -252,7 +272,7 @@ func WriteHTMLDetailed(path string, rep DetailedReport) error {
         <tbody>
           {{range .TestCases}}
           <tr data-status="{{.Status}}">
-            <td class="mono">{{.Name}}</td>
+            <td class="mono">{{.Name}}{{if .Source}}<div class="opacity-60 text-xs">{{.Source}}</div>{{end}}</td>
             <td>{{.Stage}}</td>
             <td>{{range .Tags}}<span class="badge badge-ghost mr-1">{{.}}</span>{{end}}</td>
             <td>
@@ -269,6 +289,12 @@ func WriteHTMLDetailed(path string, rep DetailedReport) error {
                   <button class="btn btn-xs" onclick="navigator.clipboard.writeText(this.previousElementSibling.innerText);return false">Copy</button>
                 </details>
               {{end}}
+              {{if .Hooks}}
+                <details>
+                  <summary class="text-xs">hooks ({{len .Hooks}})</summary>
+                  <pre class="mono" style="white-space:pre-wrap">{{template "hooks" .}}</pre>
+                </details>
+              {{end}}
             </td>
           </tr>
           {{end}}
@@ -311,7 +337,7 @@ func WriteHTMLDetailed(path string, rep DetailedReport) error {
 </body>
 </html>`
 
-	t := template.Must(template.New("report").Funcs(funcMapCommon()).Parse(tpl))
+	t := newHTMLTemplate("report", tpl)
 
 	f, err := os.Create(path)
 	if err != nil {
@@ -323,6 +349,7 @@ func WriteHTMLDetailed(path string, rep DetailedReport) error {
 
 // WriteHTMLDetailedTo renders the detailed HTML report into the provided writer.
 func WriteHTMLDetailedTo(w io.Writer, rep DetailedReport) error {
+	rep = redactDetailed(rep)
 	const tpl = `<!DOCTYPE html>
 <html lang="en" data-theme="dark">
 <head>
@@ -349,6 +376,14 @@ func WriteHTMLDetailedTo(w io.Writer, rep DetailedReport) error {
         </div>
       </div>
     </div>
+    {{if .Hooks}}
+    <div class="mt-4">
+      <details>
+        <summary class="text-sm">Suite hooks ({{len .Hooks}})</summary>
+        <pre class="mono" style="white-space:pre-wrap">{{template "hooks" .}}</pre>
+      </details>
+    </div>
+    {{end}}
     <div class="mt-4 overflow-x-auto">
       <table class="table table-zebra">
         <thead class="sticky">
@@ -364,7 +399,7 @@ func WriteHTMLDetailedTo(w io.Writer, rep DetailedReport) error {
         <tbody>
           {{range .TestCases}}
           <tr data-status="{{.Status}}">
-            <td class="mono">{{.Name}}</td>
+            <td class="mono">{{.Name}}{{if .Source}}<div class="opacity-60 text-xs">{{.Source}}</div>{{end}}</td>
             <td>{{.Stage}}</td>
             <td>{{range .Tags}}<span class="badge badge-ghost mr-1">{{.}}</span>{{end}}</td>
             <td>
@@ -380,6 +415,12 @@ func WriteHTMLDetailedTo(w io.Writer, rep DetailedReport) error {
                   <pre class="mono" style="white-space:pre-wrap">{{range .Messages}}• {{.}}&#10;{{end}}</pre>
                 </details>
               {{end}}
+              {{if .Hooks}}
+                <details>
+                  <summary class="text-xs">hooks ({{len .Hooks}})</summary>
+                  <pre class="mono" style="white-space:pre-wrap">{{template "hooks" .}}</pre>
+                </details>
+              {{end}}
             </td>
           </tr>
           {{end}}
@@ -389,12 +430,13 @@ func WriteHTMLDetailedTo(w io.Writer, rep DetailedReport) error {
   </div>
 </body>
 </html>`
-	t := template.Must(template.New("report_inline").Funcs(funcMapCommon()).Parse(tpl))
+	t := newHTMLTemplate("report_inline", tpl)
 	return t.Execute(w, rep)
 }
 
 // WriteHTMLBatch renders a batch/run-level HTML report with per-suite rows.
 func WriteHTMLBatch(path string, br BatchReport) error {
+	br = redactBatch(br)
 	const tpl = `<!DOCTYPE html>
 <html lang="en" data-theme="dark">
 <head>
@@ -579,6 +621,31 @@ func WriteHTMLBatch(path string, br BatchReport) error {
       </table>
     </div>
     {{end}}
+    {{with .OpenAPI}}
+    <details class="collapse collapse-arrow border mb-4" id="openapiCoverage">
+      <summary class="collapse-title text-sm">OpenAPI coverage: <span class="font-semibold">{{.Covered}}/{{.Total}} operations ({{printf "%.1f" .Percent}}%)</span>, {{.Responses.Covered}}/{{.Responses.Total}} documented responses</summary>
+      <div class="collapse-content">
+        {{range .Specs}}
+        <div class="text-sm mt-2"><span class="mono">{{.File}}</span> — {{.Covered}}/{{.Total}} operations ({{printf "%.1f" .Percent}}%), {{.Responses.Covered}}/{{.Responses.Total}} responses</div>
+        <div class="overflow-x-auto">
+          <table class="table table-sm">
+            <thead><tr><th>Operation</th><th class="text-right">Hits</th><th>Responses</th><th>Parameters</th></tr></thead>
+            <tbody>
+              {{range .Operations}}
+              <tr data-status="{{if .Hits}}passed{{else}}failed{{end}}">
+                <td class="mono">{{if .Hits}}<span style="color: var(--success)">●</span>{{else}}<span style="color: var(--error)">○</span>{{end}} {{.Method}} {{.Path}}</td>
+                <td class="text-right">{{.Hits}}</td>
+                <td>{{range .Statuses}}<span class="badge mr-1" {{if not .Documented}}style="color: var(--warning)" title="not documented"{{else if .Hits}}style="color: var(--success)"{{else}}style="opacity:.6"{{end}}>{{.Code}}{{if .Hits}} ×{{.Hits}}{{end}}</span>{{end}}</td>
+                <td>{{range .Params}}<span class="badge badge-ghost mr-1" {{if not .Hits}}style="opacity:.6"{{end}} title="{{.In}}">{{.Name}}{{if .Hits}} ×{{.Hits}}{{end}}</span>{{end}}</td>
+              </tr>
+              {{end}}
+            </tbody>
+          </table>
+        </div>
+        {{end}}
+      </div>
+    </details>
+    {{end}}
     <div class="mt-2 overflow-x-auto">
       <table class="table table-zebra">
   <thead class="sticky"><tr><th>Suite</th><th class="text-right">Total</th><th class="text-right" style="color: var(--success)">Passed</th><th class="text-right" style="color: var(--error)">Failed</th><th class="text-right" style="color: var(--warning)">Skipped</th><th class="text-right">Duration</th></tr></thead>
@@ -610,7 +677,7 @@ func WriteHTMLBatch(path string, br BatchReport) error {
                         <tbody>
                           {{range $s.TestCases}}
                           <tr data-status="{{.Status}}">
-                            <td class="mono">{{.Name}}</td>
+                            <td class="mono">{{.Name}}{{if .Source}}<div class="opacity-60 text-xs">{{.Source}}</div>{{end}}</td>
                             <td>{{.Stage}}</td>
                             <td>{{range .Tags}}<span class="badge badge-ghost mr-1">{{.}}</span>{{end}}</td>
                             <td>
@@ -627,6 +694,12 @@ func WriteHTMLBatch(path string, br BatchReport) error {
                                   <button class="btn btn-xs" onclick="navigator.clipboard.writeText(this.previousElementSibling.innerText);return false">Copy</button>
                                 </details>
                               {{end}}
+                              {{if .Hooks}}
+                                <details>
+                                  <summary class="text-xs">hooks ({{len .Hooks}})</summary>
+                                  <pre class="mono" style="white-space:pre-wrap">{{template "hooks" .}}</pre>
+                                </details>
+                              {{end}}
                             </td>
                           </tr>
                           {{end}}
@@ -656,7 +729,7 @@ func WriteHTMLBatch(path string, br BatchReport) error {
   </div>
 </body>
 </html>`
-	t := template.Must(template.New("batch").Funcs(funcMapCommon()).Parse(tpl))
+	t := newHTMLTemplate("batch", tpl)
 	f, err := os.Create(path)
 	if err != nil {
 		return err
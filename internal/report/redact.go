@@ -0,0 +1,61 @@
+package report
+
+import "github.com/DrWeltschmerz/HydReq/internal/secrets"
+
+// The writers in this package redact registered secrets from names, messages
+// and hook output before rendering, so no report format can leak them.
+
+func redactCases(in []TestCase) []TestCase {
+	if len(in) == 0 {
+		return in
+	}
+	out := make([]TestCase, len(in))
+	for i, tc := range in {
+		tc.Name = secrets.Redact(tc.Name)
+		tc.Messages = secrets.RedactAll(tc.Messages)
+		tc.Hooks = redactHooks(tc.Hooks)
+		out[i] = tc
+	}
+	return out
+}
+
+func redactHooks(in []HookStep) []HookStep {
+	if len(in) == 0 {
+		return in
+	}
+	out := make([]HookStep, len(in))
+	for i, h := range in {
+		h.Name = secrets.Redact(h.Name)
+		h.Console = secrets.RedactAll(h.Console)
+		h.Messages = secrets.RedactAll(h.Messages)
+		out[i] = h
+	}
+	return out
+}
+
+func redactDetailed(rep DetailedReport) DetailedReport {
+	rep.Suite = secrets.Redact(rep.Suite)
+	rep.TestCases = redactCases(rep.TestCases)
+	rep.Hooks = redactHooks(rep.Hooks)
+	return rep
+}
+
+func redactBatch(br BatchReport) BatchReport {
+	if len(br.Suites) > 0 {
+		suites := make([]DetailedReport, len(br.Suites))
+		for i, s := range br.Suites {
+			suites[i] = redactDetailed(s)
+		}
+		br.Suites = suites
+	}
+	if len(br.NotRun) > 0 {
+		notRun := make([]NotRunInfo, len(br.NotRun))
+		for i, n := range br.NotRun {
+			n.Error = secrets.Redact(n.Error)
+			n.ValidationError = secrets.Redact(n.ValidationError)
+			notRun[i] = n
+		}
+		br.NotRun = notRun
+	}
+	return br
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/DrWeltschmerz/HydReq/internal/secrets"
 	"github.com/DrWeltschmerz/HydReq/internal/ui"
 )
 
@@ -20,18 +21,61 @@ type Summary struct {
 }
 
 type TestCase struct {
-	Name       string   `json:"name"`
-	Stage      int      `json:"stage"`
-	Tags       []string `json:"tags,omitempty"`
-	Status     string   `json:"status"`
-	DurationMs int64    `json:"durationMs,omitempty"`
-	Messages   []string `json:"messages,omitempty"`
+	Name       string     `json:"name"`
+	Stage      int        `json:"stage"`
+	Tags       []string   `json:"tags,omitempty"`
+	Source     string     `json:"source,omitempty"` // included suite file the test came from
+	Status     string     `json:"status"`
+	DurationMs int64      `json:"durationMs,omitempty"`
+	Messages   []string   `json:"messages,omitempty"`
+	Hooks      []HookStep `json:"hooks,omitempty"`
+}
+
+// HookStep records a pre/post (or preSuite/postSuite) hook execution.
+type HookStep struct {
+	Name         string   `json:"name"`
+	Scope        string   `json:"scope"`
+	Status       string   `json:"status"`
+	DurationMs   int64    `json:"durationMs,omitempty"`
+	HTTPStatus   int      `json:"httpStatus,omitempty"`
+	RowsAffected int64    `json:"rowsAffected,omitempty"`
+	Console      []string `json:"console,omitempty"`
+	Messages     []string `json:"messages,omitempty"`
 }
 
 type DetailedReport struct {
 	Suite     string     `json:"suite"`
 	Summary   Summary    `json:"summary"`
 	TestCases []TestCase `json:"tests"`
+	Hooks     []HookStep `json:"hooks,omitempty"` // preSuite/postSuite hooks
+}
+
+// HookLine renders a hook step as a single human-readable line.
+func HookLine(h HookStep) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "[%s] %s: %s (%d ms)", h.Scope, h.Name, h.Status, h.DurationMs)
+	if h.HTTPStatus != 0 {
+		fmt.Fprintf(b, " http=%d", h.HTTPStatus)
+	}
+	if h.RowsAffected != 0 {
+		fmt.Fprintf(b, " rows=%d", h.RowsAffected)
+	}
+	return b.String()
+}
+
+// hookLines renders hooks with their messages and console output, one entry per line.
+func hookLines(hooks []HookStep) []string {
+	out := make([]string, 0, len(hooks))
+	for _, h := range hooks {
+		out = append(out, HookLine(h))
+		for _, m := range h.Messages {
+			out = append(out, "  "+m)
+		}
+		for _, c := range h.Console {
+			out = append(out, "  console: "+c)
+		}
+	}
+	return out
 }
 
 // BatchReport represents an aggregated run across multiple suites.
@@ -41,6 +85,7 @@ type BatchReport struct {
 	Summary Summary          `json:"summary"`
 	Suites  []DetailedReport `json:"suites,omitempty"`
 	NotRun  []NotRunInfo     `json:"notRun,omitempty"`
+	OpenAPI *OpenAPICoverage `json:"openapiCoverage,omitempty"`
 }
 
 // NotRunInfo captures suites that were discovered but not executed
@@ -63,6 +108,7 @@ func WriteJSONSummary(path string, sum Summary) error {
 }
 
 func WriteJSONDetailed(path string, rep DetailedReport) error {
+	rep = redactDetailed(rep)
 	f, err := os.Create(path)
 	if err != nil {
 		return err
@@ -75,6 +121,7 @@ func WriteJSONDetailed(path string, rep DetailedReport) error {
 
 // WriteJSONDetailedTo writes the JSON detailed report to an io.Writer (streaming).
 func WriteJSONDetailedTo(w io.Writer, rep DetailedReport) error {
+	rep = redactDetailed(rep)
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(rep)
@@ -85,16 +132,17 @@ func WriteJUnitSummary(path string, sum Summary, suiteName string) error {
 	xml := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <testsuite name="%s" tests="%d" failures="%d" skipped="%d" time="%0.3f">
 </testsuite>
-`, suiteName, sum.Total, sum.Failed, sum.Skipped, sum.Duration.Seconds())
+`, xmlEscape(secrets.Redact(suiteName)), sum.Total, sum.Failed, sum.Skipped, sum.Duration.Seconds())
 	return os.WriteFile(path, []byte(xml), 0644)
 }
 
 func WriteJUnitDetailed(path string, suite string, sum Summary, tests []TestCase) error {
+	tests = redactCases(tests)
 	b := &strings.Builder{}
 	fmt.Fprintf(b, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
-	fmt.Fprintf(b, "<testsuite name=\"%s\" tests=\"%d\" failures=\"%d\" skipped=\"%d\" time=\"%0.3f\">\n", suite, sum.Total, sum.Failed, sum.Skipped, sum.Duration.Seconds())
+	fmt.Fprintf(b, "<testsuite name=\"%s\" tests=\"%d\" failures=\"%d\" skipped=\"%d\" time=\"%0.3f\">\n", xmlEscape(secrets.Redact(suite)), sum.Total, sum.Failed, sum.Skipped, sum.Duration.Seconds())
 	for _, tc := range tests {
-		fmt.Fprintf(b, "  <testcase name=\"%s\" time=\"%0.3f\">\n", xmlEscape(tc.Name), float64(tc.DurationMs)/1000.0)
+		fmt.Fprintf(b, "  <testcase name=\"%s\"%s time=\"%0.3f\">\n", xmlEscape(tc.Name), fileAttr(tc.Source), float64(tc.DurationMs)/1000.0)
 		switch tc.Status {
 		case "skipped":
 			fmt.Fprintf(b, "    <skipped/>\n")
@@ -105,6 +153,9 @@ func WriteJUnitDetailed(path string, suite string, sum Summary, tests []TestCase
 			}
 			fmt.Fprintf(b, "    <failure message=\"%s\"/>\n", msg)
 		}
+		if len(tc.Hooks) > 0 {
+			fmt.Fprintf(b, "    <system-out>%s</system-out>\n", xmlEscape(strings.Join(hookLines(tc.Hooks), "\n")))
+		}
 		fmt.Fprintf(b, "  </testcase>\n")
 	}
 	fmt.Fprintf(b, "</testsuite>\n")
@@ -113,11 +164,12 @@ func WriteJUnitDetailed(path string, suite string, sum Summary, tests []TestCase
 
 // WriteJUnitDetailedTo writes JUnit XML for a detailed report to an io.Writer.
 func WriteJUnitDetailedTo(w io.Writer, suite string, sum Summary, tests []TestCase) error {
+	tests = redactCases(tests)
 	b := &strings.Builder{}
 	fmt.Fprintf(b, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
-	fmt.Fprintf(b, "<testsuite name=\"%s\" tests=\"%d\" failures=\"%d\" skipped=\"%d\" time=\"%0.3f\">\n", suite, sum.Total, sum.Failed, sum.Skipped, sum.Duration.Seconds())
+	fmt.Fprintf(b, "<testsuite name=\"%s\" tests=\"%d\" failures=\"%d\" skipped=\"%d\" time=\"%0.3f\">\n", xmlEscape(secrets.Redact(suite)), sum.Total, sum.Failed, sum.Skipped, sum.Duration.Seconds())
 	for _, tc := range tests {
-		fmt.Fprintf(b, "  <testcase name=\"%s\" time=\"%0.3f\">\n", xmlEscape(tc.Name), float64(tc.DurationMs)/1000.0)
+		fmt.Fprintf(b, "  <testcase name=\"%s\"%s time=\"%0.3f\">\n", xmlEscape(tc.Name), fileAttr(tc.Source), float64(tc.DurationMs)/1000.0)
 		switch tc.Status {
 		case "skipped":
 			fmt.Fprintf(b, "    <skipped/>\n")
@@ -128,6 +180,9 @@ func WriteJUnitDetailedTo(w io.Writer, suite string, sum Summary, tests []TestCa
 			}
 			fmt.Fprintf(b, "    <failure message=\"%s\"/>\n", msg)
 		}
+		if len(tc.Hooks) > 0 {
+			fmt.Fprintf(b, "    <system-out>%s</system-out>\n", xmlEscape(strings.Join(hookLines(tc.Hooks), "\n")))
+		}
 		fmt.Fprintf(b, "  </testcase>\n")
 	}
 	fmt.Fprintf(b, "</testsuite>\n")
@@ -135,6 +190,14 @@ func WriteJUnitDetailedTo(w io.Writer, suite string, sum Summary, tests []TestCa
 	return err
 }
 
+// fileAttr renders the JUnit file attribute for tests pulled in via include.
+func fileAttr(source string) string {
+	if source == "" {
+		return ""
+	}
+	return fmt.Sprintf(" file=\"%s\"", xmlEscape(source))
+}
+
 func xmlEscape(s string) string {
 	r := strings.NewReplacer(
 		"&", "&amp;",
@@ -156,6 +219,7 @@ func PrintToConsole(sum Summary) {
 }
 
 func WriteJSONBatch(path string, br BatchReport) error {
+	br = redactBatch(br)
 	f, err := os.Create(path)
 	if err != nil {
 		return err
@@ -168,6 +232,7 @@ func WriteJSONBatch(path string, br BatchReport) error {
 
 // WriteJSONBatchTo writes the batch report to an io.Writer (streaming).
 func WriteJSONBatchTo(w io.Writer, br BatchReport) error {
+	br = redactBatch(br)
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(br)
@@ -2,8 +2,11 @@ package report
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/secrets"
 )
 
 func TestWriteJSONSummary(t *testing.T) {
@@ -92,3 +95,114 @@ func TestWriteHTMLDetailed(t *testing.T) {
 		t.Fatalf("html output missing expected content: %s", s)
 	}
 }
+
+func TestWriteJUnitDetailed_HookSystemOut(t *testing.T) {
+	var b strings.Builder
+	tests := []TestCase{{Name: "t", Status: "passed", Hooks: []HookStep{
+		{Name: "seed", Scope: "pre", Status: "passed", DurationMs: 3, RowsAffected: 2, Console: []string{"hi <there>"}},
+	}}}
+	if err := WriteJUnitDetailedTo(&b, "suite", Summary{Total: 1, Passed: 1}, tests); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if !strings.Contains(out, "<system-out>[pre] seed: passed (3 ms) rows=2") || !strings.Contains(out, "console: hi &lt;there&gt;") {
+		t.Fatalf("missing hook system-out: %s", out)
+	}
+}
+
+func TestWriteHTML_Hooks(t *testing.T) {
+	hook := func(name string) []HookStep {
+		return []HookStep{{Name: name, Scope: "pre", Status: "failed", Messages: []string{"boom"}, Console: []string{"hi <there>"}}}
+	}
+	rep := DetailedReport{Suite: "suite", Hooks: hook("seed"), TestCases: []TestCase{{Name: "t", Status: "passed", Hooks: hook("login")}}}
+	var detailed, batch strings.Builder
+	if err := WriteHTMLDetailedTo(&detailed, rep); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteHTMLBatchTo(&batch, BatchReport{Suites: []DetailedReport{rep}}); err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(t.TempDir(), "report.html")
+	if err := WriteHTMLDetailed(f, rep); err != nil {
+		t.Fatal(err)
+	}
+	file, _ := os.ReadFile(f)
+	line := func(name string) string {
+		return "• [pre] " + name + ": failed (0 ms)&#10;    boom&#10;    console: hi &lt;there&gt;"
+	}
+	// batch reports list test hooks only
+	for name, out := range map[string]string{"file": string(file), "inline": detailed.String(), "batch": batch.String()} {
+		if !strings.Contains(out, line("login")) || (name != "batch" && !strings.Contains(out, line("seed"))) {
+			t.Fatalf("%s report missing hooks: %s", name, out)
+		}
+	}
+}
+
+func TestWriteJUnitDetailed_SourceFileAttr(t *testing.T) {
+	var b strings.Builder
+	tests := []TestCase{{Name: "shared", Status: "passed", Source: "common/login.yaml"}, {Name: "own", Status: "passed"}}
+	if err := WriteJUnitDetailedTo(&b, "suite", Summary{Total: 2, Passed: 2}, tests); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if !strings.Contains(out, `<testcase name="shared" file="common/login.yaml" time=`) || !strings.Contains(out, `<testcase name="own" time=`) {
+		t.Fatalf("unexpected file attributes: %s", out)
+	}
+}
+
+func TestWriters_RedactSecrets(t *testing.T) {
+	secrets.Reset()
+	defer secrets.Reset()
+	secrets.Register("s3cr3t-token")
+	tests := []TestCase{{Name: "t", Status: "failed", Messages: []string{"header Authorization: Bearer s3cr3t-token"}, Hooks: []HookStep{
+		{Name: "login", Scope: "pre", Status: "passed", Console: []string{"got s3cr3t-token"}},
+	}}}
+	var junit, js strings.Builder
+	if err := WriteJUnitDetailedTo(&junit, "suite", Summary{Total: 1, Failed: 1}, tests); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteJSONDetailedTo(&js, DetailedReport{Suite: "suite", TestCases: tests}); err != nil {
+		t.Fatal(err)
+	}
+	for name, out := range map[string]string{"junit": junit.String(), "json": js.String()} {
+		if strings.Contains(out, "s3cr3t-token") || !strings.Contains(out, secrets.Mask) {
+			t.Fatalf("%s output not redacted: %s", name, out)
+		}
+	}
+	if tests[0].Messages[0] != "header Authorization: Bearer s3cr3t-token" {
+		t.Fatal("writers must not mutate the caller's test cases")
+	}
+}
+
+func TestWriteJUnit_SuiteNameRedactedAndEscaped(t *testing.T) {
+	secrets.Reset()
+	defer secrets.Reset()
+	secrets.Register("s3cr3t-token")
+	suite := `orders <s3cr3t-token> "&"`
+	want := `<testsuite name="orders &lt;` + secrets.Mask + `&gt; &quot;&amp;&quot;"`
+	var streamed strings.Builder
+	if err := WriteJUnitDetailedTo(&streamed, suite, Summary{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	detailed, summary := filepath.Join(dir, "detailed.xml"), filepath.Join(dir, "summary.xml")
+	if err := WriteJUnitDetailed(detailed, suite, Summary{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteJUnitSummary(summary, Summary{}, suite); err != nil {
+		t.Fatal(err)
+	}
+	outputs := map[string]string{"streamed": streamed.String()}
+	for name, path := range map[string]string{"detailed": detailed, "summary": summary} {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs[name] = string(b)
+	}
+	for name, out := range outputs {
+		if !strings.Contains(out, want) {
+			t.Fatalf("%s: suite name not redacted and escaped: %s", name, out)
+		}
+	}
+}
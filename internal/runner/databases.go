@@ -0,0 +1,241 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DrWeltschmerz/HydReq/internal/secrets"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// TransactionRollbackAfterTest wraps a test's SQL in a transaction per
+// database that is rolled back once the test and its hooks finish.
+const TransactionRollbackAfterTest = "rollbackAfterTest"
+
+// sqlConn is what SQL steps need from a *sql.DB or *sql.Tx.
+type sqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// dbPool lazily opens each database once per suite run. Named connections
+// come from suite `databases:`; inline driver/dsn pairs are pooled too.
+type dbPool struct {
+	mu    sync.Mutex
+	suite *models.Suite
+	dbs   map[string]*sql.DB
+}
+
+func newDBPool(s *models.Suite) *dbPool {
+	return &dbPool{suite: s, dbs: map[string]*sql.DB{}}
+}
+
+// get returns the pooled connection for a SQL step and the key identifying it.
+func (p *dbPool) get(h *models.SQLHook, vars map[string]string) (*sql.DB, string, error) {
+	drv, dsn, poolSize, key, err := sqlTarget(p.suite, h, vars)
+	if err != nil {
+		return nil, "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if db, ok := p.dbs[key]; ok {
+		return db, key, nil
+	}
+	db, err := sql.Open(drv, dsn)
+	if err != nil {
+		return nil, "", err
+	}
+	if poolSize > 0 {
+		db.SetMaxOpenConns(poolSize)
+		db.SetMaxIdleConns(poolSize)
+	}
+	p.dbs[key] = db
+	return db, key, nil
+}
+
+// Close closes every connection opened during the run.
+func (p *dbPool) Close() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k, db := range p.dbs {
+		_ = db.Close()
+		delete(p.dbs, k)
+	}
+}
+
+// sqlTarget resolves the driver, DSN and pool size for a SQL step, either from
+// a named suite database (sql.db) or the step's own driver/dsn.
+func sqlTarget(s *models.Suite, h *models.SQLHook, vars map[string]string) (drv, dsn string, poolSize int, key string, err error) {
+	if h.DB != "" {
+		var d models.Database
+		ok := false
+		if s != nil {
+			d, ok = s.Databases[h.DB]
+		}
+		if !ok {
+			return "", "", 0, "", fmt.Errorf("unknown database %q: not defined in suite databases", h.DB)
+		}
+		drv, dsn, poolSize, key = interpolate(d.Driver, vars), interpolate(d.DSN, vars), d.PoolSize, "db:"+h.DB
+	} else {
+		drv, dsn = interpolate(h.Driver, vars), interpolate(h.DSN, vars)
+		key = drv + "\x00" + dsn
+	}
+	if drv == "" || dsn == "" {
+		return "", "", 0, "", fmt.Errorf("sql: driver and dsn are required (or set db to a suite database)")
+	}
+	if err := checkDriver(drv); err != nil {
+		return "", "", 0, "", err
+	}
+	secrets.RegisterDSN(dsn)
+	return drv, dsn, poolSize, key, nil
+}
+
+// CheckDatabases reports SQL steps whose db is not declared in suite `databases:`,
+// drivers that are not registered, and tests with an unknown transaction mode.
+func CheckDatabases(s *models.Suite) error {
+	for _, name := range sortedKeys(s.Databases) {
+		if err := checkStaticDriver(s.Databases[name].Driver); err != nil {
+			return fmt.Errorf("database %q: %w", name, err)
+		}
+	}
+	check := func(where string, h *models.SQLHook) error {
+		if h == nil {
+			return nil
+		}
+		if h.DB == "" {
+			if err := checkStaticDriver(h.Driver); err != nil {
+				return fmt.Errorf("%s: %w", where, err)
+			}
+			return nil
+		}
+		if _, ok := s.Databases[h.DB]; !ok {
+			return fmt.Errorf("%s: unknown database %q: not defined in suite databases", where, h.DB)
+		}
+		return nil
+	}
+	checkHooks := func(where string, hooks []models.Hook) error {
+		for _, h := range hooks {
+			if err := check(fmt.Sprintf("%s hook %q", where, hookName(h)), h.SQL); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := checkHooks("preSuite", s.PreSuite); err != nil {
+		return err
+	}
+	if err := checkHooks("postSuite", s.PostSuite); err != nil {
+		return err
+	}
+	for _, t := range s.Tests {
+		where := fmt.Sprintf("test %q", t.Name)
+		if t.Transaction != "" && t.Transaction != TransactionRollbackAfterTest {
+			return fmt.Errorf("%s: unknown transaction mode %q (want %s)", where, t.Transaction, TransactionRollbackAfterTest)
+		}
+		if err := check(where, t.SQL); err != nil {
+			return err
+		}
+		if err := checkHooks(where+" pre", t.Pre); err != nil {
+			return err
+		}
+		if err := checkHooks(where+" post", t.Post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkStaticDriver checks driver names that do not depend on variables.
+func checkStaticDriver(name string) error {
+	if name == "" || strings.Contains(name, "${") {
+		return nil
+	}
+	return checkDriver(name)
+}
+
+func sortedKeys(m map[string]models.Database) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// testTx holds the open transactions of a test running with
+// transaction: rollbackAfterTest, one per database it touches.
+type testTx struct {
+	mu   sync.Mutex
+	ctx  context.Context // test-scoped: step timeouts must not end the transaction
+	pool *dbPool
+	txs  map[string]*sql.Tx
+}
+
+// conn returns the transaction for the step's database, beginning it on first use.
+func (t *testTx) conn(h *models.SQLHook, vars map[string]string) (sqlConn, error) {
+	db, key, err := t.pool.get(h, vars)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tx, ok := t.txs[key]; ok {
+		return tx, nil
+	}
+	tx, err := db.BeginTx(t.ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	t.txs[key] = tx
+	return tx, nil
+}
+
+// rollback discards everything the test wrote through its transactions.
+func (t *testTx) rollback() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k, tx := range t.txs {
+		_ = tx.Rollback()
+		delete(t.txs, k)
+	}
+}
+
+// withTestTransaction returns opts scoped to one test. When the test asks for
+// rollbackAfterTest, its SQL runs in transactions rolled back by the returned func.
+func withTestTransaction(ctx context.Context, t models.TestCase, opts Options) (Options, func()) {
+	if t.Transaction != TransactionRollbackAfterTest || opts.dbs == nil {
+		return opts, func() {}
+	}
+	tx := &testTx{ctx: ctx, pool: opts.dbs, txs: map[string]*sql.Tx{}}
+	opts.tx = tx
+	return opts, tx.rollback
+}
+
+// sqlConnFor picks the connection a SQL step runs on: the test transaction,
+// the run's pool, or (outside RunSuite) a connection opened for this step only.
+func sqlConnFor(s *models.Suite, h *models.SQLHook, vars map[string]string, opts Options) (sqlConn, func(), error) {
+	if opts.tx != nil {
+		c, err := opts.tx.conn(h, vars)
+		return c, func() {}, err
+	}
+	if opts.dbs != nil {
+		db, _, err := opts.dbs.get(h, vars)
+		return db, func() {}, err
+	}
+	drv, dsn, _, _, err := sqlTarget(s, h, vars)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := sql.Open(drv, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, func() { _ = db.Close() }, nil
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestRunSuite_NamedDatabaseAndRollback(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "named.sqlite")
+	zero, one := 0, 1
+	s := &models.Suite{
+		Name:      "databases",
+		Variables: map[string]string{"who": "o'brien"},
+		Databases: map[string]models.Database{"main": {Driver: "sqlite", DSN: dsn, PoolSize: 1}},
+		PreSuite: []models.Hook{
+			{SQL: &models.SQLHook{DB: "main", Query: `CREATE TABLE people (name TEXT)`}},
+		},
+		Tests: []models.TestCase{
+			{
+				Name:        "isolated insert",
+				Stage:       0,
+				Transaction: TransactionRollbackAfterTest,
+				Pre: []models.Hook{
+					{SQL: &models.SQLHook{DB: "main", Query: `INSERT INTO people (name) VALUES (?)`, Args: []any{"${who}"}}},
+				},
+				SQL: &models.SQLHook{DB: "main", Query: `SELECT name FROM people WHERE name = ?`, Args: []any{"${who}"},
+					Assert: &models.SQLAssertions{RowCount: &one, Rows: []models.SQLRowAssert{{Equals: map[string]any{"name": "o'brien"}}}}},
+			},
+			{
+				Name:  "rolled back",
+				Stage: 1,
+				SQL:   &models.SQLHook{DB: "main", Query: `SELECT name FROM people`, Assert: &models.SQLAssertions{RowCount: &zero}},
+			},
+		},
+	}
+	var results []TestResult
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sum, err := RunSuite(ctx, s, Options{Workers: 1, OnResult: func(tr TestResult) { results = append(results, tr) }})
+	if err != nil {
+		t.Fatalf("run: %v (results: %+v)", err, results)
+	}
+	if sum.Passed != 2 {
+		t.Fatalf("summary = %+v, results = %+v", sum, results)
+	}
+}
+
+func TestCheckDatabases(t *testing.T) {
+	s := &models.Suite{
+		Databases: map[string]models.Database{"main": {Driver: "sqlite", DSN: "file::memory:"}},
+		Tests: []models.TestCase{{Name: "t", Post: []models.Hook{
+			{Name: "cleanup", SQL: &models.SQLHook{DB: "reporting", Query: "DELETE FROM x"}},
+		}}},
+	}
+	err := CheckDatabases(s)
+	if err == nil || !strings.Contains(err.Error(), `test "t" post hook "cleanup": unknown database "reporting"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.Tests[0].Post[0].SQL.DB = "main"
+	s.Tests[0].Transaction = "commit"
+	if err := CheckDatabases(s); err == nil || !strings.Contains(err.Error(), "unknown transaction mode") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -3,9 +3,12 @@ package runner
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"os"
 	"testing"
 	"time"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
 
 // TestPGIntegration runs only when PG_DSN is set.
@@ -63,3 +66,73 @@ func TestMSSQLIntegration(t *testing.T) {
 		t.Fatalf("expected rows > 0, got %d", cnt)
 	}
 }
+
+// TestMySQLIntegration runs only when MYSQL_DSN is set, e.g. to
+// root:pass@tcp(localhost:3306)/test for `docker run -e MYSQL_ROOT_PASSWORD=pass
+// -e MYSQL_DATABASE=test -p 3306:3306 mysql:8`. It also runs SQL steps with
+// placeholders against the server.
+func TestMySQLIntegration(t *testing.T) {
+	dsn := os.Getenv("MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_DSN not set; skipping MySQL integration test")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("mysql open: %v", err)
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS qa_ci_items(id INT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(100))`); err != nil {
+		t.Fatalf("mysql create: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO qa_ci_items(name) VALUES ('a')`); err != nil {
+		t.Fatalf("mysql insert: %v", err)
+	}
+	var cnt int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM qa_ci_items`).Scan(&cnt); err != nil {
+		t.Fatalf("mysql select: %v", err)
+	}
+	if cnt <= 0 {
+		t.Fatalf("expected rows > 0, got %d", cnt)
+	}
+
+	table := fmt.Sprintf("qa_ci_people_%d", time.Now().UnixNano())
+	one, two := 1, 2
+	affected := int64(2)
+	s := &models.Suite{
+		Name:      "mysql",
+		Variables: map[string]string{"who": "o'brien"},
+		Databases: map[string]models.Database{"main": {Driver: "mysql", DSN: dsn}},
+		PreSuite: []models.Hook{
+			{SQL: &models.SQLHook{DB: "main", Query: "CREATE TABLE " + table + " (id INT PRIMARY KEY, name VARCHAR(64))"}},
+		},
+		PostSuite: []models.Hook{
+			{SQL: &models.SQLHook{DB: "main", Query: "DROP TABLE " + table}},
+		},
+		Tests: []models.TestCase{
+			{
+				Name: "insert with placeholders",
+				SQL: &models.SQLHook{DB: "main", Query: "INSERT INTO " + table + " (id, name) VALUES (?, ?), (?, ?)", Args: []any{1, "${who}", 2, "ada"},
+					Assert: &models.SQLAssertions{RowsAffected: &affected}},
+			},
+			{
+				Name:  "select with placeholders",
+				Stage: 1,
+				SQL: &models.SQLHook{DB: "main", Query: "SELECT id, name FROM " + table + " WHERE name = ?", Args: []any{"${who}"},
+					Extract: map[string]string{"personId": "id"},
+					Assert:  &models.SQLAssertions{RowCount: &one, Rows: []models.SQLRowAssert{{Equals: map[string]any{"id": 1, "name": "o'brien"}}}}},
+			},
+			{
+				Name:  "count",
+				Stage: 2,
+				SQL:   &models.SQLHook{DB: "main", Query: "SELECT COUNT(*) AS n FROM " + table + " WHERE id >= ?", Args: []any{"${personId}"}, Assert: &models.SQLAssertions{Rows: []models.SQLRowAssert{{Equals: map[string]any{"n": two}}}}},
+			},
+		},
+	}
+	var results []TestResult
+	sum, err := RunSuite(ctx, s, Options{Workers: 1, OnResult: func(tr TestResult) { results = append(results, tr) }})
+	if err != nil || sum.Passed != 3 {
+		t.Fatalf("run: %v, summary = %+v, results = %+v", err, sum, results)
+	}
+}
@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// echoDriver answers every query with one row holding the query text.
+type echoDriver struct{}
+
+func (echoDriver) Open(string) (driver.Conn, error) { return echoConn{}, nil }
+
+type echoConn struct{}
+
+func (echoConn) Prepare(q string) (driver.Stmt, error) { return echoStmt{q}, nil }
+func (echoConn) Close() error                          { return nil }
+func (echoConn) Begin() (driver.Tx, error)             { return echoTx{}, nil }
+
+type echoTx struct{}
+
+func (echoTx) Commit() error   { return nil }
+func (echoTx) Rollback() error { return nil }
+
+type echoStmt struct{ q string }
+
+func (echoStmt) Close() error                               { return nil }
+func (echoStmt) NumInput() int                              { return -1 }
+func (echoStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (s echoStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &echoRows{vals: []string{s.q}}, nil
+}
+
+type echoRows struct{ vals []string }
+
+func (*echoRows) Columns() []string { return []string{"query"} }
+func (*echoRows) Close() error      { return nil }
+func (r *echoRows) Next(dest []driver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	dest[0], r.vals = r.vals[0], r.vals[1:]
+	return nil
+}
+
+func TestRegisterDriver(t *testing.T) {
+	if err := RegisterDriver(DriverInfo{Name: "hydreq-echo-missing"}); err == nil {
+		t.Fatal("expected error for a driver unknown to database/sql")
+	}
+	sql.Register("hydreq-echo", echoDriver{})
+	if err := RegisterDriver(DriverInfo{Name: "hydreq-echo", DSNTemplate: "echo://"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	found := false
+	for _, d := range Drivers() {
+		if d.Name == "hydreq-echo" {
+			found = d.Label == "hydreq-echo" && d.DSNTemplate == "echo://"
+		}
+	}
+	if !found {
+		t.Fatalf("registered driver not listed: %+v", Drivers())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	vars := map[string]string{}
+	h := models.Hook{SQL: &models.SQLHook{Driver: "hydreq-echo", DSN: "echo://", Query: "SELECT 'hi'", Extract: map[string]string{"q": "query"}}}
+	if err := runHook(ctx, &models.Suite{}, &vars, h, Options{}); err != nil {
+		t.Fatalf("hook: %v", err)
+	}
+	if vars["q"] != "SELECT 'hi'" {
+		t.Fatalf("extracted %q", vars["q"])
+	}
+}
+
+func TestCheckDatabases_UnknownDriver(t *testing.T) {
+	s := &models.Suite{Databases: map[string]models.Database{"main": {Driver: "oracle", DSN: "x"}}}
+	err := CheckDatabases(s)
+	if err == nil || !strings.Contains(err.Error(), `database "main": unknown sql driver "oracle" (available:`) || !strings.Contains(err.Error(), "mysql") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.Databases["main"] = models.Database{Driver: "${DB_DRIVER}", DSN: "x"}
+	if err := CheckDatabases(s); err != nil {
+		t.Fatalf("interpolated drivers are checked at run time: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Variable sources, lowest precedence first.
+const (
+	SourceOS      = "os"
+	SourceSuite   = "suite"
+	SourceProfile = "profile"
+	SourceCLI     = "cli"
+)
+
+// ResolvedVar is a variable after layering, with the layer that supplied it.
+type ResolvedVar struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// envRefPattern finds ${NAME} and ${ENV:NAME} references that may be satisfied by the OS environment.
+var envRefPattern = regexp.MustCompile(`\$\{(?:ENV:)?([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadProfile returns the variables of the named environment profile. Inline
+// suite `environments:` entries win over a `<profile>.env.yaml` file looked up
+// next to the suite and then in the working directory. A profile ending in
+// .env.yaml is read as a file path directly.
+func LoadProfile(s *models.Suite, suitePath, profile string) (map[string]string, error) {
+	if profile == "" {
+		return nil, nil
+	}
+	if strings.HasSuffix(profile, ".env.yaml") {
+		return LoadEnvFile(profile)
+	}
+	out := map[string]string{}
+	found := false
+	candidates := []string{profile + ".env.yaml"}
+	if suitePath != "" {
+		candidates = append([]string{filepath.Join(filepath.Dir(suitePath), profile+".env.yaml")}, candidates...)
+	}
+	for _, p := range candidates {
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		vals, err := LoadEnvFile(p)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range vals {
+			out[k] = v
+		}
+		found = true
+		break
+	}
+	if inline, ok := s.Environments[profile]; ok {
+		for k, v := range inline {
+			out[k] = v
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown environment %q: not in suite environments and no %s.env.yaml found", profile, profile)
+	}
+	return out, nil
+}
+
+// LoadEnvFile reads a flat `name: value` YAML map.
+func LoadEnvFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var vals map[string]string
+	if err := yaml.Unmarshal(b, &vals); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return vals, nil
+}
+
+// ResolveVariables layers suite vars, the selected profile and CLI overrides
+// (CLI > profile > suite). OS environment values are listed for names the suite
+// references but no other layer defines, since interpolation falls back to them.
+func ResolveVariables(s *models.Suite, suitePath, profile string, overrides map[string]string) ([]ResolvedVar, error) {
+	prof, err := LoadProfile(s, suitePath, profile)
+	if err != nil {
+		return nil, err
+	}
+	vals := map[string]ResolvedVar{}
+	for k, v := range s.Variables {
+		vals[k] = ResolvedVar{Name: k, Value: v, Source: SourceSuite}
+	}
+	for k, v := range prof {
+		vals[k] = ResolvedVar{Name: k, Value: v, Source: SourceProfile}
+	}
+	for k, v := range overrides {
+		vals[k] = ResolvedVar{Name: k, Value: v, Source: SourceCLI}
+	}
+	if b, err := yaml.Marshal(s); err == nil {
+		for _, m := range envRefPattern.FindAllStringSubmatch(string(b), -1) {
+			name := m[1]
+			if _, ok := vals[name]; ok {
+				continue
+			}
+			if v, ok := os.LookupEnv(name); ok {
+				vals[name] = ResolvedVar{Name: name, Value: v, Source: SourceOS}
+			}
+		}
+	}
+	out := make([]ResolvedVar, 0, len(vals))
+	for _, v := range vals {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// ApplyEnvironment replaces the suite vars with the layered result of
+// ResolveVariables, so RunSuite sees profile and CLI values.
+func ApplyEnvironment(s *models.Suite, suitePath, profile string, overrides map[string]string) error {
+	if profile == "" && len(overrides) == 0 {
+		return nil
+	}
+	resolved, err := ResolveVariables(s, suitePath, profile, overrides)
+	if err != nil {
+		return err
+	}
+	vars := make(map[string]string, len(resolved))
+	for _, v := range resolved {
+		if v.Source != SourceOS {
+			vars[v.Name] = v.Value
+		}
+	}
+	s.Variables = vars
+	return nil
+}
+
+// ParseVarFlags turns repeated k=v flag values into a map.
+func ParseVarFlags(kvs []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, kv := range kvs {
+		k, v, ok := strings.Cut(kv, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected key=value", kv)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestResolveVariables_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	suitePath := filepath.Join(dir, "s.hrq.yaml")
+	if err := os.WriteFile(filepath.Join(dir, "qa.env.yaml"), []byte("fromFile: file\nshared: file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HYDREQ_TEST_OS_ONLY", "os-value")
+	t.Setenv("shared", "os-shadowed")
+	s := &models.Suite{
+		Variables: map[string]string{"shared": "suite", "suiteOnly": "suite", "cliWins": "suite"},
+		Environments: map[string]map[string]string{
+			"qa": {"shared": "profile", "cliWins": "profile"},
+		},
+		Tests: []models.TestCase{{Name: "t", Request: models.Request{URL: "/x/${HYDREQ_TEST_OS_ONLY}"}}},
+	}
+	got, err := ResolveVariables(s, suitePath, "qa", map[string]string{"cliWins": "cli"})
+	if err != nil {
+		t.Fatalf("ResolveVariables: %v", err)
+	}
+	want := map[string][2]string{
+		"cliWins":             {"cli", SourceCLI},
+		"fromFile":            {"file", SourceProfile},
+		"shared":              {"profile", SourceProfile},
+		"suiteOnly":           {"suite", SourceSuite},
+		"HYDREQ_TEST_OS_ONLY": {"os-value", SourceOS},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d vars: %+v", len(got), got)
+	}
+	for _, v := range got {
+		w, ok := want[v.Name]
+		if !ok || v.Value != w[0] || v.Source != w[1] {
+			t.Fatalf("%s = %q (%s), want %q (%s)", v.Name, v.Value, v.Source, w[0], w[1])
+		}
+	}
+
+	if err := ApplyEnvironment(s, suitePath, "qa", map[string]string{"cliWins": "cli"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Variables["shared"] != "profile" || s.Variables["cliWins"] != "cli" {
+		t.Fatalf("applied vars = %v", s.Variables)
+	}
+	if _, ok := s.Variables["HYDREQ_TEST_OS_ONLY"]; ok {
+		t.Fatalf("OS values should stay a lookup fallback, not become suite vars")
+	}
+}
+
+func TestLoadProfile_Unknown(t *testing.T) {
+	if _, err := LoadProfile(&models.Suite{}, filepath.Join(t.TempDir(), "s.hrq.yaml"), "nope"); err == nil {
+		t.Fatal("expected error for unknown profile")
+	}
+}
+
+func TestInterpolate_OSFallback(t *testing.T) {
+	t.Setenv("HYDREQ_TEST_HOST", "os-host")
+	got := interpolate("${HYDREQ_TEST_HOST}/${defined}/${HYDREQ_TEST_UNSET_X}", map[string]string{"defined": "var"})
+	if got != "os-host/var/${HYDREQ_TEST_UNSET_X}" {
+		t.Fatalf("interpolate = %q", got)
+	}
+	// suite/profile vars shadow the OS environment
+	if got := interpolate("${HYDREQ_TEST_HOST}", map[string]string{"HYDREQ_TEST_HOST": "var-host"}); got != "var-host" {
+		t.Fatalf("interpolate = %q", got)
+	}
+}
+
+func TestParseVarFlags(t *testing.T) {
+	got, err := ParseVarFlags([]string{"a=1", "b=x=y", "c="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["a"] != "1" || got["b"] != "x=y" || got["c"] != "" {
+		t.Fatalf("got %v", got)
+	}
+	if _, err := ParseVarFlags([]string{"novalue"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/assert"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+	"github.com/tidwall/gjson"
+)
+
+// graphQLRequest returns r with its graphql operation in place of the body:
+// a JSON body for POST (the default method), or query, variables and
+// operationName parameters for GET.
+func graphQLRequest(r models.Request) (models.Request, error) {
+	g := r.GraphQL
+	out := r
+	out.GraphQL = nil
+	query := g.Query
+	if query == "" && g.QueryFile != "" {
+		b, err := os.ReadFile(g.QueryFile)
+		if err != nil {
+			return out, fmt.Errorf("graphql queryFile: %w", err)
+		}
+		query = string(b)
+	}
+	if strings.TrimSpace(query) == "" {
+		return out, errors.New("graphql: query or queryFile is required")
+	}
+	if out.Method == "" {
+		out.Method = "POST"
+	}
+	if !strings.EqualFold(out.Method, "GET") {
+		body := map[string]any{"query": query}
+		if len(g.Variables) > 0 {
+			body["variables"] = g.Variables
+		}
+		if g.OperationName != "" {
+			body["operationName"] = g.OperationName
+		}
+		out.Body = body
+		return out, nil
+	}
+	out.Body = nil
+	out.Query = make(map[string]string, len(r.Query)+3)
+	for k, v := range r.Query {
+		out.Query[k] = v
+	}
+	out.Query["query"] = query
+	if len(g.Variables) > 0 {
+		b, err := json.Marshal(g.Variables)
+		if err != nil {
+			return out, fmt.Errorf("graphql variables: %w", err)
+		}
+		out.Query["variables"] = string(b)
+	}
+	if g.OperationName != "" {
+		out.Query["operationName"] = g.OperationName
+	}
+	return out, nil
+}
+
+// graphQLErrorResults checks the errors array of a GraphQL response. Without
+// matchers any error is a failure.
+func graphQLErrorResults(a *models.GraphQLErrors, body []byte, vars map[string]string) []assert.Result {
+	errs := gjson.GetBytes(body, "errors").Array()
+	if a == nil {
+		if len(errs) == 0 {
+			return []assert.Result{{Passed: true, Msg: "graphql: no errors"}}
+		}
+		msgs := make([]string, 0, len(errs))
+		for _, e := range errs {
+			msgs = append(msgs, e.Get("message").String())
+		}
+		return []assert.Result{{Passed: false, Msg: fmt.Sprintf("graphql: %d error(s): %s", len(errs), strings.Join(msgs, "; "))}}
+	}
+	var out []assert.Result
+	if a.Count != nil {
+		out = append(out, assert.Equal(len(errs), *a.Count, "graphql errors"))
+	}
+	find := func(label, want string, match func(e gjson.Result) bool) {
+		for _, e := range errs {
+			if match(e) {
+				out = append(out, assert.Result{Passed: true, Msg: fmt.Sprintf("graphql errors: %s %q", label, want)})
+				return
+			}
+		}
+		out = append(out, assert.Result{Passed: false, Msg: fmt.Sprintf("graphql errors: no error with %s %q", label, want)})
+	}
+	for _, m := range a.MessageContains {
+		m = interpolate(m, vars)
+		find("message containing", m, func(e gjson.Result) bool { return strings.Contains(e.Get("message").String(), m) })
+	}
+	for _, c := range a.Codes {
+		c = interpolate(c, vars)
+		find("code", c, func(e gjson.Result) bool { return e.Get("extensions.code").String() == c })
+	}
+	for _, p := range a.Paths {
+		p = interpolate(p, vars)
+		find("path", p, func(e gjson.Result) bool {
+			var parts []string
+			for _, seg := range e.Get("path").Array() {
+				parts = append(parts, seg.String())
+			}
+			return strings.Join(parts, ".") == p
+		})
+	}
+	return out
+}
+
+// resolveQueryFiles makes relative graphql.queryFile paths relative to dir,
+// the directory of the suite file that declares them.
+func resolveQueryFiles(s *models.Suite, dir string) {
+	fix := func(r *models.Request) {
+		if r != nil && r.GraphQL != nil && r.GraphQL.QueryFile != "" && !filepath.IsAbs(r.GraphQL.QueryFile) {
+			r.GraphQL.QueryFile = filepath.Join(dir, r.GraphQL.QueryFile)
+		}
+	}
+	hooks := func(hs []models.Hook) {
+		for _, h := range hs {
+			fix(h.Request)
+		}
+	}
+	hooks(s.PreSuite)
+	hooks(s.PostSuite)
+	for i := range s.Tests {
+		fix(&s.Tests[i].Request)
+		hooks(s.Tests[i].Pre)
+		hooks(s.Tests[i].Post)
+	}
+	for _, t := range s.Templates {
+		fix(&t.Request)
+		hooks(t.Pre)
+		hooks(t.Post)
+	}
+}
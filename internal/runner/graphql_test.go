@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestGraphQLRequest(t *testing.T) {
+	g := &models.GraphQL{Query: "query U($id: ID!) { user(id: $id) { name } }", Variables: map[string]any{"id": "${id}"}, OperationName: "U"}
+	post, err := graphQLRequest(models.Request{URL: "/graphql", GraphQL: g})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"query": g.Query, "variables": g.Variables, "operationName": "U"}
+	if post.Method != "POST" || post.GraphQL != nil || !reflect.DeepEqual(post.Body, want) {
+		t.Fatalf("post = %+v", post)
+	}
+	get, err := graphQLRequest(models.Request{Method: "GET", URL: "/graphql", Query: map[string]string{"v": "1"}, GraphQL: g})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantQuery := map[string]string{"v": "1", "query": g.Query, "variables": `{"id":"${id}"}`, "operationName": "U"}
+	if get.Body != nil || !reflect.DeepEqual(get.Query, wantQuery) {
+		t.Fatalf("get = %+v", get)
+	}
+	if _, err := graphQLRequest(models.Request{GraphQL: &models.GraphQL{}}); err == nil {
+		t.Fatal("expected an error without a query")
+	}
+	if _, err := graphQLRequest(models.Request{GraphQL: &models.GraphQL{QueryFile: filepath.Join(t.TempDir(), "missing.graphql")}}); err == nil {
+		t.Fatal("expected an error for a missing queryFile")
+	}
+}
+
+func TestRunSuite_GraphQL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		w.Header().Set("Content-Type", "application/json")
+		if in.Variables["id"] == "7" && r.Header.Get("Content-Type") == "application/json" {
+			_, _ = w.Write([]byte(`{"data":{"user":{"name":"ann"}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"user":null},"errors":[{"message":"user 9 not found","path":["user"],"extensions":{"code":"NOT_FOUND"}}]}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "user.graphql"), []byte("query($id: ID!) { user(id: $id) { name } }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	suite := `name: gql
+baseUrl: ` + srv.URL + `
+vars: {id: "7"}
+tests:
+  - name: found
+    request:
+      url: /graphql
+      graphql: {queryFile: user.graphql, variables: {id: "${id}"}}
+    assert: {status: 200, jsonEquals: {data.user.name: ann}}
+  - name: unexpected error
+    request:
+      url: /graphql
+      graphql: {queryFile: user.graphql, variables: {id: "9"}}
+    assert: {status: 200}
+  - name: expected error
+    request:
+      url: /graphql
+      graphql: {queryFile: user.graphql, variables: {id: "9"}}
+    assert:
+      status: 200
+      graphqlErrors: {count: 1, messageContains: [not found], codes: [NOT_FOUND], paths: [user]}
+  - name: wrong code
+    request:
+      url: /graphql
+      graphql: {queryFile: user.graphql, variables: {id: "9"}}
+    assert:
+      graphqlErrors: {codes: [FORBIDDEN]}
+`
+	path := filepath.Join(dir, "gql.hrq.yaml")
+	if err := os.WriteFile(path, []byte(suite), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSuite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string]TestResult{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sum, _ := RunSuite(ctx, s, Options{Workers: 1, OnResult: func(tr TestResult) { results[tr.Name] = tr }})
+	if sum.Passed != 2 || sum.Failed != 2 {
+		t.Fatalf("summary = %+v, results = %+v", sum, results)
+	}
+	if r := results["unexpected error"]; len(r.Messages) != 1 || r.Messages[0] != "graphql: 1 error(s): user 9 not found" {
+		t.Fatalf("unexpected error = %+v", r)
+	}
+	if r := results["wrong code"]; len(r.Messages) != 1 || r.Messages[0] != `graphql errors: no error with code "FORBIDDEN"` {
+		t.Fatalf("wrong code = %+v", r)
+	}
+}
+
+func TestMergeGraphQL(t *testing.T) {
+	base := models.TestCase{Request: models.Request{URL: "/graphql", GraphQL: &models.GraphQL{Query: "query { a }", Variables: map[string]any{"a": 1, "b": 2}}}}
+	child := models.TestCase{Name: "t", Request: models.Request{GraphQL: &models.GraphQL{Variables: map[string]any{"b": 3}, OperationName: "Op"}}}
+	got := mergeTestCase(base, child).Request.GraphQL
+	want := &models.GraphQL{Query: "query { a }", Variables: map[string]any{"a": 1, "b": 3}, OperationName: "Op"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("merged = %+v", got)
+	}
+}
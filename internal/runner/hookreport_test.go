@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestRunSuite_ReportsHooks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+	dsn := "file:" + filepath.Join(t.TempDir(), "hooks.sqlite")
+
+	s := &models.Suite{
+		Name:    "hooks",
+		BaseURL: srv.URL,
+		PreSuite: []models.Hook{
+			{Name: "create", SQL: &models.SQLHook{Driver: "sqlite", DSN: dsn, Query: "CREATE TABLE t (id INTEGER)"}},
+		},
+		Tests: []models.TestCase{{
+			Name:    "with hooks",
+			Request: models.Request{Method: "GET", URL: "/"},
+			Assert:  models.Assertions{Status: 200},
+			Pre: []models.Hook{
+				{Name: "log", JS: &models.JSHook{Code: `console.log("hello", {a: 1}); console.warn("careful")`}},
+			},
+			Post: []models.Hook{
+				{Name: "ping", Request: &models.Request{Method: "GET", URL: "/"}, Assert: models.Assertions{Status: 200}},
+			},
+		}},
+	}
+
+	var mu sync.Mutex
+	var events []HookResult
+	var results []TestResult
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sum, err := RunSuite(ctx, s, Options{
+		Workers:  1,
+		OnHook:   func(hr HookResult) { mu.Lock(); events = append(events, hr); mu.Unlock() },
+		OnResult: func(tr TestResult) { results = append(results, tr) },
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(sum.Hooks) != 1 || sum.Hooks[0].Scope != "preSuite" || sum.Hooks[0].Status != "passed" {
+		t.Fatalf("unexpected suite hooks: %+v", sum.Hooks)
+	}
+	// each hook emits a running and a finished event
+	if len(events) != 6 {
+		t.Fatalf("expected 6 hook events, got %d: %+v", len(events), events)
+	}
+	if len(results) != 1 || len(results[0].Hooks) != 2 {
+		t.Fatalf("expected test result with 2 hooks, got %+v", results)
+	}
+	pre, post := results[0].Hooks[0], results[0].Hooks[1]
+	if pre.Scope != "pre" || pre.Test != "with hooks" {
+		t.Fatalf("unexpected pre hook: %+v", pre)
+	}
+	if len(pre.Console) != 2 || pre.Console[0] != `hello {"a":1}` || pre.Console[1] != "warn: careful" {
+		t.Fatalf("unexpected console output: %q", pre.Console)
+	}
+	if post.Scope != "post" || post.HTTPStatus != 200 {
+		t.Fatalf("unexpected post hook: %+v", post)
+	}
+}
+
+func TestRunHookReported_Failure(t *testing.T) {
+	vars := map[string]string{}
+	hr, err := runHookReported(context.Background(), &models.Suite{}, &vars, models.Hook{JS: &models.JSHook{Code: "throw new Error('nope')"}}, Options{}, "pre", "t", 0)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if hr.Status != "failed" || hr.Name != "js" || len(hr.Messages) == 0 {
+		t.Fatalf("unexpected hook result: %+v", hr)
+	}
+}
+
+func TestRunHooksSequential_Index(t *testing.T) {
+	hooks := []models.Hook{{JS: &models.JSHook{Code: "1"}}, {JS: &models.JSHook{Code: "2"}}}
+	out, err := runHooksSequential(context.Background(), &models.Suite{}, map[string]string{}, hooks, Options{}, "pre", "t")
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// unnamed hooks of the same kind share a name; the index tells them apart
+	if len(out) != 2 || out[0].Name != out[1].Name || out[0].Index != 0 || out[1].Index != 1 {
+		t.Fatalf("unexpected hook results: %+v", out)
+	}
+}
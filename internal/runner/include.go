@@ -0,0 +1,197 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// loadSuiteFile parses a suite file and merges its include: files (paths relative
+// to the including file). chain holds the absolute paths currently being loaded
+// so include cycles are reported instead of recursing forever.
+func loadSuiteFile(path, rootDir string, chain []string) (*models.Suite, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chain {
+		if c == abs {
+			names := make([]string, 0, len(chain)+1)
+			for _, p := range append(chain, abs) {
+				names = append(names, displayPath(rootDir, p))
+			}
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(names, " -> "))
+		}
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	var s models.Suite
+	err = yaml.Unmarshal(b, &node)
+	if err == nil {
+		err = node.Decode(&s)
+	}
+	if err != nil {
+		if len(chain) > 0 {
+			// parse errors carry the line; name the included file they belong to
+			return nil, fmt.Errorf("%s: %w", displayPath(rootDir, abs), err)
+		}
+		return nil, err
+	}
+	if s.SecretsFile != "" && !filepath.IsAbs(s.SecretsFile) {
+		s.SecretsFile = filepath.Join(filepath.Dir(abs), s.SecretsFile)
+	}
+	for i, sc := range s.Scripts {
+		if sc.File != "" && !filepath.IsAbs(sc.File) {
+			s.Scripts[i].File = filepath.Join(filepath.Dir(abs), sc.File)
+		}
+	}
+	resolveQueryFiles(&s, filepath.Dir(abs))
+	// The root suite's spec path stays relative to the working directory, as
+	// it always has; an included file's is relative to that file like its
+	// other paths.
+	if len(chain) > 0 && s.OpenAPI != nil && s.OpenAPI.File != "" && !filepath.IsAbs(s.OpenAPI.File) {
+		s.OpenAPI.File = filepath.Join(filepath.Dir(abs), s.OpenAPI.File)
+	}
+	if len(s.Include) == 0 {
+		return &s, nil
+	}
+	lines := includeLines(&node)
+	dir := filepath.Dir(path)
+	var base models.Suite
+	for i, inc := range s.Include {
+		p := inc
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, inc)
+		}
+		sub, err := loadSuiteFile(p, rootDir, append(chain, abs))
+		if err != nil {
+			line := 0
+			if i < len(lines) {
+				line = lines[i]
+			}
+			return nil, fmt.Errorf("%s:%d: include %q: %w", displayPath(rootDir, abs), line, inc, err)
+		}
+		src := displayPath(rootDir, p)
+		for j := range sub.Tests {
+			if sub.Tests[j].Source == "" {
+				sub.Tests[j].Source = src
+			}
+		}
+		mergeSuite(&base, sub)
+	}
+	// the including file's own postSuite runs before the teardown pulled in from includes
+	includedPost := base.PostSuite
+	base.PostSuite = nil
+	mergeSuite(&base, &s)
+	base.PostSuite = append(base.PostSuite, includedPost...)
+	base.Name = s.Name
+	base.Include = nil
+	return &base, nil
+}
+
+// mergeSuite layers over onto dst: vars, environments, templates and any baseUrl/auth/openApi
+// set on over win, while hooks and tests are appended in order.
+func mergeSuite(dst, over *models.Suite) {
+	if over.BaseURL != "" {
+		dst.BaseURL = over.BaseURL
+	}
+	if over.Auth != nil {
+		dst.Auth = over.Auth
+	}
+	if over.OpenAPI != nil {
+		dst.OpenAPI = over.OpenAPI
+	}
+	if over.SecretsFile != "" {
+		dst.SecretsFile = over.SecretsFile
+	}
+	dst.Secrets = append(dst.Secrets, over.Secrets...)
+	dst.Scripts = append(dst.Scripts, over.Scripts...)
+	if over.ScriptTimeoutMs != 0 {
+		dst.ScriptTimeoutMs = over.ScriptTimeoutMs
+	}
+	if over.ScriptMemoryMb != 0 {
+		dst.ScriptMemoryMb = over.ScriptMemoryMb
+	}
+	if len(over.Variables) > 0 {
+		if dst.Variables == nil {
+			dst.Variables = map[string]string{}
+		}
+		for k, v := range over.Variables {
+			dst.Variables[k] = v
+		}
+	}
+	for name, vals := range over.Environments {
+		if dst.Environments == nil {
+			dst.Environments = map[string]map[string]string{}
+		}
+		env := dst.Environments[name]
+		if env == nil {
+			env = map[string]string{}
+			dst.Environments[name] = env
+		}
+		for k, v := range vals {
+			env[k] = v
+		}
+	}
+	if len(over.Databases) > 0 {
+		if dst.Databases == nil {
+			dst.Databases = map[string]models.Database{}
+		}
+		for k, v := range over.Databases {
+			dst.Databases[k] = v
+		}
+	}
+	if len(over.Templates) > 0 {
+		if dst.Templates == nil {
+			dst.Templates = map[string]models.TestCase{}
+		}
+		for k, v := range over.Templates {
+			dst.Templates[k] = v
+		}
+	}
+	dst.PreSuite = append(dst.PreSuite, over.PreSuite...)
+	dst.PostSuite = append(dst.PostSuite, over.PostSuite...)
+	dst.Tests = append(dst.Tests, over.Tests...)
+}
+
+// includeLines returns the source line of each entry in the top-level include list.
+func includeLines(doc *yaml.Node) []int {
+	if doc == nil || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "include" {
+			continue
+		}
+		seq := root.Content[i+1]
+		lines := make([]int, 0, len(seq.Content))
+		for _, item := range seq.Content {
+			lines = append(lines, item.Line)
+		}
+		return lines
+	}
+	return nil
+}
+
+// displayPath shortens p relative to the root suite's directory for messages and reports.
+func displayPath(rootDir, p string) string {
+	if rootDir != "" {
+		if abs, err := filepath.Abs(p); err == nil {
+			if rel, err := filepath.Rel(rootDir, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return filepath.ToSlash(p)
+}
@@ -0,0 +1,151 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadSuite_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"root.hrq.yaml": `name: root
+baseUrl: http://example.com
+include:
+  - common/login.yaml
+vars:
+  user: root-user
+preSuite:
+  - name: root setup
+postSuite:
+  - name: root teardown
+tests:
+  - name: root test
+    extends: authed
+    request: { url: /me }
+`,
+		"common/login.yaml": `include:
+  - base.yaml
+vars:
+  user: shared-user
+  token: abc
+preSuite:
+  - name: login
+postSuite:
+  - name: logout
+templates:
+  authed:
+    request:
+      method: GET
+      headers: { Authorization: "Bearer ${token}" }
+    assert: { status: 200 }
+tests:
+  - name: login works
+    request: { method: POST, url: /login }
+    assert: { status: 200 }
+`,
+		"common/base.yaml": `openApi:
+  file: specs/api.yaml
+tests:
+  - name: health
+    request: { method: GET, url: /health }
+    assert: { status: 200 }
+`,
+	})
+	s, err := LoadSuite(filepath.Join(dir, "root.hrq.yaml"))
+	if err != nil {
+		t.Fatalf("LoadSuite: %v", err)
+	}
+	if s.Name != "root" || s.BaseURL != "http://example.com" {
+		t.Fatalf("root settings lost: %+v", s)
+	}
+	if s.Variables["user"] != "root-user" || s.Variables["token"] != "abc" {
+		t.Fatalf("vars = %v", s.Variables)
+	}
+	var names, sources []string
+	for _, tc := range s.Tests {
+		names = append(names, tc.Name)
+		sources = append(sources, tc.Source)
+	}
+	if got := strings.Join(names, ","); got != "health,login works,root test" {
+		t.Fatalf("tests = %s", got)
+	}
+	if got := strings.Join(sources, ","); got != "common/base.yaml,common/login.yaml," {
+		t.Fatalf("sources = %s", got)
+	}
+	root := s.Tests[2]
+	if root.Request.Method != "GET" || root.Request.Headers["Authorization"] == "" {
+		t.Fatalf("template from include not applied: %+v", root.Request)
+	}
+	if s.PreSuite[0].Name != "login" || s.PreSuite[1].Name != "root setup" {
+		t.Fatalf("preSuite order = %+v", s.PreSuite)
+	}
+	if s.PostSuite[0].Name != "root teardown" || s.PostSuite[1].Name != "logout" {
+		t.Fatalf("postSuite order = %+v", s.PostSuite)
+	}
+	if want := filepath.Join(dir, "common", "specs", "api.yaml"); s.OpenAPI == nil || s.OpenAPI.File != want {
+		t.Fatalf("openApi = %+v, want file %s", s.OpenAPI, want)
+	}
+}
+
+func TestLoadSuite_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr []string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"root.hrq.yaml": "name: root\ninclude:\n  - a.yaml\n",
+				"a.yaml":        "include:\n  - b.yaml\n",
+				"b.yaml":        "include:\n  - a.yaml\n",
+			},
+			wantErr: []string{"root.hrq.yaml:3: include \"a.yaml\"", "include cycle: root.hrq.yaml -> a.yaml -> b.yaml -> a.yaml"},
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"root.hrq.yaml": "name: root\nvars: {}\ninclude:\n  - nope.yaml\n",
+			},
+			wantErr: []string{"root.hrq.yaml:4: include \"nope.yaml\""},
+		},
+		{
+			name: "bad yaml in included file",
+			files: map[string]string{
+				"root.hrq.yaml": "name: root\ninclude: [bad.yaml]\n",
+				"bad.yaml":      "tests: [\n",
+			},
+			wantErr: []string{"root.hrq.yaml:2: include \"bad.yaml\": bad.yaml: yaml:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			_, err := LoadSuite(filepath.Join(dir, "root.hrq.yaml"))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("err = %v, want containing %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -53,21 +53,26 @@ func TestAnyTagMatch(t *testing.T) {
 	}
 }
 
-func TestExpandTestCases(t *testing.T) {
+func TestExpandMatrix(t *testing.T) {
 	t1 := []models.TestCase{{
 		Name: "matrix demo",
+		Vars: map[string]string{"shop": "main"},
 		Matrix: map[string][]string{
 			"color": {"red", "blue"},
 			"size":  {"S", "M"},
 		},
 	}}
-	got := expandTestCases(t1)
+	got := ExpandMatrix(t1)
 	if len(got) != 4 {
 		t.Fatalf("expected 4 cases, got %d", len(got))
 	}
 	names := map[string]bool{}
 	for _, c := range got {
 		names[c.Name] = true
+		// each combination gets its own vars
+		if want := "matrix demo [color=" + c.Vars["color"] + ",size=" + c.Vars["size"] + "]"; c.Name != want || c.Vars["shop"] != "main" {
+			t.Fatalf("%s: vars %v", c.Name, c.Vars)
+		}
 	}
 	if !names["matrix demo [color=blue,size=M]"] {
 		t.Fatalf("missing expanded name: %+v", names)
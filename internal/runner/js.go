@@ -0,0 +1,396 @@
+package runner
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dop251/goja"
+
+	"github.com/DrWeltschmerz/HydReq/internal/httpclient"
+	"github.com/DrWeltschmerz/HydReq/pkg/assert"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+//go:embed js_prelude.js
+var jsPrelude string
+
+// jsExchange is what the JS hooks of one test see of its HTTP exchange: the
+// request (editable by pre hooks before it is sent) and, for post hooks, the
+// response. Failed expect/assert checks are collected to fail the test.
+type jsExchange struct {
+	mu       sync.Mutex
+	req      *models.Request
+	resp     *httpclient.Response
+	failures []string
+	set      map[string]string // variables set through setVar
+}
+
+// fail records failed JS checks against the test.
+func (ex *jsExchange) fail(msgs ...string) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	ex.failures = append(ex.failures, msgs...)
+}
+
+// setVar records a variable set by a script so it outlives the test.
+func (ex *jsExchange) setVar(name, value string) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	if ex.set == nil {
+		ex.set = map[string]string{}
+	}
+	ex.set[name] = value
+}
+
+// setVars returns the variables scripts set during the test.
+func (ex *jsExchange) setVars() map[string]string {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	out := make(map[string]string, len(ex.set))
+	for k, v := range ex.set {
+		out[k] = v
+	}
+	return out
+}
+
+// failed returns the failed JS checks recorded so far.
+func (ex *jsExchange) failed() []string {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	return append([]string(nil), ex.failures...)
+}
+
+// jsRequest is the JSON shape of the `request` object.
+type jsRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]any    `json:"headers"`
+	Query   map[string]any    `json:"query"`
+	Body    any               `json:"body"`
+	orig    map[string]string // JSON of each field as handed to the script
+}
+
+// jsResponse is the JSON shape behind the `response` object.
+type jsResponse struct {
+	Status     int               `json:"status"`
+	StatusText string            `json:"statusText"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	DurationMs int64             `json:"durationMs"`
+}
+
+// RunJSHook executes JavaScript code with access to variables
+func RunJSHook(jsHook *models.JSHook, vars *map[string]string) error {
+	_, _, err := runJSHook(nil, jsHook, vars, nil)
+	return err
+}
+
+// RunSuiteJSHook runs a JS hook outside a suite run (e.g. from the editor) with
+// the suite's script libraries and time limit. It returns the console output;
+// failed expect/assert/test checks are reported as an error.
+func RunSuiteJSHook(s *models.Suite, jsHook *models.JSHook, vars *map[string]string) ([]string, error) {
+	env, err := newJSEnv(s)
+	if err != nil {
+		return nil, err
+	}
+	console, results, err := runJSHook(env, jsHook, vars, nil)
+	if err != nil {
+		return console, err
+	}
+	for _, r := range results {
+		if !r.Passed {
+			return console, errors.New(r.Msg)
+		}
+	}
+	return console, nil
+}
+
+// jsRun is a VM prepared for one script, collecting what the script reports.
+type jsRun struct {
+	vm      *goja.Runtime
+	env     *jsEnv
+	slot    *jsVM
+	spoiled bool            // interrupted or overflowed; the VM is not reused
+	console []string        // lines written via console.*
+	results []assert.Result // test()/check()/expect/assert outcomes
+	req     *jsRequest      // request handed to the script, if any
+}
+
+// newJSRun sets up a VM with vars, setVar/getVar, a captured console, the
+// prelude (request/response, expect/assert/test, pm/insomnia/bru shims) and the
+// suite's script libraries, on a VM from the env's pool. env and ex may be nil
+// outside a suite run or test. Call close when done. Scripts get no filesystem
+// or network access.
+func newJSRun(env *jsEnv, vars *map[string]string, ex *jsExchange) (*jsRun, error) {
+	if env == nil {
+		env = defaultJSEnv
+	}
+	slot := env.acquire()
+	r := &jsRun{vm: slot.vm, env: env, slot: slot}
+	vm := r.vm
+
+	// Set up context variables
+	varsObj := vm.NewObject()
+	for k, v := range *vars {
+		varsObj.Set(k, v)
+	}
+	vm.Set("vars", varsObj)
+
+	// Set up utility functions
+	vm.Set("setVar", func(name, value string) {
+		(*vars)[name] = value
+		varsObj.Set(name, value) // Update JS object too
+		if ex != nil {
+			ex.setVar(name, value)
+		}
+	})
+
+	vm.Set("getVar", func(name string) string {
+		return (*vars)[name]
+	})
+
+	// Capture console output so it can be reported with the hook
+	consoleObj := vm.NewObject()
+	for _, level := range []string{"log", "info", "debug", "warn", "error"} {
+		prefix := ""
+		if level == "warn" || level == "error" {
+			prefix = level + ": "
+		}
+		consoleObj.Set(level, func(call goja.FunctionCall) goja.Value {
+			parts := make([]string, 0, len(call.Arguments))
+			for _, a := range call.Arguments {
+				parts = append(parts, jsValueString(a))
+			}
+			r.console = append(r.console, prefix+strings.Join(parts, " "))
+			return goja.Undefined()
+		})
+	}
+	vm.Set("console", consoleObj)
+
+	vm.Set("__hydreqRecord", func(passed bool, msg string) {
+		r.results = append(r.results, assert.Result{Passed: passed, Msg: msg})
+	})
+	vm.Set("__hydreqInterpolate", func(s string) string { return interpolate(s, *vars) })
+	vm.Set("__hydreqEnv", os.Getenv)
+
+	if ex != nil && ex.req != nil {
+		r.req = newJSRequest(ex.req)
+		b, err := json.Marshal(r.req)
+		if err != nil {
+			return nil, fmt.Errorf("js: request: %w", err)
+		}
+		vm.Set("__hydreqRequest", string(b))
+	}
+	if ex != nil && ex.resp != nil {
+		b, _ := json.Marshal(newJSResponse(ex.resp))
+		vm.Set("__hydreqResponse", string(b))
+	}
+	if _, err := vm.RunProgram(jsPreludeProgram); err != nil {
+		return nil, err
+	}
+	for _, lib := range env.libs {
+		if err := r.exec(lib); err != nil {
+			return nil, fmt.Errorf("scripts: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// close returns the VM to the env's pool.
+func (r *jsRun) close() {
+	if !r.spoiled {
+		r.env.release(r.slot)
+	}
+}
+
+// run compiles and executes a script in its own function scope, so its
+// declarations do not outlive it on a pooled VM.
+func (r *jsRun) run(name, code string) error {
+	p, err := goja.Compile(name, "(function(){"+code+"\n})()", false)
+	if err != nil {
+		return err
+	}
+	return r.exec(p)
+}
+
+// exec runs a program under the env's time and call stack limits. A check
+// failing outside test() stops the script and is recorded as a failed result
+// rather than returned as an error.
+func (r *jsRun) exec(p *goja.Program) error {
+	stop := r.watch()
+	_, err := r.vm.RunProgram(p)
+	stop()
+	if msg, ok := jsAssertionError(err); ok {
+		r.results = append(r.results, assert.Result{Passed: false, Msg: msg})
+		return nil
+	}
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		r.spoiled = true
+		return errors.New(fmt.Sprint(interrupted.Value()))
+	}
+	var overflow *goja.StackOverflowError
+	if errors.As(err, &overflow) {
+		r.spoiled = true
+		return fmt.Errorf("script exceeded the maximum call stack size (%d)", jsMaxCallStack)
+	}
+	return err
+}
+
+// runJSHook executes a JS hook and returns the lines written via console.* and
+// the results of its test()/expect/assert checks. ex may be nil outside a test.
+func runJSHook(env *jsEnv, jsHook *models.JSHook, vars *map[string]string, ex *jsExchange) ([]string, []assert.Result, error) {
+	r, err := newJSRun(env, vars, ex)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.close()
+
+	// Add context variables if provided
+	if jsHook.Context != nil {
+		for k, v := range jsHook.Context {
+			r.vm.Set(k, v)
+		}
+	}
+
+	// Execute the JavaScript code
+	err = r.run("hook.js", jsHook.Code)
+	// Pre hooks may edit the request; post hooks see what was sent.
+	if err == nil && r.req != nil && ex.resp == nil {
+		err = r.req.apply(r.vm, ex.req)
+	}
+	return r.console, r.results, err
+}
+
+// runAssertJS evaluates an assert.js script against a response. Each
+// check(name, ok, msg) (and test/expect/assert) becomes an assertion result;
+// script errors and timeouts are reported as a failed result. Console output
+// is returned alongside.
+func runAssertJS(env *jsEnv, code string, sent models.Request, resp *httpclient.Response, vars map[string]string) ([]assert.Result, []string) {
+	local := make(map[string]string, len(vars))
+	for k, v := range vars {
+		local[k] = v
+	}
+	r, err := newJSRun(env, &local, &jsExchange{req: &sent, resp: resp})
+	if err != nil {
+		return []assert.Result{{Passed: false, Msg: "assert.js: " + err.Error()}}, nil
+	}
+	defer r.close()
+	r.vm.Set("check", func(name string, ok bool, msg goja.Value) {
+		res := assert.Result{Passed: ok, Msg: "js: " + name}
+		if !ok && msg != nil && !goja.IsUndefined(msg) && !goja.IsNull(msg) {
+			res.Msg += ": " + msg.String()
+		}
+		r.results = append(r.results, res)
+	})
+	if err := r.run("assert.js", code); err != nil {
+		r.results = append(r.results, assert.Result{Passed: false, Msg: "assert.js: " + err.Error()})
+	}
+	if len(r.results) == 0 {
+		r.results = append(r.results, assert.Result{Passed: true, Msg: "assert.js: ok"})
+	}
+	return r.results, r.console
+}
+
+// jsAssertionError reports whether err is an uncaught AssertionError from the
+// prelude's expect/assert, returning its message.
+func jsAssertionError(err error) (string, bool) {
+	var jsErr *goja.Exception
+	if !errors.As(err, &jsErr) {
+		return "", false
+	}
+	obj, ok := jsErr.Value().(*goja.Object)
+	if !ok || obj.Get("name") == nil || obj.Get("name").String() != "AssertionError" {
+		return "", false
+	}
+	return obj.Get("message").String(), true
+}
+
+func newJSRequest(r *models.Request) *jsRequest {
+	out := &jsRequest{Method: r.Method, URL: r.URL, Headers: map[string]any{}, Query: map[string]any{}, Body: r.Body}
+	for k, v := range r.Headers {
+		out.Headers[k] = v
+	}
+	for k, v := range r.Query {
+		out.Query[k] = v
+	}
+	out.orig = out.fields()
+	return out
+}
+
+// fields returns the JSON of each request field, used to detect edits.
+func (r *jsRequest) fields() map[string]string {
+	out := map[string]string{}
+	for name, v := range map[string]any{"method": r.Method, "url": r.URL, "headers": r.Headers, "query": r.Query, "body": r.Body} {
+		b, _ := json.Marshal(v)
+		out[name] = string(b)
+	}
+	return out
+}
+
+// apply copies the script's edits of `request` back onto the test request.
+// Only changed fields are replaced, so an untouched body keeps its YAML types.
+func (r *jsRequest) apply(vm *goja.Runtime, dst *models.Request) error {
+	v, err := vm.RunString(`JSON.stringify(request === undefined ? null : request)`)
+	if err != nil {
+		return err
+	}
+	if v.String() == "null" {
+		return nil
+	}
+	var edited jsRequest
+	if err := json.Unmarshal([]byte(v.String()), &edited); err != nil {
+		return err
+	}
+	changed := edited.fields()
+	if changed["method"] != r.orig["method"] {
+		dst.Method = edited.Method
+	}
+	if changed["url"] != r.orig["url"] {
+		dst.URL = edited.URL
+	}
+	if changed["headers"] != r.orig["headers"] {
+		dst.Headers = stringMap(edited.Headers)
+	}
+	if changed["query"] != r.orig["query"] {
+		dst.Query = stringMap(edited.Query)
+	}
+	if changed["body"] != r.orig["body"] {
+		dst.Body = edited.Body
+	}
+	return nil
+}
+
+func stringMap(in map[string]any) map[string]string {
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = anyToString(v)
+	}
+	return out
+}
+
+func newJSResponse(r *httpclient.Response) jsResponse {
+	out := jsResponse{Status: r.Status, StatusText: http.StatusText(r.Status), Headers: map[string]string{}, Body: string(r.Body), DurationMs: r.DurationMs}
+	for k, vs := range r.Headers {
+		out.Headers[k] = strings.Join(vs, ", ")
+	}
+	return out
+}
+
+// jsValueString renders a JS value for console output; objects are shown as JSON.
+func jsValueString(v goja.Value) string {
+	if obj, ok := v.(*goja.Object); ok {
+		if _, isFn := goja.AssertFunction(obj); !isFn {
+			if b, err := json.Marshal(obj.Export()); err == nil {
+				return string(b)
+			}
+		}
+	}
+	return v.String()
+}
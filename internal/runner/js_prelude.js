@@ -0,0 +1,538 @@
+// Prelude evaluated before every JS hook. It builds `request`/`response` from
+// the exchange handed over by Go, the expect/assert/test API, and shims for
+// the pm.*, insomnia.* and bru.* calls found in imported collections, plus
+// pact.match for the matching rules of imported Pact contracts.
+// Native helpers (set by js.go): __hydreqRecord, __hydreqInterpolate, __hydreqEnv.
+(function (g) {
+  'use strict';
+
+  function AssertionError(message) {
+    this.name = 'AssertionError';
+    this.message = message;
+  }
+  AssertionError.prototype = Object.create(Error.prototype);
+  AssertionError.prototype.constructor = AssertionError;
+  AssertionError.prototype.toString = function () { return this.message; };
+
+  function show(v) {
+    if (typeof v === 'string') return JSON.stringify(v);
+    if (v === undefined) return 'undefined';
+    if (typeof v === 'function') return 'function';
+    if (v instanceof RegExp) return String(v);
+    try { return JSON.stringify(v); } catch (e) { return String(v); }
+  }
+
+  function typeOf(v) {
+    if (v === null) return 'null';
+    if (Array.isArray(v)) return 'array';
+    if (v instanceof RegExp) return 'regexp';
+    return typeof v;
+  }
+
+  function deepEqual(a, b) {
+    if (a === b) return true;
+    if (typeof a === 'number' && typeof b === 'number') return a !== a && b !== b;
+    if (typeOf(a) !== typeOf(b) || typeof a !== 'object' || a === null) return false;
+    if (Array.isArray(a)) {
+      if (a.length !== b.length) return false;
+      for (var i = 0; i < a.length; i++) if (!deepEqual(a[i], b[i])) return false;
+      return true;
+    }
+    var ka = Object.keys(a), kb = Object.keys(b);
+    if (ka.length !== kb.length) return false;
+    for (var j = 0; j < ka.length; j++) {
+      if (!Object.prototype.hasOwnProperty.call(b, ka[j]) || !deepEqual(a[ka[j]], b[ka[j]])) return false;
+    }
+    return true;
+  }
+
+  function includes(hay, needle) {
+    if (typeof hay === 'string') return hay.indexOf(String(needle)) >= 0;
+    if (Array.isArray(hay)) {
+      for (var i = 0; i < hay.length; i++) if (deepEqual(hay[i], needle)) return true;
+      return false;
+    }
+    if (hay && typeof hay === 'object' && needle && typeof needle === 'object') {
+      for (var k in needle) if (!deepEqual(hay[k], needle[k])) return false;
+      return true;
+    }
+    return false;
+  }
+
+  function isEmpty(v) {
+    if (v == null) return true;
+    if (typeof v === 'string' || Array.isArray(v)) return v.length === 0;
+    if (typeof v === 'object') return Object.keys(v).length === 0;
+    return false;
+  }
+
+  // Assertion is a chai-style `expect` chain.
+  function Assertion(actual, message, negate) {
+    this._actual = actual;
+    this._message = message;
+    this._negate = !!negate;
+  }
+
+  Assertion.prototype._assert = function (ok, msg, negMsg) {
+    if (this._negate) ok = !ok;
+    if (!ok) {
+      var m = this._negate ? negMsg : msg;
+      throw new AssertionError(this._message ? this._message + ': ' + m : m);
+    }
+    return this;
+  };
+
+  ['to', 'be', 'been', 'is', 'that', 'which', 'and', 'has', 'have', 'with', 'at', 'of', 'same', 'does'].forEach(function (w) {
+    Object.defineProperty(Assertion.prototype, w, { get: function () { return this; } });
+  });
+  Object.defineProperty(Assertion.prototype, 'not', {
+    get: function () { this._negate = !this._negate; return this; }
+  });
+  Object.defineProperty(Assertion.prototype, 'deep', {
+    get: function () { this._deep = true; return this; }
+  });
+
+  function flag(name, test, what) {
+    Object.defineProperty(Assertion.prototype, name, {
+      get: function () {
+        var a = this._actual;
+        return this._assert(test(a), 'expected ' + show(a) + ' to be ' + what, 'expected ' + show(a) + ' not to be ' + what);
+      }
+    });
+  }
+  flag('ok', function (a) { return !!a; }, 'truthy');
+  flag('true', function (a) { return a === true; }, 'true');
+  flag('false', function (a) { return a === false; }, 'false');
+  flag('null', function (a) { return a === null; }, 'null');
+  flag('undefined', function (a) { return a === undefined; }, 'undefined');
+  flag('NaN', function (a) { return a !== a; }, 'NaN');
+  flag('exist', function (a) { return a != null; }, 'defined');
+  flag('empty', isEmpty, 'empty');
+
+  var A = Assertion.prototype;
+  A.equal = function (exp) {
+    var a = this._actual;
+    if (this._deep) return this.eql(exp);
+    return this._assert(a === exp, 'expected ' + show(a) + ' to equal ' + show(exp), 'expected ' + show(a) + ' not to equal ' + show(exp));
+  };
+  A.equals = A.eq = A.equal;
+  A.eql = function (exp) {
+    var a = this._actual;
+    return this._assert(deepEqual(a, exp), 'expected ' + show(a) + ' to deeply equal ' + show(exp), 'expected ' + show(a) + ' not to deeply equal ' + show(exp));
+  };
+  A.eqls = A.eql;
+  A.above = function (n) {
+    var a = this._actual;
+    return this._assert(a > n, 'expected ' + show(a) + ' to be above ' + show(n), 'expected ' + show(a) + ' to be at most ' + show(n));
+  };
+  A.gt = A.greaterThan = A.above;
+  A.below = function (n) {
+    var a = this._actual;
+    return this._assert(a < n, 'expected ' + show(a) + ' to be below ' + show(n), 'expected ' + show(a) + ' to be at least ' + show(n));
+  };
+  A.lt = A.lessThan = A.below;
+  A.least = function (n) {
+    var a = this._actual;
+    return this._assert(a >= n, 'expected ' + show(a) + ' to be at least ' + show(n), 'expected ' + show(a) + ' to be below ' + show(n));
+  };
+  A.gte = A.least;
+  A.most = function (n) {
+    var a = this._actual;
+    return this._assert(a <= n, 'expected ' + show(a) + ' to be at most ' + show(n), 'expected ' + show(a) + ' to be above ' + show(n));
+  };
+  A.lte = A.most;
+  A.within = function (lo, hi) {
+    var a = this._actual;
+    return this._assert(a >= lo && a <= hi, 'expected ' + show(a) + ' to be within ' + lo + '..' + hi, 'expected ' + show(a) + ' not to be within ' + lo + '..' + hi);
+  };
+  A.include = function (v) {
+    var a = this._actual;
+    return this._assert(includes(a, v), 'expected ' + show(a) + ' to include ' + show(v), 'expected ' + show(a) + ' not to include ' + show(v));
+  };
+  A.includes = A.contain = A.contains = A.include;
+  A.match = function (re) {
+    var a = this._actual;
+    if (!(re instanceof RegExp)) re = new RegExp(re);
+    return this._assert(re.test(String(a)), 'expected ' + show(a) + ' to match ' + re, 'expected ' + show(a) + ' not to match ' + re);
+  };
+  A.matches = A.match;
+  A.a = function (type) {
+    var a = this._actual, t = String(type).toLowerCase();
+    return this._assert(typeOf(a) === t, 'expected ' + show(a) + ' to be a ' + t, 'expected ' + show(a) + ' not to be a ' + t);
+  };
+  A.an = A.a;
+  A.oneOf = function (list) {
+    var a = this._actual;
+    return this._assert(includes(list, a), 'expected ' + show(a) + ' to be one of ' + show(list), 'expected ' + show(a) + ' not to be one of ' + show(list));
+  };
+  A.property = function (name, val) {
+    var a = this._actual;
+    var has = a != null && typeof a === 'object' && name in a;
+    if (arguments.length < 2) {
+      return this._assert(has, 'expected ' + show(a) + ' to have property ' + show(name), 'expected ' + show(a) + ' not to have property ' + show(name));
+    }
+    return this._assert(has && deepEqual(a[name], val),
+      'expected property ' + show(name) + ' to be ' + show(val) + ', got ' + show(has ? a[name] : undefined),
+      'expected property ' + show(name) + ' not to be ' + show(val));
+  };
+  A.keys = function () {
+    var a = this._actual, want = Array.isArray(arguments[0]) ? arguments[0] : Array.prototype.slice.call(arguments);
+    var ok = a != null && typeof a === 'object' && want.every(function (k) { return k in a; });
+    return this._assert(ok, 'expected ' + show(a) + ' to have keys ' + show(want), 'expected ' + show(a) + ' not to have keys ' + show(want));
+  };
+  A.lengthOf = function (n) {
+    var a = this._actual, got = a == null ? undefined : a.length;
+    return this._assert(got === n, 'expected length ' + n + ', got ' + got, 'expected length not to be ' + n);
+  };
+  // status and header accept a response object (or anything with status/code and headers).
+  A.status = function (code) {
+    var a = this._actual, got = a == null ? undefined : (a.code !== undefined ? a.code : a.status);
+    return this._assert(got === code, 'expected status ' + code + ', got ' + got, 'expected status not to be ' + code);
+  };
+  A.header = function (name, val) {
+    var got = headerOf(this._actual && this._actual.headers, name);
+    if (arguments.length < 2) {
+      return this._assert(got !== undefined, 'expected header ' + name, 'expected no header ' + name);
+    }
+    return this._assert(got === val, 'expected header ' + name + ' to be ' + show(val) + ', got ' + show(got), 'expected header ' + name + ' not to be ' + show(val));
+  };
+
+  function expect(actual, message) { return new Assertion(actual, message); }
+
+  function fail(message) { throw new AssertionError(message || 'assertion failed'); }
+
+  function assert(cond, message) { if (!cond) fail(message || 'expected ' + show(cond) + ' to be truthy'); }
+  assert.ok = assert;
+  assert.fail = fail;
+  assert.equal = function (a, b, m) { if (a != b) fail(m || 'expected ' + show(a) + ' to equal ' + show(b)); };
+  assert.notEqual = function (a, b, m) { if (a == b) fail(m || 'expected ' + show(a) + ' not to equal ' + show(b)); };
+  assert.strictEqual = function (a, b, m) { if (a !== b) fail(m || 'expected ' + show(a) + ' to equal ' + show(b)); };
+  assert.deepEqual = function (a, b, m) { if (!deepEqual(a, b)) fail(m || 'expected ' + show(a) + ' to deeply equal ' + show(b)); };
+  assert.isTrue = function (a, m) { if (a !== true) fail(m || 'expected ' + show(a) + ' to be true'); };
+  assert.isFalse = function (a, m) { if (a !== false) fail(m || 'expected ' + show(a) + ' to be false'); };
+  assert.include = function (hay, needle, m) { if (!includes(hay, needle)) fail(m || 'expected ' + show(hay) + ' to include ' + show(needle)); };
+  assert.match = function (s, re, m) { if (!re.test(String(s))) fail(m || 'expected ' + show(s) + ' to match ' + re); };
+
+  // test records a named check; a failure marks the owning test failed but the
+  // script keeps running, as in Postman.
+  function test(name, fn) {
+    try {
+      fn();
+      __hydreqRecord(true, name);
+    } catch (e) {
+      __hydreqRecord(false, name + ': ' + (e && e.message !== undefined ? e.message : String(e)));
+    }
+  }
+
+  function headerOf(headers, name) {
+    if (!headers) return undefined;
+    var want = String(name).toLowerCase();
+    for (var k in headers) if (k.toLowerCase() === want) return headers[k];
+    return undefined;
+  }
+
+  // request and response
+
+  var request = g.__hydreqRequest === undefined ? undefined : JSON.parse(g.__hydreqRequest);
+  if (request) {
+    request.headers = request.headers || {};
+    request.query = request.query || {};
+  }
+  var raw = g.__hydreqResponse === undefined ? undefined : JSON.parse(g.__hydreqResponse);
+  var response;
+  if (raw) {
+    response = {
+      status: raw.status,
+      code: raw.status,
+      statusText: raw.statusText,
+      headers: raw.headers,
+      body: raw.body,
+      durationMs: raw.durationMs,
+      responseTime: raw.durationMs,
+      header: function (name) { return headerOf(raw.headers, name); },
+      text: function () { return raw.body; },
+      json: function () { return JSON.parse(raw.body); }
+    };
+  }
+
+  function need(v, what) {
+    if (v === undefined) throw new Error(what + ' is not available in this hook');
+    return v;
+  }
+
+  // variable scopes: every pm/insomnia/bru scope maps onto HydReq variables.
+
+  function str(v) { return v == null ? '' : (typeof v === 'object' ? JSON.stringify(v) : String(v)); }
+  var scope = {
+    get: function (k) { return Object.prototype.hasOwnProperty.call(vars, k) ? vars[k] : undefined; },
+    set: function (k, v) { setVar(k, str(v)); },
+    has: function (k) { return Object.prototype.hasOwnProperty.call(vars, k); },
+    unset: function (k) { setVar(k, ''); },
+    replaceIn: function (s) { return __hydreqInterpolate(String(s)); },
+    toObject: function () { return Object.assign({}, vars); }
+  };
+
+  function headerList(get) {
+    return {
+      get: function (k) { return headerOf(get(), k); },
+      has: function (k) { return headerOf(get(), k) !== undefined; },
+      toObject: function () { return Object.assign({}, get()); }
+    };
+  }
+
+  function setHeader(k, v) {
+    var h = need(request, 'request').headers;
+    for (var n in h) if (n.toLowerCase() === String(k).toLowerCase()) delete h[n];
+    if (v !== undefined) h[k] = str(v);
+  }
+
+  function requestShim() {
+    var hs = headerList(function () { return need(request, 'request').headers; });
+    hs.add = hs.upsert = function (h) { setHeader(h.key, h.value); };
+    hs.remove = function (k) { setHeader(k, undefined); };
+    var o = { headers: hs };
+    ['method', 'url', 'body'].forEach(function (f) {
+      Object.defineProperty(o, f, {
+        enumerable: true,
+        get: function () { return need(request, 'request')[f]; },
+        set: function (v) { need(request, 'request')[f] = v; }
+      });
+    });
+    return o;
+  }
+
+  function responseShim() {
+    var r = function () { return need(response, 'pm.response'); };
+    var o = {
+      headers: headerList(function () { return r().headers; }),
+      json: function () { return r().json(); },
+      text: function () { return r().text(); },
+      to: {
+        have: {
+          status: function (c) { expect(r()).to.have.status(c); },
+          header: function (k, v) { var e = expect(r()).to.have; arguments.length < 2 ? e.header(k) : e.header(k, v); },
+          body: function (v) { if (arguments.length === 0) expect(r().body).not.to.be.empty; else expect(r().body).to.equal(v); },
+          jsonBody: function () { r().json(); }
+        },
+        be: {}
+      }
+    };
+    ['code', 'status', 'statusText', 'responseTime'].forEach(function (f) {
+      Object.defineProperty(o, f, { enumerable: true, get: function () { return f === 'status' ? r().statusText : r()[f]; } });
+    });
+    [['ok', 200, 299], ['success', 200, 299], ['clientError', 400, 499], ['serverError', 500, 599]].forEach(function (c) {
+      Object.defineProperty(o.to.be, c[0], {
+        get: function () { expect(r().code, 'response status').to.be.within(c[1], c[2]); return true; }
+      });
+    });
+    return o;
+  }
+
+  var pm = {
+    environment: scope,
+    globals: scope,
+    collectionVariables: scope,
+    variables: scope,
+    iterationData: scope,
+    request: requestShim(),
+    response: responseShim(),
+    test: test,
+    expect: expect,
+    sendRequest: function () { throw new Error('pm.sendRequest is not supported; use a request hook'); }
+  };
+
+  var insomnia = {
+    environment: scope,
+    globals: scope,
+    baseEnvironment: scope,
+    collectionVariables: scope,
+    variables: scope,
+    request: pm.request,
+    response: pm.response,
+    test: test,
+    expect: expect
+  };
+
+  var bru = {
+    setVar: scope.set,
+    getVar: scope.get,
+    setEnvVar: scope.set,
+    getEnvVar: scope.get,
+    getProcessEnv: function (k) { return __hydreqEnv(String(k)); },
+    interpolate: scope.replaceIn
+  };
+
+  var req = {
+    getUrl: function () { return need(request, 'req').url; },
+    setUrl: function (v) { need(request, 'req').url = String(v); },
+    getMethod: function () { return need(request, 'req').method; },
+    setMethod: function (v) { need(request, 'req').method = String(v); },
+    getHeader: function (k) { return headerOf(need(request, 'req').headers, k); },
+    getHeaders: function () { return need(request, 'req').headers; },
+    setHeader: setHeader,
+    getBody: function () { return need(request, 'req').body; },
+    setBody: function (v) { need(request, 'req').body = v; }
+  };
+
+  var res;
+  if (response) {
+    var parsed;
+    try { parsed = response.json(); } catch (e) { parsed = response.body; }
+    res = {
+      status: response.status,
+      headers: response.headers,
+      body: parsed,
+      responseTime: response.durationMs,
+      getStatus: function () { return response.status; },
+      getHeader: function (k) { return response.header(k); },
+      getHeaders: function () { return response.headers; },
+      getBody: function () { return parsed; },
+      getResponseTime: function () { return response.durationMs; }
+    };
+  }
+
+  // pact.match compares a value with a Pact example under the contract's
+  // matching rules (JSONPath -> {matchers, combine}, paths rooted at `$`).
+  // Values must equal the example unless a rule at their path, or a type
+  // rule above it (as in eachLike), says otherwise; extra object keys are
+  // allowed. It returns the mismatches, empty when the value matches.
+
+  function pactTokens(path) {
+    var out = [], re = /\.([^.[\]]+)|\[(?:'([^']*)'|"([^"]*)"|(\d+|\*))\]/g, m;
+    var s = String(path).replace(/^\$/, '');
+    while ((m = re.exec(s)) !== null) {
+      out.push(m[1] !== undefined ? m[1] : m[2] !== undefined ? m[2] : m[3] !== undefined ? m[3] : m[4]);
+    }
+    return out;
+  }
+
+  function pactLabel(path) {
+    var s = '$';
+    path.forEach(function (t) { s += typeof t === 'number' ? '[' + t + ']' : '.' + t; });
+    return s;
+  }
+
+  // pactRule picks the most specific rule for path: exact segments outweigh `*`.
+  function pactRule(rules, path) {
+    var best, bestWeight = -1;
+    for (var i = 0; i < rules.length; i++) {
+      var t = rules[i].tokens, w = t.length === path.length ? 0 : -1;
+      for (var j = 0; j < t.length && w >= 0; j++) {
+        if (t[j] === String(path[j])) w += 2;
+        else if (t[j] === '*') w += 1;
+        else w = -1;
+      }
+      if (w > bestWeight) { best = rules[i].rule; bestWeight = w; }
+    }
+    return best;
+  }
+
+  function pactCheck(m, actual, expected) {
+    var kind = typeOf(actual);
+    switch (m.match) {
+      case undefined:
+      case 'type':
+        if (kind !== typeOf(expected)) return 'expected ' + typeOf(expected) + ', got ' + kind;
+        if (kind === 'array' && m.min !== undefined && actual.length < m.min) return 'expected at least ' + m.min + ' item(s), got ' + actual.length;
+        if (kind === 'array' && m.max !== undefined && actual.length > m.max) return 'expected at most ' + m.max + ' item(s), got ' + actual.length;
+        return '';
+      case 'regex':
+        if (actual == null || typeof actual === 'object' || !new RegExp('^(?:' + m.regex + ')$').test(String(actual))) return 'expected ' + show(actual) + ' to match /' + m.regex + '/';
+        return '';
+      case 'integer':
+        return kind === 'number' && Math.floor(actual) === actual ? '' : 'expected an integer, got ' + show(actual);
+      case 'decimal':
+      case 'number':
+        return kind === 'number' ? '' : 'expected a number, got ' + show(actual);
+      case 'boolean':
+        return kind === 'boolean' ? '' : 'expected a boolean, got ' + show(actual);
+      case 'null':
+        return actual === null ? '' : 'expected null, got ' + show(actual);
+      case 'equality':
+        return deepEqual(actual, expected) ? '' : 'expected ' + show(expected) + ', got ' + show(actual);
+      case 'include':
+        return actual != null && String(actual).indexOf(m.value) >= 0 ? '' : 'expected ' + show(actual) + ' to include ' + show(m.value);
+      case 'notEmpty':
+        return kind === typeOf(expected) && !isEmpty(actual) ? '' : 'expected a non-empty ' + typeOf(expected) + ', got ' + show(actual);
+      case 'date':
+      case 'time':
+      case 'datetime':
+      case 'timestamp':
+        return kind === 'string' ? '' : 'expected a ' + m.match + ' string, got ' + show(actual);
+    }
+    return ''; // other matchers are reported by the importer and not checked
+  }
+
+  function pactWalk(actual, expected, path, rules, byType, out) {
+    var rule = pactRule(rules, path), ruled = false;
+    if (rule) {
+      var ms = rule.matchers || [], errs = [];
+      ms.forEach(function (m) {
+        var e = pactCheck(m, actual, expected);
+        if (e) errs.push(e);
+        if (m.match === 'equality') byType = false;
+        else if (m.match === undefined || m.match === 'type') byType = true;
+      });
+      if (rule.combine === 'OR' ? ms.length > 0 && errs.length === ms.length : errs.length > 0) {
+        out.push(pactLabel(path) + ': ' + errs.join(' or '));
+        return;
+      }
+      ruled = ms.length > 0;
+    }
+    var kind = typeOf(expected);
+    if (kind === 'array' || kind === 'object') {
+      if (typeOf(actual) !== kind) {
+        out.push(pactLabel(path) + ': expected ' + kind + ', got ' + typeOf(actual));
+        return;
+      }
+      if (kind === 'array') {
+        if (!ruled && !byType && actual.length !== expected.length) {
+          out.push(pactLabel(path) + ': expected ' + expected.length + ' item(s), got ' + actual.length);
+          return;
+        }
+        if (expected.length === 0) return;
+        for (var i = 0; i < actual.length; i++) {
+          pactWalk(actual[i], expected[i < expected.length ? i : 0], path.concat([i]), rules, byType, out);
+        }
+        return;
+      }
+      for (var k in expected) {
+        if (!Object.prototype.hasOwnProperty.call(actual, k)) {
+          out.push(pactLabel(path.concat([k])) + ': missing');
+          continue;
+        }
+        pactWalk(actual[k], expected[k], path.concat([k]), rules, byType, out);
+      }
+      return;
+    }
+    if (ruled) return;
+    if (byType) {
+      if (typeOf(actual) !== kind) out.push(pactLabel(path) + ': expected ' + kind + ', got ' + typeOf(actual));
+    } else if (!deepEqual(actual, expected)) {
+      out.push(pactLabel(path) + ': expected ' + show(expected) + ', got ' + show(actual));
+    }
+  }
+
+  var pact = {
+    match: function (actual, expected, rules) {
+      var list = [];
+      for (var p in rules || {}) list.push({ tokens: pactTokens(p), rule: rules[p] });
+      var out = [];
+      pactWalk(actual, expected, [], list, false, out);
+      return out;
+    }
+  };
+
+  g.AssertionError = AssertionError;
+  g.expect = expect;
+  g.assert = assert;
+  g.test = test;
+  g.request = request;
+  g.response = response;
+  g.pm = pm;
+  g.insomnia = insomnia;
+  g.bru = bru;
+  g.req = req;
+  g.res = res;
+  g.pact = pact;
+})(this);
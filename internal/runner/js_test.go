@@ -0,0 +1,224 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DrWeltschmerz/HydReq/internal/httpclient"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestRunJSHook_ExpectAndAssert(t *testing.T) {
+	cases := []struct {
+		code string
+		fail string // expected failed message; empty when the checks pass
+	}{
+		{`expect(1).to.equal(1); expect([1,2]).to.include(2); expect({a:{b:1}}).to.deep.equal({a:{b:1}})`, ""},
+		{`expect("abc").to.match(/b/).and.not.be.empty; expect(5).to.be.above(2).and.below(9)`, ""},
+		{`expect({id: 3}).to.have.property("id", 3); expect(null).to.be.null; expect(true).to.be.true`, ""},
+		{`assert.equal(1, "1"); assert.deepEqual([1], [1]); assert(true)`, ""},
+		{`expect(1).to.equal(2)`, "expected 1 to equal 2"},
+		{`expect("x", "name").not.to.equal("x")`, `name: expected "x" not to equal "x"`},
+		{`assert.strictEqual(1, "1")`, `expected 1 to equal "1"`},
+		{`test("first", () => expect(1).to.be.a("string")); test("second", () => {})`, `first: expected 1 to be a string`},
+	}
+	for _, c := range cases {
+		vars := map[string]string{}
+		_, results, err := runJSHook(nil, &models.JSHook{Code: c.code}, &vars, nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.code, err)
+		}
+		var failed []string
+		for _, r := range results {
+			if !r.Passed {
+				failed = append(failed, r.Msg)
+			}
+		}
+		if c.fail == "" && len(failed) > 0 {
+			t.Errorf("%s: unexpected failures %v", c.code, failed)
+		}
+		if c.fail != "" && (len(failed) != 1 || failed[0] != c.fail) {
+			t.Errorf("%s: failures = %v, want [%s]", c.code, failed, c.fail)
+		}
+	}
+}
+
+func TestRunJSHook_RequestEdits(t *testing.T) {
+	req := &models.Request{Method: "GET", URL: "/a", Headers: map[string]string{"X-Old": "1"}, Body: map[string]any{"n": 1}}
+	orig := req.Headers
+	vars := map[string]string{"token": "t0k"}
+	code := `request.url = request.url + "?x=1"; request.headers["Authorization"] = "Bearer " + vars.token;
+pm.request.headers.remove("x-old"); request.method = "POST"`
+	if _, _, err := runJSHook(nil, &models.JSHook{Code: code}, &vars, &jsExchange{req: req}); err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "POST" || req.URL != "/a?x=1" {
+		t.Fatalf("method/url not applied: %+v", req)
+	}
+	if len(req.Headers) != 1 || req.Headers["Authorization"] != "Bearer t0k" {
+		t.Fatalf("headers = %v", req.Headers)
+	}
+	if orig["X-Old"] != "1" {
+		t.Fatal("original header map was modified")
+	}
+	if b, ok := req.Body.(map[string]any); !ok || b["n"] != 1 {
+		t.Fatalf("untouched body should keep its value and type, got %#v", req.Body)
+	}
+}
+
+func TestRunJSHook_ResponseAndShims(t *testing.T) {
+	resp := &httpclient.Response{Status: 201, Headers: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"id":"u-1","tags":["a"]}`), DurationMs: 12}
+	ex := &jsExchange{req: &models.Request{Method: "POST", URL: "http://x/users"}, resp: resp}
+	code := `
+expect(response.status).to.equal(201);
+expect(response.header("content-type")).to.include("json");
+pm.test("created", function () {
+  pm.response.to.have.status(201);
+  pm.expect(pm.response.json().tags).to.eql(["a"]);
+});
+pm.environment.set("userId", pm.response.json().id);
+insomnia.environment.set("method", insomnia.request.method);
+bru.setVar("fromBru", res.body.id + ":" + res.getStatus());
+request.url = "/ignored";
+`
+	vars := map[string]string{}
+	_, results, err := runJSHook(nil, &models.JSHook{Code: code}, &vars, ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Passed || results[0].Msg != "created" {
+		t.Fatalf("results = %+v", results)
+	}
+	if vars["userId"] != "u-1" || vars["method"] != "POST" || vars["fromBru"] != "u-1:201" {
+		t.Fatalf("vars = %v", vars)
+	}
+	if ex.req.URL != "http://x/users" {
+		t.Fatal("post hooks must not edit the sent request")
+	}
+}
+
+func TestRunJSHook_ResponseMissingOutsideTests(t *testing.T) {
+	vars := map[string]string{}
+	_, _, err := runJSHook(nil, &models.JSHook{Code: `pm.response.json()`}, &vars, nil)
+	if err == nil || !strings.Contains(err.Error(), "pm.response is not available") {
+		t.Fatalf("expected a clear error, got %v", err)
+	}
+}
+
+func TestRunSuite_JSHooks(t *testing.T) {
+	var auths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"u-1"}`))
+	}))
+	defer srv.Close()
+
+	s := &models.Suite{
+		Name:    "js",
+		BaseURL: srv.URL,
+		Tests: []models.TestCase{
+			{
+				Name:    "signed",
+				Request: models.Request{Method: "GET", URL: "/"},
+				Assert:  models.Assertions{Status: 200, JS: `check("id", response.json().id === "u-1", "wrong id")`},
+				Pre:     []models.Hook{{Name: "sign", JS: &models.JSHook{Code: `pm.request.headers.add({key: "Authorization", value: "Bearer abc"})`}}},
+				Post:    []models.Hook{{Name: "save", JS: &models.JSHook{Code: `pm.environment.set("userId", pm.response.json().id)`}}},
+			},
+			{
+				Name:    "checked",
+				Stage:   1,
+				Request: models.Request{Method: "GET", URL: "/"},
+				Post:    []models.Hook{{Name: "check", JS: &models.JSHook{Code: `pm.test("id matches", () => pm.expect(pm.response.json().id).to.equal(vars.userId + "x"))`}}},
+			},
+		},
+	}
+	results := map[string]TestResult{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sum, _ := RunSuite(ctx, s, Options{Workers: 1, OnResult: func(tr TestResult) { results[tr.Name] = tr }})
+	if len(auths) != 2 || auths[0] != "Bearer abc" || auths[1] != "" {
+		t.Fatalf("pre hook header should be sent with the first test only, got %q", auths)
+	}
+	if sum.Passed != 1 || sum.Failed != 1 {
+		t.Fatalf("summary = %+v", sum)
+	}
+	r := results["checked"]
+	if r.Status != "failed" || len(r.Messages) != 1 || r.Messages[0] != `id matches: expected "u-1" to equal "u-1x"` {
+		t.Fatalf("checked = %+v", r)
+	}
+}
+
+func TestRunAssertJS(t *testing.T) {
+	resp := &httpclient.Response{Status: 200, Headers: http.Header{}, Body: []byte(`{"items":[{"n":1},{"n":3},{"n":2}],"total":3}`)}
+	code := `var items = response.json().items;
+check("total matches", items.length === response.json().total);
+check("sorted", items.every((it, i) => i === 0 || items[i-1].n <= it.n), "items not sorted by n");
+check("user", vars.user === "ann");`
+	results, _ := runAssertJS(nil, code, models.Request{}, resp, map[string]string{"user": "ann"})
+	want := []struct {
+		passed bool
+		msg    string
+	}{{true, "js: total matches"}, {false, "js: sorted: items not sorted by n"}, {true, "js: user"}}
+	if len(results) != len(want) {
+		t.Fatalf("results = %+v", results)
+	}
+	for i, w := range want {
+		if results[i].Passed != w.passed || results[i].Msg != w.msg {
+			t.Errorf("result %d = %+v, want %+v", i, results[i], w)
+		}
+	}
+}
+
+func TestRunAssertJS_Sandbox(t *testing.T) {
+	env := &jsEnv{timeout: 50 * time.Millisecond}
+	resp := &httpclient.Response{Status: 200, Headers: http.Header{}}
+	cases := map[string]string{
+		`while (true) {}`:                        "assert.js: script timed out after 50ms",
+		`require("fs")`:                          "assert.js: ReferenceError: require is not defined",
+		`setVar("leak", "1"); check("x", false)`: "js: x",
+	}
+	for code, want := range cases {
+		vars := map[string]string{}
+		results, _ := runAssertJS(env, code, models.Request{}, resp, vars)
+		if len(results) != 1 || results[0].Passed || !strings.HasPrefix(results[0].Msg, want) {
+			t.Errorf("%s: results = %+v, want failure %q", code, results, want)
+		}
+		if vars["leak"] != "" {
+			t.Errorf("%s: assert.js must not change suite vars", code)
+		}
+	}
+}
+
+func TestRunAssertJS_PactMatch(t *testing.T) {
+	resp := &httpclient.Response{Status: 200, Headers: http.Header{}, Body: []byte(`{"id":"u-42","name":"Ann","tags":["a","b"],"orders":[{"id":7,"total":1.5},{"id":8,"total":"9"}],"extra":true}`)}
+	expected := `{"id":"u-1","name":"Ann","tags":["x"],"orders":[{"id":1,"total":2.5}]}`
+	cases := []struct {
+		rules string
+		want  string
+	}{
+		{`{}`, `$.id: expected "u-1", got "u-42"; $.tags: expected 1 item(s), got 2; $.orders: expected 1 item(s), got 2`},
+		{`{"$.id": {"matchers": [{"match": "regex", "regex": "u-\\d+"}]}, "$.tags": {"matchers": [{"match": "type", "min": 1}]}, "$.orders": {"matchers": [{"match": "type", "min": 1}]}}`, `$.orders[1].total: expected number, got string`},
+		{`{"$": {"matchers": [{"match": "type"}]}, "$.name": {"matchers": [{"match": "equality"}]}, "$.orders[*].total": {"matchers": [{"match": "number"}, {"match": "regex", "regex": "\\d+"}], "combine": "OR"}}`, ``},
+		{`{"$.orders": {"matchers": [{"match": "type", "min": 3}]}, "$.id": {"matchers": [{"match": "integer"}]}}`, `$.id: expected an integer, got "u-42"; $.tags: expected 1 item(s), got 2; $.orders: expected at least 3 item(s), got 2`},
+	}
+	for _, c := range cases {
+		code := `var m = pact.match(response.json(), ` + expected + `, ` + c.rules + `);
+check("pact", m.length === 0, m.join("; "));`
+		results, _ := runAssertJS(nil, code, models.Request{}, resp, nil)
+		got := ""
+		if len(results) != 1 {
+			t.Fatalf("%s: results = %+v", c.rules, results)
+		}
+		if !results[0].Passed {
+			got = strings.TrimPrefix(results[0].Msg, "js: pact: ")
+		}
+		if got != c.want {
+			t.Errorf("%s:\n got  %s\n want %s", c.rules, got, c.want)
+		}
+	}
+}
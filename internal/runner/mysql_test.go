@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/DrWeltschmerz/HydReq/internal/secrets"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// mysqlStub stands in for a MySQL server: it parses DSNs the way the mysql
+// driver does, records statements with their bound arguments and answers
+// SELECTs with one row of the arguments.
+type mysqlStub struct {
+	mu    sync.Mutex
+	cfgs  []*mysql.Config
+	stmts []string
+}
+
+func (d *mysqlStub) Open(dsn string) (driver.Conn, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	d.cfgs = append(d.cfgs, cfg)
+	d.mu.Unlock()
+	return &mysqlStubConn{d}, nil
+}
+
+func (d *mysqlStub) record(q string, args []driver.NamedValue) {
+	vals := make([]string, len(args))
+	for i, a := range args {
+		vals[i] = fmt.Sprint(a.Value)
+	}
+	d.mu.Lock()
+	d.stmts = append(d.stmts, q+" "+strings.Join(vals, ","))
+	d.mu.Unlock()
+}
+
+type mysqlStubConn struct{ d *mysqlStub }
+
+func (c *mysqlStubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("mysql stub: statements are not prepared")
+}
+func (c *mysqlStubConn) Close() error              { return nil }
+func (c *mysqlStubConn) Begin() (driver.Tx, error) { return echoTx{}, nil }
+
+func (c *mysqlStubConn) ExecContext(_ context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.record(q, args)
+	return driver.RowsAffected(strings.Count(q, "(?")), nil
+}
+
+func (c *mysqlStubConn) QueryContext(_ context.Context, q string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.record(q, args)
+	row := make([]driver.Value, len(args))
+	cols := make([]string, len(args))
+	for i, a := range args {
+		cols[i], row[i] = fmt.Sprintf("arg%d", i), a.Value
+	}
+	return &mysqlStubRows{cols: cols, rows: [][]driver.Value{row}}, nil
+}
+
+type mysqlStubRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *mysqlStubRows) Columns() []string { return r.cols }
+func (r *mysqlStubRows) Close() error      { return nil }
+func (r *mysqlStubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var (
+	mysqlStubDriver   = &mysqlStub{}
+	registerMySQLStub sync.Once
+)
+
+func TestRunSuite_MySQLStyleDSN(t *testing.T) {
+	registerMySQLStub.Do(func() { sql.Register("hydreq-mysql-stub", mysqlStubDriver) })
+	stub := mysqlStubDriver
+	stub.cfgs, stub.stmts = nil, nil
+	if err := RegisterDriver(DriverInfo{Name: "hydreq-mysql-stub"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HYDREQ_TEST_MYSQL_PASS", "my-s3cret")
+	defer secrets.Reset()
+	affected := int64(2)
+	s := &models.Suite{
+		Name:      "mysql",
+		Variables: map[string]string{"who": "o'brien"},
+		Databases: map[string]models.Database{"main": {
+			Driver: "hydreq-mysql-stub",
+			DSN:    "root:${ENV:HYDREQ_TEST_MYSQL_PASS}@tcp(db.internal:3306)/qa?parseTime=true",
+		}},
+		Tests: []models.TestCase{
+			{
+				Name: "insert with placeholders",
+				SQL: &models.SQLHook{DB: "main", Query: `INSERT INTO people (id, name) VALUES (?, ?), (?, ?)`, Args: []any{1, "${who}", 2, "ada"},
+					Assert: &models.SQLAssertions{RowsAffected: &affected}},
+			},
+			{
+				Name:  "select with placeholders",
+				Stage: 1,
+				SQL: &models.SQLHook{DB: "main", Query: `SELECT id FROM people WHERE name = ?`, Args: []any{"${who}"},
+					Extract: map[string]string{"name": "arg0"},
+					Assert:  &models.SQLAssertions{Rows: []models.SQLRowAssert{{Equals: map[string]any{"arg0": "o'brien"}}}}},
+			},
+			{
+				Name:  "extracted value as argument",
+				Stage: 2,
+				SQL:   &models.SQLHook{DB: "main", Query: `SELECT COUNT(*) FROM people WHERE name = ?`, Args: []any{"${name}"}},
+			},
+		},
+	}
+	var results []TestResult
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sum, err := RunSuite(ctx, s, Options{Workers: 1, OnResult: func(tr TestResult) { results = append(results, tr) }})
+	if err != nil || sum.Passed != 3 {
+		t.Fatalf("run: %v, summary = %+v, results = %+v", err, sum, results)
+	}
+	if len(stub.cfgs) != 1 {
+		t.Fatalf("named database opened %d times", len(stub.cfgs))
+	}
+	if cfg := stub.cfgs[0]; cfg.User != "root" || cfg.Passwd != "my-s3cret" || cfg.Addr != "db.internal:3306" || cfg.DBName != "qa" || !cfg.ParseTime {
+		t.Fatalf("dsn = %+v", cfg)
+	}
+	want := []string{
+		"INSERT INTO people (id, name) VALUES (?, ?), (?, ?) 1,o'brien,2,ada",
+		"SELECT id FROM people WHERE name = ? o'brien",
+		"SELECT COUNT(*) FROM people WHERE name = ? o'brien",
+	}
+	if got := strings.Join(stub.stmts, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("statements:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+	// the password of a MySQL-style DSN is masked once the DSN is used
+	if got := secrets.Redact("dsn root:my-s3cret@tcp(db.internal:3306)/qa"); strings.Contains(got, "my-s3cret") {
+		t.Fatalf("password not redacted: %q", got)
+	}
+}
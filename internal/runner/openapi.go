@@ -0,0 +1,315 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/internal/httpclient"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+// OpenAPI request validation modes (openApi.request).
+const (
+	openapiOff  = "off"
+	openapiWarn = "warn"
+	openapiFail = "fail"
+)
+
+// OpenAPISpec lists the operations of a suite's spec, for coverage reports.
+type OpenAPISpec struct {
+	File       string
+	Operations []OpenAPIOperation
+}
+
+// OpenAPIOperation is one method and path template of a spec.
+type OpenAPIOperation struct {
+	Method   string
+	Path     string
+	Statuses []string       // documented response codes, e.g. 200, 4XX, default
+	Params   []OpenAPIParam // path item and operation parameters
+}
+
+// OpenAPIParam names a declared parameter.
+type OpenAPIParam struct {
+	Name string
+	In   string // path|query|header|cookie
+}
+
+// OpenAPIHit records the spec operation a test's request matched.
+type OpenAPIHit struct {
+	Spec   string
+	Method string
+	Path   string // path template, e.g. /users/{id}
+	Status int
+	Params []OpenAPIParam // declared parameters the request carried
+}
+
+type openapiRuntime struct {
+	enabled     bool
+	file        string
+	doc         *openapi3.T
+	router      routers.Router
+	basePaths   []string // server base paths, longest first; "" matches the raw path
+	requestMode string   // suite default for request validation
+}
+
+// newOpenAPIRuntime builds a router that matches paths relative to the
+// spec's servers. The router itself sees no servers, so a test hitting
+// http://localhost:8080/v1/users matches /users under a server of
+// https://api.example.com/v1.
+func newOpenAPIRuntime(doc *openapi3.T, cfg *models.OpenAPIConfig) (*openapiRuntime, error) {
+	bare := *doc
+	bare.Servers = nil
+	rtr, err := legacy.NewRouter(&bare)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{"": true}
+	bases := []string{""}
+	for _, srv := range doc.Servers {
+		bp, err := srv.BasePath()
+		if err != nil {
+			continue
+		}
+		bp = strings.TrimSuffix(bp, "/")
+		if !seen[bp] {
+			seen[bp] = true
+			bases = append(bases, bp)
+		}
+	}
+	sort.SliceStable(bases, func(i, j int) bool { return len(bases[i]) > len(bases[j]) })
+	mode, err := openapiRequestMode(cfg.Request)
+	if err != nil {
+		return nil, err
+	}
+	return &openapiRuntime{enabled: true, file: cfg.File, doc: doc, router: rtr, basePaths: bases, requestMode: mode}, nil
+}
+
+// openapiRequestMode checks an openApi.request value; empty means warn.
+func openapiRequestMode(s string) (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(s)); m {
+	case "":
+		return openapiWarn, nil
+	case openapiOff, openapiWarn, openapiFail:
+		return m, nil
+	}
+	return "", fmt.Errorf("openApi.request: unknown mode %q (want off, warn or fail)", s)
+}
+
+// findRoute matches req against the spec, trying each server base path.
+// The returned request carries the path relative to that base.
+func (o *openapiRuntime) findRoute(req *http.Request) (*http.Request, *routers.Route, map[string]string, error) {
+	var lastErr error
+	for _, bp := range o.basePaths {
+		path := req.URL.Path
+		if bp != "" {
+			if path != bp && !strings.HasPrefix(path, bp+"/") {
+				continue
+			}
+			path = strings.TrimPrefix(path, bp)
+			if path == "" {
+				path = "/"
+			}
+		}
+		u := *req.URL
+		u.Path, u.RawPath = path, ""
+		rel := new(http.Request)
+		*rel = *req
+		rel.URL = &u
+		route, params, err := o.router.FindRoute(rel)
+		if err == nil {
+			return rel, route, params, nil
+		}
+		lastErr = err
+	}
+	return nil, nil, nil, lastErr
+}
+
+// openapiFor reports whether OpenAPI validation applies to t.
+func openapiFor(opts Options, t models.TestCase) bool {
+	if opts.oapi == nil || !opts.oapi.enabled {
+		return false
+	}
+	if t.OpenAPI != nil && t.OpenAPI.Enabled != nil {
+		return *t.OpenAPI.Enabled
+	}
+	return true
+}
+
+// validateOpenAPIRequest checks the request about to be sent against the
+// spec. It returns a message when the request does not conform (or has no
+// route) and whether that should fail the test.
+func validateOpenAPIRequest(ctx context.Context, t models.TestCase, sent models.Request, opts Options) (string, bool) {
+	if !openapiFor(opts, t) {
+		return "", false
+	}
+	mode := opts.oapi.requestMode
+	if t.OpenAPI != nil && t.OpenAPI.Request != "" {
+		m, err := openapiRequestMode(t.OpenAPI.Request)
+		if err != nil {
+			return err.Error(), true
+		}
+		mode = m
+	}
+	if mode == openapiOff {
+		return "", false
+	}
+	req, err := httpclient.NewRequest(ctx, sent.Method, sent.URL, sent.Headers, sent.Query, sent.Body)
+	if err != nil {
+		return "", false // the send reports it
+	}
+	rel, route, params, err := opts.oapi.findRoute(req)
+	if err == nil {
+		in := &openapi3filter.RequestValidationInput{
+			Request:    rel,
+			PathParams: params,
+			Route:      route,
+			Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+		}
+		err = openapi3filter.ValidateRequest(ctx, in)
+		if err == nil {
+			return "", false
+		}
+		err = fmt.Errorf("openapi request: %v", err)
+	} else {
+		err = fmt.Errorf("openapi request route not found: %v", err)
+	}
+	if mode == openapiWarn {
+		return "warning: " + err.Error(), false
+	}
+	return err.Error(), true
+}
+
+// spec lists the operations of the loaded spec, sorted by path and method.
+func (o *openapiRuntime) spec() *OpenAPISpec {
+	out := &OpenAPISpec{File: o.file}
+	paths := o.doc.Paths.Map()
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+	for _, p := range keys {
+		item := paths[p]
+		ops := item.Operations()
+		methods := make([]string, 0, len(ops))
+		for m := range ops {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+		for _, m := range methods {
+			op := ops[m]
+			oo := OpenAPIOperation{Method: m, Path: p, Params: operationParams(item, op)}
+			if op.Responses != nil {
+				for code := range op.Responses.Map() {
+					oo.Statuses = append(oo.Statuses, code)
+				}
+				sort.Strings(oo.Statuses)
+			}
+			out.Operations = append(out.Operations, oo)
+		}
+	}
+	return out
+}
+
+// operationParams merges path item and operation parameters; the operation
+// wins when both declare the same name and location.
+func operationParams(item *openapi3.PathItem, op *openapi3.Operation) []OpenAPIParam {
+	var out []OpenAPIParam
+	seen := map[OpenAPIParam]bool{}
+	for _, list := range []openapi3.Parameters{op.Parameters, item.Parameters} {
+		for _, ref := range list {
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			p := OpenAPIParam{Name: ref.Value.Name, In: ref.Value.In}
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// hit matches the sent request against the spec and records which of the
+// operation's parameters it carried. It returns nil when no route matches.
+func (o *openapiRuntime) hit(ctx context.Context, sent models.Request, status int) *OpenAPIHit {
+	req, err := httpclient.NewRequest(ctx, sent.Method, sent.URL, sent.Headers, sent.Query, sent.Body)
+	if err != nil {
+		return nil
+	}
+	rel, route, _, err := o.findRoute(req)
+	if err != nil {
+		return nil
+	}
+	h := &OpenAPIHit{Spec: o.file, Method: route.Method, Path: route.Path, Status: status}
+	query := rel.URL.Query()
+	for _, p := range operationParams(route.PathItem, route.Operation) {
+		used := false
+		switch p.In {
+		case openapi3.ParameterInPath:
+			used = true
+		case openapi3.ParameterInQuery:
+			_, used = query[p.Name]
+		case openapi3.ParameterInHeader:
+			used = rel.Header.Get(p.Name) != ""
+		case openapi3.ParameterInCookie:
+			_, cerr := rel.Cookie(p.Name)
+			used = cerr == nil
+		}
+		if used {
+			h.Params = append(h.Params, p)
+		}
+	}
+	return h
+}
+
+// OpenAPIMatcher finds the spec operation a test targets, matching paths
+// under the spec's server base paths the way a run does.
+type OpenAPIMatcher struct {
+	rt *openapiRuntime
+}
+
+// NewOpenAPIMatcher builds a matcher for doc.
+func NewOpenAPIMatcher(doc *openapi3.T) (*OpenAPIMatcher, error) {
+	rt, err := newOpenAPIRuntime(doc, &models.OpenAPIConfig{})
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAPIMatcher{rt: rt}, nil
+}
+
+// MatchTest resolves t's URL against the suite's baseUrl and variables and
+// returns the method and path template of the operation it hits. Variables
+// only known at run time (extracted values, matrix entries) stay as
+// placeholders, which still match templated path segments.
+func (m *OpenAPIMatcher) MatchTest(s *models.Suite, t models.TestCase) (method, path string, ok bool) {
+	if t.SQL != nil {
+		return "", "", false
+	}
+	vars := make(map[string]string, len(s.Variables)+len(t.Vars))
+	for k, v := range s.Variables {
+		vars[k] = v
+	}
+	for k, v := range t.Vars {
+		vars[k] = v
+	}
+	u := resolveURL(interpolate(s.BaseURL, vars), interpolate(t.Request.URL, vars))
+	req, err := http.NewRequest(strings.ToUpper(t.Request.Method), u, nil)
+	if err != nil {
+		return "", "", false
+	}
+	_, route, _, err := m.rt.findRoute(req)
+	if err != nil {
+		return "", "", false
+	}
+	return route.Method, route.Path, true
+}
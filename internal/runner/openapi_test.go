@@ -0,0 +1,194 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const openapiTestSpec = `openapi: 3.0.3
+info: {title: users, version: "1"}
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /users:
+    post:
+      parameters:
+        - {name: dryRun, in: query, required: true, schema: {type: boolean}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string}
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id]
+                properties:
+                  id: {type: integer}
+`
+
+func openapiTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	t.Cleanup(srv.Close)
+	spec := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(spec, []byte(openapiTestSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return srv, spec
+}
+
+func TestOpenAPIRequestValidation(t *testing.T) {
+	srv, spec := openapiTestServer(t)
+	valid := models.Request{Method: "POST", URL: "/v1/users", Query: map[string]string{"dryRun": "true"}, Body: map[string]any{"name": "ada"}}
+	invalid := models.Request{Method: "POST", URL: "/v1/users", Body: map[string]any{"nick": "ada"}}
+
+	tests := []struct {
+		name     string
+		mode     string
+		override string
+		req      models.Request
+		status   string
+		msg      string
+	}{
+		{name: "valid request under server base path", req: valid, status: "passed"},
+		{name: "warn by default", req: invalid, status: "passed", msg: "warning: openapi request:"},
+		{name: "fail mode", mode: "fail", req: invalid, status: "failed", msg: "openapi request:"},
+		{name: "off mode", mode: "off", req: invalid, status: "passed"},
+		{name: "per-test override", mode: "fail", override: "warn", req: invalid, status: "passed", msg: "warning: openapi request:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := models.TestCase{Name: "create", Request: tt.req, Assert: models.Assertions{Status: 201}}
+			if tt.override != "" {
+				tc.OpenAPI = &models.OpenAPITest{Request: tt.override}
+			}
+			s := models.Suite{
+				BaseURL: srv.URL,
+				OpenAPI: &models.OpenAPIConfig{File: spec, Enabled: true, Request: tt.mode},
+				Tests:   []models.TestCase{tc},
+			}
+			var c collector
+			_, _ = RunSuite(context.Background(), &s, Options{Workers: 1, OnResult: c.onResult})
+			if len(c.results) != 1 {
+				t.Fatalf("got %d results, want 1", len(c.results))
+			}
+			r := c.results[0]
+			if r.Status != tt.status {
+				t.Fatalf("status = %s, want %s (messages %v)", r.Status, tt.status, r.Messages)
+			}
+			if tt.msg == "" {
+				if len(r.Messages) > 0 {
+					t.Fatalf("unexpected messages %v", r.Messages)
+				}
+				return
+			}
+			found := false
+			for _, m := range r.Messages {
+				if strings.HasPrefix(m, tt.msg) {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("no message starting with %q in %v", tt.msg, r.Messages)
+			}
+		})
+	}
+}
+
+func TestOpenAPIRequestModeUnknown(t *testing.T) {
+	if _, err := openapiRequestMode("strict"); err == nil {
+		t.Fatal("expected an error for an unknown mode")
+	}
+}
+
+func TestOpenAPIHit(t *testing.T) {
+	srv, spec := openapiTestServer(t)
+	s := models.Suite{
+		BaseURL: srv.URL,
+		OpenAPI: &models.OpenAPIConfig{File: spec, Enabled: true},
+		Tests: []models.TestCase{
+			{Name: "create", Request: models.Request{Method: "POST", URL: "/v1/users", Query: map[string]string{"dryRun": "true"}, Body: map[string]any{"name": "ada"}}},
+			{Name: "unknown", Request: models.Request{Method: "GET", URL: "/v1/teams"}},
+		},
+	}
+	var c collector
+	sum, _ := RunSuite(context.Background(), &s, Options{Workers: 1, OnResult: c.onResult})
+	if sum.OpenAPI == nil || len(sum.OpenAPI.Operations) != 1 {
+		t.Fatalf("summary spec = %+v", sum.OpenAPI)
+	}
+	if op := sum.OpenAPI.Operations[0]; op.Method != "POST" || op.Path != "/users" || len(op.Statuses) != 1 || op.Statuses[0] != "201" {
+		t.Fatalf("operation = %+v", op)
+	}
+	hits := map[string]*OpenAPIHit{}
+	for _, r := range c.results {
+		hits[r.Name] = r.OpenAPI
+	}
+	h := hits["create"]
+	if h == nil || h.Spec != spec || h.Path != "/users" || h.Status != 201 {
+		t.Fatalf("create hit = %+v", h)
+	}
+	if len(h.Params) != 1 || h.Params[0] != (OpenAPIParam{Name: "dryRun", In: "query"}) {
+		t.Fatalf("create params = %+v", h.Params)
+	}
+	if hits["unknown"] != nil {
+		t.Fatalf("unknown route recorded a hit: %+v", hits["unknown"])
+	}
+}
+
+func TestOpenAPIMatcher(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(openapiTestSpec + `  /users/{id}:
+    get:
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses:
+        "200": {description: ok}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewOpenAPIMatcher(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &models.Suite{BaseURL: "http://localhost:8080/${prefix}", Variables: map[string]string{"prefix": "v1"}}
+	tests := []struct {
+		name   string
+		tc     models.TestCase
+		method string
+		path   string
+	}{
+		{name: "suite variables", tc: models.TestCase{Request: models.Request{Method: "post", URL: "/users"}}, method: "POST", path: "/users"},
+		{name: "run-time placeholder", tc: models.TestCase{Request: models.Request{Method: "GET", URL: "/users/${userId}"}}, method: "GET", path: "/users/{id}"},
+		{name: "test vars", tc: models.TestCase{Vars: map[string]string{"id": "7"}, Request: models.Request{Method: "GET", URL: "/users/${id}"}}, method: "GET", path: "/users/{id}"},
+		{name: "unknown route", tc: models.TestCase{Request: models.Request{Method: "DELETE", URL: "/users"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, path, ok := m.MatchTest(s, tt.tc)
+			if ok != (tt.method != "") || method != tt.method || path != tt.path {
+				t.Fatalf("MatchTest = %s %s %v, want %s %s", method, path, ok, tt.method, tt.path)
+			}
+		})
+	}
+}
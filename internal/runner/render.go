@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"encoding/base64"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/internal/secrets"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// RenderRequest returns the request t sends, as far as it is known before a
+// run: method upper-cased, URL resolved against the suite baseUrl,
+// Content-Type set for structured bodies and the suite auth header added when
+// t sets no Authorization of its own. A graphql operation becomes the body
+// (or query, for GET) it is sent as. Suite and test vars are interpolated;
+// values captured by earlier tests are not known yet, so their ${name}
+// references stay.
+//
+// With keepVars only the test's own vars are substituted: suite variables
+// and ${ENV:NAME} references stay placeholders and bearer auth reads
+// ${ENV:NAME}. Basic auth has to be encoded, so it is only added when
+// interpolating.
+func RenderRequest(s *models.Suite, t models.TestCase, keepVars bool) models.Request {
+	if t.Request.GraphQL != nil {
+		if r, err := graphQLRequest(t.Request); err == nil {
+			t.Request = r
+		}
+	}
+	vars := make(map[string]string, len(s.Variables)+len(t.Vars))
+	if !keepVars {
+		for k, v := range s.Variables {
+			vars[k] = v
+		}
+	}
+	for k, v := range t.Vars {
+		vars[k] = v
+	}
+	render := func(str string) string {
+		if !keepVars {
+			return interpolate(str, vars)
+		}
+		for k, v := range vars {
+			str = strings.ReplaceAll(str, "${"+k+"}", v)
+		}
+		return str
+	}
+	out := models.Request{
+		Method:  strings.ToUpper(t.Request.Method),
+		URL:     resolveURL(render(s.BaseURL), render(t.Request.URL)),
+		Headers: make(map[string]string, len(t.Request.Headers)+2),
+		Body:    mapStrings(t.Request.Body, render),
+	}
+	if out.Method == "" {
+		out.Method = "GET"
+	}
+	for k, v := range t.Request.Headers {
+		out.Headers[k] = render(v)
+	}
+	if len(t.Request.Query) > 0 {
+		out.Query = make(map[string]string, len(t.Request.Query))
+		for k, v := range t.Request.Query {
+			out.Query[k] = render(v)
+		}
+	}
+	switch out.Body.(type) {
+	case nil, string, []byte:
+	default:
+		if _, ok := out.Headers["Content-Type"]; !ok {
+			out.Headers["Content-Type"] = "application/json"
+		}
+	}
+	if _, ok := out.Headers["Authorization"]; !ok && s.Auth != nil {
+		switch {
+		case s.Auth.BearerEnv != "" && keepVars:
+			out.Headers["Authorization"] = "Bearer ${ENV:" + s.Auth.BearerEnv + "}"
+		case s.Auth.BearerEnv != "":
+			if token := os.Getenv(s.Auth.BearerEnv); token != "" {
+				out.Headers["Authorization"] = "Bearer " + token
+			}
+		case s.Auth.BasicEnv != "" && !keepVars:
+			if creds := os.Getenv(s.Auth.BasicEnv); creds != "" {
+				out.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(creds))
+			}
+		}
+	}
+	return out
+}
+
+var secretRefPattern = regexp.MustCompile(`\$\{SECRET:([^{}]+)\}`)
+
+// UnknownSecrets lists the ${SECRET:NAME} references in t's request, with
+// suite and test vars substituted, that neither the environment nor a loaded
+// secrets file defines. RenderRequest renders them empty, as a run does.
+func UnknownSecrets(s *models.Suite, t models.TestCase) []string {
+	if t.Request.GraphQL != nil {
+		if r, err := graphQLRequest(t.Request); err == nil {
+			t.Request = r
+		}
+	}
+	vars := make(map[string]string, len(s.Variables)+len(t.Vars))
+	for k, v := range s.Variables {
+		vars[k] = v
+	}
+	for k, v := range t.Vars {
+		vars[k] = v
+	}
+	seen := map[string]bool{}
+	var names []string
+	scan := func(str string) string {
+		for k, v := range vars {
+			str = strings.ReplaceAll(str, "${"+k+"}", v)
+		}
+		for _, m := range secretRefPattern.FindAllStringSubmatch(str, -1) {
+			if _, ok := secrets.Lookup(m[1]); !ok && !seen[m[1]] {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+		}
+		return str
+	}
+	scan(s.BaseURL)
+	scan(t.Request.URL)
+	for _, v := range t.Request.Headers {
+		scan(v)
+	}
+	for _, v := range t.Request.Query {
+		scan(v)
+	}
+	mapStrings(t.Request.Body, scan)
+	sort.Strings(names)
+	return names
+}
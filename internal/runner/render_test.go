@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestRenderRequest(t *testing.T) {
+	t.Setenv("RENDER_TOKEN", "t0k")
+	s := &models.Suite{
+		BaseURL:   "${base}",
+		Variables: map[string]string{"base": "https://api.example.com", "tenant": "acme"},
+		Auth:      &models.Auth{BearerEnv: "RENDER_TOKEN"},
+	}
+	tc := models.TestCase{
+		Vars: map[string]string{"name": "ann"},
+		Request: models.Request{
+			Method:  "post",
+			URL:     "/users",
+			Headers: map[string]string{"X-Tenant": "${tenant}"},
+			Query:   map[string]string{"trace": "${traceId}"},
+			Body:    map[string]any{"name": "${name}", "tags": []any{"${tenant}"}},
+		},
+	}
+
+	got := RenderRequest(s, tc, false)
+	want := models.Request{
+		Method:  "POST",
+		URL:     "https://api.example.com/users",
+		Headers: map[string]string{"X-Tenant": "acme", "Content-Type": "application/json", "Authorization": "Bearer t0k"},
+		Query:   map[string]string{"trace": "${traceId}"},
+		Body:    map[string]any{"name": "ann", "tags": []any{"acme"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("interpolated:\n got %+v\nwant %+v", got, want)
+	}
+
+	got = RenderRequest(s, tc, true)
+	want = models.Request{
+		Method:  "POST",
+		URL:     "${base}/users",
+		Headers: map[string]string{"X-Tenant": "${tenant}", "Content-Type": "application/json", "Authorization": "Bearer ${ENV:RENDER_TOKEN}"},
+		Query:   map[string]string{"trace": "${traceId}"},
+		Body:    map[string]any{"name": "ann", "tags": []any{"${tenant}"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("keepVars:\n got %+v\nwant %+v", got, want)
+	}
+
+	t.Setenv("RENDER_CREDS", "u:p")
+	s.Auth = &models.Auth{BasicEnv: "RENDER_CREDS"}
+	if h := RenderRequest(s, tc, false).Headers["Authorization"]; h != "Basic dTpw" {
+		t.Fatalf("basic auth = %q", h)
+	}
+	if h, ok := RenderRequest(s, tc, true).Headers["Authorization"]; ok {
+		t.Fatalf("basic auth kept with keepVars: %q", h)
+	}
+}
+
+func TestUnknownSecrets(t *testing.T) {
+	t.Setenv("RENDER_KNOWN", "k")
+	s := &models.Suite{
+		BaseURL:   "https://${host}",
+		Variables: map[string]string{"host": "${SECRET:HOST_SECRET}", "unused": "${SECRET:UNUSED}"},
+	}
+	tc := models.TestCase{Request: models.Request{
+		URL:     "/",
+		Headers: map[string]string{"X-Known": "${SECRET:RENDER_KNOWN}", "X-Key": "${SECRET:API_KEY}"},
+		Body:    map[string]any{"nested": []any{"${SECRET:BODY_SECRET}"}},
+	}}
+	got := UnknownSecrets(s, tc)
+	if want := []string{"API_KEY", "BODY_SECRET", "HOST_SECRET"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
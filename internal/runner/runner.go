@@ -3,32 +3,26 @@ package runner
 import (
 	"context"
 	crand "crypto/rand"
-	"database/sql"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
-	neturl "net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/dop251/goja"
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
-	"github.com/getkin/kin-openapi/routers"
-	"github.com/getkin/kin-openapi/routers/legacy"
-	_ "github.com/jackc/pgx/v5/stdlib"
-	_ "github.com/microsoft/go-mssqldb"
 	"github.com/tidwall/gjson"
-	"gopkg.in/yaml.v3"
-	_ "modernc.org/sqlite"
 
 	"github.com/DrWeltschmerz/HydReq/internal/httpclient"
+	"github.com/DrWeltschmerz/HydReq/internal/report"
+	"github.com/DrWeltschmerz/HydReq/internal/secrets"
 	"github.com/DrWeltschmerz/HydReq/internal/ui"
 	"github.com/DrWeltschmerz/HydReq/pkg/assert"
 	"github.com/DrWeltschmerz/HydReq/pkg/models"
@@ -43,6 +37,8 @@ type Summary struct {
 	Failed   int
 	Skipped  int
 	Duration time.Duration
+	Hooks    []HookResult // preSuite/postSuite hook outcomes
+	OpenAPI  *OpenAPISpec // operations of the suite's spec, when one is loaded
 }
 
 // Options controls runner behavior
@@ -52,8 +48,13 @@ type Options struct {
 	Workers          int
 	OnResult         func(TestResult)
 	OnStart          func(TestResult)
-	DefaultTimeoutMs int             // default per-test request timeout when test.timeoutMs is not set
-	oapi             *openapiRuntime // internal
+	OnHook           func(HookResult) // called when a hook starts (status=running) and finishes
+	DefaultTimeoutMs int              // default per-test request timeout when test.timeoutMs is not set
+	oapi             *openapiRuntime  // internal
+	dbs              *dbPool          // internal: connections opened during the run
+	tx               *testTx          // internal: current test's rollbackAfterTest transactions
+	js               *jsExchange      // internal: current test's request/response for JS hooks
+	scripts          *jsEnv           // internal: suite script libraries and limits
 }
 
 // TestResult carries a single test outcome for reporting
@@ -61,9 +62,40 @@ type TestResult struct {
 	Name       string
 	Stage      int
 	Tags       []string
+	Source     string // suite file the test was included from; empty for the root file
 	Status     string // passed|failed|skipped
 	DurationMs int64
 	Messages   []string
+	Hooks      []HookResult         // pre/post hooks executed around this test
+	OpenAPI    *OpenAPIHit          // spec operation the request matched, if any
+	Request    *models.Request      // request as sent, after interpolation; nil for SQL tests
+	Response   *httpclient.Response // last response received, if any
+}
+
+// HookResult carries a single hook outcome for reporting
+type HookResult struct {
+	Name         string
+	Scope        string // preSuite|postSuite|pre|post
+	Test         string // owning test name for pre/post hooks
+	Index        int    // position of the hook within its scope
+	Status       string // running|passed|failed
+	DurationMs   int64
+	HTTPStatus   int      // status of the hook request, if any
+	RowsAffected int64    // rows affected by a SQL statement, if any
+	Console      []string // console output of a JS hook
+	Messages     []string
+}
+
+// HookSteps converts hook outcomes into report hook steps.
+func HookSteps(hooks []HookResult) []report.HookStep {
+	if len(hooks) == 0 {
+		return nil
+	}
+	out := make([]report.HookStep, 0, len(hooks))
+	for _, h := range hooks {
+		out = append(out, report.HookStep{Name: h.Name, Scope: h.Scope, Status: h.Status, DurationMs: h.DurationMs, HTTPStatus: h.HTTPStatus, RowsAffected: h.RowsAffected, Console: h.Console, Messages: h.Messages})
+	}
+	return out
 }
 
 // internal case result type used between goroutines and runOne
@@ -72,10 +104,25 @@ type caseResult struct {
 	passed     bool
 	failed     bool
 	durationMs int64
+	status     int
 	messages   []string
 	name       string
 	stage      int
 	tags       []string
+	source     string
+	hooks      []HookResult
+	sent       models.Request       // request as sent, after interpolation
+	resp       *httpclient.Response // last response, if any
+	openapi    *OpenAPIHit
+}
+
+// request returns the request the case sent, or nil when it sent none.
+func (r caseResult) request() *models.Request {
+	if r.sent.Method == "" {
+		return nil
+	}
+	sent := r.sent
+	return &sent
 }
 
 // genEmail creates a simple deterministic-looking random email for tests
@@ -86,31 +133,49 @@ func genEmail() string {
 	return fmt.Sprintf("qa-%s@example.com", local)
 }
 
-type openapiRuntime struct {
-	enabled bool
-	doc     *openapi3.T
-	router  routers.Router
-}
-
 func LoadSuite(path string) (*models.Suite, error) {
-	b, err := os.ReadFile(path)
+	rootDir := ""
+	if abs, err := filepath.Abs(path); err == nil {
+		rootDir = filepath.Dir(abs)
+	}
+	s, err := loadSuiteFile(path, rootDir, nil)
 	if err != nil {
 		return nil, err
 	}
-	var s models.Suite
-	if err := yaml.Unmarshal(b, &s); err != nil {
+	if err := ResolveTemplates(s); err != nil {
 		return nil, err
 	}
-	return &s, nil
+	if err := CheckDatabases(s); err != nil {
+		return nil, err
+	}
+	if err := CheckScripts(s); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
 func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, error) {
 	start := time.Now()
+	var sum Summary
+	// Suites built in memory (e.g. by the web UI) bypass LoadSuite, so resolve extends here too.
+	if err := ResolveTemplates(s); err != nil {
+		return sum, fmt.Errorf("%w: %v", ErrSuiteNotRunnable, err)
+	}
+	if err := LoadSecretsFile(s); err != nil {
+		return sum, fmt.Errorf("%w: secrets file: %v", ErrSuiteNotRunnable, err)
+	}
 	vars := map[string]string{}
 	for k, v := range s.Variables {
-		vars[k] = v
+		vars[k] = interpolateSecretRefs(v)
 	}
-	var sum Summary
+	registerSecrets(s, vars)
+	scripts, err := newJSEnv(s)
+	if err != nil {
+		return sum, fmt.Errorf("%w: %v", ErrSuiteNotRunnable, err)
+	}
+	opts.scripts = scripts
+	opts.dbs = newDBPool(s)
+	defer opts.dbs.Close()
 
 	// Identify if any test has Only=true; if so, skip others.
 	only := false
@@ -122,13 +187,16 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 	}
 
 	// Expand matrices
-	testsExpanded := expandTestCases(s.Tests)
+	testsExpanded := ExpandMatrix(s.Tests)
 
 	// Preflight: if any request URL is relative/path-like and suite.baseUrl is empty after
 	// interpolation, refuse to run the suite so we don't generate misleading request errors.
 	{
 		needBase := false
 		for _, t := range testsExpanded {
+			if t.SQL != nil {
+				continue
+			}
 			url := interpolate(t.Request.URL, vars)
 			if !(strings.HasPrefix(strings.ToLower(url), "http://") || strings.HasPrefix(strings.ToLower(url), "https://")) {
 				needBase = true
@@ -146,8 +214,10 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 
 	// Run preSuite hooks sequentially (respect vars)
 	if len(s.PreSuite) > 0 {
-		for _, h := range s.PreSuite {
-			if err := runHook(ctx, s, &vars, h, opts); err != nil {
+		for i, h := range s.PreSuite {
+			hr, err := runHookReported(ctx, s, &vars, h, opts, "preSuite", "", i)
+			sum.Hooks = append(sum.Hooks, hr)
+			if err != nil {
 				sum.Failed++
 				sum.Duration = time.Since(start)
 				return sum, fmt.Errorf("preSuite hook '%s' failed: %w", h.Name, err)
@@ -177,10 +247,11 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 				ui.Failf("OpenAPI load error: %v", err)
 			} else if err := doc.Validate(ctx); err != nil {
 				ui.Failf("OpenAPI spec invalid: %v", err)
-			} else if rtr, err := legacy.NewRouter(doc); err != nil {
+			} else if rt, err := newOpenAPIRuntime(doc, s.OpenAPI); err != nil {
 				ui.Failf("OpenAPI router error: %v", err)
 			} else {
-				opts.oapi = &openapiRuntime{enabled: true, doc: doc, router: rtr}
+				opts.oapi = rt
+				sum.OpenAPI = rt.spec()
 			}
 		}
 	}
@@ -203,7 +274,7 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 				sum.Skipped++
 				ui.Skipf("%s (only)", t.Name)
 				if opts.OnResult != nil {
-					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Status: "skipped", Messages: []string{"filtered by only"}})
+					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Source: t.Source, Status: "skipped", Messages: []string{"filtered by only"}})
 				}
 				skipped[t.Name] = "only"
 				continue
@@ -212,7 +283,7 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 				sum.Skipped++
 				ui.Skipf("%s (skip)", t.Name)
 				if opts.OnResult != nil {
-					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Status: "skipped", Messages: []string{"explicit skip"}})
+					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Source: t.Source, Status: "skipped", Messages: []string{"explicit skip"}})
 				}
 				skipped[t.Name] = "skip"
 				continue
@@ -221,7 +292,7 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 				sum.Skipped++
 				ui.Skipf("%s (tags)", t.Name)
 				if opts.OnResult != nil {
-					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Status: "skipped", Messages: []string{"filtered by tags"}})
+					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Source: t.Source, Status: "skipped", Messages: []string{"filtered by tags"}})
 				}
 				skipped[t.Name] = "tags"
 				continue
@@ -241,7 +312,7 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 						sum.Skipped++
 						ui.Skipf("%s (dep filtered: %s)", t.Name, dep)
 						if opts.OnResult != nil {
-							opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Status: "skipped", Messages: []string{"dependency filtered: " + dep}})
+							opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Source: t.Source, Status: "skipped", Messages: []string{"dependency filtered: " + dep}})
 						}
 						delete(kept, name)
 						skipped[name] = "dep-filtered"
@@ -321,23 +392,13 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 				if opts.OnStart != nil {
 					nm := interpolate(t.Name, testVars)
 					// DAG scheduling: flatten to a single visual stage (0)
-					opts.OnStart(TestResult{Name: nm, Stage: 0, Tags: t.Tags, Status: "running"})
+					opts.OnStart(TestResult{Name: nm, Stage: 0, Tags: t.Tags, Source: t.Source, Status: "running"})
 				}
 				go func(tc models.TestCase, vv map[string]string) {
 					defer func() { <-sem }()
-					// pre hooks
-					if len(tc.Pre) > 0 {
-						_ = runHooksSequential(ctx, s, vv, tc.Pre, opts)
-					}
-					r := runOne(ctx, s, tc, vv, opts)
-					r.name = tc.Name
+					r := runTestWithHooks(ctx, s, tc, vv, opts)
 					// DAG scheduling: flatten to stage 0 for reporting/UI progress
 					r.stage = 0
-					r.tags = tc.Tags
-					// post hooks (only run if test passed)
-					if r.passed && len(tc.Post) > 0 {
-						_ = runHooksSequential(ctx, s, vv, tc.Post, opts)
-					}
 					done <- r
 				}(t, testVars)
 			}
@@ -359,7 +420,7 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 						status = "passed"
 					}
 					// For DAG, r.stage is flattened to 0 for consistent UI stage progress
-					opts.OnResult(TestResult{Name: r.name, Stage: r.stage, Tags: r.tags, Status: status, DurationMs: r.durationMs, Messages: r.messages})
+					opts.OnResult(TestResult{Name: r.name, Stage: r.stage, Tags: r.tags, Source: r.source, Status: status, DurationMs: r.durationMs, Messages: r.messages, Hooks: r.hooks, OpenAPI: r.openapi, Request: r.request(), Response: r.resp})
 				}
 				// on failure, mark descendants as blocked
 				if r.failed {
@@ -421,7 +482,7 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 				sum.Skipped++
 				ui.Skipf("%s (only)", t.Name)
 				if opts.OnResult != nil {
-					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Status: "skipped", Messages: []string{"filtered by only"}})
+					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Source: t.Source, Status: "skipped", Messages: []string{"filtered by only"}})
 				}
 				continue
 			}
@@ -429,7 +490,7 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 				sum.Skipped++
 				ui.Skipf("%s (skip)", t.Name)
 				if opts.OnResult != nil {
-					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Status: "skipped", Messages: []string{"explicit skip"}})
+					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Source: t.Source, Status: "skipped", Messages: []string{"explicit skip"}})
 				}
 				continue
 			}
@@ -437,7 +498,7 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 				sum.Skipped++
 				ui.Skipf("%s (tags)", t.Name)
 				if opts.OnResult != nil {
-					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Status: "skipped", Messages: []string{"filtered by tags"}})
+					opts.OnResult(TestResult{Name: t.Name, Stage: t.Stage, Tags: t.Tags, Source: t.Source, Status: "skipped", Messages: []string{"filtered by tags"}})
 				}
 				continue
 			}
@@ -485,20 +546,12 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 				}
 				if opts.OnStart != nil {
 					nm := interpolate(t.Name, testVars)
-					opts.OnStart(TestResult{Name: nm, Stage: t.Stage, Tags: t.Tags, Status: "running"})
+					opts.OnStart(TestResult{Name: nm, Stage: t.Stage, Tags: t.Tags, Source: t.Source, Status: "running"})
 				}
 				go func(tc models.TestCase, vv map[string]string) {
 					defer func() { <-sem }()
-					if len(tc.Pre) > 0 {
-						_ = runHooksSequential(ctx, s, vv, tc.Pre, opts)
-					}
-					r := runOne(ctx, s, tc, vv, opts)
-					r.name = tc.Name
+					r := runTestWithHooks(ctx, s, tc, vv, opts)
 					r.stage = tc.Stage
-					r.tags = tc.Tags
-					if r.passed && len(tc.Post) > 0 {
-						_ = runHooksSequential(ctx, s, vv, tc.Post, opts)
-					}
 					done <- r
 				}(t, testVars)
 			}
@@ -519,15 +572,17 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 					if r.passed {
 						status = "passed"
 					}
-					opts.OnResult(TestResult{Name: r.name, Stage: r.stage, Tags: r.tags, Status: status, DurationMs: r.durationMs, Messages: r.messages})
+					opts.OnResult(TestResult{Name: r.name, Stage: r.stage, Tags: r.tags, Source: r.source, Status: status, DurationMs: r.durationMs, Messages: r.messages, Hooks: r.hooks, OpenAPI: r.openapi, Request: r.request(), Response: r.resp})
 				}
 			}
 		}
 	}
 	// postSuite hooks
 	if len(s.PostSuite) > 0 {
-		for _, h := range s.PostSuite {
-			if err := runHook(ctx, s, &vars, h, opts); err != nil {
+		for i, h := range s.PostSuite {
+			hr, err := runHookReported(ctx, s, &vars, h, opts, "postSuite", "", i)
+			sum.Hooks = append(sum.Hooks, hr)
+			if err != nil {
 				// treat postSuite failure as suite failure
 				sum.Failed++
 				break
@@ -542,6 +597,54 @@ func RunSuite(ctx context.Context, s *models.Suite, opts Options) (Summary, erro
 	return sum, nil
 }
 
+// runTestWithHooks runs a test with its pre hooks and, if it passed, its post
+// hooks. Failed expect/assert checks in JS hooks fail the test, and variables
+// JS hooks set with setVar (or pm.environment.set etc.) carry over to later tests.
+func runTestWithHooks(ctx context.Context, s *models.Suite, tc models.TestCase, vv map[string]string, opts Options) caseResult {
+	opts, rollback := withTestTransaction(ctx, tc, opts)
+	defer rollback()
+	ex := &jsExchange{req: &tc.Request}
+	opts.js = ex
+	var hooks []HookResult
+	if len(tc.Pre) > 0 {
+		hooks, _ = runHooksSequential(ctx, s, vv, tc.Pre, opts, "pre", tc.Name)
+	}
+	r := runOne(ctx, s, tc, vv, opts)
+	r.name = tc.Name
+	r.tags = tc.Tags
+	r.source = tc.Source
+	if r.passed && len(tc.Post) > 0 {
+		for k, v := range r.extracted {
+			vv[k] = v
+		}
+		ex.req, ex.resp = &r.sent, r.resp
+		post, _ := runHooksSequential(ctx, s, vv, tc.Post, opts, "post", tc.Name)
+		hooks = append(hooks, post...)
+	}
+	r.hooks = hooks
+	for _, h := range hooks {
+		for _, line := range h.Console {
+			r.messages = append(r.messages, fmt.Sprintf("console (%s hook %q): %s", h.Scope, h.Name, line))
+		}
+	}
+	if set := ex.setVars(); len(set) > 0 {
+		if r.extracted == nil {
+			r.extracted = map[string]string{}
+		}
+		for k, v := range set {
+			r.extracted[k] = v
+		}
+	}
+	if failed := ex.failed(); len(failed) > 0 {
+		if r.passed {
+			ui.Failf("%s", interpolate(tc.Name, vv))
+		}
+		r.passed, r.failed = false, true
+		r.messages = append(r.messages, failed...)
+	}
+	return r
+}
+
 // runOne executes a single test case and returns result (without mutating shared state)
 func runOne(ctx context.Context, s *models.Suite, t models.TestCase, vars map[string]string, opts Options) (res caseResult) {
 	// merge per-test vars into local copy
@@ -552,6 +655,20 @@ func runOne(ctx context.Context, s *models.Suite, t models.TestCase, vars map[st
 	}
 
 	name := interpolate(t.Name, vars)
+	if t.SQL != nil {
+		return runSQLCase(ctx, s, t, name, vars, opts)
+	}
+	gql := t.Request.GraphQL != nil
+	if gql {
+		req, err := graphQLRequest(t.Request)
+		if err != nil {
+			ui.Failf("%s: %v", name, err)
+			res.failed = true
+			res.messages = append(res.messages, err.Error())
+			return
+		}
+		t.Request = req
+	}
 	// allow environment or vars to control baseUrl
 	base := interpolate(s.BaseURL, vars)
 	reqURL := resolveURL(base, interpolate(t.Request.URL, vars))
@@ -570,10 +687,12 @@ func runOne(ctx context.Context, s *models.Suite, t models.TestCase, vars map[st
 		if _, ok := headers["Authorization"]; !ok {
 			if s.Auth.BearerEnv != "" {
 				if token := os.Getenv(s.Auth.BearerEnv); token != "" {
+					secrets.Register(token)
 					headers["Authorization"] = "Bearer " + token
 				}
 			} else if s.Auth.BasicEnv != "" {
 				if creds := os.Getenv(s.Auth.BasicEnv); creds != "" {
+					secrets.Register(creds)
 					enc := base64.StdEncoding.EncodeToString([]byte(creds))
 					headers["Authorization"] = "Basic " + enc
 				}
@@ -587,6 +706,19 @@ func runOne(ctx context.Context, s *models.Suite, t models.TestCase, vars map[st
 	}
 	client := httpclient.New(durationFromMs(t.TimeoutMs, defTimeout))
 	repeats := repeatsFor(t)
+	res.sent = models.Request{Method: strings.ToUpper(t.Request.Method), URL: reqURL, Headers: headers, Query: query, Body: body}
+	// Optional OpenAPI request validation before sending
+	if msg, fail := validateOpenAPIRequest(ctx, t, res.sent, opts); fail {
+		ui.Failf("%s: %s", name, msg)
+		res.failed = true
+		res.messages = append(res.messages, msg)
+		return
+	} else if msg != "" {
+		if opts.Verbose {
+			ui.Detail(name + ": " + msg)
+		}
+		res.messages = append(res.messages, msg)
+	}
 	var lastErr error
 	var lastResp *httpclient.Response
 	for i := 0; i < repeats; i++ {
@@ -606,12 +738,16 @@ func runOne(ctx context.Context, s *models.Suite, t models.TestCase, vars map[st
 			time.Sleep(d)
 		}
 	}
+	res.resp = lastResp
 	if lastErr != nil {
 		ui.Failf("%s: request error: %v", name, lastErr)
 		res.failed = true
 		res.messages = append(res.messages, fmt.Sprintf("request error: %v", lastErr))
 		return
 	}
+	if opts.oapi != nil {
+		res.openapi = opts.oapi.hit(ctx, res.sent, lastResp.Status)
+	}
 
 	// Assertions
 	results := []assert.Result{}
@@ -635,31 +771,31 @@ func runOne(ctx context.Context, s *models.Suite, t models.TestCase, vars map[st
 	for _, sub := range t.Assert.BodyContains {
 		results = append(results, assert.Contains(string(lastResp.Body), interpolate(sub, vars), "body"))
 	}
-	// Optional OpenAPI response validation (if configured and enabled)
-	if opts.oapi != nil && opts.oapi.enabled {
-		enabled := true
-		if t.OpenAPI != nil && t.OpenAPI.Enabled != nil {
-			enabled = *t.OpenAPI.Enabled
+	if gql || t.Assert.GraphQLErrors != nil {
+		results = append(results, graphQLErrorResults(t.Assert.GraphQLErrors, lastResp.Body, vars)...)
+	}
+	if t.Assert.JS != "" {
+		checks, console := runAssertJS(opts.scripts, t.Assert.JS, res.sent, lastResp, vars)
+		results = append(results, checks...)
+		for _, line := range console {
+			res.messages = append(res.messages, "console (assert.js): "+line)
 		}
-		if enabled {
-			ctype := lastResp.Headers.Get("Content-Type")
-			if strings.Contains(strings.ToLower(ctype), "json") {
-				// Build http.Request for route matching (path only)
-				pu, _ := neturl.Parse(reqURL)
-				req, _ := http.NewRequestWithContext(ctx, strings.ToUpper(t.Request.Method), pu.Path, nil)
-				route, pathParams, err := opts.oapi.router.FindRoute(req)
-				if err != nil {
-					results = append(results, assert.Result{Passed: false, Msg: fmt.Sprintf("openapi route not found: %v", err)})
-				} else {
-					in := &openapi3filter.RequestValidationInput{Request: req, PathParams: pathParams, Route: route}
-					rvi := &openapi3filter.ResponseValidationInput{RequestValidationInput: in, Status: lastResp.Status, Header: lastResp.Headers}
-					rvi.SetBodyBytes(lastResp.Body)
-					if err := openapi3filter.ValidateResponse(ctx, rvi); err != nil {
-						results = append(results, assert.Result{Passed: false, Msg: fmt.Sprintf("openapi: %v", err)})
-					} else {
-						results = append(results, assert.Result{Passed: true, Msg: "openapi: ok"})
-					}
-				}
+	}
+	// Optional OpenAPI response validation (if configured and enabled)
+	if openapiFor(opts, t) && strings.Contains(strings.ToLower(lastResp.Headers.Get("Content-Type")), "json") {
+		// Build http.Request for route matching relative to the spec's servers
+		req, _ := http.NewRequestWithContext(ctx, strings.ToUpper(t.Request.Method), reqURL, nil)
+		req, route, pathParams, err := opts.oapi.findRoute(req)
+		if err != nil {
+			results = append(results, assert.Result{Passed: false, Msg: fmt.Sprintf("openapi route not found: %v", err)})
+		} else {
+			in := &openapi3filter.RequestValidationInput{Request: req, PathParams: pathParams, Route: route}
+			rvi := &openapi3filter.ResponseValidationInput{RequestValidationInput: in, Status: lastResp.Status, Header: lastResp.Headers}
+			rvi.SetBodyBytes(lastResp.Body)
+			if err := openapi3filter.ValidateResponse(ctx, rvi); err != nil {
+				results = append(results, assert.Result{Passed: false, Msg: fmt.Sprintf("openapi: %v", err)})
+			} else {
+				results = append(results, assert.Result{Passed: true, Msg: "openapi: ok"})
 			}
 		}
 	}
@@ -681,6 +817,9 @@ func runOne(ctx context.Context, s *models.Suite, t models.TestCase, vars map[st
 	if failed {
 		ui.Failf("%s", name)
 		if opts.Verbose {
+			if t.Source != "" {
+				ui.Detail("from " + t.Source)
+			}
 			for _, r := range results {
 				if !r.Passed {
 					ui.Detail(r.Msg)
@@ -691,6 +830,7 @@ func runOne(ctx context.Context, s *models.Suite, t models.TestCase, vars map[st
 		}
 		res.failed = true
 		res.durationMs = lastResp.DurationMs
+		res.status = lastResp.Status
 		return
 	}
 
@@ -700,9 +840,11 @@ func runOne(ctx context.Context, s *models.Suite, t models.TestCase, vars map[st
 		v := gjson.GetBytes(lastResp.Body, ex.JSONPath)
 		res.extracted[key] = fmt.Sprintf("%v", v.Value())
 	}
+	registerSecrets(s, res.extracted)
 	ui.Successf("%s (%d ms)", name, lastResp.DurationMs)
 	res.passed = true
 	res.durationMs = lastResp.DurationMs
+	res.status = lastResp.Status
 	return
 }
 
@@ -711,6 +853,7 @@ func interpolate(s string, vars map[string]string) string {
 	for k, v := range vars {
 		res = strings.ReplaceAll(res, "${"+k+"}", v)
 	}
+	res = interpolateSecretRefs(res)
 	// ${ENV:VAR} expansion
 	for {
 		start := strings.Index(res, "${ENV:")
@@ -734,28 +877,44 @@ func interpolate(s string, vars map[string]string) string {
 	}
 	// Special generators: ${FAKE:uuid}, ${NOW:layout}, ${RANDINT:min:max}
 	res = expandGenerators(res)
+	// Lowest layer: names no var defines fall back to the OS environment
+	if strings.Contains(res, "${") {
+		res = plainRefPattern.ReplaceAllStringFunc(res, func(m string) string {
+			if v, ok := os.LookupEnv(m[2 : len(m)-1]); ok {
+				return v
+			}
+			return m
+		})
+	}
 	return res
 }
 
+var plainRefPattern = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
+
 // interpolateAny walks common JSON-like structures and interpolates strings.
 func interpolateAny(v any, vars map[string]string) any {
+	return mapStrings(v, func(s string) string { return interpolate(s, vars) })
+}
+
+// mapStrings applies f to every string in a JSON-like structure.
+func mapStrings(v any, f func(string) string) any {
 	switch t := v.(type) {
 	case nil:
 		return nil
 	case string:
-		return interpolate(t, vars)
+		return f(t)
 	case []byte:
-		return []byte(interpolate(string(t), vars))
+		return []byte(f(string(t)))
 	case []any:
 		out := make([]any, len(t))
 		for i := range t {
-			out[i] = interpolateAny(t[i], vars)
+			out[i] = mapStrings(t[i], f)
 		}
 		return out
 	case map[string]any:
 		out := make(map[string]any, len(t))
 		for k, vv := range t {
-			out[k] = interpolateAny(vv, vars)
+			out[k] = mapStrings(vv, f)
 		}
 		return out
 	default:
@@ -828,8 +987,9 @@ func withJitter(d time.Duration, pct int) time.Duration {
 	return d + jitter
 }
 
-// expandTestCases applies the cartesian product of matrix variables to create multiple cases
-func expandTestCases(tests []models.TestCase) []models.TestCase {
+// ExpandMatrix applies the cartesian product of matrix variables to create
+// multiple cases, named "name [k=v,...]" as they are reported in a run.
+func ExpandMatrix(tests []models.TestCase) []models.TestCase {
 	out := make([]models.TestCase, 0, len(tests))
 	for _, t := range tests {
 		if len(t.Matrix) == 0 {
@@ -858,8 +1018,9 @@ func expandTestCases(tests []models.TestCase) []models.TestCase {
 		}
 		for _, c := range combos {
 			tc := t // copy
-			if tc.Vars == nil {
-				tc.Vars = map[string]string{}
+			tc.Vars = make(map[string]string, len(t.Vars)+len(c))
+			for k, v := range t.Vars {
+				tc.Vars[k] = v
 			}
 			suffixParts := make([]string, 0, len(c))
 			for _, kv := range c {
@@ -887,6 +1048,57 @@ func repeatsFor(t models.TestCase) int {
 
 // runHook executes a single hook: merges Vars, performs optional HTTP request with assertions, and extracts vars.
 func runHook(ctx context.Context, s *models.Suite, vars *map[string]string, h models.Hook, opts Options) error {
+	var hr HookResult
+	return execHook(ctx, s, vars, h, opts, &hr)
+}
+
+// runHookReported runs a hook, timing it and emitting start/finish events through opts.OnHook.
+func runHookReported(ctx context.Context, s *models.Suite, vars *map[string]string, h models.Hook, opts Options, scope, test string, index int) (HookResult, error) {
+	hr := HookResult{Name: hookName(h), Scope: scope, Test: test, Index: index, Status: "running"}
+	if opts.OnHook != nil {
+		opts.OnHook(hr)
+	}
+	start := time.Now()
+	err := execHook(ctx, s, vars, h, opts, &hr)
+	registerSecrets(s, *vars)
+	hr.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		hr.Status = "failed"
+		hr.Messages = append(hr.Messages, err.Error())
+	} else {
+		hr.Status = "passed"
+	}
+	if opts.Verbose {
+		ui.Detail(fmt.Sprintf("%s hook '%s' %s (%d ms)", scope, hr.Name, hr.Status, hr.DurationMs))
+		for _, line := range hr.Console {
+			ui.Detail("console: " + line)
+		}
+	}
+	if opts.OnHook != nil {
+		opts.OnHook(hr)
+	}
+	return hr, err
+}
+
+// hookName returns the hook label, falling back to the kind of action it performs.
+func hookName(h models.Hook) string {
+	if h.Name != "" {
+		return h.Name
+	}
+	switch {
+	case h.SQL != nil:
+		return "sql"
+	case h.JS != nil:
+		return "js"
+	case h.Request != nil:
+		return "request"
+	default:
+		return "vars"
+	}
+}
+
+// execHook does the work of runHook and records details (rows, console, HTTP status) into hr.
+func execHook(ctx context.Context, s *models.Suite, vars *map[string]string, h models.Hook, opts Options, hr *HookResult) error {
 	// merge vars first (with interpolation support)
 	if len(h.Vars) > 0 {
 		for k, v := range h.Vars {
@@ -895,53 +1107,38 @@ func runHook(ctx context.Context, s *models.Suite, vars *map[string]string, h mo
 	}
 	// SQL action
 	if h.SQL != nil {
-		drv := interpolate(h.SQL.Driver, *vars)
-		dsn := interpolate(h.SQL.DSN, *vars)
-		db, err := sql.Open(drv, dsn)
+		r, set, err := runSQLStep(ctx, s, h.SQL, *vars, opts)
+		hr.RowsAffected = r.rowsAffected
+		if r.query || r.rowsAffected > 0 {
+			hr.Messages = append(hr.Messages, r.summary())
+		}
 		if err != nil {
 			return err
 		}
-		defer db.Close()
-		q := interpolate(h.SQL.Query, *vars)
-		rows, qerr := db.QueryContext(ctx, q)
-		if qerr != nil {
-			if _, e := db.ExecContext(ctx, q); e != nil {
-				return e
-			}
-		} else {
-			defer rows.Close()
-			cols, _ := rows.Columns()
-			if rows.Next() {
-				vals := make([]any, len(cols))
-				ptrs := make([]any, len(cols))
-				for i := range vals {
-					ptrs[i] = &vals[i]
-				}
-				if err := rows.Scan(ptrs...); err == nil {
-					m := map[string]string{}
-					for i, c := range cols {
-						v := vals[i]
-						switch vv := v.(type) {
-						case []byte:
-							m[c] = string(vv)
-						case nil:
-							m[c] = ""
-						default:
-							m[c] = fmt.Sprintf("%v", vv)
-						}
-					}
-					for varName, col := range h.SQL.Extract {
-						(*vars)[varName] = m[col]
-					}
-				}
-			}
+		for k, v := range set {
+			(*vars)[k] = v
 		}
 	}
 	// JS action
 	if h.JS != nil {
-		if err := RunJSHook(h.JS, vars); err != nil {
+		console, results, err := runJSHook(opts.scripts, h.JS, vars, opts.js)
+		hr.Console = append(hr.Console, console...)
+		var failed []string
+		for _, r := range results {
+			hr.Messages = append(hr.Messages, r.Msg)
+			if !r.Passed {
+				failed = append(failed, r.Msg)
+			}
+		}
+		if len(failed) > 0 && opts.js != nil {
+			opts.js.fail(failed...)
+		}
+		if err != nil {
 			return err
 		}
+		if len(failed) > 0 {
+			return fmt.Errorf("%d of %d JS check(s) failed", len(failed), len(results))
+		}
 	}
 	if h.Request == nil {
 		return nil
@@ -959,6 +1156,7 @@ func runHook(ctx context.Context, s *models.Suite, vars *map[string]string, h mo
 		vv[k] = v
 	}
 	r := runOne(ctx, s, tc, vv, opts)
+	hr.HTTPStatus = r.status
 	if r.failed {
 		// Summarize messages
 		msg := "hook failed"
@@ -975,39 +1173,6 @@ func runHook(ctx context.Context, s *models.Suite, vars *map[string]string, h mo
 	return nil
 }
 
-// RunJSHook executes JavaScript code with access to variables
-func RunJSHook(jsHook *models.JSHook, vars *map[string]string) error {
-	vm := goja.New()
-
-	// Set up context variables
-	varsObj := vm.NewObject()
-	for k, v := range *vars {
-		varsObj.Set(k, v)
-	}
-	vm.Set("vars", varsObj)
-
-	// Set up utility functions
-	vm.Set("setVar", func(name, value string) {
-		(*vars)[name] = value
-		varsObj.Set(name, value) // Update JS object too
-	})
-
-	vm.Set("getVar", func(name string) string {
-		return (*vars)[name]
-	})
-
-	// Add context variables if provided
-	if jsHook.Context != nil {
-		for k, v := range jsHook.Context {
-			vm.Set(k, v)
-		}
-	}
-
-	// Execute the JavaScript code
-	_, err := vm.RunString(jsHook.Code)
-	return err
-}
-
 // Generators expansion helpers
 func expandGenerators(s string) string {
 	// UUIDs
@@ -1102,12 +1267,16 @@ func genUUIDv4() string {
 	return fmt.Sprintf("%s-%s-%s-%s-%s", x[0:8], x[8:12], x[12:16], x[16:20], x[20:32])
 }
 
-// runHooksSequential runs hooks in order, mutating the provided vars map (shared across hooks)
-func runHooksSequential(ctx context.Context, s *models.Suite, vars map[string]string, hooks []models.Hook, opts Options) error {
-	for _, h := range hooks {
-		if err := runHook(ctx, s, &vars, h, opts); err != nil {
-			return err
+// runHooksSequential runs hooks in order, mutating the provided vars map (shared across hooks).
+// It returns the outcome of every hook that ran; execution stops at the first failure.
+func runHooksSequential(ctx context.Context, s *models.Suite, vars map[string]string, hooks []models.Hook, opts Options, scope, test string) ([]HookResult, error) {
+	out := make([]HookResult, 0, len(hooks))
+	for i, h := range hooks {
+		hr, err := runHookReported(ctx, s, &vars, h, opts, scope, test, i)
+		out = append(out, hr)
+		if err != nil {
+			return out, err
 		}
 	}
-	return nil
+	return out, nil
 }
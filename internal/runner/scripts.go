@@ -0,0 +1,237 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/ast"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// Limits applied to every JS hook and assert.js script.
+var (
+	defaultScriptTimeout = 5 * time.Second
+	defaultScriptMemory  = uint64(256 << 20) // heap growth allowed while a script runs
+	jsMemoryCheckEvery   = 100 * time.Millisecond
+)
+
+const jsMaxCallStack = 4096
+
+var jsPreludeProgram = goja.MustCompile("prelude.js", jsPrelude, false)
+
+// jsEnv is what every script of a run shares: the suite's `scripts:` libraries,
+// compiled once, the time and memory limits and a pool of VMs. Scripts run in
+// their own function scope and a VM's globals are reset before it is reused,
+// so hooks cannot see each other's globals.
+type jsEnv struct {
+	libs     []*goja.Program
+	timeout  time.Duration
+	memLimit uint64
+	noReuse  bool // a library destructures in a top-level let/const, so it cannot be wrapped and re-run
+	pool     sync.Pool
+}
+
+var defaultJSEnv = &jsEnv{timeout: defaultScriptTimeout, memLimit: defaultScriptMemory}
+
+// jsVM is a pooled runtime and the globals it started with.
+type jsVM struct {
+	vm      *goja.Runtime
+	globals map[string]goja.Value
+}
+
+// acquire returns a VM from the pool, or a new one.
+func (e *jsEnv) acquire() *jsVM {
+	if v, ok := e.pool.Get().(*jsVM); ok {
+		return v
+	}
+	vm := goja.New()
+	vm.SetMaxCallStackSize(jsMaxCallStack)
+	g := vm.GlobalObject()
+	globals := map[string]goja.Value{}
+	for _, k := range g.GetOwnPropertyNames() {
+		globals[k] = g.Get(k)
+	}
+	return &jsVM{vm: vm, globals: globals}
+}
+
+// release resets the VM's globals and puts it back in the pool. Library
+// globals that cannot be deleted are redefined when the libraries run again.
+// Changes to built-in prototypes are not undone.
+func (e *jsEnv) release(v *jsVM) {
+	if e.noReuse {
+		return
+	}
+	g := v.vm.GlobalObject()
+	for _, k := range g.GetOwnPropertyNames() {
+		if _, ok := v.globals[k]; !ok {
+			_ = g.Delete(k)
+		}
+	}
+	for k, val := range v.globals {
+		if cur := g.Get(k); cur == nil || !cur.SameAs(val) {
+			_ = g.Set(k, val)
+		}
+	}
+	e.pool.Put(v)
+}
+
+// newJSEnv reads and compiles the suite's script libraries. Libraries with
+// top-level let, const or class declarations run in a function scope, like
+// hooks, and copy what they declare onto the global object, so they can be
+// loaded again into a pooled VM.
+func newJSEnv(s *models.Suite) (*jsEnv, error) {
+	env := &jsEnv{timeout: defaultScriptTimeout, memLimit: defaultScriptMemory}
+	if s == nil {
+		return env, nil
+	}
+	if s.ScriptTimeoutMs > 0 {
+		env.timeout = time.Duration(s.ScriptTimeoutMs) * time.Millisecond
+	}
+	if s.ScriptMemoryMb > 0 {
+		env.memLimit = uint64(s.ScriptMemoryMb) << 20
+	}
+	for i, sc := range s.Scripts {
+		name := scriptName(i, sc)
+		code := sc.Code
+		if sc.File != "" {
+			if code != "" {
+				return nil, fmt.Errorf("%s: set either file or code, not both", name)
+			}
+			b, err := os.ReadFile(sc.File)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			code = string(b)
+		}
+		prg, err := goja.Parse(name, code)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if names, lexical, ok := libraryGlobals(prg); lexical && !ok {
+			env.noReuse = true
+		} else if lexical {
+			var b strings.Builder
+			b.WriteString("(function(){" + code + "\n;")
+			for _, n := range names {
+				b.WriteString("globalThis." + n + " = " + n + ";")
+			}
+			b.WriteString("})()")
+			if prg, err = goja.Parse(name, b.String()); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		p, err := goja.CompileAST(prg, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		env.libs = append(env.libs, p)
+	}
+	return env, nil
+}
+
+func scriptName(i int, sc models.Script) string {
+	switch {
+	case sc.Name != "":
+		return fmt.Sprintf("scripts[%d] %q", i, sc.Name)
+	case sc.File != "":
+		return fmt.Sprintf("scripts[%d] (%s)", i, sc.File)
+	default:
+		return fmt.Sprintf("scripts[%d]", i)
+	}
+}
+
+// CheckScripts reports suite `scripts:` entries that cannot be read or do not compile.
+func CheckScripts(s *models.Suite) error {
+	_, err := newJSEnv(s)
+	return err
+}
+
+// libraryGlobals lists the names a library declares at top level. lexical
+// reports let/const/class declarations; ok is false when a declaration
+// destructures, so its names are not listed.
+func libraryGlobals(prg *ast.Program) (names []string, lexical, ok bool) {
+	ok = true
+	bindings := func(list []*ast.Binding) {
+		for _, b := range list {
+			if id, isID := b.Target.(*ast.Identifier); isID {
+				names = append(names, string(id.Name))
+			} else {
+				ok = false
+			}
+		}
+	}
+	for _, st := range prg.Body {
+		switch st := st.(type) {
+		case *ast.LexicalDeclaration:
+			lexical = true
+			bindings(st.List)
+		case *ast.ClassDeclaration:
+			lexical = true
+			names = append(names, string(st.Class.Name.Name))
+		case *ast.VariableStatement:
+			bindings(st.List)
+		case *ast.FunctionDeclaration:
+			names = append(names, string(st.Function.Name.Name))
+		}
+	}
+	return names, lexical, ok
+}
+
+// heapBytes reads the size of the heap's objects, including ones not yet
+// collected, without stopping the world.
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// watch interrupts the run's VM once it exceeds the time limit, or once the
+// heap has grown by more than the memory limit since the script started and
+// stays that large after a collection. The heap is the process's, so scripts
+// running at the same time share the budget. Call the returned func when the
+// script finishes: it waits for the watcher to stop and clears an interrupt
+// that fired after the script returned.
+func (r *jsRun) watch() func() {
+	done, stopped := make(chan struct{}), make(chan struct{})
+	base := heapBytes()
+	go func() {
+		defer close(stopped)
+		deadline := time.NewTimer(r.env.timeout)
+		defer deadline.Stop()
+		tick := time.NewTicker(jsMemoryCheckEvery)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-deadline.C:
+				r.vm.Interrupt(fmt.Sprintf("script timed out after %s", r.env.timeout))
+				return
+			case <-tick.C:
+				if r.env.memLimit == 0 || heapBytes() < base+r.env.memLimit {
+					continue
+				}
+				runtime.GC()
+				if heapBytes() >= base+r.env.memLimit {
+					r.vm.Interrupt(fmt.Sprintf("script exceeded the memory limit (%d MiB)", r.env.memLimit>>20))
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		r.vm.ClearInterrupt()
+	}
+}
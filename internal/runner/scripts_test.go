@@ -0,0 +1,159 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestRunSuite_ScriptLibraries(t *testing.T) {
+	var gotSig string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get("X-Sig")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"items":[1,2,3]}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lib", "sign.js"), []byte(`function sign(s) { return "sig:" + s; }`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	suite := `name: libs
+baseUrl: ` + srv.URL + `
+scripts:
+  - file: lib/sign.js
+  - name: sorted
+    code: |
+      function isSorted(a) { return a.every((x, i) => i === 0 || a[i-1] <= x); }
+tests:
+  - name: uses libraries
+    request: { method: GET, url: /items }
+    pre:
+      - name: sign
+        js:
+          code: |
+            request.headers["X-Sig"] = sign(request.url);
+            console.log("signed", request.url);
+    assert:
+      status: 200
+      js: |
+        console.log("checking");
+        check("sorted", isSorted(response.json().items));
+`
+	path := filepath.Join(dir, "libs.hrq.yaml")
+	if err := os.WriteFile(path, []byte(suite), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSuite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res TestResult
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sum, err := RunSuite(ctx, s, Options{Workers: 1, OnResult: func(tr TestResult) { res = tr }})
+	if err != nil || sum.Passed != 1 {
+		t.Fatalf("run: %v, summary %+v, result %+v", err, sum, res)
+	}
+	if gotSig != "sig:/items" {
+		t.Fatalf("X-Sig = %q", gotSig)
+	}
+	want := []string{"console (assert.js): checking", `console (pre hook "sign"): signed /items`}
+	if strings.Join(res.Messages, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("messages = %q, want %q", res.Messages, want)
+	}
+}
+
+func TestRunJSHook_Limits(t *testing.T) {
+	env := &jsEnv{timeout: 2 * time.Second, memLimit: 32 << 20}
+	cases := map[string]string{
+		`for (;;) {}`:                          "script timed out after 2s",
+		`function f() { return f() + 1; } f()`: "call stack",
+		`var a = []; for (;;) a.push(new Array(100000).fill(1));`: "script exceeded the memory limit (32 MiB)",
+	}
+	if testing.Short() {
+		delete(cases, `for (;;) {}`)
+	}
+	for code, want := range cases {
+		vars := map[string]string{}
+		_, _, err := runJSHook(env, &models.JSHook{Code: code}, &vars, nil)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", code, err, want)
+		}
+	}
+}
+
+func TestRunJSHook_PooledVMIsolation(t *testing.T) {
+	env, err := newJSEnv(&models.Suite{Scripts: []models.Script{{Code: "function twice(x) { return 2 * x; }"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scripts := []string{
+		`const c = 1; var leaked = 1; globalThis.added = 1; JSON = null; twice = null;`,
+		`const c = 2;
+		 if (typeof leaked !== 'undefined' || typeof added !== 'undefined') throw new Error('globals leaked');
+		 if (JSON === null || twice(2) !== 4) throw new Error('globals not restored');`,
+	}
+	for _, code := range scripts {
+		vars := map[string]string{}
+		if _, _, err := runJSHook(env, &models.JSHook{Code: code}, &vars, nil); err != nil {
+			t.Fatalf("%s: %v", code, err)
+		}
+	}
+
+	// libraries with top-level let/const/class run wrapped, so VMs are still reused
+	env, err = newJSEnv(&models.Suite{Scripts: []models.Script{{Code: "const base = getVar('base');\nclass Box { constructor(v) { this.v = v; } }\nfunction box(v) { return new Box(v); }"}}})
+	if err != nil || env.noReuse {
+		t.Fatalf("env = %+v, err = %v", env, err)
+	}
+	for _, base := range []string{"a", "b"} {
+		vars := map[string]string{"base": base}
+		if _, _, err := runJSHook(env, &models.JSHook{Code: "setVar('seen', box(base).v)"}, &vars, nil); err != nil || vars["seen"] != base {
+			t.Fatalf("seen = %q, err = %v", vars["seen"], err)
+		}
+	}
+
+	// a destructuring declaration cannot be listed, so each script gets a fresh VM
+	env, err = newJSEnv(&models.Suite{Scripts: []models.Script{{Code: "const { base } = vars;"}}})
+	if err != nil || !env.noReuse {
+		t.Fatalf("env = %+v, err = %v", env, err)
+	}
+	for _, base := range []string{"a", "b"} {
+		vars := map[string]string{"base": base}
+		if _, _, err := runJSHook(env, &models.JSHook{Code: "setVar('seen', base)"}, &vars, nil); err != nil || vars["seen"] != base {
+			t.Fatalf("seen = %q, err = %v", vars["seen"], err)
+		}
+	}
+}
+
+func TestCheckScripts(t *testing.T) {
+	cases := []struct {
+		scripts []models.Script
+		want    string
+	}{
+		{[]models.Script{{Code: "function ok() {}"}}, ""},
+		{[]models.Script{{Name: "broken", Code: "function ("}}, `scripts[0] "broken": SyntaxError`},
+		{[]models.Script{{Code: "x"}, {File: filepath.Join(t.TempDir(), "missing.js")}}, "scripts[1] ("},
+		{[]models.Script{{File: "a.js", Code: "x"}}, "set either file or code"},
+	}
+	for _, c := range cases {
+		err := CheckScripts(&models.Suite{Scripts: c.scripts})
+		if c.want == "" && err != nil {
+			t.Errorf("%+v: unexpected error %v", c.scripts, err)
+		}
+		if c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)) {
+			t.Errorf("%+v: err = %v, want %q", c.scripts, err, c.want)
+		}
+	}
+}
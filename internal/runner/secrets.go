@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/internal/secrets"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// registerSecrets marks the current values of variables listed in suite
+// `secrets:` so they are redacted from console, report and web UI output.
+func registerSecrets(s *models.Suite, vars map[string]string) {
+	for _, name := range s.Secrets {
+		if v, ok := vars[name]; ok {
+			secrets.Register(v)
+		}
+	}
+}
+
+// LoadSecretsFile loads the suite's secretsFile, if it sets one, for
+// ${SECRET:NAME} lookups.
+func LoadSecretsFile(s *models.Suite) error {
+	if s.SecretsFile == "" {
+		return nil
+	}
+	return secrets.LoadFile(s.SecretsFile)
+}
+
+// interpolateSecretRefs expands ${SECRET:NAME} from the environment or a loaded
+// secrets file. Unknown names expand to an empty string, like ${ENV:NAME}.
+func interpolateSecretRefs(s string) string {
+	for {
+		start := strings.Index(s, "${SECRET:")
+		if start < 0 {
+			return s
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			return s
+		}
+		end += start
+		val, _ := secrets.Lookup(s[start+9 : end])
+		s = s[:start] + val + s[end+1:]
+	}
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DrWeltschmerz/HydReq/internal/secrets"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestRunSuite_RegistersSecrets(t *testing.T) {
+	secrets.Reset()
+	defer secrets.Reset()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token":"tok-from-login"}`))
+	}))
+	defer srv.Close()
+	file := filepath.Join(t.TempDir(), "secrets.env")
+	if err := os.WriteFile(file, []byte("API_KEY=\"key-from-file\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &models.Suite{
+		Name:        "secrets",
+		BaseURL:     srv.URL,
+		Variables:   map[string]string{"password": "hunter22", "user": "alice"},
+		Secrets:     []string{"password", "token"},
+		SecretsFile: file,
+		Tests: []models.TestCase{{
+			Name:    "login",
+			Request: models.Request{Method: "GET", URL: "/", Headers: map[string]string{"X-Key": "${SECRET:API_KEY}"}},
+			Assert:  models.Assertions{Status: 200},
+			Extract: map[string]models.Extract{"token": {JSONPath: "token"}},
+		}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := RunSuite(ctx, s, Options{Workers: 1}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, v := range []string{"hunter22", "tok-from-login", "key-from-file"} {
+		if got := secrets.Redact("v=" + v); got != "v="+secrets.Mask {
+			t.Errorf("%s not registered: %q", v, got)
+		}
+	}
+	if got := secrets.Redact("alice"); got != "alice" {
+		t.Errorf("non-secret var was masked: %q", got)
+	}
+}
@@ -0,0 +1,287 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DrWeltschmerz/HydReq/internal/ui"
+	"github.com/DrWeltschmerz/HydReq/pkg/assert"
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// sqlResult holds the outcome of one SQL statement.
+type sqlResult struct {
+	query        bool // statement returned rows
+	columns      []string
+	rows         []map[string]any
+	rowsAffected int64
+}
+
+// rowKeywords start statements that return rows; anything else is executed.
+var rowKeywords = map[string]bool{"SELECT": true, "WITH": true, "SHOW": true, "PRAGMA": true, "EXPLAIN": true, "VALUES": true, "DESCRIBE": true, "DESC": true, "TABLE": true}
+
+var returningPattern = regexp.MustCompile(`(?i)\b(RETURNING|OUTPUT\s+(INSERTED|DELETED))\b`)
+
+// sqlCode blanks out comments and quoted strings and identifiers in q, so
+// keywords inside them are not mistaken for the statement's own.
+func sqlCode(q string) string {
+	var b strings.Builder
+	for i := 0; i < len(q); {
+		switch {
+		case strings.HasPrefix(q[i:], "--"):
+			end := strings.IndexByte(q[i:], '\n')
+			if end < 0 {
+				return b.String()
+			}
+			i += end
+		case strings.HasPrefix(q[i:], "/*"):
+			end := strings.Index(q[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 4
+			b.WriteByte(' ')
+		case q[i] == '\'' || q[i] == '"' || q[i] == '`':
+			// a doubled quote inside a string reads as two adjacent strings
+			end := strings.IndexByte(q[i+1:], q[i])
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 2
+			b.WriteString(" '' ")
+		default:
+			b.WriteByte(q[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// returnsRows reports whether q should be run with QueryContext rather than ExecContext.
+func returnsRows(q string) bool {
+	fields := strings.Fields(sqlCode(q))
+	if len(fields) == 0 {
+		return false
+	}
+	first := strings.ToUpper(strings.TrimLeft(fields[0], "("))
+	return rowKeywords[first] || returningPattern.MatchString(strings.Join(fields, " "))
+}
+
+// execSQL interpolates and runs a SQL step, collecting all rows for queries.
+func execSQL(ctx context.Context, s *models.Suite, h *models.SQLHook, vars map[string]string, opts Options) (sqlResult, error) {
+	var res sqlResult
+	q := interpolate(h.Query, vars)
+	if strings.TrimSpace(sqlCode(q)) == "" {
+		return res, errors.New("sql: empty query")
+	}
+	db, done, err := sqlConnFor(s, h, vars, opts)
+	if err != nil {
+		return res, err
+	}
+	defer done()
+	args := sqlArgs(h.Args, vars)
+	if !returnsRows(q) {
+		r, err := db.ExecContext(ctx, q, args...)
+		if err != nil {
+			return res, err
+		}
+		if n, err := r.RowsAffected(); err == nil {
+			res.rowsAffected = n
+		}
+		return res, nil
+	}
+	res.query = true
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+	res.columns, _ = rows.Columns()
+	for rows.Next() {
+		vals := make([]any, len(res.columns))
+		ptrs := make([]any, len(res.columns))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return res, err
+		}
+		row := make(map[string]any, len(res.columns))
+		for i, c := range res.columns {
+			row[c] = sqlValue(vals[i])
+		}
+		res.rows = append(res.rows, row)
+	}
+	return res, rows.Err()
+}
+
+// sqlArgs interpolates string query parameters; other values are passed as-is.
+func sqlArgs(in []any, vars map[string]string) []any {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]any, len(in))
+	for i, a := range in {
+		if str, ok := a.(string); ok {
+			out[i] = interpolate(str, vars)
+		} else {
+			out[i] = a
+		}
+	}
+	return out
+}
+
+// sqlValue converts driver values into JSON-friendly ones.
+func sqlValue(v any) any {
+	switch vv := v.(type) {
+	case []byte:
+		return string(vv)
+	case time.Time:
+		return vv.Format(time.RFC3339Nano)
+	default:
+		return vv
+	}
+}
+
+// summary is the one-line message recorded for a SQL step.
+func (r sqlResult) summary() string {
+	if r.query {
+		return fmt.Sprintf("sql: %d row(s) returned", len(r.rows))
+	}
+	return fmt.Sprintf("sql: %d row(s) affected", r.rowsAffected)
+}
+
+// vars returns the variables a SQL step sets: extract from the first row and
+// the full result as a JSON array under resultVar.
+func (r sqlResult) vars(h *models.SQLHook) map[string]string {
+	out := map[string]string{}
+	if len(r.rows) > 0 {
+		for varName, col := range h.Extract {
+			out[varName] = anyToString(r.rows[0][col])
+		}
+	}
+	if h.ResultVar != "" {
+		rows := r.rows
+		if rows == nil {
+			rows = []map[string]any{}
+		}
+		b, _ := json.Marshal(rows)
+		out[h.ResultVar] = string(b)
+	}
+	return out
+}
+
+// sqlAssertions evaluates sql.assert against a result.
+func sqlAssertions(a *models.SQLAssertions, r sqlResult, vars map[string]string) []assert.Result {
+	if a == nil {
+		return nil
+	}
+	var results []assert.Result
+	if a.RowCount != nil {
+		if !r.query {
+			results = append(results, assert.Result{Passed: false, Msg: "sql rowCount: statement returned no rows; use rowsAffected"})
+		} else {
+			results = append(results, assert.Equal(len(r.rows), *a.RowCount, "sql rowCount"))
+		}
+	}
+	if a.RowsAffected != nil {
+		if r.query {
+			results = append(results, assert.Result{Passed: false, Msg: "sql rowsAffected: statement returned rows; use rowCount"})
+		} else {
+			results = append(results, assert.Equal(r.rowsAffected, *a.RowsAffected, "sql rowsAffected"))
+		}
+	}
+	for _, ra := range a.Rows {
+		label := fmt.Sprintf("sql row[%d]", ra.Row)
+		if ra.Row < 0 || ra.Row >= len(r.rows) {
+			results = append(results, assert.Result{Passed: false, Msg: fmt.Sprintf("%s: only %d row(s) returned", label, len(r.rows))})
+			continue
+		}
+		row := r.rows[ra.Row]
+		for col, exp := range ra.Equals {
+			results = append(results, assert.Equal(anyToString(row[col]), interpolate(anyToString(exp), vars), label+"."+col))
+		}
+		for col, sub := range ra.Contains {
+			results = append(results, assert.Contains(anyToString(row[col]), interpolate(sub, vars), label+"."+col))
+		}
+		for col, pat := range ra.Matches {
+			re, err := regexp.Compile(interpolate(pat, vars))
+			if err != nil {
+				results = append(results, assert.Result{Passed: false, Msg: fmt.Sprintf("%s.%s: invalid regex: %v", label, col, err)})
+				continue
+			}
+			got := anyToString(row[col])
+			if re.MatchString(got) {
+				results = append(results, assert.Result{Passed: true, Msg: fmt.Sprintf("%s.%s: matches %q", label, col, re.String())})
+			} else {
+				results = append(results, assert.Result{Passed: false, Msg: fmt.Sprintf("%s.%s: %q does not match %q", label, col, got, re.String())})
+			}
+		}
+	}
+	return results
+}
+
+// runSQLStep executes a SQL step with its assertions, returning the result,
+// the variables it sets and an error describing the first failed assertion.
+func runSQLStep(ctx context.Context, s *models.Suite, h *models.SQLHook, vars map[string]string, opts Options) (sqlResult, map[string]string, error) {
+	r, err := execSQL(ctx, s, h, vars, opts)
+	if err != nil {
+		return r, nil, err
+	}
+	for _, a := range sqlAssertions(h.Assert, r, vars) {
+		if !a.Passed {
+			return r, nil, errors.New(a.Msg)
+		}
+	}
+	return r, r.vars(h), nil
+}
+
+// RunSQLHook runs a single SQL step outside a suite run (e.g. from the editor),
+// applying its assertions and storing extracted values into vars.
+func RunSQLHook(ctx context.Context, s *models.Suite, h *models.SQLHook, vars *map[string]string) (string, error) {
+	r, set, err := runSQLStep(ctx, s, h, *vars, Options{})
+	if err != nil {
+		return r.summary(), err
+	}
+	for k, v := range set {
+		(*vars)[k] = v
+	}
+	return r.summary(), nil
+}
+
+// runSQLCase runs a test whose step is SQL rather than HTTP and reports it like one.
+func runSQLCase(ctx context.Context, s *models.Suite, t models.TestCase, name string, vars map[string]string, opts Options) (res caseResult) {
+	defTimeout := opts.DefaultTimeoutMs
+	if defTimeout <= 0 {
+		defTimeout = 30_000
+	}
+	ctxQ, cancel := context.WithTimeout(ctx, durationFromMs(t.TimeoutMs, defTimeout))
+	defer cancel()
+	start := time.Now()
+	r, extracted, err := runSQLStep(ctxQ, s, t.SQL, vars, opts)
+	res.durationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		ui.Failf("%s", name)
+		if opts.Verbose {
+			if t.Source != "" {
+				ui.Detail("from " + t.Source)
+			}
+			ui.Detail(err.Error())
+			ui.Detail(r.summary())
+		}
+		res.failed = true
+		res.messages = append(res.messages, err.Error())
+		return
+	}
+	res.extracted = extracted
+	registerSecrets(s, res.extracted)
+	res.messages = append(res.messages, r.summary())
+	ui.Successf("%s (%d ms)", name, res.durationMs)
+	res.passed = true
+	return
+}
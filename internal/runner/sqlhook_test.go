@@ -2,7 +2,10 @@ package runner
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -67,3 +70,127 @@ func TestSQLHook_SQLiteMemory(t *testing.T) {
 		t.Fatalf("expected extracted email, got %q", vars["uemail"])
 	}
 }
+
+func TestSQLHook_Assertions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	s := &models.Suite{Name: "sql-assert"}
+	vars := map[string]string{}
+	dsn := "file:" + filepath.Join(t.TempDir(), "assert.sqlite")
+	one, two := int64(1), 2
+	sqlHook := func(q string, a *models.SQLAssertions) models.Hook {
+		return models.Hook{SQL: &models.SQLHook{Driver: "sqlite", DSN: dsn, Query: q, Assert: a}}
+	}
+	steps := []models.Hook{
+		sqlHook(`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)`, nil),
+		sqlHook(`INSERT INTO users(email) VALUES ('a@example.com')`, &models.SQLAssertions{RowsAffected: &one}),
+		sqlHook(`INSERT INTO users(email) VALUES ('b@example.com')`, &models.SQLAssertions{RowsAffected: &one}),
+	}
+	for _, h := range steps {
+		if err := runHook(ctx, s, &vars, h, Options{}); err != nil {
+			t.Fatalf("%s: %v", h.SQL.Query, err)
+		}
+	}
+
+	sel := sqlHook(`SELECT id, email FROM users ORDER BY id`, &models.SQLAssertions{
+		RowCount: &two,
+		Rows: []models.SQLRowAssert{
+			{Row: 0, Equals: map[string]any{"id": 1, "email": "a@example.com"}},
+			{Row: 1, Contains: map[string]string{"email": "b@"}, Matches: map[string]string{"id": `^\d+$`}},
+		},
+	})
+	sel.SQL.ResultVar = "users"
+	if err := runHook(ctx, s, &vars, sel, Options{}); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(vars["users"]), &rows); err != nil || len(rows) != 2 || rows[1]["email"] != "b@example.com" {
+		t.Fatalf("resultVar = %q (%v)", vars["users"], err)
+	}
+
+	bad := sqlHook(`SELECT email FROM users ORDER BY id`, &models.SQLAssertions{Rows: []models.SQLRowAssert{{Row: 1, Equals: map[string]any{"email": "a@example.com"}}}})
+	err := runHook(ctx, s, &vars, bad, Options{})
+	if err == nil || !strings.Contains(err.Error(), "sql row[1].email: got b@example.com, want a@example.com") {
+		t.Fatalf("expected column mismatch, got %v", err)
+	}
+	missing := sqlHook(`SELECT email FROM users`, &models.SQLAssertions{Rows: []models.SQLRowAssert{{Row: 5}}})
+	if err := runHook(ctx, s, &vars, missing, Options{}); err == nil || !strings.Contains(err.Error(), "only 2 row(s)") {
+		t.Fatalf("expected missing row error, got %v", err)
+	}
+}
+
+func TestReturnsRows(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT 1":                                  true,
+		"  with x as (select 1) select * from x":    true,
+		"-- note\nselect 1":                         true,
+		"/* c */ PRAGMA table_info(t)":              true,
+		"INSERT INTO t VALUES (1) RETURNING id":     true,
+		"INSERT INTO t VALUES (1)":                  false,
+		"update t set a = 1":                        false,
+		"CREATE TABLE t (id int)":                   false,
+		"UPDATE notes SET content='returning soon'": false,
+		"update t set a = 1 -- returning later":     false,
+		`SELECT 'it''s' AS "returning"`:             true,
+		"/* select */ delete from t":                false,
+		"":                                          false,
+		"  \n\t":                                    false,
+		"/* x */":                                   false,
+		"-- x":                                      false,
+	}
+	for q, want := range cases {
+		if got := returnsRows(q); got != want {
+			t.Errorf("returnsRows(%q) = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestExecSQL_EmptyQuery(t *testing.T) {
+	s := &models.Suite{Name: "sql-empty"}
+	for _, q := range []string{"", "   ", "/* x */", "-- x", "${MAYBE_EMPTY}"} {
+		h := &models.SQLHook{Driver: "sqlite", DSN: "file::memory:", Query: q}
+		if _, err := execSQL(context.Background(), s, h, map[string]string{"MAYBE_EMPTY": ""}, Options{}); err == nil || err.Error() != "sql: empty query" {
+			t.Errorf("execSQL(%q) error = %v, want sql: empty query", q, err)
+		}
+	}
+}
+
+func TestRunSuite_SQLStep(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "step.sqlite")
+	n := 1
+	s := &models.Suite{
+		Name: "sql-step",
+		PreSuite: []models.Hook{
+			{SQL: &models.SQLHook{Driver: "sqlite", DSN: dsn, Query: `CREATE TABLE orders (id TEXT, total INTEGER); INSERT INTO orders VALUES ('o-1', 42)`}},
+		},
+		Tests: []models.TestCase{
+			{Name: "order written", SQL: &models.SQLHook{Driver: "sqlite", DSN: dsn, Query: "SELECT id, total FROM orders",
+				Extract: map[string]string{"orderId": "id"},
+				Assert:  &models.SQLAssertions{RowCount: &n, Rows: []models.SQLRowAssert{{Equals: map[string]any{"total": 42}}}}}},
+			{Name: "no refunds", SQL: &models.SQLHook{Driver: "sqlite", DSN: dsn, Query: "SELECT id FROM orders WHERE total < 0",
+				Assert: &models.SQLAssertions{RowCount: &n}}},
+		},
+	}
+	var results []TestResult
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sum, err := RunSuite(ctx, s, Options{Workers: 1, OnResult: func(tr TestResult) { results = append(results, tr) }})
+	if errors.Is(err, ErrSuiteNotRunnable) {
+		t.Fatalf("run: %v", err)
+	}
+	if sum.Passed != 1 || sum.Failed != 1 {
+		t.Fatalf("summary = %+v, results = %+v", sum, results)
+	}
+	for _, r := range results {
+		switch r.Name {
+		case "order written":
+			if r.Status != "passed" {
+				t.Fatalf("order written: %+v", r)
+			}
+		case "no refunds":
+			if r.Status != "failed" || len(r.Messages) == 0 || !strings.Contains(r.Messages[0], "sql rowCount: got 0, want 1") {
+				t.Fatalf("no refunds: %+v", r)
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+// ResolveTemplates expands `extends` references on the suite's tests in place.
+// Templates may themselves extend other templates; cycles and unknown names are errors.
+// Tests without extends are left untouched, so calling it twice is harmless.
+func ResolveTemplates(s *models.Suite) error {
+	if s == nil {
+		return nil
+	}
+	resolved := map[string]models.TestCase{}
+	for i := range s.Tests {
+		t := s.Tests[i]
+		if t.Extends == "" {
+			continue
+		}
+		base, err := resolveTemplate(s.Templates, t.Extends, resolved, nil)
+		if err != nil {
+			return fmt.Errorf("test %q: %w", t.Name, err)
+		}
+		s.Tests[i] = mergeTestCase(base, t)
+		s.Tests[i].Extends = ""
+	}
+	return nil
+}
+
+func resolveTemplate(templates map[string]models.TestCase, name string, resolved map[string]models.TestCase, chain []string) (models.TestCase, error) {
+	if t, ok := resolved[name]; ok {
+		return t, nil
+	}
+	for _, c := range chain {
+		if c == name {
+			return models.TestCase{}, fmt.Errorf("template cycle: %s -> %s", strings.Join(chain, " -> "), name)
+		}
+	}
+	t, ok := templates[name]
+	if !ok {
+		return models.TestCase{}, fmt.Errorf("unknown template %q", name)
+	}
+	if t.Extends != "" {
+		base, err := resolveTemplate(templates, t.Extends, resolved, append(chain, name))
+		if err != nil {
+			return models.TestCase{}, err
+		}
+		t = mergeTestCase(base, t)
+		t.Extends = ""
+	}
+	resolved[name] = t
+	return t, nil
+}
+
+// mergeTestCase deep-merges child over base. Scalars set on the child win,
+// maps are merged key by key (nested body objects recursively), and lists
+// (tags, bodyContains, hooks) are concatenated base-first.
+func mergeTestCase(base, child models.TestCase) models.TestCase {
+	out := child
+	if out.Request.Method == "" {
+		out.Request.Method = base.Request.Method
+	}
+	if out.Request.URL == "" {
+		out.Request.URL = base.Request.URL
+	}
+	out.Request.Headers = mergeStringMap(base.Request.Headers, child.Request.Headers)
+	out.Request.Query = mergeStringMap(base.Request.Query, child.Request.Query)
+	out.Request.Body = mergeBody(base.Request.Body, child.Request.Body)
+	out.Request.GraphQL = mergeGraphQL(base.Request.GraphQL, child.Request.GraphQL)
+
+	if out.Assert.Status == 0 {
+		out.Assert.Status = base.Assert.Status
+	}
+	if out.Assert.MaxDurationMs == 0 {
+		out.Assert.MaxDurationMs = base.Assert.MaxDurationMs
+	}
+	out.Assert.HeaderEquals = mergeStringMap(base.Assert.HeaderEquals, child.Assert.HeaderEquals)
+	out.Assert.JSONEquals = mergeAnyMap(base.Assert.JSONEquals, child.Assert.JSONEquals)
+	out.Assert.JSONContains = mergeAnyMap(base.Assert.JSONContains, child.Assert.JSONContains)
+	out.Assert.BodyContains = appendUnique(base.Assert.BodyContains, child.Assert.BodyContains)
+	if out.Assert.JS == "" {
+		out.Assert.JS = base.Assert.JS
+	}
+	if out.Assert.GraphQLErrors == nil {
+		out.Assert.GraphQLErrors = base.Assert.GraphQLErrors
+	}
+
+	if len(base.Extract) > 0 {
+		ex := make(map[string]models.Extract, len(base.Extract)+len(child.Extract))
+		for k, v := range base.Extract {
+			ex[k] = v
+		}
+		for k, v := range child.Extract {
+			ex[k] = v
+		}
+		out.Extract = ex
+	}
+	if out.SQL == nil {
+		out.SQL = base.SQL
+	}
+	if out.Transaction == "" {
+		out.Transaction = base.Transaction
+	}
+	out.Vars = mergeStringMap(base.Vars, child.Vars)
+	out.Tags = appendUnique(base.Tags, child.Tags)
+	if len(base.Pre) > 0 {
+		out.Pre = append(append([]models.Hook{}, base.Pre...), child.Pre...)
+	}
+	if len(base.Post) > 0 {
+		out.Post = append(append([]models.Hook{}, base.Post...), child.Post...)
+	}
+	if out.TimeoutMs == 0 {
+		out.TimeoutMs = base.TimeoutMs
+	}
+	if out.Repeat == 0 {
+		out.Repeat = base.Repeat
+	}
+	if out.Retry == nil {
+		out.Retry = base.Retry
+	}
+	if out.OpenAPI == nil {
+		out.OpenAPI = base.OpenAPI
+	}
+	return out
+}
+
+// mergeGraphQL overlays the fields child sets on base; variables merge key
+// by key.
+func mergeGraphQL(base, child *models.GraphQL) *models.GraphQL {
+	if base == nil {
+		return child
+	}
+	if child == nil {
+		return base
+	}
+	out := *base
+	if child.Query != "" || child.QueryFile != "" {
+		out.Query, out.QueryFile = child.Query, child.QueryFile
+	}
+	if child.OperationName != "" {
+		out.OperationName = child.OperationName
+	}
+	out.Variables = mergeAnyMap(base.Variables, child.Variables)
+	return &out
+}
+
+func mergeStringMap(base, over map[string]string) map[string]string {
+	if len(base) == 0 {
+		return over
+	}
+	out := make(map[string]string, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		out[k] = v
+	}
+	return out
+}
+
+func mergeAnyMap(base, over map[string]any) map[string]any {
+	if len(base) == 0 {
+		return over
+	}
+	out := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		out[k] = v
+	}
+	return out
+}
+
+// mergeBody recursively merges object bodies; any non-object child value replaces the base.
+func mergeBody(base, over any) any {
+	if over == nil {
+		return base
+	}
+	bm, ok1 := base.(map[string]any)
+	om, ok2 := over.(map[string]any)
+	if !ok1 || !ok2 {
+		return over
+	}
+	out := make(map[string]any, len(bm)+len(om))
+	for k, v := range bm {
+		out[k] = v
+	}
+	for k, v := range om {
+		if _, isMap := v.(map[string]any); isMap {
+			v = mergeBody(bm[k], v)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func appendUnique(base, extra []string) []string {
+	if len(base) == 0 {
+		return extra
+	}
+	out := append([]string{}, base...)
+	seen := map[string]bool{}
+	for _, v := range base {
+		seen[v] = true
+	}
+	for _, v := range extra {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,139 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+)
+
+func TestResolveTemplates_DeepMerge(t *testing.T) {
+	s := models.Suite{
+		Templates: map[string]models.TestCase{
+			"base": {
+				Request: models.Request{
+					Method:  "POST",
+					URL:     "/items",
+					Headers: map[string]string{"Content-Type": "application/json", "X-Trace": "base"},
+					Body:    map[string]any{"meta": map[string]any{"source": "tpl", "v": 1}, "name": "default"},
+				},
+				Assert:  models.Assertions{Status: 201, JSONEquals: map[string]any{"meta.source": "tpl"}, BodyContains: []string{"ok"}},
+				Extract: map[string]models.Extract{"id": {JSONPath: "id"}},
+				Tags:    []string{"tpl"},
+			},
+			"authed": {
+				Extends: "base",
+				Request: models.Request{Headers: map[string]string{"Authorization": "Bearer x"}},
+			},
+		},
+		Tests: []models.TestCase{
+			{
+				Name:    "child",
+				Extends: "authed",
+				Request: models.Request{
+					Headers: map[string]string{"X-Trace": "child"},
+					Body:    map[string]any{"meta": map[string]any{"v": 2}, "name": "widget"},
+				},
+				Assert: models.Assertions{JSONEquals: map[string]any{"name": "widget"}, BodyContains: []string{"ok", "widget"}},
+				Tags:   []string{"smoke"},
+			},
+			{Name: "plain", Request: models.Request{Method: "GET", URL: "/ping"}},
+		},
+	}
+	if err := ResolveTemplates(&s); err != nil {
+		t.Fatalf("ResolveTemplates: %v", err)
+	}
+	got := s.Tests[0]
+	if got.Extends != "" {
+		t.Fatalf("extends should be cleared, got %q", got.Extends)
+	}
+	if got.Request.Method != "POST" || got.Request.URL != "/items" {
+		t.Fatalf("request not inherited: %+v", got.Request)
+	}
+	wantHeaders := map[string]string{"Content-Type": "application/json", "X-Trace": "child", "Authorization": "Bearer x"}
+	if !reflect.DeepEqual(got.Request.Headers, wantHeaders) {
+		t.Fatalf("headers = %v, want %v", got.Request.Headers, wantHeaders)
+	}
+	wantBody := map[string]any{"meta": map[string]any{"source": "tpl", "v": 2}, "name": "widget"}
+	if !reflect.DeepEqual(got.Request.Body, wantBody) {
+		t.Fatalf("body = %v, want %v", got.Request.Body, wantBody)
+	}
+	if got.Assert.Status != 201 || len(got.Assert.JSONEquals) != 2 {
+		t.Fatalf("assertions not merged: %+v", got.Assert)
+	}
+	if !reflect.DeepEqual(got.Assert.BodyContains, []string{"ok", "widget"}) {
+		t.Fatalf("bodyContains = %v", got.Assert.BodyContains)
+	}
+	if got.Extract["id"].JSONPath != "id" {
+		t.Fatalf("extract not inherited: %v", got.Extract)
+	}
+	if !reflect.DeepEqual(got.Tags, []string{"tpl", "smoke"}) {
+		t.Fatalf("tags = %v", got.Tags)
+	}
+	// template maps must not be mutated by the merge
+	if s.Templates["base"].Request.Headers["X-Trace"] != "base" {
+		t.Fatalf("template headers were mutated")
+	}
+	if s.Tests[1].Request.URL != "/ping" {
+		t.Fatalf("plain test changed: %+v", s.Tests[1])
+	}
+}
+
+func TestResolveTemplates_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		suite   models.Suite
+		wantErr string
+	}{
+		{
+			name:    "unknown template",
+			suite:   models.Suite{Tests: []models.TestCase{{Name: "t", Extends: "missing"}}},
+			wantErr: `unknown template "missing"`,
+		},
+		{
+			name: "cycle",
+			suite: models.Suite{
+				Templates: map[string]models.TestCase{"a": {Extends: "b"}, "b": {Extends: "a"}},
+				Tests:     []models.TestCase{{Name: "t", Extends: "a"}},
+			},
+			wantErr: "template cycle: a -> b -> a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ResolveTemplates(&tt.suite)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadSuite_ResolvesTemplates(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "tpl.hrq.yaml")
+	yaml := `name: tpl
+baseUrl: http://example.com
+templates:
+  get:
+    request: { method: GET, headers: { Accept: application/json } }
+    assert: { status: 200 }
+tests:
+  - name: one
+    extends: get
+    request: { url: /one }
+`
+	if err := os.WriteFile(p, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSuite(p)
+	if err != nil {
+		t.Fatalf("LoadSuite: %v", err)
+	}
+	tc := s.Tests[0]
+	if tc.Request.Method != "GET" || tc.Request.URL != "/one" || tc.Assert.Status != 200 || tc.Request.Headers["Accept"] != "application/json" {
+		t.Fatalf("unexpected resolved test: %+v", tc)
+	}
+}
@@ -0,0 +1,528 @@
+package script
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/token"
+)
+
+// API tables for the three source tools. Variable scopes all map onto HydReq vars.
+var (
+	setters = set("pm.environment.set", "pm.globals.set", "pm.collectionVariables.set", "pm.variables.set",
+		"insomnia.environment.set", "insomnia.globals.set", "insomnia.baseEnvironment.set",
+		"insomnia.collectionVariables.set", "insomnia.variables.set",
+		"bru.setVar", "bru.setEnvVar", "setVar",
+		"postman.setEnvironmentVariable", "postman.setGlobalVariable")
+	getters = set("pm.environment.get", "pm.globals.get", "pm.collectionVariables.get", "pm.variables.get",
+		"insomnia.environment.get", "insomnia.globals.get", "insomnia.baseEnvironment.get",
+		"insomnia.collectionVariables.get", "insomnia.variables.get",
+		"bru.getVar", "bru.getEnvVar", "getVar",
+		"postman.getEnvironmentVariable", "postman.getGlobalVariable")
+	tests      = set("pm.test", "insomnia.test", "test")
+	expects    = set("pm.expect", "insomnia.expect", "expect")
+	statusOf   = set("pm.response.code", "insomnia.response.code", "res.status", "res.getStatus()", "response.status", "response.code")
+	headerOf   = set("pm.response.headers.get", "insomnia.response.headers.get", "res.getHeader", "response.header")
+	textOf     = set("pm.response.text()", "insomnia.response.text()", "response.text()")
+	timeOf     = set("pm.response.responseTime", "insomnia.response.responseTime", "res.responseTime", "res.getResponseTime()", "response.durationMs", "response.responseTime")
+	statusCall = set("pm.response.to.have.status", "insomnia.response.to.have.status")
+	headerCall = set("pm.response.to.have.header", "insomnia.response.to.have.header")
+	bodyRoots  = set("pm.response.json()", "insomnia.response.json()", "response.json()", "res.getBody()", "res.body",
+		"JSON.parse(responseBody)", "JSON.parse(pm.response.text())", "JSON.parse(insomnia.response.text())")
+
+	// chai language chains that don't change an expect's meaning
+	chainWords = set("to", "be", "been", "is", "that", "which", "and", "has", "have", "with", "at", "of", "same", "does", "deep")
+	equalNames = set("equal", "equals", "eq", "eql", "eqls")
+	inclNames  = set("include", "includes", "contain", "contains")
+
+	// members the JS hook shims provide; anything else on these objects can't run
+	shimAPI = map[string]map[string]bool{
+		"pm": set("environment", "globals", "collectionVariables", "variables", "iterationData",
+			"request", "response", "test", "expect"),
+		"insomnia": set("environment", "globals", "baseEnvironment", "collectionVariables", "variables",
+			"request", "response", "test", "expect"),
+		"bru":     set("setVar", "getVar", "setEnvVar", "getEnvVar", "getProcessEnv", "interpolate"),
+		"postman": set(),
+	}
+	responseTo    = set("have", "be", "status", "header", "body", "jsonBody", "ok", "success", "clientError", "serverError")
+	legacyGlobals = set("responseBody", "responseCode", "responseHeaders", "responseTime", "tests")
+)
+
+func set(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+// native collects the assertions and extractions of one statement before they
+// are merged into the translation.
+type native struct {
+	assert  models.Assertions
+	extract map[string]models.Extract
+}
+
+// add merges m into n unless it contradicts what n already asserts or
+// extracts, in which case n is left unchanged.
+func (n *native) add(m native) bool {
+	a, b := &n.assert, m.assert
+	if b.Status != 0 && a.Status != 0 && a.Status != b.Status ||
+		b.MaxDurationMs != 0 && a.MaxDurationMs != 0 && a.MaxDurationMs != b.MaxDurationMs ||
+		conflicts(a.HeaderEquals, b.HeaderEquals) || conflicts(a.JSONEquals, b.JSONEquals) ||
+		conflicts(a.JSONContains, b.JSONContains) || conflicts(n.extract, m.extract) {
+		return false
+	}
+	if b.Status != 0 {
+		a.Status = b.Status
+	}
+	if b.MaxDurationMs != 0 {
+		a.MaxDurationMs = b.MaxDurationMs
+	}
+	a.HeaderEquals = mergeMap(a.HeaderEquals, b.HeaderEquals)
+	a.JSONEquals = mergeMap(a.JSONEquals, b.JSONEquals)
+	a.JSONContains = mergeMap(a.JSONContains, b.JSONContains)
+	a.BodyContains = append(a.BodyContains, b.BodyContains...)
+	n.extract = mergeMap(n.extract, m.extract)
+	return true
+}
+
+func conflicts[V any](have, add map[string]V) bool {
+	for k, v := range add {
+		if old, ok := have[k]; ok && !reflect.DeepEqual(old, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// nativeStatement maps a post-response statement to assertions or extractions:
+// a pm.test block whose body is made only of mappable statements, a bare
+// expect/status assertion, or a variable set from the response body.
+func (t *translator) nativeStatement(st ast.Statement) (native, bool) {
+	es, ok := st.(*ast.ExpressionStatement)
+	if !ok {
+		return native{}, false
+	}
+	call, ok := es.Expression.(*ast.CallExpression)
+	if !ok {
+		return native{}, false
+	}
+	if tests[chain(call.Callee)] && len(call.ArgumentList) == 2 {
+		if _, ok := stringValue(call.ArgumentList[0]); !ok {
+			return native{}, false
+		}
+		body, ok := functionBody(call.ArgumentList[1])
+		if !ok {
+			return native{}, false
+		}
+		outer := t.aliases
+		t.aliases = make(map[string]bool, len(outer))
+		for k := range outer {
+			t.aliases[k] = true
+		}
+		defer func() { t.aliases = outer }()
+		var acc native
+		for _, s := range body {
+			if name, ok := t.aliasDecl(s); ok {
+				t.aliases[name] = true
+				continue
+			}
+			if _, ok := s.(*ast.EmptyStatement); ok {
+				continue
+			}
+			m, ok := t.nativeStatement(s)
+			if !ok || !acc.add(m) {
+				return native{}, false
+			}
+		}
+		return acc, true
+	}
+	if k, v, ok := setCall(call); ok {
+		path, ok := t.jsonPath(v)
+		if !ok || path == "" {
+			return native{}, false
+		}
+		return native{extract: map[string]models.Extract{k: {JSONPath: path}}}, true
+	}
+	var n native
+	ok = t.assertion(call, &n.assert)
+	return n, ok
+}
+
+// functionBody returns the statements of a function or arrow function without parameters.
+func functionBody(e ast.Expression) ([]ast.Statement, bool) {
+	switch f := e.(type) {
+	case *ast.FunctionLiteral:
+		if len(f.ParameterList.List) > 0 || f.Async || f.Generator {
+			return nil, false
+		}
+		return f.Body.List, true
+	case *ast.ArrowFunctionLiteral:
+		if len(f.ParameterList.List) > 0 || f.Async {
+			return nil, false
+		}
+		switch b := f.Body.(type) {
+		case *ast.BlockStatement:
+			return b.List, true
+		case *ast.ExpressionBody:
+			return []ast.Statement{&ast.ExpressionStatement{Expression: b.Expression}}, true
+		}
+	}
+	return nil, false
+}
+
+// assertion maps pm.response.to.have.status/header and expect(...) chains.
+func (t *translator) assertion(call *ast.CallExpression, a *models.Assertions) bool {
+	callee := chain(call.Callee)
+	args := call.ArgumentList
+	switch {
+	case statusCall[callee] && len(args) == 1:
+		code, ok := intValue(args[0])
+		a.Status = code
+		return ok
+	case headerCall[callee] && len(args) == 2:
+		name, ok1 := stringValue(args[0])
+		val, ok2 := stringValue(args[1])
+		a.HeaderEquals = map[string]string{name: val}
+		return ok1 && ok2
+	}
+	dot, ok := call.Callee.(*ast.DotExpression)
+	if !ok || len(args) != 1 {
+		return false
+	}
+	matcher := string(dot.Identifier.Name)
+	left := dot.Left
+	for {
+		d, ok := left.(*ast.DotExpression)
+		if !ok {
+			break
+		}
+		if !chainWords[string(d.Identifier.Name)] {
+			return false // includes .not
+		}
+		left = d.Left
+	}
+	ex, ok := left.(*ast.CallExpression)
+	if !ok || !expects[chain(ex.Callee)] || len(ex.ArgumentList) == 0 || len(ex.ArgumentList) > 2 {
+		return false
+	}
+	target := ex.ArgumentList[0]
+	want := args[0]
+	switch {
+	case statusOf[chain(target)] && equalNames[matcher]:
+		code, ok := intValue(want)
+		a.Status = code
+		return ok
+	case timeOf[chain(target)] && (matcher == "below" || matcher == "lessThan" || matcher == "lt"):
+		ms, ok := intValue(want)
+		a.MaxDurationMs = int64(ms - 1)
+		return ok && ms > 1
+	case timeOf[chain(target)] && (matcher == "most" || matcher == "lte"):
+		ms, ok := intValue(want)
+		a.MaxDurationMs = int64(ms)
+		return ok && ms > 0
+	case textOf[chain(target)] && inclNames[matcher]:
+		s, ok := stringValue(want)
+		a.BodyContains = []string{s}
+		return ok
+	}
+	if hc, ok := target.(*ast.CallExpression); ok && headerOf[chain(hc.Callee)] && len(hc.ArgumentList) == 1 && equalNames[matcher] {
+		name, ok1 := stringValue(hc.ArgumentList[0])
+		val, ok2 := stringValue(want)
+		a.HeaderEquals = map[string]string{name: val}
+		return ok1 && ok2
+	}
+	path, ok := t.jsonPath(target)
+	if !ok || path == "" {
+		return false
+	}
+	switch {
+	case equalNames[matcher]:
+		v, ok := literal(want)
+		a.JSONEquals = map[string]any{path: v}
+		return ok
+	case inclNames[matcher]:
+		s, ok := stringValue(want)
+		a.JSONContains = map[string]any{path: s}
+		return ok
+	}
+	return false
+}
+
+// aliasDecl recognises `var jsonData = pm.response.json()` and its variants.
+func (t *translator) aliasDecl(st ast.Statement) (string, bool) {
+	var list []*ast.Binding
+	switch d := st.(type) {
+	case *ast.VariableStatement:
+		list = d.List
+	case *ast.LexicalDeclaration:
+		list = d.List
+	}
+	if len(list) != 1 || list[0].Initializer == nil {
+		return "", false
+	}
+	id, ok := list[0].Target.(*ast.Identifier)
+	if !ok {
+		return "", false
+	}
+	if path, ok := t.jsonPath(list[0].Initializer); !ok || path != "" {
+		return "", false
+	}
+	return string(id.Name), true
+}
+
+// jsonPath turns an access into the response body (jsonData.items[0].id) into
+// a gjson path ("items.0.id"). The body itself has the empty path.
+func (t *translator) jsonPath(e ast.Expression) (string, bool) {
+	if bodyRoots[chain(e)] {
+		return "", true
+	}
+	switch x := e.(type) {
+	case *ast.Identifier:
+		return "", t.aliases[string(x.Name)]
+	case *ast.DotExpression:
+		p, ok := t.jsonPath(x.Left)
+		return joinPath(p, gjsonKey(string(x.Identifier.Name))), ok
+	case *ast.BracketExpression:
+		p, ok := t.jsonPath(x.Left)
+		if !ok {
+			return "", false
+		}
+		switch m := x.Member.(type) {
+		case *ast.StringLiteral:
+			return joinPath(p, gjsonKey(string(m.Value))), true
+		case *ast.NumberLiteral:
+			if i, ok := m.Value.(int64); ok && i >= 0 {
+				return joinPath(p, strconv.FormatInt(i, 10)), true
+			}
+		}
+	}
+	return "", false
+}
+
+func joinPath(p, key string) string {
+	if p == "" {
+		return key
+	}
+	return p + "." + key
+}
+
+func gjsonKey(k string) string {
+	var b strings.Builder
+	for _, r := range k {
+		if strings.ContainsRune(`.*?|#@\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// literalSet recognises a variable set whose key and value are known without
+// running the script, e.g. pm.environment.set("base", "https://" + pm.environment.get("host")).
+func (t *translator) literalSet(st ast.Statement) (string, string, bool) {
+	es, ok := st.(*ast.ExpressionStatement)
+	if !ok {
+		return "", "", false
+	}
+	call, ok := es.Expression.(*ast.CallExpression)
+	if !ok {
+		return "", "", false
+	}
+	k, v, ok := setCall(call)
+	if !ok {
+		return "", "", false
+	}
+	if s, ok := stringValue(v); ok {
+		return k, s, true
+	}
+	if lit, ok := literal(v); ok {
+		return k, fmt.Sprint(lit), true
+	}
+	return "", "", false
+}
+
+func setCall(call *ast.CallExpression) (string, ast.Expression, bool) {
+	if !setters[chain(call.Callee)] || len(call.ArgumentList) != 2 {
+		return "", nil, false
+	}
+	k, ok := stringValue(call.ArgumentList[0])
+	return k, call.ArgumentList[1], ok && k != ""
+}
+
+// stringValue evaluates string literals, template literals and concatenations
+// of them; variable reads become ${name} placeholders.
+func stringValue(e ast.Expression) (string, bool) {
+	switch x := e.(type) {
+	case *ast.StringLiteral:
+		return string(x.Value), true
+	case *ast.TemplateLiteral:
+		if x.Tag != nil {
+			return "", false
+		}
+		var b strings.Builder
+		for i, el := range x.Elements {
+			b.WriteString(string(el.Parsed))
+			if i < len(x.Expressions) {
+				s, ok := stringValue(x.Expressions[i])
+				if !ok {
+					return "", false
+				}
+				b.WriteString(s)
+			}
+		}
+		return b.String(), true
+	case *ast.CallExpression:
+		if getters[chain(x.Callee)] && len(x.ArgumentList) == 1 {
+			if name, ok := x.ArgumentList[0].(*ast.StringLiteral); ok {
+				return "${" + string(name.Value) + "}", true
+			}
+		}
+	case *ast.BinaryExpression:
+		if x.Operator == token.PLUS {
+			l, ok1 := stringValue(x.Left)
+			r, ok2 := stringValue(x.Right)
+			return l + r, ok1 && ok2
+		}
+	}
+	return "", false
+}
+
+// literal evaluates a string, number or boolean literal.
+func literal(e ast.Expression) (any, bool) {
+	switch x := e.(type) {
+	case *ast.NumberLiteral:
+		switch v := x.Value.(type) {
+		case int64:
+			return int(v), true
+		case float64:
+			return v, true
+		}
+	case *ast.BooleanLiteral:
+		return x.Value, true
+	case *ast.UnaryExpression:
+		if x.Operator == token.MINUS && !x.Postfix {
+			if v, ok := literal(x.Operand); ok {
+				switch n := v.(type) {
+				case int:
+					return -n, true
+				case float64:
+					return -n, true
+				}
+			}
+		}
+	}
+	return stringValue(e)
+}
+
+func intValue(e ast.Expression) (int, bool) {
+	v, ok := literal(e)
+	n, isInt := v.(int)
+	return n, ok && isInt
+}
+
+// chain renders a member/call chain such as pm.response.json() as text; it is
+// empty for anything else.
+func chain(e ast.Expression) string {
+	switch x := e.(type) {
+	case *ast.Identifier:
+		return string(x.Name)
+	case *ast.DotExpression:
+		if l := chain(x.Left); l != "" {
+			return l + "." + string(x.Identifier.Name)
+		}
+	case *ast.CallExpression:
+		c := chain(x.Callee)
+		if c == "" {
+			return ""
+		}
+		var args []string
+		for _, a := range x.ArgumentList {
+			s := chain(a)
+			if s == "" {
+				return ""
+			}
+			args = append(args, s)
+		}
+		return c + "(" + strings.Join(args, ", ") + ")"
+	}
+	return ""
+}
+
+// unsupported returns why st can't run in a HydReq JS hook, or "" if it can:
+// calls the pm/insomnia/bru shims don't provide, the legacy postman.* API and
+// sandbox globals, and require().
+func (t *translator) unsupported(st ast.Statement) string {
+	var reason string
+	inspect(st, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.DotExpression:
+			if id, ok := x.Left.(*ast.Identifier); ok {
+				if api, ok := shimAPI[string(id.Name)]; ok && !api[string(x.Identifier.Name)] {
+					reason = fmt.Sprintf("%s.%s is not supported in HydReq JS hooks", id.Name, x.Identifier.Name)
+				}
+			}
+			if c := chain(x); (strings.HasPrefix(c, "pm.response.to.") || strings.HasPrefix(c, "insomnia.response.to.")) &&
+				!responseTo[string(x.Identifier.Name)] {
+				reason = c + " is not supported in HydReq JS hooks"
+			}
+		case *ast.CallExpression:
+			if id, ok := x.Callee.(*ast.Identifier); ok && id.Name == "require" {
+				reason = "require() is not available in HydReq JS hooks; use suite scripts"
+			}
+		case *ast.Identifier:
+			if legacyGlobals[string(x.Name)] {
+				reason = fmt.Sprintf("legacy sandbox global %q is not available in HydReq JS hooks", x.Name)
+			}
+		}
+		return reason == ""
+	})
+	return reason
+}
+
+var nodeType = reflect.TypeOf((*ast.Node)(nil)).Elem()
+
+// inspect walks the AST below n depth-first, calling f for every node reached
+// through a pointer; it stops descending where f returns false.
+func inspect(n any, f func(ast.Node) bool) {
+	var walk func(v reflect.Value) bool
+	walk = func(v reflect.Value) bool {
+		switch v.Kind() {
+		case reflect.Interface:
+			if v.IsNil() {
+				return true
+			}
+			return walk(v.Elem())
+		case reflect.Pointer:
+			if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+				return true
+			}
+			if v.Type().Implements(nodeType) && !f(v.Interface().(ast.Node)) {
+				return false
+			}
+			return walk(v.Elem())
+		case reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).Name == "DeclarationList" || !v.Type().Field(i).IsExported() {
+					continue
+				}
+				if !walk(v.Field(i)) {
+					return false
+				}
+			}
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				if !walk(v.Index(i)) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	walk(reflect.ValueOf(n))
+}
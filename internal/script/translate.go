@@ -1,227 +1,274 @@
 package script
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/DrWeltschmerz/HydReq/pkg/models"
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/file"
+	"github.com/dop251/goja/parser"
 )
 
-// TranslateJSToHook converts JavaScript code from external tools to HydReq hooks
-func TranslateJSToHook(jsCode, source string) []models.Hook {
-	var hooks []models.Hook
+// Phase tells the translator when an imported script runs. Assertions and
+// extractions are only lifted out of PostResponse scripts.
+type Phase int
 
-	// Split multi-line JS into statements
-	statements := splitJSStatements(jsCode)
-
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" {
-			continue
-		}
-
-		// Try pattern-based translation first
-		if hook := translatePatternToHook(stmt, source); hook != nil {
-			hooks = append(hooks, *hook)
-			continue
-		}
-
-		// Fall back to JS hook for complex logic
-		hook := models.Hook{
-			Name: fmt.Sprintf("%s script", source),
-			JS: &models.JSHook{
-				Code: translateJSApi(stmt, source),
-			},
-		}
-		hooks = append(hooks, hook)
-	}
+const (
+	PreRequest Phase = iota
+	PostResponse
+)
 
-	return hooks
+// Issue is a script construct that could not be carried over to HydReq.
+// The statement holding it is left out of the generated hooks.
+type Issue struct {
+	Item   string `json:"item,omitempty"`   // request or folder the script belongs to
+	Script string `json:"script,omitempty"` // which of the item's scripts, in the source tool's terms
+	Line   int    `json:"line"`
+	Code   string `json:"code,omitempty"`
+	Reason string `json:"reason"`
 }
 
-// splitJSStatements splits JavaScript code into individual statements
-func splitJSStatements(jsCode string) []string {
-	// Simple splitting on semicolons, ignoring those in strings
-	var statements []string
-	var current strings.Builder
-	inString := false
-	stringChar := byte(0)
-
-	for i := 0; i < len(jsCode); i++ {
-		char := jsCode[i]
-
-		if !inString && (char == '"' || char == '\'') {
-			inString = true
-			stringChar = char
-		} else if inString && char == stringChar && jsCode[i-1] != '\\' {
-			inString = false
-			stringChar = 0
-		} else if !inString && char == ';' {
-			stmt := strings.TrimSpace(current.String())
-			if stmt != "" {
-				statements = append(statements, stmt+";")
-			}
-			current.Reset()
-			continue
-		}
-
-		current.WriteByte(char)
+func (i Issue) String() string {
+	var b strings.Builder
+	if i.Item != "" {
+		b.WriteString(i.Item + ": ")
 	}
-
-	// Add remaining statement
-	if remaining := strings.TrimSpace(current.String()); remaining != "" {
-		statements = append(statements, remaining)
+	if i.Script != "" {
+		b.WriteString(i.Script + " script ")
 	}
+	if i.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", i.Line)
+	}
+	b.WriteString(i.Reason)
+	if i.Code != "" {
+		b.WriteString(" (" + i.Code + ")")
+	}
+	return b.String()
+}
 
-	return statements
+// Translation is the HydReq form of one imported script: native assertions and
+// extractions where possible, a vars hook for literal variable sets, and a JS
+// hook with the remaining statements (run through the pm/insomnia/bru shims).
+type Translation struct {
+	Phase   Phase
+	Hooks   []models.Hook
+	Assert  models.Assertions
+	Extract map[string]models.Extract
+	Issues  []Issue
 }
 
-// translatePatternToHook converts common patterns to native HydReq features
-func translatePatternToHook(stmt, source string) *models.Hook {
-	switch source {
-	case "postman":
-		return translatePostmanPattern(stmt)
-	case "insomnia":
-		return translateInsomniaPattern(stmt)
-	case "bruno":
-		return translateBrunoPattern(stmt)
+// IssuesFor returns the translation's issues labelled with the item and script they came from.
+func (t Translation) IssuesFor(item, script string) []Issue {
+	out := make([]Issue, 0, len(t.Issues))
+	for _, is := range t.Issues {
+		is.Item, is.Script = item, script
+		out = append(out, is)
 	}
-	return nil
+	return out
 }
 
-// translatePostmanPattern handles Postman-specific patterns
-func translatePostmanPattern(stmt string) *models.Hook {
-	// pm.environment.set('key', 'value')
-	if matched, _ := regexp.MatchString(`pm\.environment\.set\(`, stmt); matched {
-		return translatePostmanEnvSet(stmt)
+// ApplyTo adds the translation to a test: hooks go to Pre or Post by phase, and
+// native assertions and extractions are merged into the test's own.
+func (t Translation) ApplyTo(tc *models.TestCase) {
+	if t.Phase == PreRequest {
+		tc.Pre = append(tc.Pre, t.Hooks...)
+	} else {
+		tc.Post = append(tc.Post, t.Hooks...)
 	}
-
-	// pm.globals.set('key', 'value')
-	if matched, _ := regexp.MatchString(`pm\.globals\.set\(`, stmt); matched {
-		return translatePostmanGlobalSet(stmt)
+	if t.Assert.Status != 0 {
+		tc.Assert.Status = t.Assert.Status
 	}
-
-	// pm.test('name', function() { ... })
-	if matched, _ := regexp.MatchString(`pm\.test\(`, stmt); matched {
-		return translatePostmanTest(stmt)
+	if t.Assert.MaxDurationMs != 0 {
+		tc.Assert.MaxDurationMs = t.Assert.MaxDurationMs
 	}
-
-	return nil
+	tc.Assert.HeaderEquals = mergeMap(tc.Assert.HeaderEquals, t.Assert.HeaderEquals)
+	tc.Assert.JSONEquals = mergeMap(tc.Assert.JSONEquals, t.Assert.JSONEquals)
+	tc.Assert.JSONContains = mergeMap(tc.Assert.JSONContains, t.Assert.JSONContains)
+	tc.Assert.BodyContains = append(tc.Assert.BodyContains, t.Assert.BodyContains...)
+	tc.Extract = mergeMap(tc.Extract, t.Extract)
 }
 
-// translatePostmanEnvSet converts pm.environment.set to HydReq vars
-func translatePostmanEnvSet(stmt string) *models.Hook {
-	re := regexp.MustCompile(`pm\.environment\.set\(\s*['"]([^'"]+)['"]\s*,\s*['"]([^'"]+)['"]\s*\)`)
-	matches := re.FindStringSubmatch(stmt)
-	if len(matches) == 3 {
-		return &models.Hook{
-			Name: "Postman environment variable",
-			Vars: map[string]string{
-				matches[1]: matches[2],
-			},
-		}
+func mergeMap[V any](dst, src map[string]V) map[string]V {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]V, len(src))
 	}
-	return nil
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
 }
 
-// translatePostmanGlobalSet converts pm.globals.set to HydReq vars
-func translatePostmanGlobalSet(stmt string) *models.Hook {
-	re := regexp.MustCompile(`pm\.globals\.set\(\s*['"]([^'"]+)['"]\s*,\s*['"]([^'"]+)['"]\s*\)`)
-	matches := re.FindStringSubmatch(stmt)
-	if len(matches) == 3 {
-		return &models.Hook{
-			Name: "Postman global variable",
-			Vars: map[string]string{
-				matches[1]: matches[2],
-			},
+// TranslateJSToHook converts a Postman, Insomnia or Bruno script (source names
+// the tool) to HydReq. The script is parsed as JavaScript; pm.test/expect
+// chains and variable sets that have a native equivalent are mapped to
+// assertions, extract and vars, everything else is kept as a JS hook, and
+// constructs the JS runtime cannot run are reported as issues.
+func TranslateJSToHook(jsCode, source string, phase Phase) Translation {
+	t := &translator{src: jsCode, source: source, aliases: map[string]bool{}}
+	t.out.Phase = phase
+	if strings.TrimSpace(jsCode) == "" {
+		return t.out
+	}
+	prog, err := parser.ParseFile(nil, "", jsCode, 0)
+	if err != nil {
+		issue := Issue{Reason: "script does not parse: " + err.Error()}
+		var list parser.ErrorList
+		if errors.As(err, &list) && len(list) > 0 {
+			issue.Line, issue.Reason = list[0].Position.Line, "script does not parse: "+list[0].Message
 		}
+		t.out.Issues = append(t.out.Issues, issue)
+		return t.out
 	}
-	return nil
+	t.translate(prog.Body, phase)
+	return t.out
+}
+
+type translator struct {
+	src     string
+	source  string
+	aliases map[string]bool // identifiers bound to the parsed response body
+	native  native          // assertions and extractions lifted so far
+	out     Translation
 }
 
-// translatePostmanTest converts pm.test to HydReq assertions
-func translatePostmanTest(stmt string) *models.Hook {
-	// This is complex - for now, just wrap in JS
-	return nil
+// kept is a top-level statement that stays in the JS hook.
+type kept struct {
+	code  string
+	alias string // set for response-body alias declarations, kept only if referenced
+	stmt  ast.Statement
 }
 
-// translateInsomniaPattern handles Insomnia-specific patterns
-func translateInsomniaPattern(stmt string) *models.Hook {
-	// insomnia.globals.set('key', 'value')
-	if matched, _ := regexp.MatchString(`insomnia\.globals\.set\(`, stmt); matched {
-		re := regexp.MustCompile(`insomnia\.globals\.set\(\s*['"]([^'"]+)['"]\s*,\s*([^)]+)\)`)
-		matches := re.FindStringSubmatch(stmt)
-		if len(matches) == 3 {
-			return &models.Hook{
-				Name: "Insomnia global variable",
-				Vars: map[string]string{
-					matches[1]: strings.Trim(matches[2], "'\""),
-				},
+func (t *translator) translate(body []ast.Statement, phase Phase) {
+	post := phase == PostResponse
+	vars := map[string]string{}
+	var js []kept
+	prevEnd := 0
+	for _, st := range body {
+		code := t.chunk(prevEnd, st)
+		prevEnd = int(st.Idx1()) - 1
+		if _, ok := st.(*ast.EmptyStatement); ok {
+			continue
+		}
+		if post {
+			if name, ok := t.aliasDecl(st); ok {
+				t.aliases[name] = true
+				js = append(js, kept{code: code, alias: name, stmt: st})
+				continue
 			}
 		}
+		if k, v, ok := t.literalSet(st); ok && !hasCode(js) {
+			vars[k] = v
+			continue
+		}
+		if post {
+			if n, ok := t.nativeStatement(st); ok && t.native.add(n) {
+				continue
+			}
+		}
+		if reason := t.unsupported(st); reason != "" {
+			t.report(st, reason)
+			continue
+		}
+		js = append(js, kept{code: code})
+	}
+	t.out.Assert, t.out.Extract = t.native.assert, t.native.extract
+	if len(vars) > 0 {
+		t.out.Hooks = append(t.out.Hooks, models.Hook{Name: sourceLabel(t.source) + " variables", Vars: vars})
 	}
-	return nil
-}
-
-// translateBrunoPattern handles Bruno-specific patterns
-func translateBrunoPattern(stmt string) *models.Hook {
-	// bru.setVar('key', 'value')
-	if matched, _ := regexp.MatchString(`bru\.setVar\(`, stmt); matched {
-		re := regexp.MustCompile(`bru\.setVar\(\s*['"]([^'"]+)['"]\s*,\s*['"]([^'"]*)['"]\s*\)`)
-		matches := re.FindStringSubmatch(stmt)
-		if len(matches) == 3 {
-			return &models.Hook{
-				Name: "Bruno variable",
-				Vars: map[string]string{
-					matches[1]: matches[2],
-				},
+	if !hasCode(js) {
+		return
+	}
+	var lines []string
+	for i, k := range js {
+		if k.alias != "" && !referenced(k.alias, js[i+1:]) {
+			continue
+		}
+		if k.alias != "" {
+			if reason := t.unsupported(k.stmt); reason != "" {
+				t.report(k.stmt, reason)
+				continue
 			}
 		}
+		lines = append(lines, k.code)
 	}
-	return nil
+	t.out.Hooks = append(t.out.Hooks, models.Hook{
+		Name: t.source + " script",
+		JS:   &models.JSHook{Code: strings.Join(lines, "\n")},
+	})
 }
 
-// translateJSApi converts external JS APIs to HydReq equivalents
-func translateJSApi(stmt, source string) string {
-	switch source {
-	case "postman":
-		return translatePostmanAPI(stmt)
-	case "insomnia":
-		return translateInsomniaAPI(stmt)
-	case "bruno":
-		return translateBrunoAPI(stmt)
+// chunk returns the source of st together with the comments before it.
+func (t *translator) chunk(from int, st ast.Statement) string {
+	end := int(st.Idx1()) - 1
+	code := strings.TrimSpace(t.src[from:end])
+	code = strings.TrimSpace(strings.TrimLeft(code, ";"))
+	switch st.(type) {
+	case *ast.ExpressionStatement, *ast.VariableStatement, *ast.LexicalDeclaration, *ast.ReturnStatement, *ast.ThrowStatement:
+		code += ";"
 	}
-	return stmt
+	return code
 }
 
-// translatePostmanAPI converts Postman APIs to HydReq equivalents
-func translatePostmanAPI(stmt string) string {
-	// pm.environment.set('key', 'value') -> setVar('key', 'value')
-	stmt = regexp.MustCompile(`pm\.environment\.set\(`).ReplaceAllString(stmt, `setVar(`)
-	stmt = regexp.MustCompile(`pm\.globals\.set\(`).ReplaceAllString(stmt, `setVar(`)
-
-	// pm.environment.get('key') -> getVar('key')
-	stmt = regexp.MustCompile(`pm\.environment\.get\(`).ReplaceAllString(stmt, `getVar(`)
-	stmt = regexp.MustCompile(`pm\.globals\.get\(`).ReplaceAllString(stmt, `getVar(`)
+func hasCode(js []kept) bool {
+	for _, k := range js {
+		if k.alias == "" {
+			return true
+		}
+	}
+	return false
+}
 
-	return stmt
+func referenced(name string, rest []kept) bool {
+	re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+	for _, k := range rest {
+		if re.MatchString(k.code) {
+			return true
+		}
+	}
+	return false
 }
 
-// translateInsomniaAPI converts Insomnia APIs to HydReq equivalents
-func translateInsomniaAPI(stmt string) string {
-	// insomnia.globals.set('key', 'value') -> setVar('key', 'value')
-	stmt = regexp.MustCompile(`insomnia\.globals\.set\(`).ReplaceAllString(stmt, `setVar(`)
-	stmt = regexp.MustCompile(`insomnia\.globals\.get\(`).ReplaceAllString(stmt, `getVar(`)
+func (t *translator) report(n ast.Node, reason string) {
+	code := t.text(n)
+	if i := strings.IndexByte(code, '\n'); i >= 0 {
+		code = strings.TrimSpace(code[:i]) + " ..."
+	}
+	if len(code) > 80 {
+		code = code[:77] + "..."
+	}
+	t.out.Issues = append(t.out.Issues, Issue{Line: t.line(n.Idx0()), Code: code, Reason: reason})
+}
 
-	return stmt
+func (t *translator) text(n ast.Node) string {
+	from, to := int(n.Idx0())-1, int(n.Idx1())-1
+	if from < 0 || to > len(t.src) || from > to {
+		return ""
+	}
+	return t.src[from:to]
 }
 
-// translateBrunoAPI converts Bruno APIs to HydReq equivalents
-func translateBrunoAPI(stmt string) string {
-	// bru.setVar('key', 'value') -> setVar('key', 'value')
-	stmt = regexp.MustCompile(`bru\.setVar\(`).ReplaceAllString(stmt, `setVar(`)
-	stmt = regexp.MustCompile(`bru\.getVar\(`).ReplaceAllString(stmt, `getVar(`)
+func (t *translator) line(idx file.Idx) int {
+	off := int(idx) - 1
+	if off < 0 || off > len(t.src) {
+		return 0
+	}
+	return 1 + strings.Count(t.src[:off], "\n")
+}
 
-	return stmt
+func sourceLabel(source string) string {
+	switch source {
+	case "postman":
+		return "Postman"
+	case "insomnia":
+		return "Insomnia"
+	case "bruno":
+		return "Bruno"
+	}
+	return source
 }
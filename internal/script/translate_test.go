@@ -3,53 +3,52 @@ package script
 import (
 	"strings"
 	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/pkg/models"
 )
 
 func TestTranslateJSToHook_Postman(t *testing.T) {
 	js := `pm.environment.set('token', 'abc123');
-pm.globals.set('userId', '456');`
+pm.globals.set('userId', 456);`
 
-	hooks := TranslateJSToHook(js, "postman")
+	tr := TranslateJSToHook(js, "postman", PreRequest)
 
-	if len(hooks) != 2 {
-		t.Fatalf("expected 2 hooks, got %d", len(hooks))
+	if len(tr.Hooks) != 1 {
+		t.Fatalf("expected 1 hook, got %d", len(tr.Hooks))
 	}
-
-	// First hook should be vars
-	if hooks[0].Vars["token"] != "abc123" {
-		t.Errorf("expected token=abc123, got %v", hooks[0].Vars)
-	}
-
-	if hooks[1].Vars["userId"] != "456" {
-		t.Errorf("expected userId=456, got %v", hooks[1].Vars)
+	if tr.Hooks[0].Vars["token"] != "abc123" || tr.Hooks[0].Vars["userId"] != "456" {
+		t.Errorf("unexpected vars %v", tr.Hooks[0].Vars)
 	}
 }
 
 func TestTranslateJSToHook_Insomnia(t *testing.T) {
 	js := `insomnia.globals.set('apiKey', 'secret');`
 
-	hooks := TranslateJSToHook(js, "insomnia")
+	tr := TranslateJSToHook(js, "insomnia", PreRequest)
 
-	if len(hooks) != 1 {
-		t.Fatalf("expected 1 hook, got %d", len(hooks))
+	if len(tr.Hooks) != 1 {
+		t.Fatalf("expected 1 hook, got %d", len(tr.Hooks))
 	}
-
-	if hooks[0].Vars["apiKey"] != "secret" {
-		t.Errorf("expected apiKey=secret, got %v", hooks[0].Vars)
+	if tr.Hooks[0].Vars["apiKey"] != "secret" {
+		t.Errorf("expected apiKey=secret, got %v", tr.Hooks[0].Vars)
 	}
 }
 
 func TestTranslateJSToHook_Bruno(t *testing.T) {
-	js := `bru.setVar('endpoint', '/api/v1');`
+	js := `bru.setVar('endpoint', '/api/v1')
+bru.setVar("url", ` + "`${bru.getVar('host')}/v1`" + `)
+bru.setVar("auth", "Bearer " + bru.getEnvVar("token"))`
 
-	hooks := TranslateJSToHook(js, "bruno")
+	tr := TranslateJSToHook(js, "bruno", PreRequest)
 
-	if len(hooks) != 1 {
-		t.Fatalf("expected 1 hook, got %d", len(hooks))
+	if len(tr.Hooks) != 1 {
+		t.Fatalf("expected 1 hook, got %d", len(tr.Hooks))
 	}
-
-	if hooks[0].Vars["endpoint"] != "/api/v1" {
-		t.Errorf("expected endpoint=/api/v1, got %v", hooks[0].Vars)
+	want := map[string]string{"endpoint": "/api/v1", "url": "${host}/v1", "auth": "Bearer ${token}"}
+	for k, v := range want {
+		if tr.Hooks[0].Vars[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, tr.Hooks[0].Vars[k])
+		}
 	}
 }
 
@@ -57,62 +56,136 @@ func TestTranslateJSToHook_ComplexJS(t *testing.T) {
 	js := `console.log('Complex logic');
 setVar('computed', 'value');`
 
-	hooks := TranslateJSToHook(js, "postman")
+	tr := TranslateJSToHook(js, "postman", PreRequest)
 
-	if len(hooks) != 2 {
-		t.Fatalf("expected 2 hooks, got %d", len(hooks))
+	// a set after other code stays in the script so it runs in order
+	if len(tr.Hooks) != 1 || tr.Hooks[0].JS == nil {
+		t.Fatalf("expected one JS hook, got %+v", tr.Hooks)
 	}
-
-	// First hook should be JS
-	if hooks[0].JS == nil {
-		t.Fatal("expected first hook to be JS")
+	code := tr.Hooks[0].JS.Code
+	if !strings.Contains(code, "console.log") || !strings.Contains(code, "setVar('computed'") {
+		t.Errorf("unexpected code %q", code)
 	}
+}
 
-	if !strings.Contains(hooks[0].JS.Code, "console.log") {
-		t.Errorf("expected console.log in first hook, got %q", hooks[0].JS.Code)
-	}
+func TestTranslateJSToHook_NativeAssertions(t *testing.T) {
+	js := `// parse once
+const jsonData = pm.response.json();
+
+pm.test("Status is 201", function () {
+    pm.response.to.have.status(201);
+});
+pm.test("Body", () => {
+    pm.expect(jsonData.user.name).to.eql("Ada");
+    pm.expect(jsonData.items[0].id).to.equal(7);
+    pm.expect(pm.response.text()).to.include("Ada");
+    pm.expect(pm.response.headers.get("Content-Type")).to.equal("application/json");
+});
+pm.test("fast", () => pm.expect(pm.response.responseTime).to.be.below(500));
+pm.environment.set("userId", jsonData.user.id);`
 
-	// Second hook should be JS
-	if hooks[1].JS == nil {
-		t.Fatal("expected second hook to be JS")
+	tr := TranslateJSToHook(js, "postman", PostResponse)
+
+	if len(tr.Hooks) != 0 || len(tr.Issues) != 0 {
+		t.Fatalf("expected everything native, got hooks %+v issues %v", tr.Hooks, tr.Issues)
+	}
+	a := tr.Assert
+	if a.Status != 201 || a.MaxDurationMs != 499 {
+		t.Errorf("status/duration: %+v", a)
+	}
+	if a.JSONEquals["user.name"] != "Ada" || a.JSONEquals["items.0.id"] != 7 {
+		t.Errorf("jsonEquals: %v", a.JSONEquals)
+	}
+	if len(a.BodyContains) != 1 || a.BodyContains[0] != "Ada" {
+		t.Errorf("bodyContains: %v", a.BodyContains)
+	}
+	if a.HeaderEquals["Content-Type"] != "application/json" {
+		t.Errorf("headerEquals: %v", a.HeaderEquals)
+	}
+	if tr.Extract["userId"].JSONPath != "user.id" {
+		t.Errorf("extract: %v", tr.Extract)
 	}
+}
+
+func TestTranslateJSToHook_KeepsUnmappedTestsAsJS(t *testing.T) {
+	js := `var jsonData = pm.response.json();
+pm.test("role", function () {
+    pm.expect(jsonData.role).to.be.oneOf(["admin", "user"]);
+});
+pm.test("id", function () { pm.expect(jsonData.id).to.equal(1); });`
+
+	tr := TranslateJSToHook(js, "postman", PostResponse)
 
-	if !strings.Contains(hooks[1].JS.Code, "setVar") {
-		t.Errorf("expected setVar in second hook, got %q", hooks[1].JS.Code)
+	if tr.Assert.JSONEquals["id"] != 1 {
+		t.Errorf("expected id assertion, got %v", tr.Assert.JSONEquals)
+	}
+	if len(tr.Hooks) != 1 || tr.Hooks[0].JS == nil {
+		t.Fatalf("expected one JS hook, got %+v", tr.Hooks)
+	}
+	code := tr.Hooks[0].JS.Code
+	if !strings.HasPrefix(code, "var jsonData = pm.response.json();") || !strings.Contains(code, "oneOf") {
+		t.Errorf("alias or unmapped test missing from %q", code)
+	}
+	if strings.Contains(code, `pm.test("id"`) {
+		t.Errorf("translated test left in JS: %q", code)
 	}
 }
 
-func TestSplitJSStatements(t *testing.T) {
-	js := `stmt1; stmt2; stmt3;`
+func TestTranslateJSToHook_ReportsUnsupported(t *testing.T) {
+	js := `const id = 1;
+pm.sendRequest("https://example.com", function (err, res) {
+    console.log(res);
+});
+tests["ok"] = responseCode.code === 200;
+console.log(id);`
 
-	stmts := splitJSStatements(js)
+	tr := TranslateJSToHook(js, "postman", PostResponse)
 
-	expected := []string{"stmt1;", "stmt2;", "stmt3;"}
-	if len(stmts) != len(expected) {
-		t.Fatalf("expected %d statements, got %d", len(expected), len(stmts))
+	if len(tr.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %v", tr.Issues)
+	}
+	if tr.Issues[0].Line != 2 || !strings.Contains(tr.Issues[0].Reason, "pm.sendRequest") {
+		t.Errorf("first issue: %+v", tr.Issues[0])
+	}
+	if tr.Issues[1].Line != 5 {
+		t.Errorf("second issue: %+v", tr.Issues[1])
+	}
+	if len(tr.Hooks) != 1 || tr.Hooks[0].JS.Code != "const id = 1;\nconsole.log(id);" {
+		t.Errorf("unexpected hooks %+v", tr.Hooks)
 	}
+}
 
-	for i, stmt := range stmts {
-		if stmt != expected[i] {
-			t.Errorf("statement %d: expected %q, got %q", i, expected[i], stmt)
-		}
+func TestTranslateJSToHook_ParseError(t *testing.T) {
+	tr := TranslateJSToHook("pm.test('x', function () {\n  pm.expect(1).to.equal(1);\n", "postman", PostResponse)
+
+	if len(tr.Hooks) != 0 || len(tr.Issues) != 1 {
+		t.Fatalf("expected one issue and no hooks, got %+v", tr)
+	}
+	if !strings.Contains(tr.Issues[0].Reason, "does not parse") {
+		t.Errorf("unexpected issue %v", tr.Issues[0])
 	}
 }
 
-func TestSplitJSStatements_WithStrings(t *testing.T) {
-	js := `setVar('key', 'value;with;semicolons'); otherStmt;`
+func TestTranslateJSToHook_PreRequestKeepsTests(t *testing.T) {
+	js := `pm.test("s", function () { pm.response.to.have.status(200); });`
 
-	stmts := splitJSStatements(js)
+	tr := TranslateJSToHook(js, "postman", PreRequest)
 
-	if len(stmts) != 2 {
-		t.Fatalf("expected 2 statements, got %d", len(stmts))
+	if tr.Assert.Status != 0 || len(tr.Hooks) != 1 || tr.Hooks[0].JS == nil {
+		t.Errorf("pre-request script should stay JS, got %+v", tr)
 	}
+}
 
-	if stmts[0] != `setVar('key', 'value;with;semicolons');` {
-		t.Errorf("first statement: %q", stmts[0])
-	}
+func TestTranslation_ApplyTo(t *testing.T) {
+	tc := models.TestCase{Assert: models.Assertions{Status: 200}}
+	tr := TranslateJSToHook(`pm.response.to.have.status(204); pm.environment.set("a", "b");`, "postman", PostResponse)
 
-	if stmts[1] != "otherStmt;" {
-		t.Errorf("second statement: %q", stmts[1])
+	tr.ApplyTo(&tc)
+
+	if tc.Assert.Status != 204 {
+		t.Errorf("expected status 204, got %d", tc.Assert.Status)
+	}
+	if len(tc.Post) != 1 || tc.Post[0].Vars["a"] != "b" || len(tc.Pre) != 0 {
+		t.Errorf("unexpected hooks pre=%+v post=%+v", tc.Pre, tc.Post)
 	}
 }
@@ -0,0 +1,223 @@
+// Package secrets keeps track of secret values for the current process and
+// masks them in any text HydReq prints, streams or writes to reports.
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces secret values in redacted output.
+const Mask = "****"
+
+// MinLength is the shortest value that gets registered; shorter values would
+// mask unrelated text (e.g. "1" or "ok").
+const MinLength = 4
+
+var (
+	mu      sync.RWMutex
+	values  = map[string]struct{}{}
+	ordered []string // longest first so overlapping secrets mask fully
+	files   = map[string]string{}
+)
+
+// Register marks values as secret. Empty and very short values are ignored.
+// Base64 and JSON-escaped forms are registered as well since tokens often
+// travel encoded (Basic auth headers, JSON reports and SSE payloads).
+func Register(vals ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	changed := false
+	for _, v := range vals {
+		if len(v) < MinLength {
+			continue
+		}
+		for _, form := range append([]string{v, base64.StdEncoding.EncodeToString([]byte(v))}, jsonForms(v)...) {
+			if _, ok := values[form]; ok {
+				continue
+			}
+			values[form] = struct{}{}
+			changed = true
+		}
+	}
+	if changed {
+		ordered = ordered[:0]
+		for v := range values {
+			ordered = append(ordered, v)
+		}
+		sort.Slice(ordered, func(i, j int) bool { return len(ordered[i]) > len(ordered[j]) })
+	}
+}
+
+// RegisterDSN marks a database DSN as secret, including the password of
+// URL-style, MySQL-style and key=value DSNs so it is masked even when the
+// DSN is printed in pieces.
+func RegisterDSN(dsn string) {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if p, ok := u.User.Password(); ok {
+			Register(p)
+		}
+	}
+	if !strings.Contains(dsn, "://") {
+		// MySQL style: user:pass@tcp(host:3306)/db
+		head := dsn
+		if i := strings.Index(head, "("); i >= 0 {
+			head = head[:i]
+		}
+		if i := strings.LastIndex(head, "@"); i > 0 {
+			if _, p, ok := strings.Cut(head[:i], ":"); ok {
+				Register(p)
+			}
+		}
+	}
+	for _, part := range strings.Split(dsn, ";") {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case "password", "pwd":
+			Register(strings.TrimSpace(v))
+		}
+	}
+	Register(dsn)
+}
+
+// Redact masks every registered secret in s.
+func Redact(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(ordered) == 0 || s == "" {
+		return s
+	}
+	for _, v := range ordered {
+		if strings.Contains(s, v) {
+			s = strings.ReplaceAll(s, v, Mask)
+		}
+	}
+	return s
+}
+
+// RedactAll masks secrets in each element, returning a new slice.
+func RedactAll(in []string) []string {
+	if len(in) == 0 {
+		return in
+	}
+	out := make([]string, len(in))
+	for i, s := range in {
+		out[i] = Redact(s)
+	}
+	return out
+}
+
+// Reset forgets all registered values and loaded files (tests only).
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	values = map[string]struct{}{}
+	ordered = nil
+	files = map[string]string{}
+}
+
+// Lookup resolves ${SECRET:NAME}: the OS environment first, then values loaded
+// with LoadFile. Found values are registered for redaction.
+func Lookup(name string) (string, bool) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		mu.RLock()
+		v, ok = files[name]
+		mu.RUnlock()
+	}
+	if ok {
+		Register(v)
+	}
+	return v, ok
+}
+
+// LoadFile reads a dotenv file (KEY=VALUE lines) into the lookup table used by
+// Lookup. Files ending in .age are decrypted with the `age` CLI using the
+// identity file named by HYDREQ_AGE_IDENTITY.
+func LoadFile(path string) error {
+	var data []byte
+	var err error
+	if strings.HasSuffix(path, ".age") {
+		data, err = decryptAge(path)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	parsed := parseDotenv(data)
+	mu.Lock()
+	for k, v := range parsed {
+		files[k] = v
+	}
+	mu.Unlock()
+	return nil
+}
+
+func decryptAge(path string) ([]byte, error) {
+	identity := os.Getenv("HYDREQ_AGE_IDENTITY")
+	if identity == "" {
+		return nil, fmt.Errorf("%s: set HYDREQ_AGE_IDENTITY to an age identity file to decrypt", path)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("age", "--decrypt", "--identity", identity, path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: age decrypt failed: %v: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func parseDotenv(data []byte) map[string]string {
+	out := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		k = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// jsonForms returns v as it appears inside JSON strings, with and without HTML escaping.
+func jsonForms(v string) []string {
+	var out []string
+	for _, escapeHTML := range []bool{true, false} {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(escapeHTML)
+		if err := enc.Encode(v); err != nil {
+			continue
+		}
+		b := bytes.TrimSpace(buf.Bytes())
+		if esc := string(b[1 : len(b)-1]); esc != v {
+			out = append(out, esc)
+		}
+	}
+	return out
+}
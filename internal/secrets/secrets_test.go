@@ -0,0 +1,87 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	Reset()
+	t.Cleanup(Reset)
+	Register("s3cr3t-token", "ab", "")
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"raw", "Authorization: Bearer s3cr3t-token", "Authorization: Bearer ****"},
+		{"base64", "Basic " + base64.StdEncoding.EncodeToString([]byte("s3cr3t-token")), "Basic ****"},
+		{"short values ignored", "ab cd", "ab cd"},
+		{"no secrets", "plain text", "plain text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Fatalf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact_JSONEscaped(t *testing.T) {
+	Reset()
+	t.Cleanup(Reset)
+	Register(`pa"ss<word>`)
+	if got := Redact(`{"a":"pa\"ss<word>","b":"pa\"ss\u003cword\u003e"}`); got != `{"a":"****","b":"****"}` {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestRegisterDSN(t *testing.T) {
+	Reset()
+	t.Cleanup(Reset)
+	RegisterDSN("postgres://qa:hunter22@db:5432/app")
+	RegisterDSN("sqlserver://db;user id=sa;password=Str0ngPass;database=app")
+	RegisterDSN("app:my$ql@pw@tcp(db:3306)/shop?parseTime=true")
+	if got := Redact("auth failed for qa:hunter22"); got != "auth failed for qa:****" {
+		t.Fatalf("got %q", got)
+	}
+	if got := Redact("password=Str0ngPass"); got != "password=****" {
+		t.Fatalf("got %q", got)
+	}
+	if got := Redact("Access denied (using password: my$ql@pw)"); got != "Access denied (using password: ****)" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestLoadFileAndLookup(t *testing.T) {
+	Reset()
+	t.Cleanup(Reset)
+	p := filepath.Join(t.TempDir(), ".secrets.env")
+	data := "# comment\nAPI_KEY=from-file-key\nexport QUOTED=\"quoted value\"\n"
+	if err := os.WriteFile(p, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFile(p); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HYDREQ_SECRET_TEST_ENV", "from-env-value")
+	if v, ok := Lookup("API_KEY"); !ok || v != "from-file-key" {
+		t.Fatalf("API_KEY = %q, %v", v, ok)
+	}
+	if v, _ := Lookup("QUOTED"); v != "quoted value" {
+		t.Fatalf("QUOTED = %q", v)
+	}
+	if v, _ := Lookup("HYDREQ_SECRET_TEST_ENV"); v != "from-env-value" {
+		t.Fatalf("env lookup = %q", v)
+	}
+	if _, ok := Lookup("MISSING_SECRET_X"); ok {
+		t.Fatal("expected missing")
+	}
+	// looked-up values are registered for redaction
+	if got := Redact("key=from-file-key"); got != "key=****" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"time"
+
+	"github.com/DrWeltschmerz/HydReq/internal/secrets"
 )
 
 const (
@@ -17,17 +19,19 @@ const (
 	Gray   = "\033[90m"
 )
 
+// The printers below pass their text through secrets.Redact so registered
+// secret values never reach the terminal.
 func Successf(format string, a ...any) {
 	if !Enabled {
 		return
 	}
-	fmt.Printf(Green+"✓ "+Reset+format+"\n", a...)
+	fmt.Println(Green + "✓ " + Reset + secrets.Redact(fmt.Sprintf(format, a...)))
 }
 func Failf(format string, a ...any) {
 	if !Enabled {
 		return
 	}
-	fmt.Printf(Red+"✗ "+Reset+format+"\n", a...)
+	fmt.Println(Red + "✗ " + Reset + secrets.Redact(fmt.Sprintf(format, a...)))
 }
 
 // FailWithBoldPrefix prints an error with a bold, red prefix and a normal-colored message.
@@ -37,32 +41,32 @@ func FailWithBoldPrefix(prefix string, format string, a ...any) {
 		return
 	}
 	// Red exclamation, bold red prefix, reset, then message
-	fmt.Print(Red + "✗ " + Bold + prefix + ":" + Reset + " ")
-	fmt.Printf(format+"\n", a...)
+	fmt.Print(Red + "✗ " + Bold + secrets.Redact(prefix) + ":" + Reset + " ")
+	fmt.Println(secrets.Redact(fmt.Sprintf(format, a...)))
 }
 func Skipf(format string, a ...any) {
 	if !Enabled {
 		return
 	}
-	fmt.Printf(Gray+"- "+Reset+format+"\n", a...)
+	fmt.Println(Gray + "- " + Reset + secrets.Redact(fmt.Sprintf(format, a...)))
 }
 func Infof(format string, a ...any) {
 	if !Enabled {
 		return
 	}
-	fmt.Printf(Blue+format+Reset+"\n", a...)
+	fmt.Println(Blue + secrets.Redact(fmt.Sprintf(format, a...)) + Reset)
 }
 func Detail(msg string) {
 	if !Enabled {
 		return
 	}
-	fmt.Println(Gray + "  - " + msg + Reset)
+	fmt.Println(Gray + "  - " + secrets.Redact(msg) + Reset)
 }
 func CodeBlock(s string) {
 	if !Enabled {
 		return
 	}
-	fmt.Println(Gray + indent(s, "  ") + Reset)
+	fmt.Println(Gray + indent(secrets.Redact(s), "  ") + Reset)
 }
 
 func Summary(total, passed, failed, skipped int, d time.Duration) {
@@ -91,7 +95,7 @@ func SuiteHeader(name string) {
 		return
 	}
 	// One blank line before header is handled by caller when needed
-	fmt.Printf(Bold+"%s"+Reset+"\n", name)
+	fmt.Printf(Bold+"%s"+Reset+"\n", secrets.Redact(name))
 }
 
 // SuiteSeparator prints a single blank line between suites
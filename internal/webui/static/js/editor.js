@@ -489,6 +489,7 @@ function openEditor(path, data){
     const btnRunTest = modal.querySelector('#ed_run_test');
     const btnValidate = modal.querySelector('#ed_validate');
     const btnRunSuite = modal.querySelector('#ed_run_suite');
+    const btnCopyCurl = modal.querySelector('#ed_copy_curl');
     const btnSave = modal.querySelector('#ed_save');
     const btnSaveClose = modal.querySelector('#ed_save_close');
     // Prefer controls module
@@ -504,6 +505,9 @@ function openEditor(path, data){
           }catch{}
         }; }
     }
+    if (btnCopyCurl && window.hydreqEditorControls?.copyCurl){
+      btnCopyCurl.onclick = ()=> window.hydreqEditorControls.copyCurl(modal, ctx);
+    }
     if (btnValidate){
       if (window.hydreqEditorControls?.validate){ btnValidate.onclick = ()=> window.hydreqEditorControls.validate(modal, ctx); }
       else { btnValidate.onclick = async ()=>{
@@ -22,7 +22,9 @@
         const file = oapiFileEl ? (oapiFileEl.value||'').trim() : '';
         const enabled = oapiEnabledEl ? !!oapiEnabledEl.checked : false;
         if (file) {
+          const request = out.openApi && out.openApi.request;
           out.openApi = { file: file };
+          if (request) out.openApi.request = request;
           if (enabled) out.openApi.enabled = true; else delete out.openApi.enabled;
         } else {
           // If no file, remove openApi entirely regardless of enabled toggle.
@@ -49,6 +51,7 @@
     const stageEl = modal.querySelector('#ed_stage');
     const statusEl = modal.querySelector('#ed_assert_status');
     const maxDurationEl = modal.querySelector('#ed_assert_maxDuration');
+    const assertJsEl = modal.querySelector('#ed_assert_js');
     const dependsEl = modal.querySelector('#ed_test_depends');
     const tagsEl = modal.querySelector('#ed_tags');
     const oapiEl = modal.querySelector('#ed_oapi_enabled');
@@ -65,6 +68,7 @@
     if (statusEl || maxDurationEl){ test.assert = test.assert || {}; }
     if (statusEl){ const st = statusEl.value ? parseInt(statusEl.value,10) : NaN; if (!isNaN(st)) test.assert.status=st; else if (test.assert) delete test.assert.status; }
     if (maxDurationEl){ const md = maxDurationEl.value ? parseInt(maxDurationEl.value,10) : NaN; if (!isNaN(md)) test.assert.maxDurationMs = md; else if (test.assert) delete test.assert.maxDurationMs; }
+    if (assertJsEl){ test.assert = test.assert || {}; const code = assertJsEl.value; if (code.trim()) test.assert.js = code; else delete test.assert.js; }
     function tryParse(s){ try{ return JSON.parse(s); }catch{ return s; } }
     try{ if (!test.assert) test.assert = {}; if (typeof getters.assertHeaderGet==='function'){ const hv = getters.assertHeaderGet(); if (hv && Object.keys(hv).length) test.assert.headerEquals = hv; else delete test.assert.headerEquals; } if (typeof getters.assertJsonEqGet==='function'){ const jv = getters.assertJsonEqGet(); const outMap={}; Object.keys(jv||{}).forEach(k=>{ const v=jv[k]; if (v!=='' && v!=null) outMap[k]=tryParse(v); }); if (Object.keys(outMap).length) test.assert.jsonEquals=outMap; else delete test.assert.jsonEquals; } if (typeof getters.assertJsonContainsGet==='function'){ const jc=getters.assertJsonContainsGet(); const outMap={}; Object.keys(jc||{}).forEach(k=>{ const v=jc[k]; if (v!=='' && v!=null) outMap[k]=tryParse(v); }); if (Object.keys(outMap).length) test.assert.jsonContains=outMap; else delete test.assert.jsonContains; } if (typeof getters.assertBodyContainsGet==='function'){ const bc=getters.assertBodyContainsGet(); if (Array.isArray(bc) && bc.length) test.assert.bodyContains=bc; else delete test.assert.bodyContains; } }catch{}
     if (test.assert && Object.keys(test.assert).length===0) delete test.assert;
@@ -75,7 +79,7 @@
     if (tagsEl){ const tg=(tagsEl.value||'').split(',').map(s=>s.trim()).filter(Boolean); if (tg.length) test.tags=tg; else delete test.tags; }
     try{ if (typeof getters.extractGet==='function'){ const ex=getters.extractGet(); if (ex && Object.keys(ex).length) test.extract=ex; else delete test.extract; } }catch{}
     try{ if (typeof getters.matrixGet==='function'){ const mx=getters.matrixGet(); if (mx && Object.keys(mx).length) test.matrix=mx; else delete test.matrix; } }catch{}
-    if (oapiEl){ const v=(oapiEl.value||'inherit'); if (v==='inherit'){ delete test.openApi; } else { test.openApi = { enabled: (v==='true') }; } }
+    if (oapiEl){ const v=(oapiEl.value||'inherit'); const request=test.openApi && test.openApi.request; if (v==='inherit'){ if (request) test.openApi={ request }; else delete test.openApi; } else { test.openApi = { enabled: (v==='true') }; if (request) test.openApi.request=request; } }
     try{ if (typeof getters.testPreGet==='function'){ const arr=getters.testPreGet(); if (Array.isArray(arr)&&arr.length) test.pre=arr; else delete test.pre; } if (typeof getters.testPostGet==='function'){ const arr=getters.testPostGet(); if (Array.isArray(arr)&&arr.length) test.post=arr; else delete test.post; } }catch{}
     try{ const en=modal.querySelector('#ed_retry_enable'); const mx=modal.querySelector('#ed_retry_max'); const bo=modal.querySelector('#ed_retry_backoff'); const ji=modal.querySelector('#ed_retry_jitter'); const enabled=!!(en && en.checked); const r={}; if (mx && mx.value){ const n=parseInt(mx.value,10); if (!isNaN(n)) r.max=n; } if (bo && bo.value){ const n=parseInt(bo.value,10); if (!isNaN(n)) r.backoffMs=n; } if (ji && ji.value){ const n=parseInt(ji.value,10); if (!isNaN(n)) r.jitterPct=n; } if (enabled || Object.keys(r).length){ test.retry=r; } else { delete test.retry; } }catch{}
 
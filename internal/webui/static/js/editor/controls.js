@@ -66,6 +66,29 @@
     });
   }
 
+  async function copyCurl(modal, ctx){
+    const btn = modal.querySelector('#ed_copy_curl');
+    try{
+      const w = ctx.getWorking();
+      const sel = ctx.getSelIndex();
+      ctx.collectFormData();
+      if (!w.tests || !w.tests[sel]){
+        ctx.appendQuickRunLine('No test selected', 'text-warning');
+        return;
+      }
+      const payload = { parsed: w, testIndex: sel, env: ctx.parseEnvFromPage() };
+      const res = await fetch('/api/editor/curl', { method:'POST', headers:{'Content-Type':'application/json'}, body: JSON.stringify(payload) });
+      if (!res.ok){ let txt=''; try{ txt=await res.text(); }catch{} throw new Error('HTTP '+res.status+(txt?(': '+txt):'')); }
+      const data = await res.json();
+      await navigator.clipboard.writeText(data.curl || '');
+      (data.warnings || []).forEach(msg => { try{ ctx.appendQuickRunLine('Copy as curl: '+msg, 'text-warning'); }catch{} });
+      if (btn){ btn.textContent = 'Copied'; setTimeout(()=> btn.textContent = 'Copy as curl', 1200); }
+    }catch(e){
+      console.error(e);
+      try{ ctx.appendQuickRunLine('Copy as curl failed: '+e.message, 'text-error'); }catch{}
+    }
+  }
+
   async function validate(modal, ctx){
     try{
       ctx.collectFormData();
@@ -152,6 +175,7 @@
   window.hydreqEditorControls = window.hydreqEditorControls || {
     runTest,
     runSuite,
+    copyCurl,
     validate,
     save,
     saveClose
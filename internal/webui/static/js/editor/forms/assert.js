@@ -5,6 +5,8 @@
     const maxDurationEl = modal.querySelector('#ed_assert_maxDuration');
     if (statusEl) statusEl.value = (test.assert && (test.assert.status||'')) || '';
     if (maxDurationEl) maxDurationEl.value = (test.assert && (test.assert.maxDurationMs || test.assert.maxDuration)) || '';
+    const jsEl = modal.querySelector('#ed_assert_js');
+    if (jsEl) jsEl.value = (test.assert && test.assert.js) || '';
 
     let assertHeaderGet = null, assertJsonEqGet = null, assertJsonContainsGet = null, assertBodyContainsGet = null;
     try {
@@ -9,7 +9,7 @@
     const queryEl = modal.querySelector('#ed_query');
 
     // Populate primitives
-    if (methodEl && test.request) methodEl.value = test.request.method || 'GET';
+    if (methodEl && test.request) methodEl.value = test.request.method || (test.request.graphql ? 'POST' : 'GET');
     if (urlEl && test.request) urlEl.value = test.request.url || '';
     if (timeoutEl && test.request) timeoutEl.value = test.request.timeout || '';
     if (bodyEl && test.request) {
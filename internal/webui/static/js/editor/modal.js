@@ -137,6 +137,7 @@
         return label;
       })(),
       el('button', { id: 'ed_run_test', class: 'btn btn-sm', title: 'Validate and run the selected test without saving', text: 'Run test' }),
+      el('button', { id: 'ed_copy_curl', class: 'btn btn-sm', title: 'Copy the selected test as a curl command', text: 'Copy as curl' }),
       el('button', { id: 'ed_run_suite', class: 'btn btn-sm', title: 'Validate and run the whole suite without saving', text: 'Run suite' }),
       el('button', { id: 'ed_validate', class: 'btn btn-sm', text: 'Validate' }),
       el('button', { id: 'ed_save', class: 'btn btn-sm', text: 'Save' }),
@@ -284,6 +285,8 @@
     form.appendChild(el('div', { id: 'ed_assert_bodyContains', class: 'ed-grid-col-2' }));
     form.appendChild(el('label', { text: 'Max duration (ms)' }));
     form.appendChild(el('input', { id: 'ed_assert_maxDuration', type: 'number', min: '0' }));
+    form.appendChild(el('label', { text: 'Script (assert.js)' }));
+    form.appendChild(el('textarea', { id: 'ed_assert_js', class: 'ed-textarea-md', placeholder: 'check("sorted", response.json().items.length > 0, "no items")' }));
     const d = el('details', { class: 'ed-panel', open: true });
     d.appendChild(el('summary', { class: 'ed-summary', text: '✅ Response Assertions' }));
     d.appendChild(el('div', { class: 'ed-body', id: 'ed_assert' }, [ form ]));
@@ -15,21 +15,54 @@
       if (oa && (oa.File || oa.file)){
         out.openApi = { file: (oa.File || oa.file) };
         if (oa.Enabled !== undefined || oa.enabled !== undefined) out.openApi.enabled = (oa.Enabled ?? oa.enabled);
+        const req = oa.Request || oa.request || '';
+        if (req) out.openApi.request = req;
       }
     })();
+    // Keys without form fields are carried through so saving does not drop them
+    const envs = inObj.Environments || inObj.environments || null;
+    if (envs && Object.keys(envs).length) out.environments = envs;
+    const sec = inObj.Secrets || inObj.secrets || null;
+    if (Array.isArray(sec) && sec.length) out.secrets = sec;
+    const secFile = inObj.SecretsFile || inObj.secretsFile || '';
+    if (secFile) out.secretsFile = secFile;
+    const dbs = inObj.Databases || inObj.databases || null;
+    if (dbs && Object.keys(dbs).length) out.databases = dbs;
+    const scripts = inObj.Scripts || inObj.scripts || null;
+    if (Array.isArray(scripts) && scripts.length) out.scripts = scripts;
+    const scriptTimeout = inObj.ScriptTimeoutMs ?? inObj.scriptTimeoutMs;
+    if (scriptTimeout) out.scriptTimeoutMs = scriptTimeout;
+    const scriptMemory = inObj.ScriptMemoryMb ?? inObj.scriptMemoryMb;
+    if (scriptMemory) out.scriptMemoryMb = scriptMemory;
+    const inc = inObj.Include || inObj.include || null;
+    if (Array.isArray(inc) && inc.length) out.include = inc;
+    // Templates are not edited in the form; keep them so saving does not drop them
+    const tpl = inObj.Templates || inObj.templates || null;
+    if (tpl && Object.keys(tpl).length) out.templates = tpl;
     const testsArr = Array.isArray(inObj.Tests) ? inObj.Tests : (Array.isArray(inObj.tests) ? inObj.tests : []);
     if (Array.isArray(testsArr)){
       out.tests = testsArr.map(function(tc){
         const t = {};
         t.name = tc.Name || tc.name || '';
+        const ext = tc.Extends || tc.extends || '';
+        if (ext) t.extends = ext;
+        const sq = tc.SQL || tc.sql || null;
+        if (sq) t.sql = sq;
+        const txn = tc.Transaction || tc.transaction || '';
+        if (txn) t.transaction = txn;
         const rq = tc.Request || tc.request || {};
+        const gql = rq.GraphQL || rq.graphql || null;
         t.request = {
-          method: rq.Method || rq.method || 'GET',
+          // tests extending a template inherit its method unless set explicitly; SQL steps have none
+          // and GraphQL requests default to POST in the runner
+          method: rq.Method || rq.method || ((ext || sq || gql) ? '' : 'GET'),
           url: rq.URL || rq.url || '',
           headers: rq.Headers || rq.headers || {},
           query: rq.Query || rq.query || {},
           body: (rq.Body !== undefined ? rq.Body : rq.body)
         };
+        // GraphQL operations have no form fields yet; keep them so saving does not drop them
+        if (gql) t.request.graphql = gql;
         const as = tc.Assert || tc.assert || {};
         const aOut = {};
         if (as.Status !== undefined || as.status !== undefined) aOut.status = (as.Status !== undefined ? as.Status : as.status);
@@ -38,6 +71,8 @@
         if (as.JSONContains || as.jsonContains) aOut.jsonContains = as.JSONContains || as.jsonContains;
         if (as.BodyContains || as.bodyContains) aOut.bodyContains = as.BodyContains || as.bodyContains;
         if (as.MaxDurationMs !== undefined || as.maxDurationMs !== undefined) aOut.maxDurationMs = (as.MaxDurationMs !== undefined ? as.MaxDurationMs : as.maxDurationMs);
+        if (as.JS || as.js) aOut.js = as.JS || as.js;
+        if (as.GraphQLErrors || as.graphqlErrors) aOut.graphqlErrors = as.GraphQLErrors || as.graphqlErrors;
         if (Object.keys(aOut).length) t.assert = aOut;
         const ex = tc.Extract || tc.extract || {};
         const exOut = {};
@@ -66,7 +101,13 @@
         }
         t.matrix = tc.Matrix || tc.matrix || {};
         const oa = tc.OpenAPI || tc.openApi || null;
-        if (oa && (oa.Enabled !== undefined || oa.enabled !== undefined)) t.openApi = { enabled: (oa.Enabled ?? oa.enabled) };
+        if (oa){
+          const oOut = {};
+          if (oa.Enabled !== undefined || oa.enabled !== undefined) oOut.enabled = (oa.Enabled ?? oa.enabled);
+          const req = oa.Request || oa.request || '';
+          if (req) oOut.request = req;
+          if (Object.keys(oOut).length) t.openApi = oOut;
+        }
         return t;
       });
     } else {
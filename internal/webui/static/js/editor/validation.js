@@ -53,7 +53,7 @@
     const fields = [
       '#ed_suite_name','#ed_suite_baseurl','#ed_auth_bearer','#ed_auth_basic',
       '#ed_test_name','#ed_url','#ed_method','#ed_timeout','#ed_body',
-      '#ed_assert_status','#ed_assert_maxDuration','#ed_stage'
+      '#ed_assert_status','#ed_assert_maxDuration','#ed_assert_js','#ed_stage'
     ];
     fields.forEach(sel=>{
       const el = modal.querySelector(sel);
@@ -75,10 +75,26 @@
   }
 
   // Import collection from external format
+  // Render the importer's warnings (dropped or changed features) under the status line
+  function renderImportWarnings(listEl, warnings){
+    if (!listEl) return;
+    listEl.innerHTML = '';
+    (warnings||[]).forEach(w => {
+      const li = document.createElement('li');
+      let text = (w.item ? w.item + ': ' : '') + w.feature + ': ' + w.action;
+      if (w.detail) text += ' (' + w.detail + ')';
+      li.textContent = text;
+      listEl.appendChild(li);
+    });
+    listEl.style.display = listEl.children.length ? '' : 'none';
+  }
+
   async function importCollection(){
     const format = (document.getElementById('importFormat')||{}).value;
     const fileInput = document.getElementById('importFile');
     const statusEl = document.getElementById('importStatus');
+    const warningsEl = document.getElementById('importWarnings');
+    renderImportWarnings(warningsEl, []);
 
     if (!fileInput || !fileInput.files || !fileInput.files.length) {
       if (statusEl) statusEl.textContent = 'Please select a file';
@@ -91,6 +107,17 @@
     const formData = new FormData();
     formData.append('file', file);
     formData.append('format', format||'');
+    formData.append('report', '1');
+    const val = (id) => (document.getElementById(id)||{}).value || '';
+    const checked = (id) => !!(document.getElementById(id)||{}).checked;
+    formData.append('folders', val('importFolders'));
+    formData.append('baseUrl', val('importBaseUrl'));
+    if (checked('importNoScripts')) formData.append('noScripts', '1');
+    if (checked('importSkipAuth')) formData.append('skipAuth', '1');
+    formData.append('includeHost', val('importHosts'));
+    if (checked('importOnlyXhr')) formData.append('onlyXhr', '1');
+    const envInput = document.getElementById('importEnv');
+    if (envInput && envInput.files && envInput.files.length) formData.append('env', envInput.files[0]);
 
     try {
       const res = await fetch('/api/import', { method: 'POST', body: formData });
@@ -100,12 +127,16 @@
         return;
       }
 
-      const yaml = await res.text();
-      const blob = new Blob([yaml], { type: 'application/x-yaml' });
+      const data = await res.json();
+      const warnings = (data.report && data.report.warnings) || [];
+      const blob = new Blob([data.yaml||''], { type: 'application/x-yaml' });
       const url = URL.createObjectURL(blob);
       const a = document.createElement('a');
       a.href = url; a.download = 'imported-suite.hrq.yaml'; document.body.appendChild(a); a.click(); a.remove(); URL.revokeObjectURL(url);
-      if (statusEl) statusEl.textContent = 'Import successful! Downloaded as imported-suite.hrq.yaml';
+      let msg = 'Import successful! Downloaded as imported-suite.hrq.yaml';
+      if (warnings.length) msg += ' — ' + warnings.length + ' warning(s):';
+      if (statusEl) statusEl.textContent = msg;
+      renderImportWarnings(warningsEl, warnings);
       fileInput.value = '';
     } catch (e) {
       if (statusEl) statusEl.textContent = 'Import failed: ' + (e && e.message ? e.message : String(e));
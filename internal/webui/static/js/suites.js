@@ -416,6 +416,44 @@ function listen(id){
     scrollBottom();
   }
 
+  // Hook lines are nested under their test row (pre/post) or shown inline for suite hooks
+  const hookRows = new Map(); // key: path::Test::Scope::Index::Name
+  function hookLineText(p){
+    const scope = p.Scope || 'hook';
+    if (p.Status === 'running') return '… [' + scope + '] ' + (p.Name||'');
+    let txt = (p.Status==='passed'?'✓':'✗') + ' [' + scope + '] ' + (p.Name||'') + ' (' + (p.DurationMs||0) + ' ms)';
+    if (p.HTTPStatus) txt += ' http=' + p.HTTPStatus;
+    if (p.RowsAffected) txt += ' rows=' + p.RowsAffected;
+    return txt;
+  }
+  function handleHookStart(payload){ handleHook(payload); }
+  function handleHook(payload){
+    const evPath = payload.path;
+    if (evPath && currentSuitePath && evPath !== currentSuitePath) return;
+    const key = (currentSuitePath||'') + '::' + (payload.Test||'') + '::' + (payload.Scope||'') + '::' + (payload.Index||0) + '::' + (payload.Name||'');
+    let rec = hookRows.get(key);
+    if (!rec){
+      const wrap = document.createElement('div'); wrap.className = 'hook-step';
+      const line = document.createElement('div');
+      wrap.appendChild(line);
+      const row = payload.Test ? testRows.get((currentSuitePath||'') + '::' + payload.Test) : null;
+      if (row && row.container){ wrap.style.marginLeft = '1.5em'; row.container.appendChild(wrap); }
+      else if (results) results.appendChild(wrap);
+      rec = { container: wrap, line }; hookRows.set(key, rec);
+    }
+    rec.line.className = payload.Status==='running' ? 'dim' : (payload.Status==='passed' ? 'ok' : 'fail');
+    rec.line.textContent = hookLineText(payload);
+    const extra = [].concat(Array.isArray(payload.Messages)? payload.Messages: [], (Array.isArray(payload.Console)? payload.Console: []).map(c=> 'console: ' + c));
+    if (payload.Status !== 'running' && extra.length){
+      let det = rec.container.querySelector('details.suite-test-details');
+      if (!det){ det = document.createElement('details'); det.className='suite-test-details'; const sum=document.createElement('summary'); sum.textContent='details'; det.appendChild(sum); rec.container.appendChild(det); }
+      let pre = det.querySelector('pre'); if (!pre){ pre = document.createElement('pre'); det.appendChild(pre); }
+      pre.className = 'message-block ' + (payload.Status==='failed' ? 'fail' : 'ok');
+      pre.textContent = extra.join('\n');
+    }
+    scrollBottom();
+  }
+
   function handleBatchStart(payload){ batch.total = payload.total; batch.done = 0; if (batchBar) setBar(batchBar,0,batch.total); if (batchText) batchText.textContent = '0/' + batch.total; }
 
   async function handleSuiteStart(payload){
@@ -454,7 +492,7 @@ function listen(id){
   }
 
   function handleTest(payload){
-    const {Name, Status, DurationMs, Stage, Messages, Tags, path: evPath} = payload;
+    const {Name, Status, DurationMs, Stage, Messages, Tags, Source, path: evPath} = payload;
     if (evPath && currentSuitePath && evPath !== currentSuitePath) return;
     try{
       // Ensure stage header appears even if we missed testStart or dynamic stages
@@ -529,7 +567,8 @@ function listen(id){
         let pre = det.querySelector('pre'); if (!pre){ pre = document.createElement('pre'); pre.className='message-block skip'; det.appendChild(pre); }
         pre.textContent = msgArr.length ? msgArr.join('\n') : 'skipped';
     } else {
-  row.line.textContent = (Status==='passed'?'✓':(Status==='failed'?'✗':'○')) + ' ' + Name + ' (' + DurationMs + ' ms)';
+  row.line.textContent = (Status==='passed'?'✓':(Status==='failed'?'✗':'○')) + ' ' + Name + ' (' + DurationMs + ' ms)' + (Source ? ' [' + Source + ']' : '');
+      if (Source) row.line.title = 'from ' + Source;
       if (Status==='failed'){
         let det = row.container.querySelector('details.suite-test-details');
         if (!det){ det = document.createElement('details'); det.className='suite-test-details'; const sum=document.createElement('summary'); sum.textContent='details'; det.appendChild(sum); row.container.appendChild(det); }
@@ -685,6 +724,8 @@ function listen(id){
       batchStart: handleBatchStart,
       suiteStart: handleSuiteStart,
       test: handleTest,
+      hookStart: handleHookStart,
+      hook: handleHook,
       suiteEnd: handleSuiteEnd,
       batchEnd: handleBatchEnd,
       error: handleError,
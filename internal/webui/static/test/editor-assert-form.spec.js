@@ -12,6 +12,7 @@ describe('editor assert form wiring', function(){
         <div id="ed_assert_bodyContains"></div>
         <input id="ed_assert_status" />
         <input id="ed_assert_maxDuration" />
+        <textarea id="ed_assert_js"></textarea>
       </div>
     </body></html>`, { runScripts:'outside-only' });
     const { window } = dom; global.window = window; global.document = window.document;
@@ -37,7 +38,8 @@ describe('editor assert form wiring', function(){
         jsonContains: { x: 'y' },
         bodyContains: ['foo','bar'],
         status: 200,
-        maxDurationMs: 50
+        maxDurationMs: 50,
+        js: 'check("ok", true)'
       }
     };
 
@@ -52,5 +54,6 @@ describe('editor assert form wiring', function(){
     assert.strictEqual(ct.x, 'y');
     const list = assertBodyContainsGet();
     assert.ok(Array.isArray(list));
+    assert.strictEqual(document.getElementById('ed_assert_js').value, 'check("ok", true)');
   });
 });
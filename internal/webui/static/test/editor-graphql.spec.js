@@ -0,0 +1,38 @@
+const { JSDOM } = require('jsdom');
+const fs = require('fs');
+const assert = require('assert');
+
+describe('editor GraphQL round-trip', function(){
+  it('keeps request.graphql and assert.graphqlErrors and shows POST for an unset method', function(){
+    const dom = new JSDOM(`<!doctype html><html><body></body></html>`, { runScripts:'outside-only' });
+    const window = dom.window; global.window = window; global.document = window.document;
+    window.kvTable = (el, init) => (() => init || {});
+    ['modal.js','forms/request.js','normalize.js','collect.js'].forEach(function(f){
+      window.eval(fs.readFileSync('internal/webui/static/js/editor/' + f,'utf8'));
+    });
+
+    const graphql = { query: 'query($id: ID!) { user(id: $id) { name } }', variables: { id: '7' } };
+    const graphqlErrors = { count: 1, codes: ['NOT_FOUND'] };
+    const parsed = { name:'n', tests: [
+      { name:'user', request:{ url:'/graphql', graphql }, assert:{ status: 200, graphqlErrors } },
+      { name:'plain', request:{ url:'/health' } }
+    ] };
+    const working = window.hydreqEditorNormalize.normalize(parsed);
+    assert.strictEqual(working.tests[0].request.method, '');
+    assert.deepStrictEqual(JSON.parse(JSON.stringify(working.tests[0].request.graphql)), graphql);
+    assert.deepStrictEqual(JSON.parse(JSON.stringify(working.tests[0].assert.graphqlErrors)), graphqlErrors);
+    assert.strictEqual(working.tests[1].request.method, 'GET');
+    assert.strictEqual(working.tests[1].request.graphql, undefined);
+
+    const modal = window.hydreqEditorModal.open({ title:'Suite', path:'x.yaml' });
+    const fns = window.hydreqEditorForms.request.wire(modal, working.tests[0], ()=>{});
+    assert.strictEqual(modal.querySelector('#ed_method').value, 'POST');
+
+    const out = window.hydreqEditorCollect.collect(modal, working, 0, fns);
+    const t = out.tests[0];
+    assert.strictEqual(t.request.url, '/graphql');
+    assert.deepStrictEqual(JSON.parse(JSON.stringify(t.request.graphql)), graphql);
+    assert.deepStrictEqual(JSON.parse(JSON.stringify(t.assert.graphqlErrors)), graphqlErrors);
+    assert.strictEqual(t.request.body, undefined);
+  });
+});
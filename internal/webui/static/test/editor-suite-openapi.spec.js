@@ -37,4 +37,28 @@ describe('suite-level OpenAPI UI', function(){
     assert.strictEqual(out.openApi.file, 'specs/openapi.yaml');
     assert.strictEqual(out.openApi.enabled, true);
   });
+
+  it('keeps openApi.request at suite and test level', function(){
+    const dom = new JSDOM(`<!doctype html><html><body></body></html>`, { runScripts:'outside-only' });
+    const window = dom.window; global.window = window; global.document = window.document;
+    window.hydreqEditorTables = { kvTable: () => (()=>({})) };
+    ['modal.js','forms/suite.js','normalize.js','collect.js'].forEach(function(f){
+      window.eval(fs.readFileSync('internal/webui/static/js/editor/' + f,'utf8'));
+    });
+
+    const parsed = { name:'n', openApi: { file: 'specs/openapi.yaml', request: 'fail' }, tests: [
+      { name:'a', request:{ method:'GET', url:'/a' }, openApi: { request: 'off' } },
+      { name:'b', request:{ method:'GET', url:'/b' }, openApi: { enabled: false, request: 'warn' } }
+    ] };
+    const working = window.hydreqEditorNormalize.normalize(parsed);
+    assert.strictEqual(working.openApi.request, 'fail');
+    assert.deepStrictEqual(JSON.parse(JSON.stringify(working.tests[0].openApi)), { request: 'off' });
+    assert.deepStrictEqual(JSON.parse(JSON.stringify(working.tests[1].openApi)), { enabled: false, request: 'warn' });
+
+    const modal = window.hydreqEditorModal.open({ title:'Suite', path:'x.yaml' });
+    const fns = window.hydreqEditorForms.suite.wire(modal, working, null);
+    const out = window.hydreqEditorCollect.collect(modal, working, -1, fns);
+    assert.strictEqual(out.openApi.file, 'specs/openapi.yaml');
+    assert.strictEqual(out.openApi.request, 'fail');
+  });
 });
@@ -2,11 +2,11 @@ package webui
 
 import (
 	"context"
-	"database/sql"
 	"embed"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"net/http"
@@ -21,15 +21,19 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 	"github.com/DrWeltschmerz/HydReq/internal/adapters/bruno"
+	"github.com/DrWeltschmerz/HydReq/internal/adapters/curl"
 	"github.com/DrWeltschmerz/HydReq/internal/adapters/har"
 	"github.com/DrWeltschmerz/HydReq/internal/adapters/insomnia"
 	"github.com/DrWeltschmerz/HydReq/internal/adapters/newman"
 	"github.com/DrWeltschmerz/HydReq/internal/adapters/oapi"
+	"github.com/DrWeltschmerz/HydReq/internal/adapters/pact"
 	"github.com/DrWeltschmerz/HydReq/internal/adapters/postman"
 	"github.com/DrWeltschmerz/HydReq/internal/adapters/restclient"
 	"github.com/DrWeltschmerz/HydReq/internal/report"
 	"github.com/DrWeltschmerz/HydReq/internal/runner"
+	"github.com/DrWeltschmerz/HydReq/internal/secrets"
 	"github.com/DrWeltschmerz/HydReq/internal/ui"
 	valfmt "github.com/DrWeltschmerz/HydReq/internal/validate"
 	"github.com/DrWeltschmerz/HydReq/pkg/models"
@@ -98,6 +102,10 @@ func (s *server) routes() {
 	s.mux.HandleFunc("/api/editor/envcheck", s.handleEditorEnvCheck)
 	// Run a single hook (suite/test; pre/post)
 	s.mux.HandleFunc("/api/editor/hookrun", s.handleEditorHookRun)
+	// SQL drivers and DSN templates for the hook editor
+	s.mux.HandleFunc("/api/editor/sqldrivers", s.handleEditorSQLDrivers)
+	// Selected test as a curl command ("Copy as curl")
+	s.mux.HandleFunc("/api/editor/curl", s.handleEditorCurl)
 	// Import collection endpoint
 	s.mux.HandleFunc("/api/import", s.handleImport)
 	// Optional: serve .env for UI preload (dev only; guard with HYDREQ_ENV_UI=1)
@@ -521,6 +529,22 @@ func (s *server) handleEditorValidate(w http.ResponseWriter, r *http.Request) {
 	} else {
 		issues = append(issues, map[string]any{"path": "root", "message": "no content to validate", "severity": "error"})
 	}
+	// resolve templates so later per-test checks see the merged request/assertions;
+	// the editor keeps the unresolved suite (with extends) in its response
+	unresolved := parsed
+	if parsedValid && len(parsed.Include) > 0 {
+		// included files are not available here; templates they define resolve at run time
+		issues = append(issues, map[string]any{"path": "include", "message": "tests and templates from included files are merged at run time", "severity": "info"})
+	} else if parsedValid {
+		unresolved.Tests = append([]models.TestCase(nil), parsed.Tests...)
+		if err := runner.ResolveTemplates(&parsed); err != nil {
+			issues = append(issues, map[string]any{"path": "tests[].extends", "message": err.Error(), "severity": "error"})
+		} else if err := runner.CheckDatabases(&parsed); err != nil {
+			issues = append(issues, map[string]any{"path": "databases", "message": err.Error(), "severity": "error"})
+		} else if err := runner.CheckScripts(&models.Suite{Scripts: inlineScripts(parsed.Scripts)}); err != nil {
+			issues = append(issues, map[string]any{"path": "scripts", "message": err.Error(), "severity": "error"})
+		}
+	}
 	// minimal engine validations (examples)
 	// duplicate test names when using dependsOn
 	names := map[string]struct{}{}
@@ -575,6 +599,17 @@ func (s *server) handleEditorValidate(w http.ResponseWriter, r *http.Request) {
 		// Per-test checks
 		for i, t := range parsed.Tests {
 			pathBase := fmt.Sprintf("tests[%d]", i)
+			if t.Extends != "" {
+				// still extends an included template; its request is only known after LoadSuite
+				continue
+			}
+			if t.SQL != nil {
+				// SQL step: no HTTP request to check
+				if strings.TrimSpace(t.SQL.Query) == "" {
+					issues = append(issues, map[string]any{"path": pathBase + ".sql.query", "message": "query is empty", "severity": "error"})
+				}
+				continue
+			}
 			// request method/url
 			m := strings.ToUpper(strings.TrimSpace(t.Request.Method))
 			if m == "" {
@@ -680,8 +715,7 @@ func (s *server) handleEditorValidate(w http.ResponseWriter, r *http.Request) {
 	var parsedPtr *models.Suite
 	var outYAML string
 	if parsedValid {
-		parsedCopy := parsed
-		parsedPtr = &parsedCopy
+		parsedPtr = &unresolved
 	}
 	// YAML preview/content: when validating raw, echo the raw text; when validating parsed, render canonical YAML
 	if strings.TrimSpace(vr.Raw) != "" {
@@ -690,7 +724,7 @@ func (s *server) handleEditorValidate(w http.ResponseWriter, r *http.Request) {
 		var buf strings.Builder
 		enc := yaml.NewEncoder(&buf)
 		enc.SetIndent(2)
-		_ = enc.Encode(&parsed)
+		_ = enc.Encode(&unresolved)
 		_ = enc.Close()
 		outYAML = buf.String()
 	}
@@ -816,6 +850,106 @@ func (s *server) handleEditorEnvCheck(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(envCheckResp{Present: out})
 }
 
+// handleEditorSQLDrivers lists the registered SQL drivers with their DSN templates.
+func (s *server) handleEditorSQLDrivers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(runner.Drivers())
+}
+
+type curlReq struct {
+	Parsed  interface{}       `json:"parsed"`
+	TestIdx int               `json:"testIndex"`
+	Env     map[string]string `json:"env"`
+}
+
+// handleEditorCurl renders the selected test of the in-memory suite as a
+// curl command, interpolated with the page env the way a test run sees it.
+// A matrix test yields one command per combination; secrets that resolve to
+// nothing are reported as warnings.
+func (s *server) handleEditorCurl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(405)
+		return
+	}
+	var req curlReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(400)
+		return
+	}
+	var suite models.Suite
+	bjson, err := json.Marshal(req.Parsed)
+	if err == nil {
+		err = json.Unmarshal(bjson, &suite)
+	}
+	if err != nil {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte("bad suite model"))
+		return
+	}
+	if req.TestIdx < 0 || req.TestIdx >= len(suite.Tests) {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte("invalid test index"))
+		return
+	}
+	if err := runner.ResolveTemplates(&suite); err != nil {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	t := suite.Tests[req.TestIdx]
+	if t.SQL != nil {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte("SQL tests have no HTTP request"))
+		return
+	}
+	type curlResp struct {
+		Curl     string   `json:"curl"`
+		Warnings []string `json:"warnings,omitempty"`
+	}
+	render := func() (curlResp, error) {
+		var out curlResp
+		if err := runner.LoadSecretsFile(&suite); err != nil {
+			out.Warnings = append(out.Warnings, "secrets file: "+err.Error())
+		}
+		combos := runner.ExpandMatrix([]models.TestCase{t})
+		var cmds []string
+		for _, tc := range combos {
+			cmd, err := curl.Command(adapters.ExportRequest{Name: tc.Name, Request: runner.RenderRequest(&suite, tc, false)})
+			if err != nil {
+				return out, err
+			}
+			if len(combos) > 1 {
+				cmd = "# " + tc.Name + "\n" + cmd
+			}
+			cmds = append(cmds, cmd)
+			if names := runner.UnknownSecrets(&suite, tc); len(names) > 0 {
+				out.Warnings = append(out.Warnings, tc.Name+": secrets not set: "+strings.Join(names, ", "))
+			}
+		}
+		out.Curl = strings.Join(cmds, "\n\n")
+		return out, nil
+	}
+	var out curlResp
+	if len(req.Env) > 0 {
+		s.envMu.Lock()
+		out, err = withEnv(req.Env, render)
+		s.envMu.Unlock()
+	} else {
+		out, err = render()
+	}
+	if err != nil {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
 type testRunReq struct {
 	Parsed            interface{}       `json:"parsed"`
 	TestIdx           int               `json:"testIndex"`
@@ -833,6 +967,19 @@ type testRunResp struct {
 }
 
 // --- Hook run support ---
+// inlineScripts keeps only inline suite scripts: file paths are relative to the
+// suite file, which the editor endpoints don't know. File entries stay as empty
+// placeholders so error messages keep their scripts[i] index.
+func inlineScripts(in []models.Script) []models.Script {
+	out := make([]models.Script, len(in))
+	for i, sc := range in {
+		if sc.File == "" {
+			out[i] = sc
+		}
+	}
+	return out
+}
+
 type hookRunReq struct {
 	Parsed  interface{}       `json:"parsed"`
 	Scope   string            `json:"scope"` // suitePre|suitePost|testPre|testPost
@@ -929,59 +1076,27 @@ func (s *server) handleEditorHookRun(w http.ResponseWriter, r *http.Request) {
 	durMs := int64(0)
 	// Run SQL part if present
 	if hr.Hook.SQL != nil {
-		drv := interpolateLite(hr.Hook.SQL.Driver, vars)
-		dsn := interpolateLite(hr.Hook.SQL.DSN, vars)
-		q := interpolateLite(hr.Hook.SQL.Query, vars)
 		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 		defer cancel()
-		db, e := sql.Open(drv, dsn)
-		if e != nil {
+		start := time.Now()
+		summary, err := runner.RunSQLHook(ctx, &suite, hr.Hook.SQL, &vars)
+		durMs = time.Since(start).Milliseconds()
+		if err != nil {
 			status = "failed"
-			messages = append(messages, e.Error())
+			messages = append(messages, err.Error())
 			goto RESP
 		}
-		defer db.Close()
-		rows, qerr := db.QueryContext(ctx, q)
-		if qerr != nil {
-			if _, e2 := db.ExecContext(ctx, q); e2 != nil {
-				status = "failed"
-				messages = append(messages, e2.Error())
-				goto RESP
-			}
-		} else {
-			defer rows.Close()
-			cols, _ := rows.Columns()
-			if rows.Next() {
-				vals := make([]any, len(cols))
-				ptrs := make([]any, len(cols))
-				for i := range vals {
-					ptrs[i] = &vals[i]
-				}
-				if err := rows.Scan(ptrs...); err == nil {
-					m := map[string]string{}
-					for i, c := range cols {
-						switch vv := vals[i].(type) {
-						case []byte:
-							m[c] = string(vv)
-						case nil:
-							m[c] = ""
-						default:
-							m[c] = fmt.Sprintf("%v", vv)
-						}
-					}
-					for varName, col := range hr.Hook.SQL.Extract {
-						vars[varName] = m[col]
-					}
-				}
-			}
-		}
-		messages = append(messages, "SQL executed")
+		messages = append(messages, summary)
 	}
 	// Run JS hook if present
 	if hr.Hook.JS != nil {
 		start := time.Now()
-		err := runner.RunJSHook(hr.Hook.JS, &vars)
+		libs := models.Suite{Scripts: inlineScripts(suite.Scripts), ScriptTimeoutMs: suite.ScriptTimeoutMs, ScriptMemoryMb: suite.ScriptMemoryMb}
+		console, err := runner.RunSuiteJSHook(&libs, hr.Hook.JS, &vars)
 		durMs = time.Since(start).Milliseconds()
+		for _, line := range console {
+			messages = append(messages, "console: "+line)
+		}
 		if err != nil {
 			status = "failed"
 			messages = append(messages, err.Error())
@@ -1013,8 +1128,7 @@ func (s *server) handleEditorHookRun(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 RESP:
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(hookRunResp{Name: hr.Hook.Name, Status: status, DurationMs: durMs, Messages: messages, Vars: vars})
+	writeJSONRedacted(w, hookRunResp{Name: hr.Hook.Name, Status: status, DurationMs: durMs, Messages: messages, Vars: vars})
 }
 
 func (s *server) handleEditorTestRun(w http.ResponseWriter, r *http.Request) {
@@ -1209,8 +1323,18 @@ func (s *server) handleEditorTestRun(w http.ResponseWriter, r *http.Request) {
 			resp.Cases = allResults
 		}
 	}
+	writeJSONRedacted(w, resp)
+}
+
+// writeJSONRedacted encodes v as a JSON response with registered secrets masked.
+func writeJSONRedacted(w http.ResponseWriter, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
+	_, _ = w.Write([]byte(secrets.Redact(string(b)) + "\n"))
 }
 
 // handleEditorStreamRun starts an in-memory run (single test or full suite) and streams via /api/stream using returned runId
@@ -1360,23 +1484,34 @@ func (s *server) handleImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	opts, err := importOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Convert based on format
 	var suite *models.Suite
+	var warnings []adapters.Warning
 	switch format {
 	case "postman":
-		suite, err = postman.Convert(file, nil)
+		suite, warnings, err = postman.ConvertWithReport(file, opts)
 	case "insomnia":
-		suite, err = insomnia.Convert(file)
+		suite, warnings, err = insomnia.ConvertWithReport(file, opts)
 	case "har":
-		suite, err = har.Convert(file)
+		suite, warnings, err = har.ConvertWithReport(file, opts)
 	case "openapi":
-		suite, err = oapi.Convert(file)
+		suite, warnings, err = oapi.ConvertWithReport(file, opts)
 	case "bruno":
-		suite, err = bruno.Convert(file)
+		suite, warnings, err = bruno.ConvertWithReport(file, opts)
 	case "restclient":
-		suite, err = restclient.Convert(file)
+		suite, warnings, err = restclient.ConvertWithReport(file, opts)
 	case "newman":
-		suite, err = newman.Convert(file, nil)
+		suite, warnings, err = newman.ConvertWithReport(file, opts)
+	case "curl":
+		suite, warnings, err = curl.ConvertWithReport(file, opts)
+	case "pact":
+		suite, warnings, err = pact.ConvertWithReport(file, opts)
 	default:
 		http.Error(w, "Unsupported format: "+format, http.StatusBadRequest)
 		return
@@ -1393,15 +1528,68 @@ func (s *server) handleImport(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to marshal YAML: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	filename := strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename)) + "_imported.yaml"
+
+	// With report=1 the dialog gets the YAML and the warnings in one JSON reply
+	if r.FormValue("report") == "1" {
+		if warnings == nil {
+			warnings = []adapters.Warning{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"filename": filename,
+			"yaml":     string(yamlData),
+			"report":   adapters.Report{Format: format, Tests: len(suite.Tests), Warnings: warnings},
+		})
+		return
+	}
 
 	// Set headers for file download
 	w.Header().Set("Content-Type", "application/x-yaml")
-	w.Header().Set("Content-Disposition", "attachment; filename=\""+strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))+"_imported.yaml\"")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
 
 	// Write YAML data
 	w.Write(yamlData)
 }
 
+// importOptions reads the import dialog's options from the parsed form:
+// noScripts, skipAuth, folders, baseUrl and an optional Postman env file.
+func importOptions(r *http.Request) (adapters.Options, error) {
+	on := func(k string) bool { v := r.FormValue(k); return v == "1" || v == "true" || v == "on" }
+	list := func(k string) []string {
+		var out []string
+		for _, v := range strings.Split(r.FormValue(k), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	opts := adapters.Options{NoScripts: on("noScripts"), SkipAuth: on("skipAuth"), BaseURL: strings.TrimSpace(r.FormValue("baseUrl")), IncludeHosts: list("includeHost"), OnlyXHR: on("onlyXhr")}
+	if _, ok := r.Form["excludeExt"]; ok {
+		opts.ExcludeExts = append([]string{}, list("excludeExt")...)
+	}
+	mode, err := adapters.ParseFolderMode(r.FormValue("folders"))
+	if err != nil {
+		return opts, err
+	}
+	if mode == adapters.FoldersFiles {
+		return opts, fmt.Errorf("folder-per-file import writes a directory; use hydreq import --folders files --out <dir>")
+	}
+	opts.Folders = mode
+	if env, _, err := r.FormFile("env"); err == nil {
+		defer env.Close()
+		data, err := io.ReadAll(env)
+		if err != nil {
+			return opts, fmt.Errorf("failed to read environment file: %w", err)
+		}
+		if opts.Env, err = postman.ParseEnvironment(data); err != nil {
+			return opts, fmt.Errorf("failed to parse environment file: %w", err)
+		}
+	}
+	return opts, nil
+}
+
 // handleReportRun streams a run-level report (batch) in either json|junit|html
 func (s *server) handleReportRun(w http.ResponseWriter, r *http.Request) {
 	runId := r.URL.Query().Get("runId")
@@ -1677,7 +1865,7 @@ func (s *server) runSuites(id string, suites []string, workers int, env map[stri
 		ch := s.streams[id]
 		s.mu.Unlock()
 		select {
-		case ch <- string(b):
+		case ch <- secrets.Redact(string(b)):
 		default:
 		}
 	}
@@ -1823,10 +2011,29 @@ func (s *server) runOneWithCtx(runId string, ctx context.Context, path string, w
 				"Name":       tr.Name,
 				"Stage":      tr.Stage,
 				"Tags":       tr.Tags,
+				"Source":     tr.Source,
 				"Status":     tr.Status,
 				"DurationMs": tr.DurationMs,
 				"Messages":   tr.Messages,
 			}})
+		}, OnHook: func(hr runner.HookResult) {
+			typ := "hook"
+			if hr.Status == "running" {
+				typ = "hookStart"
+			}
+			out(evt{Type: typ, Payload: map[string]any{
+				"path":         path,
+				"Name":         hr.Name,
+				"Scope":        hr.Scope,
+				"Test":         hr.Test,
+				"Index":        hr.Index,
+				"Status":       hr.Status,
+				"DurationMs":   hr.DurationMs,
+				"HTTPStatus":   hr.HTTPStatus,
+				"RowsAffected": hr.RowsAffected,
+				"Console":      hr.Console,
+				"Messages":     hr.Messages,
+			}})
 		}, OnResult: func(tr runner.TestResult) {
 			// collect results for detailed report and stream events
 			allResults = append(allResults, tr)
@@ -1836,6 +2043,7 @@ func (s *server) runOneWithCtx(runId string, ctx context.Context, path string, w
 				"Name":       tr.Name,
 				"Stage":      tr.Stage,
 				"Tags":       tr.Tags,
+				"Source":     tr.Source,
 				"Status":     tr.Status,
 				"DurationMs": tr.DurationMs,
 				"Messages":   tr.Messages,
@@ -1872,15 +2080,18 @@ func (s *server) runOneWithCtx(runId string, ctx context.Context, path string, w
 	dr := report.DetailedReport{
 		Suite:   suite.Name,
 		Summary: report.FromRunner(sum.Total, sum.Passed, sum.Failed, sum.Skipped, sum.Duration),
+		Hooks:   runner.HookSteps(sum.Hooks),
 	}
 	for _, r := range allResults {
 		tc := report.TestCase{
 			Name:       r.Name,
 			Stage:      r.Stage,
 			Tags:       r.Tags,
+			Source:     r.Source,
 			Status:     r.Status,
 			DurationMs: r.DurationMs,
 			Messages:   r.Messages,
+			Hooks:      runner.HookSteps(r.Hooks),
 		}
 		dr.TestCases = append(dr.TestCases, tc)
 	}
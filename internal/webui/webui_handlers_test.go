@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/DrWeltschmerz/HydReq/internal/adapters"
 )
 
 func TestHandleEditorSuites(t *testing.T) {
@@ -134,3 +138,126 @@ func TestHandleEditorCheckPathRejectsLegacyExtensions(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleEditorSQLDrivers(t *testing.T) {
+	s := &server{mux: http.NewServeMux()}
+	w := httptest.NewRecorder()
+	s.handleEditorSQLDrivers(w, httptest.NewRequest(http.MethodGet, "/api/editor/sqldrivers", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", w.Code)
+	}
+	var out []map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("failed to decode json: %v", err)
+	}
+	names := map[string]string{}
+	for _, d := range out {
+		names[d["name"]] = d["dsnTemplate"]
+	}
+	for _, n := range []string{"sqlite", "pgx", "sqlserver", "mysql"} {
+		if names[n] == "" {
+			t.Fatalf("driver %s missing or without template: %v", n, out)
+		}
+	}
+}
+
+func TestHandleImportReport(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "coll.json")
+	fw.Write([]byte(`{"info":{"name":"c"},"item":[{"name":"get","request":{"method":"GET","url":"https://example.com","auth":{"type":"digest"}}}]}`))
+	mw.WriteField("format", "postman")
+	mw.WriteField("report", "1")
+	mw.Close()
+
+	s := &server{mux: http.NewServeMux()}
+	req := httptest.NewRequest(http.MethodPost, "/api/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	s.handleImport(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", w.Code, w.Body.String())
+	}
+	var out struct {
+		Filename string          `json:"filename"`
+		YAML     string          `json:"yaml"`
+		Report   adapters.Report `json:"report"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("failed to decode json: %v", err)
+	}
+	if out.Filename != "coll_imported.yaml" || out.YAML == "" || out.Report.Tests != 1 {
+		t.Fatalf("unexpected reply %+v", out)
+	}
+	if len(out.Report.Warnings) != 1 || out.Report.Warnings[0].Feature != "auth:digest" {
+		t.Fatalf("unexpected warnings %+v", out.Report.Warnings)
+	}
+}
+
+func TestHandleEditorCurl(t *testing.T) {
+	parsed := map[string]any{
+		"name":    "s",
+		"baseUrl": "https://api.example.com",
+		"vars":    map[string]any{"id": "7"},
+		"auth":    map[string]any{"bearerEnv": "HYDREQ_CURL_TOKEN"},
+		"tests": []any{map[string]any{
+			"name":    "get user",
+			"request": map[string]any{"method": "GET", "url": "/users/${id}"},
+		}},
+	}
+	b, _ := json.Marshal(map[string]any{"parsed": parsed, "testIndex": 0, "env": map[string]string{"HYDREQ_CURL_TOKEN": "t0k"}})
+	s := &server{mux: http.NewServeMux()}
+	w := httptest.NewRecorder()
+	s.handleEditorCurl(w, httptest.NewRequest(http.MethodPost, "/api/editor/curl", bytes.NewReader(b)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", w.Code, w.Body.String())
+	}
+	var out map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("failed to decode json: %v", err)
+	}
+	want := "curl 'https://api.example.com/users/7' \\\n  -H 'Authorization: Bearer t0k'"
+	if out["curl"] != want {
+		t.Fatalf("curl = %q, want %q", out["curl"], want)
+	}
+	if _, ok := os.LookupEnv("HYDREQ_CURL_TOKEN"); ok {
+		t.Fatalf("page env leaked into the process environment")
+	}
+}
+
+func TestHandleEditorCurl_MatrixExtendsSecrets(t *testing.T) {
+	parsed := map[string]any{
+		"name":    "s",
+		"baseUrl": "https://api.example.com",
+		"templates": map[string]any{"authed": map[string]any{
+			"request": map[string]any{"headers": map[string]any{"X-Key": "${SECRET:HYDREQ_CURL_MISSING}"}},
+		}},
+		"tests": []any{map[string]any{
+			"name":    "list",
+			"extends": "authed",
+			"request": map[string]any{"method": "GET", "url": "/items/${id}"},
+			"matrix":  map[string]any{"id": []any{"1", "2"}},
+		}},
+	}
+	b, _ := json.Marshal(map[string]any{"parsed": parsed, "testIndex": 0})
+	s := &server{mux: http.NewServeMux()}
+	w := httptest.NewRecorder()
+	s.handleEditorCurl(w, httptest.NewRequest(http.MethodPost, "/api/editor/curl", bytes.NewReader(b)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", w.Code, w.Body.String())
+	}
+	var out struct {
+		Curl     string   `json:"curl"`
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("failed to decode json: %v", err)
+	}
+	want := "# list [id=1]\ncurl 'https://api.example.com/items/1' \\\n  -H 'X-Key: '\n\n# list [id=2]\ncurl 'https://api.example.com/items/2' \\\n  -H 'X-Key: '"
+	if out.Curl != want {
+		t.Fatalf("curl = %q, want %q", out.Curl, want)
+	}
+	if len(out.Warnings) != 2 || !strings.Contains(out.Warnings[0], "HYDREQ_CURL_MISSING") {
+		t.Fatalf("warnings = %v", out.Warnings)
+	}
+}
@@ -4,34 +4,47 @@ package models
 // This is a first pass; fields may evolve as features are added.
 
 type Suite struct {
-	Name      string            `yaml:"name,omitempty" json:"name"`
-	BaseURL   string            `yaml:"baseUrl,omitempty" json:"baseUrl"`
-	Variables map[string]string `yaml:"vars,omitempty" json:"vars"`
-	Auth      *Auth             `yaml:"auth,omitempty" json:"auth"`
-	PreSuite  []Hook            `yaml:"preSuite,omitempty" json:"preSuite"`
-	PostSuite []Hook            `yaml:"postSuite,omitempty" json:"postSuite"`
-	OpenAPI   *OpenAPIConfig    `yaml:"openApi,omitempty" json:"openApi"`
-	Tests     []TestCase        `yaml:"tests,omitempty" json:"tests"`
+	Name            string                       `yaml:"name,omitempty" json:"name"`
+	Include         []string                     `yaml:"include,omitempty" json:"include,omitempty"` // other suite files merged in by LoadSuite
+	BaseURL         string                       `yaml:"baseUrl,omitempty" json:"baseUrl"`
+	Variables       map[string]string            `yaml:"vars,omitempty" json:"vars"`
+	Environments    map[string]map[string]string `yaml:"environments,omitempty" json:"environments,omitempty"`       // profiles selected with --env
+	Secrets         []string                     `yaml:"secrets,omitempty" json:"secrets,omitempty"`                 // variable names masked in all output
+	SecretsFile     string                       `yaml:"secretsFile,omitempty" json:"secretsFile,omitempty"`         // dotenv or .age file backing ${SECRET:NAME}
+	Databases       map[string]Database          `yaml:"databases,omitempty" json:"databases,omitempty"`             // named connections referenced by sql.db
+	Scripts         []Script                     `yaml:"scripts,omitempty" json:"scripts,omitempty"`                 // JS libraries loaded before every JS hook and assert.js
+	ScriptTimeoutMs int                          `yaml:"scriptTimeoutMs,omitempty" json:"scriptTimeoutMs,omitempty"` // time limit per JS hook or assert.js (default 5000)
+	ScriptMemoryMb  int                          `yaml:"scriptMemoryMb,omitempty" json:"scriptMemoryMb,omitempty"`   // heap growth allowed per JS script (default 256)
+	Auth            *Auth                        `yaml:"auth,omitempty" json:"auth"`
+	PreSuite        []Hook                       `yaml:"preSuite,omitempty" json:"preSuite"`
+	PostSuite       []Hook                       `yaml:"postSuite,omitempty" json:"postSuite"`
+	OpenAPI         *OpenAPIConfig               `yaml:"openApi,omitempty" json:"openApi"`
+	Templates       map[string]TestCase          `yaml:"templates,omitempty" json:"templates,omitempty"` // reusable test fragments referenced via extends
+	Tests           []TestCase                   `yaml:"tests,omitempty" json:"tests"`
 }
 
 type TestCase struct {
-	Name      string              `yaml:"name" json:"name"`
-	Request   Request             `yaml:"request" json:"request"`
-	Assert    Assertions          `yaml:"assert,omitempty" json:"assert"`
-	Extract   map[string]Extract  `yaml:"extract,omitempty" json:"extract"`
-	Skip      bool                `yaml:"skip,omitempty" json:"skip"`
-	Only      bool                `yaml:"only,omitempty" json:"only"`
-	TimeoutMs int                 `yaml:"timeoutMs,omitempty" json:"timeoutMs"`
-	Repeat    int                 `yaml:"repeat,omitempty" json:"repeat"`
-	Tags      []string            `yaml:"tags,omitempty" json:"tags"`
-	Retry     *Retry              `yaml:"retry,omitempty" json:"retry"`
-	Stage     int                 `yaml:"stage,omitempty" json:"stage"`   // tests with same stage can run in parallel
-	Matrix    map[string][]string `yaml:"matrix,omitempty" json:"matrix"` // data-driven: expands vars
-	Vars      map[string]string   `yaml:"vars,omitempty" json:"vars"`     // per-test variable overrides
-	DependsOn []string            `yaml:"dependsOn,omitempty" json:"dependsOn"`
-	Pre       []Hook              `yaml:"pre,omitempty" json:"pre"`
-	Post      []Hook              `yaml:"post,omitempty" json:"post"`
-	OpenAPI   *OpenAPITest        `yaml:"openApi,omitempty" json:"openApi"`
+	Name        string              `yaml:"name" json:"name"`
+	Extends     string              `yaml:"extends,omitempty" json:"extends,omitempty"` // name of a suite template to deep-merge
+	Request     Request             `yaml:"request" json:"request"`
+	Assert      Assertions          `yaml:"assert,omitempty" json:"assert"`
+	Extract     map[string]Extract  `yaml:"extract,omitempty" json:"extract"`
+	SQL         *SQLHook            `yaml:"sql,omitempty" json:"sql,omitempty"`                 // run a SQL step instead of an HTTP request
+	Transaction string              `yaml:"transaction,omitempty" json:"transaction,omitempty"` // rollbackAfterTest: roll back the test's SQL when it finishes
+	Skip        bool                `yaml:"skip,omitempty" json:"skip"`
+	Only        bool                `yaml:"only,omitempty" json:"only"`
+	TimeoutMs   int                 `yaml:"timeoutMs,omitempty" json:"timeoutMs"`
+	Repeat      int                 `yaml:"repeat,omitempty" json:"repeat"`
+	Tags        []string            `yaml:"tags,omitempty" json:"tags"`
+	Retry       *Retry              `yaml:"retry,omitempty" json:"retry"`
+	Stage       int                 `yaml:"stage,omitempty" json:"stage"`   // tests with same stage can run in parallel
+	Matrix      map[string][]string `yaml:"matrix,omitempty" json:"matrix"` // data-driven: expands vars
+	Vars        map[string]string   `yaml:"vars,omitempty" json:"vars"`     // per-test variable overrides
+	DependsOn   []string            `yaml:"dependsOn,omitempty" json:"dependsOn"`
+	Pre         []Hook              `yaml:"pre,omitempty" json:"pre"`
+	Post        []Hook              `yaml:"post,omitempty" json:"post"`
+	OpenAPI     *OpenAPITest        `yaml:"openApi,omitempty" json:"openApi"`
+	Source      string              `yaml:"-" json:"-"` // set by LoadSuite for tests pulled in via include
 }
 
 type Request struct {
@@ -40,6 +53,15 @@ type Request struct {
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers"`
 	Query   map[string]string `yaml:"query,omitempty" json:"query"`
 	Body    any               `yaml:"body,omitempty" json:"body"`
+	GraphQL *GraphQL          `yaml:"graphql,omitempty" json:"graphql,omitempty"` // sent as the body (POST) or query (GET) instead of body
+}
+
+// GraphQL is an operation posted as {"query", "variables", "operationName"}.
+type GraphQL struct {
+	Query         string         `yaml:"query,omitempty" json:"query,omitempty"`
+	QueryFile     string         `yaml:"queryFile,omitempty" json:"queryFile,omitempty"` // .graphql file, relative to the suite
+	Variables     map[string]any `yaml:"variables,omitempty" json:"variables,omitempty"`
+	OperationName string         `yaml:"operationName,omitempty" json:"operationName,omitempty"`
 }
 
 type Assertions struct {
@@ -49,6 +71,17 @@ type Assertions struct {
 	JSONContains  map[string]any    `yaml:"jsonContains,omitempty" json:"jsonContains"` // JSONPath -> expected substring or value
 	BodyContains  []string          `yaml:"bodyContains,omitempty" json:"bodyContains"`
 	MaxDurationMs int64             `yaml:"maxDurationMs,omitempty" json:"maxDurationMs"`
+	JS            string            `yaml:"js,omitempty" json:"js"` // script calling check(name, ok, msg) against the response
+	GraphQLErrors *GraphQLErrors    `yaml:"graphqlErrors,omitempty" json:"graphqlErrors,omitempty"`
+}
+
+// GraphQLErrors checks the errors array of a GraphQL response. GraphQL
+// requests without it fail on any error; with it only these checks apply.
+type GraphQLErrors struct {
+	Count           *int     `yaml:"count,omitempty" json:"count,omitempty"`                     // exact number of errors
+	MessageContains []string `yaml:"messageContains,omitempty" json:"messageContains,omitempty"` // each must appear in some error message
+	Codes           []string `yaml:"codes,omitempty" json:"codes,omitempty"`                     // each must be some error's extensions.code
+	Paths           []string `yaml:"paths,omitempty" json:"paths,omitempty"`                     // each must be some error's path, joined with "."
 }
 
 type Extract struct {
@@ -78,19 +111,53 @@ type Hook struct {
 }
 
 type SQLHook struct {
-	Driver  string            `yaml:"driver,omitempty" json:"driver"` // e.g. sqlite
-	DSN     string            `yaml:"dsn,omitempty" json:"dsn"`       // e.g. file:./qa.sqlite?cache=shared
-	Query   string            `yaml:"query,omitempty" json:"query"`
-	Extract map[string]string `yaml:"extract,omitempty" json:"extract"` // varName -> columnName
+	DB        string            `yaml:"db,omitempty" json:"db,omitempty"` // name of a suite database; replaces driver/dsn
+	Driver    string            `yaml:"driver,omitempty" json:"driver"`   // e.g. sqlite
+	DSN       string            `yaml:"dsn,omitempty" json:"dsn"`         // e.g. file:./qa.sqlite?cache=shared
+	Query     string            `yaml:"query,omitempty" json:"query"`
+	Args      []any             `yaml:"args,omitempty" json:"args,omitempty"`           // query parameters bound to driver placeholders
+	Extract   map[string]string `yaml:"extract,omitempty" json:"extract"`               // varName -> columnName (first row)
+	ResultVar string            `yaml:"resultVar,omitempty" json:"resultVar,omitempty"` // var receiving all rows as a JSON array
+	Assert    *SQLAssertions    `yaml:"assert,omitempty" json:"assert,omitempty"`
+}
+
+// Database is a named connection shared by the SQL steps of a suite run.
+type Database struct {
+	Driver   string `yaml:"driver" json:"driver"`
+	DSN      string `yaml:"dsn" json:"dsn"`                               // supports ${ENV:NAME} and ${SECRET:NAME}
+	PoolSize int    `yaml:"poolSize,omitempty" json:"poolSize,omitempty"` // max open connections; 0 = driver default
+}
+
+type SQLAssertions struct {
+	RowCount     *int           `yaml:"rowCount,omitempty" json:"rowCount,omitempty"`
+	RowsAffected *int64         `yaml:"rowsAffected,omitempty" json:"rowsAffected,omitempty"` // INSERT/UPDATE/DELETE only
+	Rows         []SQLRowAssert `yaml:"rows,omitempty" json:"rows,omitempty"`
+}
+
+// SQLRowAssert checks column values of one result row.
+type SQLRowAssert struct {
+	Row      int               `yaml:"row,omitempty" json:"row"` // zero-based
+	Equals   map[string]any    `yaml:"equals,omitempty" json:"equals,omitempty"`
+	Contains map[string]string `yaml:"contains,omitempty" json:"contains,omitempty"`
+	Matches  map[string]string `yaml:"matches,omitempty" json:"matches,omitempty"` // regular expressions
 }
 
 type OpenAPIConfig struct {
-	File    string `yaml:"file,omitempty" json:"file"`       // path to spec file
-	Enabled bool   `yaml:"enabled,omitempty" json:"enabled"` // default true when file present
+	File    string `yaml:"file,omitempty" json:"file"`                 // path to spec file
+	Enabled bool   `yaml:"enabled,omitempty" json:"enabled"`           // default true when file present
+	Request string `yaml:"request,omitempty" json:"request,omitempty"` // off|warn|fail: validate requests before sending (default warn)
 }
 
 type OpenAPITest struct {
-	Enabled *bool `yaml:"enabled,omitempty" json:"enabled"` // override per-test
+	Enabled *bool  `yaml:"enabled,omitempty" json:"enabled"`           // override per-test
+	Request string `yaml:"request,omitempty" json:"request,omitempty"` // override openApi.request per-test
+}
+
+// Script is a suite-level JS library: inline code or a file (relative to the suite).
+type Script struct {
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+	Code string `yaml:"code,omitempty" json:"code,omitempty"`
 }
 
 type JSHook struct {